@@ -5,5 +5,6 @@ go 1.14
 require (
 	github.com/frankban/quicktest v1.7.2 // indirect
 	github.com/golang/snappy v0.0.1
+	github.com/klauspost/compress v1.9.5
 	github.com/pierrec/lz4 v2.3.0+incompatible
 )
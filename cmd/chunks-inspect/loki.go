@@ -10,6 +10,8 @@ import (
 	"io/ioutil"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/flate"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4"
 )
 
@@ -38,8 +40,12 @@ var (
 	encDumb   = Encoding{code: 2, name: "dumb", readerFn: func(reader io.Reader) (io.Reader, error) { return reader, nil }}
 	encLZ4    = Encoding{code: 3, name: "lz4", readerFn: func(reader io.Reader) (io.Reader, error) { return lz4.NewReader(reader), nil }}
 	encSnappy = Encoding{code: 4, name: "snappy", readerFn: func(reader io.Reader) (io.Reader, error) { return snappy.NewReader(reader), nil }}
+	encFlate  = Encoding{code: 8, name: "flate", readerFn: func(reader io.Reader) (io.Reader, error) { return flate.NewReader(reader), nil }}
+	encZstd   = Encoding{code: 9, name: "zstd", readerFn: func(reader io.Reader) (io.Reader, error) { return zstd.NewReader(reader) }}
+	// zstd-fast only differs from zstd by its compression level.
+	encZstdFast = Encoding{code: 10, name: "zstd-fast", readerFn: encZstd.readerFn}
 
-	Encodings = []Encoding{encNone, encGZIP, encDumb, encLZ4, encSnappy}
+	Encodings = []Encoding{encNone, encGZIP, encDumb, encLZ4, encSnappy, encFlate, encZstd, encZstdFast}
 )
 
 type LokiChunk struct {
@@ -60,6 +66,7 @@ type LokiBlock struct {
 
 	rawData      []byte // data as stored in chunk file, compressed
 	originalData []byte // data uncompressed from rawData
+	filter       []byte // bloom filter of the tokens of the lines, format 4 and later
 
 	// parsed rawData
 	entries          []LokiEntry
@@ -68,8 +75,14 @@ type LokiBlock struct {
 }
 
 type LokiEntry struct {
-	timestamp int64
-	line      string
+	timestamp          int64
+	line               string
+	structuredMetadata []LokiLabel // format 5 only
+}
+
+type LokiLabel struct {
+	name  string
+	value string
 }
 
 func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
@@ -83,7 +96,8 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		return nil, fmt.Errorf("invalid magic number: %0x", num)
 	}
 
-	compression, err := getCompression(data[4], data[5])
+	format := data[4]
+	compression, err := getCompression(format, data[5])
 	if err != nil {
 		return nil, fmt.Errorf("failed to read compression: %w", err)
 	}
@@ -117,6 +131,16 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		block.dataOffset, metadata, err = readUvarint(err, metadata)
 		dataLength := uint64(0)
 		dataLength, metadata, err = readUvarint(err, metadata)
+		if format >= 4 {
+			filterLength := uint64(0)
+			filterLength, metadata, err = readUvarint(err, metadata)
+			if err == nil && filterLength > uint64(len(metadata)) {
+				err = fmt.Errorf("invalid block filter length: %d", filterLength)
+			}
+			if err == nil {
+				block.filter, metadata = metadata[:filterLength], metadata[filterLength:]
+			}
+		}
 
 		if err != nil {
 			return nil, err
@@ -125,14 +149,14 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		block.rawData = data[block.dataOffset : block.dataOffset+dataLength]
 		block.storedChecksum = binary.BigEndian.Uint32(data[block.dataOffset+dataLength : block.dataOffset+dataLength+4])
 		block.computedChecksum = crc32.Checksum(block.rawData, castagnoliTable)
-		block.originalData, block.entries, err = parseLokiBlock(compression, block.rawData)
+		block.originalData, block.entries, err = parseLokiBlock(format, compression, block.rawData)
 		lokiChunk.blocks = append(lokiChunk.blocks, block)
 	}
 
 	return lokiChunk, nil
 }
 
-func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, error) {
+func parseLokiBlock(format byte, compression Encoding, data []byte) ([]byte, []LokiEntry, error) {
 	r, err := compression.readerFn(bytes.NewReader(data))
 	if err != nil {
 		return nil, nil, err
@@ -159,17 +183,55 @@ func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, err
 			return origDecompressed, nil, fmt.Errorf("not enough line data, need %d, got %d", lineLength, len(decompressed))
 		}
 
-		entries = append(entries, LokiEntry{
+		entry := LokiEntry{
 			timestamp: timestamp,
 			line:      string(decompressed[0:lineLength]),
-		})
-
+		}
 		decompressed = decompressed[lineLength:]
+
+		if format >= 5 {
+			entry.structuredMetadata, decompressed, err = readStructuredMetadata(decompressed)
+			if err != nil {
+				return origDecompressed, nil, err
+			}
+		}
+
+		entries = append(entries, entry)
 	}
 
 	return origDecompressed, entries, nil
 }
 
+func readStructuredMetadata(buf []byte) ([]LokiLabel, []byte, error) {
+	count, buf, err := readUvarint(nil, buf)
+	if err != nil {
+		return nil, buf, err
+	}
+
+	metadata := []LokiLabel(nil)
+	for i := uint64(0); i < count; i++ {
+		var name, value string
+		name, buf, err = readString(err, buf)
+		value, buf, err = readString(err, buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		metadata = append(metadata, LokiLabel{name: name, value: value})
+	}
+	return metadata, buf, nil
+}
+
+func readString(prevErr error, buf []byte) (string, []byte, error) {
+	length, buf, err := readUvarint(prevErr, buf)
+	if err != nil {
+		return "", buf, err
+	}
+	if uint64(len(buf)) < length {
+		return "", buf, fmt.Errorf("not enough string data, need %d, got %d", length, len(buf))
+	}
+	return string(buf[:length]), buf[length:], nil
+}
+
 func readVarint(prevErr error, buf []byte) (int64, []byte, error) {
 	if prevErr != nil {
 		return 0, buf, prevErr
@@ -199,7 +261,10 @@ func getCompression(format byte, code byte) (Encoding, error) {
 		return encGZIP, nil
 	}
 
-	if format == 2 {
+	// Format 3 has the same layout as format 2, its blocks can overlap.
+	// Format 4 adds a byte of flags after the encoding and a filter to the metadata of the blocks.
+	// Format 5 adds the structured metadata after each entry of the blocks.
+	if format >= 2 && format <= 5 {
 		for _, e := range Encodings {
 			if e.code == int(code) {
 				return e, nil
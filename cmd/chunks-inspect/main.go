@@ -102,12 +102,19 @@ func printFile(filename string, blockDetails, printLines, storeBlocks bool) {
 				time.Unix(0, b.minT).In(timezone).Format(format), time.Unix(0, b.maxT).In(timezone).Format(format),
 				cksum)
 			fmt.Printf("Block %4d: digest compressed: %02x, original: %02x\n", ix, sha256.Sum256(b.rawData), sha256.Sum256(b.originalData))
+			if b.filter != nil {
+				fmt.Printf("Block %4d: filter length: %d\n", ix, len(b.filter))
+			}
 		}
 
 		totalSize += len(b.originalData)
 
 		if printLines {
 			for _, l := range b.entries {
+				if len(l.structuredMetadata) > 0 {
+					fmt.Printf("%v\t%s\t%s\n", time.Unix(0, l.timestamp).In(timezone).Format(format), strings.TrimSpace(l.line), formatStructuredMetadata(l.structuredMetadata))
+					continue
+				}
 				fmt.Printf("%v\t%s\n", time.Unix(0, l.timestamp).In(timezone).Format(format), strings.TrimSpace(l.line))
 			}
 		}
@@ -129,3 +136,11 @@ func writeBlockToFile(data []byte, blockIndex int, filename string) {
 		log.Println("Stored block", blockIndex, "to file", filename)
 	}
 }
+
+func formatStructuredMetadata(metadata []LokiLabel) string {
+	pairs := make([]string, 0, len(metadata))
+	for _, l := range metadata {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", l.name, l.value))
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -13,10 +13,13 @@ import (
 
 	_ "github.com/grafana/loki/pkg/build"
 	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/contextquery"
+	"github.com/grafana/loki/pkg/logcli/explainquery"
 	"github.com/grafana/loki/pkg/logcli/labelquery"
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/logcli/query"
 	"github.com/grafana/loki/pkg/logcli/seriesquery"
+	"github.com/grafana/loki/pkg/logcli/statsquery"
 )
 
 var (
@@ -78,6 +81,35 @@ https://github.com/grafana/loki/blob/master/docs/logql.md`)
 
 	seriesCmd   = app.Command("series", "Run series query.")
 	seriesQuery = newSeriesQuery(seriesCmd)
+
+	explainCmd = app.Command("explain", `Explain how a LogQL query is executed.
+
+The "explain" command shows the normalized query, its line filters once
+regular expressions are simplified and, when a query frontend is used, how
+the query is split by time, which splits are found in the results cache and
+how each split is sharded.`)
+	explainQuery = newExplainQuery(explainCmd)
+
+	statsCmd = app.Command("stats", `Show the amount of data selected by a stream selector.
+
+The "stats" command returns the number of streams, chunks, bytes and entries
+selected by a stream selector within a time range, looked up from the index
+without fetching any chunk. Bytes and entries only account for the chunks
+not yet flushed by the ingesters.`)
+	statsQuery = newStatsQuery(statsCmd)
+
+	volumeCmd = app.Command("volume", `Show the amount of data selected by a stream selector grouped by a label.
+
+The "volume" command returns the stats of the "stats" command for each
+value of a label, sorted by decreasing bytes.`)
+	volumeQuery = newVolumeQuery(volumeCmd)
+
+	contextCmd = app.Command("context", `Show the lines surrounding a log entry.
+
+The "context" command returns the lines of a stream before and after the
+entries at a given timestamp, which are highlighted in the default output
+mode. It is useful to understand what happened around an error line.`)
+	contextQuery = newContextQuery(contextCmd)
 )
 
 func main() {
@@ -155,6 +187,29 @@ func main() {
 		labelsQuery.DoLabels(queryClient)
 	case seriesCmd.FullCommand():
 		seriesQuery.DoSeries(queryClient)
+	case explainCmd.FullCommand():
+		explainQuery.DoExplain(queryClient)
+	case statsCmd.FullCommand():
+		statsQuery.DoStats(queryClient)
+	case volumeCmd.FullCommand():
+		volumeQuery.DoVolume(queryClient)
+	case contextCmd.FullCommand():
+		location, err := time.LoadLocation(*timezone)
+		if err != nil {
+			log.Fatalf("Unable to load timezone '%s': %s", *timezone, err)
+		}
+
+		outputOptions := &output.LogOutputOptions{
+			Timezone:  location,
+			Highlight: contextQuery.Anchor,
+		}
+
+		out, err := output.NewLogOutput(os.Stdout, *outputMode, outputOptions)
+		if err != nil {
+			log.Fatalf("Unable to create log output: %s", err)
+		}
+
+		contextQuery.DoContext(queryClient, out)
 	}
 }
 
@@ -240,6 +295,117 @@ func newSeriesQuery(cmd *kingpin.CmdClause) *seriesquery.SeriesQuery {
 	return q
 }
 
+func newExplainQuery(cmd *kingpin.CmdClause) *explainquery.ExplainQuery {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &explainquery.ExplainQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("query", "eg 'rate({foo=\"bar\"} |~ \".*error.*\" [5m])'").Required().StringVar(&q.QueryString)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start of the query time range (inclusive)").StringVar(&from)
+	cmd.Flag("to", "End of the query time range (exclusive)").StringVar(&to)
+	cmd.Flag("step", "Query resolution step width, for metric queries.").DurationVar(&q.Step)
+
+	return q
+}
+
+func newStatsQuery(cmd *kingpin.CmdClause) *statsquery.StatsQuery {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &statsquery.StatsQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("query", "eg '{foo=\"bar\",baz=~\".*blip\"}'").Required().StringVar(&q.QueryString)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+
+	return q
+}
+
+func newVolumeQuery(cmd *kingpin.CmdClause) *statsquery.VolumeQuery {
+	// calculate query range from cli params
+	var from, to string
+	var since time.Duration
+
+	q := &statsquery.VolumeQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+
+		defaultEnd := time.Now()
+		defaultStart := defaultEnd.Add(-since)
+
+		q.Start = mustParse(from, defaultStart)
+		q.End = mustParse(to, defaultEnd)
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("query", "eg '{foo=\"bar\",baz=~\".*blip\"}'").Required().StringVar(&q.QueryString)
+	cmd.Flag("label", "Label to group the streams by.").Required().StringVar(&q.Label)
+	cmd.Flag("limit", "Limit on number of label values to print.").Default("100").IntVar(&q.Limit)
+	cmd.Flag("since", "Lookback window.").Default("1h").DurationVar(&since)
+	cmd.Flag("from", "Start looking for logs at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for logs at this absolute time (exclusive)").StringVar(&to)
+
+	return q
+}
+
+func newContextQuery(cmd *kingpin.CmdClause) *contextquery.ContextQuery {
+	var ts, from, to string
+	var window time.Duration
+
+	q := &contextquery.ContextQuery{}
+
+	// executed after all command flags are parsed
+	cmd.Action(func(c *kingpin.ParseContext) error {
+
+		q.Anchor = mustParse(ts, time.Now())
+		q.Start = mustParse(from, q.Anchor.Add(-window))
+		q.End = mustParse(to, q.Anchor.Add(window))
+		q.Quiet = *quiet
+		return nil
+	})
+
+	cmd.Arg("stream", "eg '{foo=\"bar\",baz=\"blip\"}'").Required().StringVar(&q.Stream)
+	cmd.Flag("ts", "Timestamp of the entry to show the context of, in RFC3339Nano format.").Required().StringVar(&ts)
+	cmd.Flag("before", "Number of lines to show before the entry.").Default("10").IntVar(&q.Before)
+	cmd.Flag("after", "Number of lines to show after the entry.").Default("10").IntVar(&q.After)
+	cmd.Flag("window", "Time range to look for lines before and after the entry.").Default("1h").DurationVar(&window)
+	cmd.Flag("from", "Start looking for lines at this absolute time (inclusive)").StringVar(&from)
+	cmd.Flag("to", "Stop looking for lines at this absolute time (exclusive)").StringVar(&to)
+
+	return q
+}
+
 func newQuery(instant bool, cmd *kingpin.CmdClause) *query.Query {
 	// calculate query range from cli params
 	var now, from, to string
@@ -0,0 +1,141 @@
+package chunkenc
+
+import (
+	"math"
+
+	"github.com/cespare/xxhash/v2"
+)
+
+const (
+	// The tokens of the lines are indexed by their n-grams so that a filter literal can be looked up even
+	// when it only contains a part of a token. Tokens shorter than a n-gram are indexed as they are.
+	tokenNgramSize = 4
+
+	blockFilterBitsPerItem = 8
+	blockFilterMinBytes    = 8
+	blockFilterMaxBytes    = 4 << 10
+	blockFilterMaxHashes   = 8
+)
+
+// blockFilter is a bloom filter of the tokens of the lines of a block. The tokens are the sequences
+// of letters, digits, underscores and non-ASCII bytes of the lines.
+// It is encoded as its number of hash functions followed by its bits.
+type blockFilter []byte
+
+// newBlockFilter builds the filter of the given entries.
+func newBlockFilter(entries []entry) blockFilter {
+	items := map[uint64]struct{}{}
+	for _, e := range entries {
+		forEachToken(e.s, func(token string, _, _ bool) {
+			if len(token) < tokenNgramSize {
+				items[xxhash.Sum64String(token)] = struct{}{}
+				return
+			}
+			for i := 0; i+tokenNgramSize <= len(token); i++ {
+				items[xxhash.Sum64String(token[i:i+tokenNgramSize])] = struct{}{}
+			}
+		})
+	}
+
+	size := len(items) * blockFilterBitsPerItem / 8
+	if size < blockFilterMinBytes {
+		size = blockFilterMinBytes
+	}
+	if size > blockFilterMaxBytes {
+		size = blockFilterMaxBytes
+	}
+	hashes := 1
+	if len(items) > 0 {
+		// The optimal number of hash functions for the number of bits per item.
+		hashes = int(math.Round(float64(size*8) / float64(len(items)) * math.Ln2))
+	}
+	if hashes < 1 {
+		hashes = 1
+	}
+	if hashes > blockFilterMaxHashes {
+		hashes = blockFilterMaxHashes
+	}
+
+	f := make(blockFilter, size+1)
+	f[0] = byte(hashes)
+	for h := range items {
+		f.add(h)
+	}
+	return f
+}
+
+func (f blockFilter) add(h uint64) {
+	bits := f[1:]
+	m := uint64(len(bits) * 8)
+	h1, h2 := h>>32, h&math.MaxUint32
+	for i := uint64(0); i < uint64(f[0]); i++ {
+		bit := (h1 + i*h2) % m
+		bits[bit/8] |= 1 << (bit % 8)
+	}
+}
+
+func (f blockFilter) test(h uint64) bool {
+	bits := f[1:]
+	m := uint64(len(bits) * 8)
+	h1, h2 := h>>32, h&math.MaxUint32
+	for i := uint64(0); i < uint64(f[0]); i++ {
+		bit := (h1 + i*h2) % m
+		if bits[bit/8]&(1<<(bit%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// mayContain returns false if none of the lines of the block contain the literal.
+func (f blockFilter) mayContain(literal []byte) bool {
+	if len(f) < 2 {
+		return true
+	}
+	contained := true
+	forEachToken(string(literal), func(token string, leftBounded, rightBounded bool) {
+		if !contained {
+			return
+		}
+		if len(token) >= tokenNgramSize {
+			// The token of the literal may only be a part of the token of the line, which contains its n-grams.
+			for i := 0; i+tokenNgramSize <= len(token); i++ {
+				if !f.test(xxhash.Sum64String(token[i : i+tokenNgramSize])) {
+					contained = false
+					return
+				}
+			}
+			return
+		}
+		// A short token can only be looked up when it is a whole token of the line, that is when the literal
+		// has a delimiter on both its sides.
+		if leftBounded && rightBounded && !f.test(xxhash.Sum64String(token)) {
+			contained = false
+		}
+	})
+	return contained
+}
+
+// forEachToken calls fn with the tokens of s and whether they are preceded and followed by a delimiter in s.
+func forEachToken(s string, fn func(token string, leftBounded, rightBounded bool)) {
+	start := -1
+	for i := 0; i < len(s); i++ {
+		if isTokenByte(s[i]) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			fn(s[start:i], start > 0, true)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		fn(s[start:], start > 0, false)
+	}
+}
+
+func isTokenByte(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z' || b >= '0' && b <= '9' || b == '_' || b >= 0x80
+}
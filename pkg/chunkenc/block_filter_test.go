@@ -0,0 +1,50 @@
+package chunkenc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlockFilter(t *testing.T) {
+	f := newBlockFilter([]entry{
+		{t: 1, s: `level=info msg="request done" traceID=abc123 id=42`},
+		{t: 2, s: `level=error msg="request failed" traceID=def456 id=7 user=émilie`},
+	})
+
+	for literal, expected := range map[string]bool{
+		"traceID=abc123":    true,
+		"ceID=abc1":         true,
+		"request":           true,
+		" id=42":            true,
+		"id=4":              true,
+		"user=émilie":       true,
+		"e":                 true, // too short to be looked up.
+		"=":                 true, // no tokens.
+		"traceID=abc124":    false,
+		"warning":           false,
+		" id=43 ":           false,
+		"msg=\"timed out\"": false,
+	} {
+		require.Equal(t, expected, f.mayContain([]byte(literal)), literal)
+	}
+
+	// Blocks without filter may contain anything.
+	require.True(t, blockFilter(nil).mayContain([]byte("warning")))
+}
+
+func TestForEachToken(t *testing.T) {
+	type token struct {
+		s                         string
+		leftBounded, rightBounded bool
+	}
+	var tokens []token
+	forEachToken("foo=bar_1 é.", func(s string, leftBounded, rightBounded bool) {
+		tokens = append(tokens, token{s, leftBounded, rightBounded})
+	})
+	require.Equal(t, []token{
+		{"foo", false, true},
+		{"bar_1", true, true},
+		{"é", true, true},
+	}, tokens)
+}
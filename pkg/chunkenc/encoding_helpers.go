@@ -41,6 +41,16 @@ func (e *encbuf) putVarint64(x int64) {
 	e.b = append(e.b, e.c[:n]...)
 }
 
+func (e *encbuf) putUvarintStr(s string) {
+	e.putUvarint(len(s))
+	e.b = append(e.b, s...)
+}
+
+func (e *encbuf) putUvarintBytes(b []byte) {
+	e.putUvarint(len(b))
+	e.b = append(e.b, b...)
+}
+
 // putHash appends a hash over the buffers current contents to the buffer.
 func (e *encbuf) putHash(h hash.Hash) {
 	h.Reset()
@@ -93,6 +103,35 @@ func (d *decbuf) uvarint64() uint64 {
 	return x
 }
 
+func (d *decbuf) uvarintStr() string {
+	l := d.uvarint64()
+	if d.e != nil {
+		return ""
+	}
+	if len(d.b) < int(l) {
+		d.e = ErrInvalidSize
+		return ""
+	}
+	s := string(d.b[:l])
+	d.b = d.b[l:]
+	return s
+}
+
+// uvarintBytes returns a slice of the buffer of the length prefixing it, it is not copied.
+func (d *decbuf) uvarintBytes() []byte {
+	l := d.uvarint64()
+	if d.e != nil {
+		return nil
+	}
+	if len(d.b) < int(l) {
+		d.e = ErrInvalidSize
+		return nil
+	}
+	b := d.b[:l:l]
+	d.b = d.b[l:]
+	return b
+}
+
 func (d *decbuf) be32() uint32 {
 	if d.e != nil {
 		return 0
@@ -19,6 +19,8 @@ var (
 	ErrInvalidSize     = errors.New("invalid size")
 	ErrInvalidFlag     = errors.New("invalid flag")
 	ErrInvalidChecksum = errors.New("invalid chunk checksum")
+	// ErrStructuredMetadata is returned when appending an entry with structured metadata to a chunk which can't store them.
+	ErrStructuredMetadata = errors.New("chunk can't store structured metadata")
 )
 
 // Encoding is the identifier for a chunk encoding.
@@ -37,6 +39,10 @@ const (
 	EncLZ4_256k
 	EncLZ4_1M
 	EncLZ4_4M
+
+	EncFlate
+	EncZstd
+	EncZstdFast
 )
 
 var supportedEncoding = []Encoding{
@@ -44,6 +50,9 @@ var supportedEncoding = []Encoding{
 	EncGZIP,
 	EncLZ4_64k,
 	EncSnappy,
+	EncFlate,
+	EncZstd,
+	EncZstdFast,
 }
 
 func (e Encoding) String() string {
@@ -64,6 +73,12 @@ func (e Encoding) String() string {
 		return "lz4-4M"
 	case EncSnappy:
 		return "snappy"
+	case EncFlate:
+		return "flate"
+	case EncZstd:
+		return "zstd"
+	case EncZstdFast:
+		return "zstd-fast"
 	default:
 		return "unknown"
 	}
@@ -97,10 +112,13 @@ type Chunk interface {
 	Bounds() (time.Time, time.Time)
 	SpaceFor(*logproto.Entry) bool
 	Append(*logproto.Entry) error
-	Iterator(ctx context.Context, from, through time.Time, direction logproto.Direction, filter logql.LineFilter) (iter.EntryIterator, error)
-	SampleIterator(ctx context.Context, from, through time.Time, filter logql.LineFilter, extractor logql.SampleExtractor) iter.SampleIterator
+	Iterator(ctx context.Context, from, through time.Time, direction logproto.Direction, pipeline logql.StreamPipeline) (iter.EntryIterator, error)
+	SampleIterator(ctx context.Context, from, through time.Time, extractor logql.StreamSampleExtractor) iter.SampleIterator
 	// Returns the list of blocks in the chunks.
 	Blocks(mintT, maxtT time.Time) []Block
+	// Ordered returns whether the entries of the chunk are ordered across its blocks.
+	// The blocks of an unordered chunk may overlap in time.
+	Ordered() bool
 	Size() int
 	Bytes() ([]byte, error)
 	BlockCount() int
@@ -121,7 +139,7 @@ type Block interface {
 	// Entries is the amount of entries in the block.
 	Entries() int
 	// Iterator returns an entry iterator for the block.
-	Iterator(context.Context, logql.LineFilter) iter.EntryIterator
+	Iterator(context.Context, logql.StreamPipeline) iter.EntryIterator
 	// SampleIterator returns a sample iterator for the block.
-	SampleIterator(context.Context, logql.LineFilter, logql.SampleExtractor) iter.SampleIterator
+	SampleIterator(context.Context, logql.StreamSampleExtractor) iter.SampleIterator
 }
@@ -9,12 +9,14 @@ import (
 	"hash"
 	"hash/crc32"
 	"io"
+	"sort"
 	"time"
 
 	"github.com/cespare/xxhash/v2"
 	"github.com/cortexproject/cortex/pkg/util"
 	"github.com/go-kit/kit/log/level"
 	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
 
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
@@ -32,6 +34,18 @@ var (
 
 	chunkFormatV1 = byte(1)
 	chunkFormatV2 = byte(2)
+	// chunkFormatV3 has the same layout as v2, but the entries are only ordered within each block:
+	// the blocks of the chunk may overlap in time.
+	chunkFormatV3 = byte(3)
+	// chunkFormatV4 adds a byte of flags after the encoding, telling whether the chunk is unordered like v3,
+	// and a bloom filter of the tokens of the lines of each block to the blocks metadata.
+	chunkFormatV4 = byte(4)
+	// chunkFormatV5 has the same layout as v4, but each entry of the blocks is followed by its structured metadata,
+	// and a flag tells whether the blocks have a bloom filter. The blocks without filter have an empty one.
+	chunkFormatV5 = byte(5)
+
+	chunkFlagUnordered    = byte(1 << 0)
+	chunkFlagBlockFilters = byte(1 << 1)
 )
 
 // The table gets initialized with sync.Once but may still cause a race
@@ -64,9 +78,12 @@ type MemChunk struct {
 	// Current in-mem block being appended to.
 	head *headBlock
 
-	// the chunk format default to v2
+	// the chunk format default to v2, v3 for unordered chunks, v4 for chunks with block filters,
+	// v5 for chunks with structured metadata.
 	format   byte
 	encoding Encoding
+	// whether a bloom filter of the tokens of the lines is built for the blocks.
+	blockFilters bool
 
 	readers ReaderPool
 	writers WriterPool
@@ -82,6 +99,11 @@ type block struct {
 	offset           int // The offset of the block in the chunk.
 	uncompressedSize int // Total uncompressed size in bytes when the chunk is cut.
 
+	// The bloom filter of the tokens of the lines, only for the v4 chunk format and above.
+	filter blockFilter
+	// whether the entries are followed by their structured metadata, for the v5 chunk format.
+	structuredMetadata bool
+
 	readers ReaderPool
 }
 
@@ -93,27 +115,48 @@ type headBlock struct {
 	size    int // size of uncompressed bytes.
 
 	mint, maxt int64
+
+	// unordered head blocks accept entries in any order, they are sorted when the block is cut.
+	unordered bool
+	// whether the entries can have structured metadata.
+	structuredMetadata bool
 }
 
 func (hb *headBlock) isEmpty() bool {
 	return len(hb.entries) == 0
 }
 
-func (hb *headBlock) append(ts int64, line string) error {
-	if !hb.isEmpty() && hb.maxt > ts {
+func (hb *headBlock) append(ts int64, line string, metadata labels.Labels) error {
+	if len(metadata) > 0 && !hb.structuredMetadata {
+		return ErrStructuredMetadata
+	}
+	if !hb.unordered && !hb.isEmpty() && hb.maxt > ts {
 		return ErrOutOfOrder
 	}
 
-	hb.entries = append(hb.entries, entry{ts, line})
+	hb.entries = append(hb.entries, entry{ts, line, metadata})
 	if hb.mint == 0 || hb.mint > ts {
 		hb.mint = ts
 	}
-	hb.maxt = ts
-	hb.size += len(line)
+	if hb.maxt < ts {
+		hb.maxt = ts
+	}
+	hb.size += len(line) + metadataSize(metadata)
 
 	return nil
 }
 
+// sort sorts the entries of an unordered head block by timestamp, keeping the order of the entries
+// with the same timestamp.
+func (hb *headBlock) sort() {
+	if !hb.unordered {
+		return
+	}
+	sort.SliceStable(hb.entries, func(i, j int) bool {
+		return hb.entries[i].t < hb.entries[j].t
+	})
+}
+
 func (hb *headBlock) serialise(pool WriterPool) ([]byte, error) {
 	inBuf := serializeBytesBufferPool.Get().(*bytes.Buffer)
 	defer func() {
@@ -133,6 +176,19 @@ func (hb *headBlock) serialise(pool WriterPool) ([]byte, error) {
 		inBuf.Write(encBuf[:n])
 
 		inBuf.WriteString(logEntry.s)
+
+		if hb.structuredMetadata {
+			n = binary.PutUvarint(encBuf, uint64(len(logEntry.metadata)))
+			inBuf.Write(encBuf[:n])
+			for _, m := range logEntry.metadata {
+				n = binary.PutUvarint(encBuf, uint64(len(m.Name)))
+				inBuf.Write(encBuf[:n])
+				inBuf.WriteString(m.Name)
+				n = binary.PutUvarint(encBuf, uint64(len(m.Value)))
+				inBuf.Write(encBuf[:n])
+				inBuf.WriteString(m.Value)
+			}
+		}
 	}
 
 	if _, err := compressedWriter.Write(inBuf.Bytes()); err != nil {
@@ -145,9 +201,63 @@ func (hb *headBlock) serialise(pool WriterPool) ([]byte, error) {
 	return outBuf.Bytes(), nil
 }
 
+// checkpointBytes returns the uncompressed entries of the head block, along with their structured metadata
+// when the head block can have some.
+func (hb *headBlock) checkpointBytes() []byte {
+	eb := encbuf{b: make([]byte, 0, hb.size+len(hb.entries)*3*binary.MaxVarintLen64+binary.MaxVarintLen64)}
+	eb.putUvarint(len(hb.entries))
+	for _, e := range hb.entries {
+		eb.putVarint64(e.t)
+		eb.putUvarintStr(e.s)
+		if hb.structuredMetadata {
+			eb.putUvarint(len(e.metadata))
+			for _, m := range e.metadata {
+				eb.putUvarintStr(m.Name)
+				eb.putUvarintStr(m.Value)
+			}
+		}
+	}
+	return eb.get()
+}
+
+// loadCheckpointBytes appends the entries returned by checkpointBytes to the head block.
+func (hb *headBlock) loadCheckpointBytes(b []byte) error {
+	db := decbuf{b: b}
+	num := db.uvarint()
+	for i := 0; i < num && db.err() == nil; i++ {
+		ts, line := db.varint64(), db.uvarintStr()
+		var metadata labels.Labels
+		if hb.structuredMetadata {
+			if n := db.uvarint(); n > 0 && db.err() == nil {
+				metadata = make(labels.Labels, 0, n)
+				for j := 0; j < n && db.err() == nil; j++ {
+					metadata = append(metadata, labels.Label{Name: db.uvarintStr(), Value: db.uvarintStr()})
+				}
+			}
+		}
+		if db.err() != nil {
+			break
+		}
+		if err := hb.append(ts, line, metadata); err != nil {
+			return err
+		}
+	}
+	return db.err()
+}
+
 type entry struct {
-	t int64
-	s string
+	t        int64
+	s        string
+	metadata labels.Labels
+}
+
+// metadataSize returns the size of the names and values of the structured metadata.
+func metadataSize(metadata labels.Labels) int {
+	size := 0
+	for _, m := range metadata {
+		size += len(m.Name) + len(m.Value)
+	}
+	return size
 }
 
 // NewMemChunk returns a new in-mem chunk.
@@ -168,6 +278,32 @@ func NewMemChunk(enc Encoding, blockSize, targetSize int) *MemChunk {
 	return c
 }
 
+// NewUnorderedMemChunk returns a new in-mem chunk accepting entries in any order.
+// Its blocks may overlap in time, they are encoded with the v3 chunk format.
+func NewUnorderedMemChunk(enc Encoding, blockSize, targetSize int) *MemChunk {
+	c := NewMemChunk(enc, blockSize, targetSize)
+	c.format = chunkFormatV3
+	c.head.unordered = true
+	return c
+}
+
+// EnableBlockFilters makes the chunk store a bloom filter of the tokens of the lines of each of its blocks,
+// allowing to skip the blocks which can't contain the literals of the line filters of a query.
+// The chunk is encoded with at least the v4 chunk format, it must be called before the first block is cut.
+func (c *MemChunk) EnableBlockFilters() {
+	c.blockFilters = true
+	if c.format < chunkFormatV4 {
+		c.format = chunkFormatV4
+	}
+}
+
+// EnableStructuredMetadata makes the chunk accept entries with structured metadata, which are stored along
+// with their lines. The chunk is encoded with the v5 chunk format, it must be called before the first append.
+func (c *MemChunk) EnableStructuredMetadata() {
+	c.format = chunkFormatV5
+	c.head.structuredMetadata = true
+}
+
 // NewByteChunk returns a MemChunk on the passed bytes.
 func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	bc := &MemChunk{
@@ -189,8 +325,8 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	switch version {
 	case chunkFormatV1:
 		bc.readers, bc.writers = &Gzip, &Gzip
-	case chunkFormatV2:
-		// format v2 has a byte for block encoding.
+	case chunkFormatV2, chunkFormatV3, chunkFormatV4, chunkFormatV5:
+		// format v2 and above have a byte for block encoding.
 		enc := Encoding(db.byte())
 		if db.err() != nil {
 			return nil, errors.Wrap(db.err(), "verifying encoding")
@@ -200,6 +336,19 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 	default:
 		return nil, errors.Errorf("invalid version %d", version)
 	}
+	switch version {
+	case chunkFormatV3:
+		bc.head.unordered = true
+	case chunkFormatV4, chunkFormatV5:
+		flags := db.byte()
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "verifying flags")
+		}
+		bc.head.unordered = flags&chunkFlagUnordered != 0
+		// v4 chunks always have block filters.
+		bc.blockFilters = version == chunkFormatV4 || flags&chunkFlagBlockFilters != 0
+		bc.head.structuredMetadata = version >= chunkFormatV5
+	}
 
 	metasOffset := binary.BigEndian.Uint64(b[len(b)-8:])
 	mb := b[metasOffset : len(b)-(8+4)] // storing the metasOffset + checksum of meta
@@ -216,7 +365,8 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 
 	for i := 0; i < num; i++ {
 		blk := block{
-			readers: bc.readers,
+			readers:            bc.readers,
+			structuredMetadata: bc.head.structuredMetadata,
 		}
 		// Read #entries.
 		blk.numEntries = db.uvarint()
@@ -230,6 +380,12 @@ func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
 		l := db.uvarint()
 		blk.b = b[blk.offset : blk.offset+l]
 
+		if version >= chunkFormatV4 {
+			if filter := db.uvarintBytes(); len(filter) > 0 {
+				blk.filter = filter
+			}
+		}
+
 		// Verify checksums.
 		expCRC := binary.BigEndian.Uint32(b[blk.offset+l:])
 		if expCRC != crc32.Checksum(blk.b, castagnoliTable) {
@@ -258,6 +414,35 @@ func (c *MemChunk) Bytes() ([]byte, error) {
 			return nil, err
 		}
 	}
+	return c.bytes(true)
+}
+
+// CheckpointBytes returns the bytes of the cut blocks, in the chunk format, and the bytes of the head block.
+// Unlike Bytes, the head block is not cut so that the chunk is left untouched and can be restored as it is
+// by MemChunkFromCheckpoint.
+func (c *MemChunk) CheckpointBytes() (chk, head []byte, err error) {
+	chk, err = c.bytes(false)
+	if err != nil {
+		return nil, nil, err
+	}
+	return chk, c.head.checkpointBytes(), nil
+}
+
+// MemChunkFromCheckpoint restores a MemChunk from the bytes returned by CheckpointBytes.
+func MemChunkFromCheckpoint(chk, head []byte, blockSize, targetSize int) (*MemChunk, error) {
+	c, err := NewByteChunk(chk, blockSize, targetSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.head.loadCheckpointBytes(head); err != nil {
+		return nil, errors.Wrap(err, "decoding head block")
+	}
+	return c, nil
+}
+
+// bytes encodes the chunk, the offsets of the blocks are only updated when setOffsets is true
+// so that the chunk can be encoded while being read.
+func (c *MemChunk) bytes(setOffsets bool) ([]byte, error) {
 	crc32Hash := newCRC32()
 
 	buf := bytes.NewBuffer(nil)
@@ -268,10 +453,20 @@ func (c *MemChunk) Bytes() ([]byte, error) {
 	// Write the header (magicNum + version).
 	eb.putBE32(magicNumber)
 	eb.putByte(c.format)
-	if c.format == chunkFormatV2 {
+	if c.format >= chunkFormatV2 {
 		// chunk format v2 has a byte for encoding.
 		eb.putByte(byte(c.encoding))
 	}
+	if c.format >= chunkFormatV4 {
+		var flags byte
+		if c.head.unordered {
+			flags |= chunkFlagUnordered
+		}
+		if c.format >= chunkFormatV5 && c.blockFilters {
+			flags |= chunkFlagBlockFilters
+		}
+		eb.putByte(flags)
+	}
 
 	n, err := buf.Write(eb.get())
 	if err != nil {
@@ -280,8 +475,12 @@ func (c *MemChunk) Bytes() ([]byte, error) {
 	offset += n
 
 	// Write Blocks.
+	offsets := make([]int, len(c.blocks))
 	for i, b := range c.blocks {
-		c.blocks[i].offset = offset
+		offsets[i] = offset
+		if setOffsets {
+			c.blocks[i].offset = offset
+		}
 
 		eb.reset()
 		eb.putBytes(b.b)
@@ -300,12 +499,15 @@ func (c *MemChunk) Bytes() ([]byte, error) {
 	eb.putUvarint(len(c.blocks))
 
 	// Write BlockMetas.
-	for _, b := range c.blocks {
+	for i, b := range c.blocks {
 		eb.putUvarint(b.numEntries)
 		eb.putVarint64(b.mint)
 		eb.putVarint64(b.maxt)
-		eb.putUvarint(b.offset)
+		eb.putUvarint(offsets[i])
 		eb.putUvarint(len(b.b))
+		if c.format >= chunkFormatV4 {
+			eb.putUvarintBytes(b.filter)
+		}
 	}
 	eb.putHash(crc32Hash)
 
@@ -344,17 +546,27 @@ func (c *MemChunk) Size() int {
 	return ne
 }
 
+// Ordered implements Chunk.
+func (c *MemChunk) Ordered() bool {
+	return !c.head.unordered
+}
+
 // BlockCount implements Chunk.
 func (c *MemChunk) BlockCount() int {
 	return len(c.blocks)
 }
 
 // SpaceFor implements Chunk.
+// An entry with structured metadata never fits in a non-empty chunk which can't store them, so that it is
+// appended to a new chunk.
 func (c *MemChunk) SpaceFor(e *logproto.Entry) bool {
+	if len(e.StructuredMetadata) > 0 && !c.head.structuredMetadata && c.Size() > 0 {
+		return false
+	}
 	if c.targetSize > 0 {
 		// This is looking to see if the uncompressed lines will fit which is not
 		// a great check, but it will guarantee we are always under the target size
-		newHBSize := c.head.size + len(e.Line)
+		newHBSize := c.head.size + len(e.Line) + metadataSize(logproto.StructuredMetadataLabels(e.StructuredMetadata))
 		return (c.cutBlockSize + newHBSize) < c.targetSize
 	}
 	// if targetSize is not defined, default to the original behavior of fixed blocks per chunk
@@ -403,11 +615,11 @@ func (c *MemChunk) Append(entry *logproto.Entry) error {
 
 	// If the head block is empty but there are cut blocks, we have to make
 	// sure the new entry is not out of order compared to the previous block
-	if c.head.isEmpty() && len(c.blocks) > 0 && c.blocks[len(c.blocks)-1].maxt > entryTimestamp {
+	if c.Ordered() && c.head.isEmpty() && len(c.blocks) > 0 && c.blocks[len(c.blocks)-1].maxt > entryTimestamp {
 		return ErrOutOfOrder
 	}
 
-	if err := c.head.append(entryTimestamp, entry.Line); err != nil {
+	if err := c.head.append(entryTimestamp, entry.Line, logproto.StructuredMetadataLabels(entry.StructuredMetadata)); err != nil {
 		return err
 	}
 
@@ -430,24 +642,33 @@ func (c *MemChunk) cut() error {
 		return nil
 	}
 
+	c.head.sort()
 	b, err := c.head.serialise(c.writers)
 	if err != nil {
 		return err
 	}
 
+	var filter blockFilter
+	if c.blockFilters {
+		filter = newBlockFilter(c.head.entries)
+	}
+
 	c.blocks = append(c.blocks, block{
-		readers:          c.readers,
-		b:                b,
-		numEntries:       len(c.head.entries),
-		mint:             c.head.mint,
-		maxt:             c.head.maxt,
-		uncompressedSize: c.head.size,
+		readers:            c.readers,
+		b:                  b,
+		numEntries:         len(c.head.entries),
+		mint:               c.head.mint,
+		maxt:               c.head.maxt,
+		uncompressedSize:   c.head.size,
+		filter:             filter,
+		structuredMetadata: c.head.structuredMetadata,
 	})
 
 	c.cutBlockSize += len(b)
 
 	c.head.entries = c.head.entries[:0]
 	c.head.mint = 0 // Will be set on first append.
+	c.head.maxt = 0
 	c.head.size = 0
 
 	return nil
@@ -460,6 +681,17 @@ func (c *MemChunk) Bounds() (fromT, toT time.Time) {
 		from = c.blocks[0].mint
 		to = c.blocks[len(c.blocks)-1].maxt
 	}
+	if !c.Ordered() {
+		// The blocks of unordered chunks may overlap.
+		for _, b := range c.blocks {
+			if b.mint < from {
+				from = b.mint
+			}
+			if b.maxt > to {
+				to = b.maxt
+			}
+		}
+	}
 
 	if !c.head.isEmpty() {
 		if from == 0 || from > c.head.mint {
@@ -475,7 +707,7 @@ func (c *MemChunk) Bounds() (fromT, toT time.Time) {
 }
 
 // Iterator implements Chunk.
-func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, direction logproto.Direction, filter logql.LineFilter) (iter.EntryIterator, error) {
+func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, direction logproto.Direction, pipeline logql.StreamPipeline) (iter.EntryIterator, error) {
 	mint, maxt := mintT.UnixNano(), maxtT.UnixNano()
 	its := make([]iter.EntryIterator, 0, len(c.blocks)+1)
 
@@ -483,15 +715,21 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 		if maxt < b.mint || b.maxt < mint {
 			continue
 		}
-		its = append(its, b.Iterator(ctx, filter))
+		its = append(its, b.Iterator(ctx, pipeline))
 	}
 
 	if !c.head.isEmpty() {
-		its = append(its, c.head.iterator(ctx, mint, maxt, filter))
+		its = append(its, c.head.iterator(ctx, mint, maxt, pipeline))
 	}
 
+	var it iter.EntryIterator
+	if c.Ordered() {
+		it = iter.NewNonOverlappingIterator(its, "")
+	} else {
+		it = iter.NewHeapIterator(ctx, its, logproto.FORWARD)
+	}
 	iterForward := iter.NewTimeRangedIterator(
-		iter.NewNonOverlappingIterator(its, ""),
+		it,
 		time.Unix(0, mint),
 		time.Unix(0, maxt),
 	)
@@ -503,8 +741,8 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 	return iter.NewEntryReversedIter(iterForward)
 }
 
-// Iterator implements Chunk.
-func (c *MemChunk) SampleIterator(ctx context.Context, mintT, maxtT time.Time, filter logql.LineFilter, extractor logql.SampleExtractor) iter.SampleIterator {
+// SampleIterator implements Chunk.
+func (c *MemChunk) SampleIterator(ctx context.Context, mintT, maxtT time.Time, extractor logql.StreamSampleExtractor) iter.SampleIterator {
 	mint, maxt := mintT.UnixNano(), maxtT.UnixNano()
 	its := make([]iter.SampleIterator, 0, len(c.blocks)+1)
 
@@ -512,15 +750,21 @@ func (c *MemChunk) SampleIterator(ctx context.Context, mintT, maxtT time.Time, f
 		if maxt < b.mint || b.maxt < mint {
 			continue
 		}
-		its = append(its, b.SampleIterator(ctx, filter, extractor))
+		its = append(its, b.SampleIterator(ctx, extractor))
 	}
 
 	if !c.head.isEmpty() {
-		its = append(its, c.head.sampleIterator(ctx, mint, maxt, filter, extractor))
+		its = append(its, c.head.sampleIterator(ctx, mint, maxt, extractor))
 	}
 
+	var it iter.SampleIterator
+	if c.Ordered() {
+		it = iter.NewNonOverlappingSampleIterator(its, "")
+	} else {
+		it = iter.NewHeapSampleIterator(ctx, its)
+	}
 	return iter.NewTimeRangedSampleIterator(
-		iter.NewNonOverlappingSampleIterator(its, ""),
+		it,
 		mint,
 		maxt,
 	)
@@ -539,18 +783,36 @@ func (c *MemChunk) Blocks(mintT, maxtT time.Time) []Block {
 	return blocks
 }
 
-func (b block) Iterator(ctx context.Context, filter logql.LineFilter) iter.EntryIterator {
-	if len(b.b) == 0 {
-		return emptyIterator
+func (b block) Iterator(ctx context.Context, pipeline logql.StreamPipeline) iter.EntryIterator {
+	if len(b.b) == 0 || b.skip(ctx, pipeline) {
+		return iter.NoopIterator
 	}
-	return newEntryIterator(ctx, b.readers, b.b, filter)
+	return newEntryIterator(ctx, b.readers, b.b, b.structuredMetadata, pipeline)
 }
 
-func (b block) SampleIterator(ctx context.Context, filter logql.LineFilter, extractor logql.SampleExtractor) iter.SampleIterator {
-	if len(b.b) == 0 {
+func (b block) SampleIterator(ctx context.Context, extractor logql.StreamSampleExtractor) iter.SampleIterator {
+	if len(b.b) == 0 || b.skip(ctx, extractor) {
 		return iter.NoopIterator
 	}
-	return newSampleIterator(ctx, b.readers, b.b, filter, extractor)
+	return newSampleIterator(ctx, b.readers, b.b, b.structuredMetadata, extractor)
+}
+
+// skip returns true when the filter of the block tells that none of its lines contain one of the literals
+// required by the pipeline or extractor.
+func (b block) skip(ctx context.Context, pipeline interface{}) bool {
+	chunkStats := stats.GetChunkData(ctx)
+	chunkStats.TotalBlocks++
+	f, ok := pipeline.(logql.LiteralFilterer)
+	if b.filter == nil || !ok {
+		return false
+	}
+	for _, literal := range f.RequiredLiterals() {
+		if !b.filter.mayContain(literal) {
+			chunkStats.SkippedBlocks++
+			return true
+		}
+	}
+	return false
 }
 
 func (b block) Offset() int {
@@ -567,9 +829,9 @@ func (b block) MaxTime() int64 {
 	return b.maxt
 }
 
-func (hb *headBlock) iterator(ctx context.Context, mint, maxt int64, filter logql.LineFilter) iter.EntryIterator {
+func (hb *headBlock) iterator(ctx context.Context, mint, maxt int64, pipeline logql.StreamPipeline) iter.EntryIterator {
 	if hb.isEmpty() || (maxt < hb.mint || hb.maxt < mint) {
-		return emptyIterator
+		return iter.NoopIterator
 	}
 
 	chunkStats := stats.GetChunkData(ctx)
@@ -579,82 +841,85 @@ func (hb *headBlock) iterator(ctx context.Context, mint, maxt int64, filter logq
 	// but the tradeoff is that queries to near-realtime data would be much lower than
 	// cutting of blocks.
 	chunkStats.HeadChunkLines += int64(len(hb.entries))
-	entries := make([]entry, 0, len(hb.entries))
+	streams := map[uint64]*logproto.Stream{}
 	for _, e := range hb.entries {
 		chunkStats.HeadChunkBytes += int64(len(e.s))
-		if filter == nil || filter.Filter([]byte(e.s)) {
-			entries = append(entries, e)
+		line, lbs, ok := pipeline.Process([]byte(e.s), e.metadata...)
+		if !ok {
+			continue
+		}
+		var stream *logproto.Stream
+		if stream, ok = streams[lbs.Hash()]; !ok {
+			stream = &logproto.Stream{
+				Labels: lbs.String(),
+			}
+			streams[lbs.Hash()] = stream
 		}
+		stream.Entries = append(stream.Entries, logproto.Entry{
+			Timestamp: time.Unix(0, e.t),
+			Line:      string(line),
+		})
 	}
 
-	if len(entries) == 0 {
-		return emptyIterator
+	if len(streams) == 0 {
+		return iter.NoopIterator
 	}
-
-	return &listIterator{
-		entries: entries,
-		cur:     -1,
+	streamsResult := make([]logproto.Stream, 0, len(streams))
+	for _, stream := range streams {
+		if hb.unordered {
+			entries := stream.Entries
+			sort.SliceStable(entries, func(i, j int) bool {
+				return entries[i].Timestamp.Before(entries[j].Timestamp)
+			})
+		}
+		streamsResult = append(streamsResult, *stream)
 	}
+	return iter.NewStreamsIterator(ctx, streamsResult, logproto.FORWARD)
 }
 
-func (hb *headBlock) sampleIterator(ctx context.Context, mint, maxt int64, filter logql.LineFilter, extractor logql.SampleExtractor) iter.SampleIterator {
+func (hb *headBlock) sampleIterator(ctx context.Context, mint, maxt int64, extractor logql.StreamSampleExtractor) iter.SampleIterator {
 	if hb.isEmpty() || (maxt < hb.mint || hb.maxt < mint) {
 		return iter.NoopIterator
 	}
 	chunkStats := stats.GetChunkData(ctx)
 	chunkStats.HeadChunkLines += int64(len(hb.entries))
-	samples := make([]logproto.Sample, 0, len(hb.entries))
+	series := map[uint64]*logproto.Series{}
 	for _, e := range hb.entries {
 		chunkStats.HeadChunkBytes += int64(len(e.s))
-		if filter == nil || filter.Filter([]byte(e.s)) {
-			if value, ok := extractor.Extract([]byte(e.s)); ok {
-				samples = append(samples, logproto.Sample{
-					Timestamp: e.t,
-					Value:     value,
-					Hash:      xxhash.Sum64([]byte(e.s)),
-				})
-
+		value, lbs, ok := extractor.Process([]byte(e.s), e.metadata...)
+		if !ok {
+			continue
+		}
+		var s *logproto.Series
+		if s, ok = series[lbs.Hash()]; !ok {
+			s = &logproto.Series{
+				Labels: lbs.String(),
 			}
+			series[lbs.Hash()] = s
 		}
+		s.Samples = append(s.Samples, logproto.Sample{
+			Timestamp: e.t,
+			Value:     value,
+			Hash:      xxhash.Sum64([]byte(e.s)),
+		})
 	}
 
-	if len(samples) == 0 {
+	if len(series) == 0 {
 		return iter.NoopIterator
 	}
-
-	return iter.NewSeriesIterator(logproto.Series{Samples: samples})
-}
-
-var emptyIterator = &listIterator{}
-
-type listIterator struct {
-	entries []entry
-	cur     int
-}
-
-func (li *listIterator) Next() bool {
-	li.cur++
-
-	return li.cur < len(li.entries)
-}
-
-func (li *listIterator) Entry() logproto.Entry {
-	if li.cur < 0 || li.cur >= len(li.entries) {
-		return logproto.Entry{}
-	}
-
-	cur := li.entries[li.cur]
-
-	return logproto.Entry{
-		Timestamp: time.Unix(0, cur.t),
-		Line:      cur.s,
+	seriesRes := make([]logproto.Series, 0, len(series))
+	for _, s := range series {
+		if hb.unordered {
+			samples := s.Samples
+			sort.SliceStable(samples, func(i, j int) bool {
+				return samples[i].Timestamp < samples[j].Timestamp
+			})
+		}
+		seriesRes = append(seriesRes, *s)
 	}
+	return iter.NewMultiSeriesIterator(ctx, seriesRes)
 }
 
-func (li *listIterator) Error() error   { return nil }
-func (li *listIterator) Close() error   { return nil }
-func (li *listIterator) Labels() string { return "" }
-
 type bufferedIterator struct {
 	origBytes []byte
 	stats     *stats.ChunkData
@@ -671,23 +936,25 @@ type bufferedIterator struct {
 	currTs   int64
 	consumed bool
 
-	closed bool
+	// whether the entries are followed by their structured metadata.
+	structuredMetadata bool
+	currMetadata       labels.Labels
 
-	filter logql.LineFilter
+	closed bool
 }
 
-func newBufferedIterator(ctx context.Context, pool ReaderPool, b []byte, filter logql.LineFilter) *bufferedIterator {
+func newBufferedIterator(ctx context.Context, pool ReaderPool, b []byte, structuredMetadata bool) *bufferedIterator {
 	chunkStats := stats.GetChunkData(ctx)
 	chunkStats.CompressedBytes += int64(len(b))
 	return &bufferedIterator{
-		stats:     chunkStats,
-		origBytes: b,
-		reader:    nil, // will be initialized later
-		bufReader: nil, // will be initialized later
-		pool:      pool,
-		filter:    filter,
-		decBuf:    make([]byte, binary.MaxVarintLen64),
-		consumed:  true,
+		stats:              chunkStats,
+		origBytes:          b,
+		reader:             nil, // will be initialized later
+		bufReader:          nil, // will be initialized later
+		pool:               pool,
+		decBuf:             make([]byte, binary.MaxVarintLen64),
+		consumed:           true,
+		structuredMetadata: structuredMetadata,
 	}
 }
 
@@ -707,12 +974,16 @@ func (si *bufferedIterator) Next() bool {
 		// we decode always the line length and ts as varint
 		si.stats.DecompressedBytes += int64(len(line)) + 2*binary.MaxVarintLen64
 		si.stats.DecompressedLines++
-		if si.filter != nil && !si.filter.Filter(line) {
-			continue
-		}
 		si.currTs = ts
 		si.currLine = line
 		si.consumed = false
+		if si.structuredMetadata {
+			if !si.readMetadata() {
+				si.Close()
+				return false
+			}
+			si.stats.DecompressedBytes += int64(metadataSize(si.currMetadata))
+		}
 		return true
 	}
 }
@@ -769,6 +1040,45 @@ func (si *bufferedIterator) moveNext() (int64, []byte, bool) {
 	return ts, si.buf[:lineSize], true
 }
 
+// readMetadata reads the structured metadata following the current line.
+func (si *bufferedIterator) readMetadata() bool {
+	n, err := binary.ReadUvarint(si.bufReader)
+	if err != nil {
+		si.err = errors.Wrap(err, "reading structured metadata")
+		return false
+	}
+	si.currMetadata = si.currMetadata[:0]
+	for i := uint64(0); i < n; i++ {
+		name, err := si.readString()
+		if err != nil {
+			si.err = errors.Wrap(err, "reading structured metadata")
+			return false
+		}
+		value, err := si.readString()
+		if err != nil {
+			si.err = errors.Wrap(err, "reading structured metadata")
+			return false
+		}
+		si.currMetadata = append(si.currMetadata, labels.Label{Name: name, Value: value})
+	}
+	return true
+}
+
+func (si *bufferedIterator) readString() (string, error) {
+	l, err := binary.ReadUvarint(si.bufReader)
+	if err != nil {
+		return "", err
+	}
+	if l >= maxLineLength {
+		return "", fmt.Errorf("structured metadata too long %d, maximum %d", l, maxLineLength)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(si.bufReader, b); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
 func (si *bufferedIterator) Error() error { return si.err }
 
 func (si *bufferedIterator) Close() error {
@@ -799,15 +1109,19 @@ func (si *bufferedIterator) close() {
 
 func (si *bufferedIterator) Labels() string { return "" }
 
-func newEntryIterator(ctx context.Context, pool ReaderPool, b []byte, filter logql.LineFilter) iter.EntryIterator {
+func newEntryIterator(ctx context.Context, pool ReaderPool, b []byte, structuredMetadata bool, pipeline logql.StreamPipeline) iter.EntryIterator {
 	return &entryBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, filter),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, structuredMetadata),
+		pipeline:         pipeline,
 	}
 }
 
 type entryBufferedIterator struct {
 	*bufferedIterator
-	cur logproto.Entry
+	pipeline logql.StreamPipeline
+
+	cur        logproto.Entry
+	currLabels logql.LabelsResult
 }
 
 func (e *entryBufferedIterator) Entry() logproto.Entry {
@@ -819,9 +1133,24 @@ func (e *entryBufferedIterator) Entry() logproto.Entry {
 	return e.cur
 }
 
-func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, filter logql.LineFilter, extractor logql.SampleExtractor) iter.SampleIterator {
+func (e *entryBufferedIterator) Labels() string { return e.currLabels.String() }
+
+func (e *entryBufferedIterator) Next() bool {
+	for e.bufferedIterator.Next() {
+		newLine, lbs, ok := e.pipeline.Process(e.currLine, e.currMetadata...)
+		if !ok {
+			continue
+		}
+		e.currLine = newLine
+		e.currLabels = lbs
+		return true
+	}
+	return false
+}
+
+func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, structuredMetadata bool, extractor logql.StreamSampleExtractor) iter.SampleIterator {
 	it := &sampleBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, filter),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, structuredMetadata),
 		extractor:        extractor,
 	}
 	return it
@@ -829,21 +1158,28 @@ func newSampleIterator(ctx context.Context, pool ReaderPool, b []byte, filter lo
 
 type sampleBufferedIterator struct {
 	*bufferedIterator
-	extractor logql.SampleExtractor
-	cur       logproto.Sample
-	currValue float64
+	extractor logql.StreamSampleExtractor
+
+	cur        logproto.Sample
+	currValue  float64
+	currLabels logql.LabelsResult
 }
 
 func (e *sampleBufferedIterator) Next() bool {
-	var ok bool
 	for e.bufferedIterator.Next() {
-		if e.currValue, ok = e.extractor.Extract(e.currLine); ok {
-			return true
+		val, labels, ok := e.extractor.Process(e.currLine, e.currMetadata...)
+		if !ok {
+			continue
 		}
+		e.currValue = val
+		e.currLabels = labels
+		return true
 	}
 	return false
 }
 
+func (e *sampleBufferedIterator) Labels() string { return e.currLabels.String() }
+
 func (e *sampleBufferedIterator) Sample() logproto.Sample {
 	if !e.consumed {
 		e.cur.Timestamp = e.currTs
@@ -7,11 +7,13 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/dustin/go-humanize"
+	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
@@ -30,11 +32,16 @@ var testEncoding = []Encoding{
 	EncLZ4_1M,
 	EncLZ4_4M,
 	EncSnappy,
+	EncFlate,
+	EncZstd,
+	EncZstdFast,
 }
 
 var (
-	testBlockSize  = 256 * 1024
-	testTargetSize = 1500 * 1024
+	testBlockSize      = 256 * 1024
+	testTargetSize     = 1500 * 1024
+	noopStreamPipeline = logql.NoopPipeline.ForStream(labels.Labels{})
+	countExtractor     = logql.ExtractCount.ToSampleExtractor().ForStream(labels.Labels{})
 )
 
 func TestBlocksInclusive(t *testing.T) {
@@ -112,7 +119,7 @@ func TestBlock(t *testing.T) {
 				}
 			}
 
-			it, err := chk.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, nil)
+			it, err := chk.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
 			require.NoError(t, err)
 
 			idx := 0
@@ -127,7 +134,7 @@ func TestBlock(t *testing.T) {
 			require.NoError(t, it.Close())
 			require.Equal(t, len(cases), idx)
 
-			sampleIt := chk.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), nil, logql.ExtractCount)
+			sampleIt := chk.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), countExtractor)
 			idx = 0
 			for sampleIt.Next() {
 				s := sampleIt.Sample()
@@ -142,7 +149,7 @@ func TestBlock(t *testing.T) {
 			require.Equal(t, len(cases), idx)
 
 			t.Run("bounded-iteration", func(t *testing.T) {
-				it, err := chk.Iterator(context.Background(), time.Unix(0, 3), time.Unix(0, 7), logproto.FORWARD, nil)
+				it, err := chk.Iterator(context.Background(), time.Unix(0, 3), time.Unix(0, 7), logproto.FORWARD, noopStreamPipeline)
 				require.NoError(t, err)
 
 				idx := 2
@@ -175,7 +182,7 @@ func TestReadFormatV1(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	it, err := r.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, nil)
+	it, err := r.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -202,7 +209,7 @@ func TestRoundtripV2(t *testing.T) {
 
 			assertLines := func(c *MemChunk) {
 				require.Equal(t, enc, c.Encoding())
-				it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, nil)
+				it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -264,7 +271,7 @@ func TestSerialization(t *testing.T) {
 			bc, err := NewByteChunk(byt, testBlockSize, testTargetSize)
 			require.NoError(t, err)
 
-			it, err := bc.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, nil)
+			it, err := bc.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
 			require.NoError(t, err)
 			for i := 0; i < numSamples; i++ {
 				require.True(t, it.Next())
@@ -275,7 +282,7 @@ func TestSerialization(t *testing.T) {
 			}
 			require.NoError(t, it.Error())
 
-			sampleIt := bc.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), nil, logql.ExtractCount)
+			sampleIt := bc.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), countExtractor)
 			for i := 0; i < numSamples; i++ {
 				require.True(t, sampleIt.Next(), i)
 
@@ -293,6 +300,49 @@ func TestSerialization(t *testing.T) {
 	}
 }
 
+func TestCheckpointSerialization(t *testing.T) {
+	for _, enc := range testEncoding {
+		t.Run(enc.String(), func(t *testing.T) {
+			chk := NewMemChunk(enc, testBlockSize, testTargetSize)
+
+			numSamples := 50000
+			for i := 0; i < numSamples; i++ {
+				require.NoError(t, chk.Append(logprotoEntry(int64(i), strconv.Itoa(i))))
+			}
+			require.False(t, chk.head.isEmpty())
+			blocks := chk.BlockCount()
+
+			b, head, err := chk.CheckpointBytes()
+			require.NoError(t, err)
+			// the chunk is left untouched.
+			require.Equal(t, blocks, chk.BlockCount())
+			require.False(t, chk.head.isEmpty())
+
+			restored, err := MemChunkFromCheckpoint(b, head, testBlockSize, testTargetSize)
+			require.NoError(t, err)
+			require.Equal(t, blocks, restored.BlockCount())
+			require.Equal(t, chk.Size(), restored.Size())
+			require.Equal(t, chk.CompressedSize(), restored.CompressedSize())
+
+			// the restored chunk can be appended to.
+			require.NoError(t, restored.Append(logprotoEntry(int64(numSamples), strconv.Itoa(numSamples))))
+			require.Equal(t, ErrOutOfOrder, restored.Append(logprotoEntry(0, "0")))
+
+			it, err := restored.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+			require.NoError(t, err)
+			for i := 0; i <= numSamples; i++ {
+				require.True(t, it.Next())
+
+				e := it.Entry()
+				require.Equal(t, int64(i), e.Timestamp.UnixNano())
+				require.Equal(t, strconv.Itoa(i), e.Line)
+			}
+			require.False(t, it.Next())
+			require.NoError(t, it.Error())
+		})
+	}
+}
+
 func TestChunkFilling(t *testing.T) {
 	for _, enc := range testEncoding {
 		t.Run(enc.String(), func(t *testing.T) {
@@ -318,7 +368,7 @@ func TestChunkFilling(t *testing.T) {
 
 			require.Equal(t, int64(lines), i)
 
-			it, err := chk.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, nil)
+			it, err := chk.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, noopStreamPipeline)
 			require.NoError(t, err)
 			i = 0
 			for it.Next() {
@@ -423,6 +473,203 @@ func TestMemChunk_AppendOutOfOrder(t *testing.T) {
 	}
 }
 
+func TestUnorderedMemChunk(t *testing.T) {
+	for _, enc := range testEncoding {
+		t.Run(enc.String(), func(t *testing.T) {
+			chk := NewUnorderedMemChunk(enc, 50, testTargetSize)
+
+			// Entries are appended in a random order, so that several overlapping blocks are cut.
+			const numEntries = 100
+			for _, i := range rand.Perm(numEntries) {
+				require.NoError(t, chk.Append(logprotoEntry(int64(i+1), strconv.Itoa(i+1))))
+			}
+			require.Greater(t, chk.BlockCount(), 1)
+			require.False(t, chk.Ordered())
+
+			from, to := chk.Bounds()
+			require.Equal(t, int64(1), from.UnixNano())
+			require.Equal(t, int64(numEntries), to.UnixNano())
+
+			b, err := chk.Bytes()
+			require.NoError(t, err)
+			bc, err := NewByteChunk(b, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, chunkFormatV3, bc.format)
+			require.False(t, bc.Ordered())
+
+			for _, c := range []*MemChunk{chk, bc} {
+				it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, noopStreamPipeline)
+				require.NoError(t, err)
+				for i := 1; i <= numEntries; i++ {
+					require.True(t, it.Next())
+					require.Equal(t, int64(i), it.Entry().Timestamp.UnixNano())
+					require.Equal(t, strconv.Itoa(i), it.Entry().Line)
+				}
+				require.False(t, it.Next())
+				require.NoError(t, it.Close())
+
+				it, err = c.Iterator(context.Background(), time.Unix(0, 10), time.Unix(0, 20), logproto.BACKWARD, noopStreamPipeline)
+				require.NoError(t, err)
+				for i := 19; i >= 10; i-- {
+					require.True(t, it.Next())
+					require.Equal(t, int64(i), it.Entry().Timestamp.UnixNano())
+				}
+				require.False(t, it.Next())
+				require.NoError(t, it.Close())
+
+				sampleIt := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), countExtractor)
+				for i := 1; i <= numEntries; i++ {
+					require.True(t, sampleIt.Next())
+					require.Equal(t, int64(i), sampleIt.Sample().Timestamp)
+				}
+				require.False(t, sampleIt.Next())
+				require.NoError(t, sampleIt.Close())
+			}
+		})
+	}
+}
+
+func TestMemChunkBlockFilters(t *testing.T) {
+	expr, err := logql.ParseLogSelector(`{app="foo"} |= "traceID=trace7" | logfmt`)
+	require.NoError(t, err)
+	pipeline, err := expr.Pipeline()
+	require.NoError(t, err)
+	sampleExpr, err := logql.ParseSampleExpr(`count_over_time({app="foo"} |= "traceID=trace7" [1m])`)
+	require.NoError(t, err)
+	extractor, err := sampleExpr.Extractor()
+	require.NoError(t, err)
+
+	for _, unordered := range []bool{false, true} {
+		t.Run(fmt.Sprintf("unordered=%v", unordered), func(t *testing.T) {
+			chk := NewMemChunk(EncSnappy, 1000, 0)
+			if unordered {
+				chk = NewUnorderedMemChunk(EncSnappy, 1000, 0)
+			}
+			chk.EnableBlockFilters()
+
+			// Each block has the lines of a single trace.
+			const numTraces = 10
+			for i := 0; i < numTraces*100; i++ {
+				require.NoError(t, chk.Append(logprotoEntry(int64(i+1), fmt.Sprintf("level=info traceID=trace%d line=%d", i/100, i))))
+			}
+			require.Greater(t, chk.BlockCount(), numTraces)
+
+			b, err := chk.Bytes()
+			require.NoError(t, err)
+			bc, err := NewByteChunk(b, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, chunkFormatV4, bc.format)
+			require.Equal(t, !unordered, bc.Ordered())
+
+			for _, c := range []*MemChunk{chk, bc} {
+				ctx := stats.NewContext(context.Background())
+				it, err := c.Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, pipeline.ForStream(labels.Labels{{Name: "app", Value: "foo"}}))
+				require.NoError(t, err)
+				for i := 700; i < 800; i++ {
+					require.True(t, it.Next())
+					require.Equal(t, int64(i+1), it.Entry().Timestamp.UnixNano())
+				}
+				require.False(t, it.Next())
+				require.NoError(t, it.Close())
+
+				chunkStats := stats.GetChunkData(ctx)
+				require.Equal(t, int64(c.BlockCount()), chunkStats.TotalBlocks)
+				// Only the blocks of the trace and at most a few false positives are decompressed.
+				require.Greater(t, chunkStats.SkippedBlocks, int64(c.BlockCount()-numTraces))
+
+				ctx = stats.NewContext(context.Background())
+				sampleIt := c.SampleIterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), extractor.ForStream(labels.Labels{{Name: "app", Value: "foo"}}))
+				for i := 700; i < 800; i++ {
+					require.True(t, sampleIt.Next())
+					require.Equal(t, int64(i+1), sampleIt.Sample().Timestamp)
+				}
+				require.False(t, sampleIt.Next())
+				require.NoError(t, sampleIt.Close())
+				require.Greater(t, stats.GetChunkData(ctx).SkippedBlocks, int64(c.BlockCount()-numTraces))
+			}
+		})
+	}
+}
+
+func TestMemChunkStructuredMetadata(t *testing.T) {
+	expr, err := logql.ParseLogSelector(`{app="foo"} | traceID="trace3"`)
+	require.NoError(t, err)
+	pipeline, err := expr.Pipeline()
+	require.NoError(t, err)
+	sampleExpr, err := logql.ParseSampleExpr(`count_over_time({app="foo"} | traceID="trace3" [1m])`)
+	require.NoError(t, err)
+	extractor, err := sampleExpr.Extractor()
+	require.NoError(t, err)
+	lbs := labels.Labels{{Name: "app", Value: "foo"}}
+
+	for _, blockFilters := range []bool{false, true} {
+		t.Run(fmt.Sprintf("blockFilters=%v", blockFilters), func(t *testing.T) {
+			chk := NewMemChunk(EncSnappy, 300, 0)
+			chk.EnableStructuredMetadata()
+			if blockFilters {
+				chk.EnableBlockFilters()
+			}
+
+			var expected []int64
+			for i := 0; i < 100; i++ {
+				e := logprotoEntry(int64(i+1), fmt.Sprintf("line %d", i))
+				// Some entries have no metadata.
+				if i%3 != 0 {
+					e.StructuredMetadata = []logproto.LabelPair{{Name: "traceID", Value: fmt.Sprintf("trace%d", i%10)}}
+					if i%10 == 3 {
+						expected = append(expected, int64(i+1))
+					}
+				}
+				require.NoError(t, chk.Append(e))
+			}
+			require.Greater(t, chk.BlockCount(), 1)
+			require.False(t, chk.head.isEmpty())
+
+			cb, hb, err := chk.CheckpointBytes()
+			require.NoError(t, err)
+			restored, err := MemChunkFromCheckpoint(cb, hb, 300, 0)
+			require.NoError(t, err)
+
+			b, err := chk.Bytes()
+			require.NoError(t, err)
+			bc, err := NewByteChunk(b, 0, 0)
+			require.NoError(t, err)
+			require.Equal(t, chunkFormatV5, bc.format)
+			require.Equal(t, blockFilters, bc.blockFilters)
+
+			for _, c := range []*MemChunk{chk, bc, restored} {
+				it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, pipeline.ForStream(lbs))
+				require.NoError(t, err)
+				for _, ts := range expected {
+					require.True(t, it.Next())
+					require.Equal(t, ts, it.Entry().Timestamp.UnixNano())
+					require.Equal(t, `{app="foo", traceID="trace3"}`, it.Labels())
+				}
+				require.False(t, it.Next())
+				require.NoError(t, it.Close())
+
+				sampleIt := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), extractor.ForStream(lbs))
+				for _, ts := range expected {
+					require.True(t, sampleIt.Next())
+					require.Equal(t, ts, sampleIt.Sample().Timestamp)
+					require.Equal(t, `{app="foo", traceID="trace3"}`, sampleIt.Labels())
+				}
+				require.False(t, sampleIt.Next())
+				require.NoError(t, sampleIt.Close())
+			}
+		})
+	}
+
+	// Chunks which can't store structured metadata reject them, an entry with metadata doesn't fit once they have entries.
+	chk := NewMemChunk(EncSnappy, testBlockSize, 0)
+	e := logprotoEntry(1, "line")
+	e.StructuredMetadata = []logproto.LabelPair{{Name: "traceID", Value: "trace1"}}
+	require.True(t, chk.SpaceFor(e))
+	require.Equal(t, ErrStructuredMetadata, chk.Append(e))
+	require.NoError(t, chk.Append(logprotoEntry(1, "line")))
+	require.False(t, chk.SpaceFor(e))
+}
+
 func TestChunkSize(t *testing.T) {
 	for _, enc := range testEncoding {
 		t.Run(enc.String(), func(t *testing.T) {
@@ -461,7 +708,7 @@ func TestChunkStats(t *testing.T) {
 	expectedSize := (inserted * len(entry.Line)) + (inserted * 2 * binary.MaxVarintLen64)
 	ctx := stats.NewContext(context.Background())
 
-	it, err := c.Iterator(ctx, first.Add(-time.Hour), entry.Timestamp.Add(time.Hour), logproto.BACKWARD, logql.LineFilterFunc(func(line []byte) bool { return false }))
+	it, err := c.Iterator(ctx, first.Add(-time.Hour), entry.Timestamp.Add(time.Hour), logproto.BACKWARD, logql.NewPipeline([]logql.Stage{logql.StageFunc(func(line []byte, _ *logql.LabelsBuilder) ([]byte, bool) { return line, false })}).ForStream(labels.Labels{}))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -490,7 +737,7 @@ func TestChunkStats(t *testing.T) {
 		t.Fatal(err)
 	}
 	ctx = stats.NewContext(context.Background())
-	it, err = cb.Iterator(ctx, first.Add(-time.Hour), entry.Timestamp.Add(time.Hour), logproto.BACKWARD, logql.LineFilterFunc(func(line []byte) bool { return false }))
+	it, err = cb.Iterator(ctx, first.Add(-time.Hour), entry.Timestamp.Add(time.Hour), logproto.BACKWARD, logql.NewPipeline([]logql.Stage{logql.StageFunc(func(line []byte, _ *logql.LabelsBuilder) ([]byte, bool) { return line, false })}).ForStream(labels.Labels{}))
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -538,7 +785,7 @@ func TestIteratorClose(t *testing.T) {
 			} {
 				c := NewMemChunk(enc, testBlockSize, testTargetSize)
 				inserted := fillChunk(c)
-				iter, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, inserted), logproto.BACKWARD, nil)
+				iter, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, inserted), logproto.BACKWARD, noopStreamPipeline)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -589,7 +836,7 @@ func BenchmarkRead(b *testing.B) {
 			for n := 0; n < b.N; n++ {
 				for _, c := range chunks {
 					// use forward iterator for benchmark -- backward iterator does extra allocations by keeping entries in memory
-					iterator, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Now(), logproto.FORWARD, nil)
+					iterator, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Now(), logproto.FORWARD, noopStreamPipeline)
 					if err != nil {
 						panic(err)
 					}
@@ -614,7 +861,7 @@ func BenchmarkBackwardIterator(b *testing.B) {
 	_ = fillChunk(c)
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		iterator, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Now(), logproto.BACKWARD, nil)
+		iterator, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Now(), logproto.BACKWARD, noopStreamPipeline)
 		if err != nil {
 			panic(err)
 		}
@@ -635,9 +882,7 @@ func TestGenerateDataSize(t *testing.T) {
 			bytesRead := uint64(0)
 			for _, c := range chunks {
 				// use forward iterator for benchmark -- backward iterator does extra allocations by keeping entries in memory
-				iterator, err := c.Iterator(context.TODO(), time.Unix(0, 0), time.Now(), logproto.FORWARD, logql.LineFilterFunc(func(line []byte) bool {
-					return true // return all
-				}))
+				iterator, err := c.Iterator(context.TODO(), time.Unix(0, 0), time.Now(), logproto.FORWARD, noopStreamPipeline)
 				if err != nil {
 					panic(err)
 				}
@@ -663,7 +908,7 @@ func BenchmarkHeadBlockIterator(b *testing.B) {
 			h := headBlock{}
 
 			for i := 0; i < j; i++ {
-				if err := h.append(int64(i), "this is the append string"); err != nil {
+				if err := h.append(int64(i), "this is the append string", nil); err != nil {
 					b.Fatal(err)
 				}
 			}
@@ -730,7 +975,7 @@ func TestMemChunk_IteratorBounds(t *testing.T) {
 				c := createChunk()
 
 				// testing headchunk
-				it, err := c.Iterator(context.Background(), tt.mint, tt.maxt, tt.direction, nil)
+				it, err := c.Iterator(context.Background(), tt.mint, tt.maxt, tt.direction, noopStreamPipeline)
 				require.NoError(t, err)
 				for i := range tt.expect {
 					require.Equal(t, tt.expect[i], it.Next())
@@ -739,7 +984,7 @@ func TestMemChunk_IteratorBounds(t *testing.T) {
 
 				// testing chunk blocks
 				require.NoError(t, c.cut())
-				it, err = c.Iterator(context.Background(), tt.mint, tt.maxt, tt.direction, nil)
+				it, err = c.Iterator(context.Background(), tt.mint, tt.maxt, tt.direction, noopStreamPipeline)
 				require.NoError(t, err)
 				for i := range tt.expect {
 					require.Equal(t, tt.expect[i], it.Next())
@@ -758,7 +1003,7 @@ func TestMemchunkLongLine(t *testing.T) {
 			for i := 1; i <= 10; i++ {
 				require.NoError(t, c.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: strings.Repeat("e", 200000)}))
 			}
-			it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, nil)
+			it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, noopStreamPipeline)
 			require.NoError(t, err)
 			for i := 1; i <= 10; i++ {
 				require.True(t, it.Next())
@@ -767,3 +1012,52 @@ func TestMemchunkLongLine(t *testing.T) {
 		})
 	}
 }
+
+func TestMemChunk_ParsedLabels(t *testing.T) {
+	lbs := labels.Labels{{Name: "app", Value: "foo"}}
+	expr, err := logql.ParseLogSelector(`{app="foo"} | json`)
+	require.NoError(t, err)
+	pipeline, err := expr.Pipeline()
+	require.NoError(t, err)
+	sampleExpr, err := logql.ParseSampleExpr(`count_over_time({app="foo"} | json [1m])`)
+	require.NoError(t, err)
+	extractor, err := sampleExpr.Extractor()
+	require.NoError(t, err)
+
+	for _, cut := range []bool{false, true} {
+		t.Run(fmt.Sprintf("cut=%v", cut), func(t *testing.T) {
+			c := NewMemChunk(EncSnappy, testBlockSize, testTargetSize)
+			for i := 0; i < 10; i++ {
+				require.NoError(t, c.Append(&logproto.Entry{
+					Timestamp: time.Unix(0, int64(i)),
+					Line:      fmt.Sprintf(`{"status":"%d"}`, 200+(i%2)*300),
+				}))
+			}
+			if cut {
+				require.NoError(t, c.cut())
+			}
+
+			it, err := c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), logproto.FORWARD, pipeline.ForStream(lbs))
+			require.NoError(t, err)
+			var i int64
+			for it.Next() {
+				require.Equal(t, i, it.Entry().Timestamp.UnixNano())
+				require.Equal(t, fmt.Sprintf(`{app="foo", status="%d"}`, 200+(i%2)*300), it.Labels())
+				i++
+			}
+			require.Equal(t, int64(10), i)
+			require.NoError(t, it.Close())
+
+			sampleIt := c.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, 100), extractor.ForStream(lbs))
+			i = 0
+			for sampleIt.Next() {
+				require.Equal(t, i, sampleIt.Sample().Timestamp)
+				require.Equal(t, 1., sampleIt.Sample().Value)
+				require.Equal(t, fmt.Sprintf(`{app="foo", status="%d"}`, 200+(i%2)*300), sampleIt.Labels())
+				i++
+			}
+			require.Equal(t, int64(10), i)
+			require.NoError(t, sampleIt.Close())
+		})
+	}
+}
@@ -7,7 +7,9 @@ import (
 	"sync"
 
 	"github.com/golang/snappy"
+	"github.com/klauspost/compress/flate"
 	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
 	"github.com/pierrec/lz4"
 	"github.com/prometheus/prometheus/pkg/pool"
 )
@@ -33,6 +35,12 @@ var (
 	Lz4_1M   = LZ4Pool{bufferSize: 1 << 20} // Lz4_1M uses 1M buffer
 	Lz4_4M   = LZ4Pool{bufferSize: 1 << 22} // Lz4_4M uses 4M buffer
 
+	// Flate is the flate compression pool
+	Flate = FlatePool{level: flate.DefaultCompression}
+	// Zstd is the zstd compression pool, ZstdFast trades compression ratio for speed.
+	Zstd     = ZstdPool{level: zstd.SpeedDefault}
+	ZstdFast = ZstdPool{level: zstd.SpeedFastest}
+
 	// Snappy is the snappy compression pool
 	Snappy SnappyPool
 	// Noop is the no compression pool
@@ -72,6 +80,12 @@ func getReaderPool(enc Encoding) ReaderPool {
 		return &Lz4_4M
 	case EncSnappy:
 		return &Snappy
+	case EncFlate:
+		return &Flate
+	case EncZstd:
+		return &Zstd
+	case EncZstdFast:
+		return &ZstdFast
 	case EncNone:
 		return &Noop
 	default:
@@ -132,6 +146,169 @@ func (pool *GzipPool) PutWriter(writer io.WriteCloser) {
 	pool.writers.Put(writer)
 }
 
+// FlatePool is a flate compression pool
+type FlatePool struct {
+	readers sync.Pool
+	writers sync.Pool
+	level   int
+}
+
+// GetReader gets or creates a new CompressionReader and reset it to read from src
+func (pool *FlatePool) GetReader(src io.Reader) io.Reader {
+	if r := pool.readers.Get(); r != nil {
+		reader := r.(io.ReadCloser)
+		if err := reader.(flate.Resetter).Reset(src, nil); err != nil {
+			panic(err)
+		}
+		return reader
+	}
+	return flate.NewReader(src)
+}
+
+// PutReader places back in the pool a CompressionReader
+func (pool *FlatePool) PutReader(reader io.Reader) {
+	pool.readers.Put(reader)
+}
+
+// GetWriter gets or creates a new CompressionWriter and reset it to write to dst
+func (pool *FlatePool) GetWriter(dst io.Writer) io.WriteCloser {
+	if w := pool.writers.Get(); w != nil {
+		writer := w.(*flate.Writer)
+		writer.Reset(dst)
+		return writer
+	}
+	w, err := flate.NewWriter(dst, pool.level)
+	if err != nil {
+		panic(err) // never happens, error is only returned on wrong compression level.
+	}
+	return w
+}
+
+// PutWriter places back in the pool a CompressionWriter
+func (pool *FlatePool) PutWriter(writer io.WriteCloser) {
+	pool.writers.Put(writer)
+}
+
+// ZstdPool is a zstd compression pool.
+// Blocks are compressed and decompressed at once by an encoder and a decoder shared by all the readers and writers:
+// zstd streaming encoders and decoders run background goroutines which would leak when pooled.
+type ZstdPool struct {
+	readers sync.Pool
+	writers sync.Pool
+	level   zstd.EncoderLevel
+
+	encoderOnce sync.Once
+	encoder     *zstd.Encoder
+}
+
+var (
+	zstdDecoderOnce sync.Once
+	zstdDecoder     *zstd.Decoder
+)
+
+func getZstdDecoder() *zstd.Decoder {
+	zstdDecoderOnce.Do(func() {
+		var err error
+		if zstdDecoder, err = zstd.NewReader(nil); err != nil {
+			panic(err) // never happens, error is only returned on invalid options.
+		}
+	})
+	return zstdDecoder
+}
+
+func (pool *ZstdPool) getEncoder() *zstd.Encoder {
+	pool.encoderOnce.Do(func() {
+		var err error
+		if pool.encoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(pool.level)); err != nil {
+			panic(err) // never happens, error is only returned on invalid options.
+		}
+	})
+	return pool.encoder
+}
+
+// zstdReader decompresses all of its source on the first read.
+type zstdReader struct {
+	src     io.Reader
+	in, out []byte
+	r       bytes.Reader
+	err     error
+	decoded bool
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	if !z.decoded {
+		z.decoded = true
+		buf := bytes.NewBuffer(z.in[:0])
+		if _, z.err = buf.ReadFrom(z.src); z.err == nil {
+			z.in = buf.Bytes()
+			z.out, z.err = getZstdDecoder().DecodeAll(z.in, z.out[:0])
+		}
+		z.r.Reset(z.out)
+	}
+	if z.err != nil {
+		return 0, z.err
+	}
+	return z.r.Read(p)
+}
+
+func (z *zstdReader) reset(src io.Reader) {
+	z.src = src
+	z.err = nil
+	z.decoded = false
+	z.r.Reset(nil)
+}
+
+// GetReader gets or creates a new CompressionReader and reset it to read from src
+func (pool *ZstdPool) GetReader(src io.Reader) io.Reader {
+	if r := pool.readers.Get(); r != nil {
+		reader := r.(*zstdReader)
+		reader.reset(src)
+		return reader
+	}
+	return &zstdReader{src: src}
+}
+
+// PutReader places back in the pool a CompressionReader
+func (pool *ZstdPool) PutReader(reader io.Reader) {
+	reader.(*zstdReader).src = nil
+	pool.readers.Put(reader)
+}
+
+// zstdWriter compresses all the bytes written to it when it is closed.
+type zstdWriter struct {
+	encoder *zstd.Encoder
+	dst     io.Writer
+	in, out []byte
+}
+
+func (z *zstdWriter) Write(p []byte) (int, error) {
+	z.in = append(z.in, p...)
+	return len(p), nil
+}
+
+func (z *zstdWriter) Close() error {
+	z.out = z.encoder.EncodeAll(z.in, z.out[:0])
+	_, err := z.dst.Write(z.out)
+	return err
+}
+
+// GetWriter gets or creates a new CompressionWriter and reset it to write to dst
+func (pool *ZstdPool) GetWriter(dst io.Writer) io.WriteCloser {
+	if w := pool.writers.Get(); w != nil {
+		writer := w.(*zstdWriter)
+		writer.dst = dst
+		writer.in = writer.in[:0]
+		return writer
+	}
+	return &zstdWriter{encoder: pool.getEncoder(), dst: dst}
+}
+
+// PutWriter places back in the pool a CompressionWriter
+func (pool *ZstdPool) PutWriter(writer io.WriteCloser) {
+	writer.(*zstdWriter).dst = nil
+	pool.writers.Put(writer)
+}
+
 type LZ4Pool struct {
 	readers    sync.Pool
 	writers    sync.Pool
@@ -188,7 +188,7 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	lineCount := 0
 	for _, stream := range req.Streams {
 		for _, entry := range stream.Entries {
-			bytesCount += len(entry.Line)
+			bytesCount += entrySize(entry)
 			lineCount++
 		}
 	}
@@ -217,7 +217,7 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 				continue
 			}
 			entries = append(entries, entry)
-			validatedSamplesSize += len(entry.Line)
+			validatedSamplesSize += entrySize(entry)
 			validatedSamplesCount++
 		}
 
@@ -289,6 +289,15 @@ func (d *Distributor) Push(ctx context.Context, req *logproto.PushRequest) (*log
 	}
 }
 
+// entrySize returns the size of the line and of the structured metadata of an entry.
+func entrySize(entry logproto.Entry) int {
+	size := len(entry.Line)
+	for _, m := range entry.StructuredMetadata {
+		size += len(m.Name) + len(m.Value)
+	}
+	return size
+}
+
 // TODO taken from Cortex, see if we can refactor out an usable interface.
 func (d *Distributor) sendSamples(ctx context.Context, ingester ring.IngesterDesc, streamTrackers []*streamTracker, pushTracker *pushTracker) {
 	err := d.sendSamplesErr(ctx, ingester, streamTrackers)
@@ -9,6 +9,7 @@ type Limits interface {
 	MaxLabelNamesPerSeries(userID string) int
 	MaxLabelNameLength(userID string) int
 	MaxLabelValueLength(userID string) int
+	AllowStructuredMetadata(userID string) bool
 
 	CreationGracePeriod(userID string) time.Duration
 	RejectOldSamples(userID string) bool
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	cortex_client "github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/prometheus/common/model"
 	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/loki/pkg/logproto"
@@ -49,9 +50,45 @@ func (v Validator) ValidateEntry(userID string, labels string, entry logproto.En
 		return httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg(maxSize, len(entry.Line), labels))
 	}
 
+	if len(entry.StructuredMetadata) > 0 {
+		if !v.AllowStructuredMetadata(userID) {
+			validation.DiscardedSamples.WithLabelValues(validation.DisallowedStructuredMetadata, userID).Inc()
+			validation.DiscardedBytes.WithLabelValues(validation.DisallowedStructuredMetadata, userID).Add(float64(len(entry.Line)))
+			return httpgrpc.Errorf(http.StatusBadRequest, validation.DisallowedStructuredMetadataErrorMsg(labels))
+		}
+		if name, reason := v.invalidStructuredMetadata(userID, entry.StructuredMetadata); reason != "" {
+			validation.DiscardedSamples.WithLabelValues(validation.InvalidStructuredMetadata, userID).Inc()
+			validation.DiscardedBytes.WithLabelValues(validation.InvalidStructuredMetadata, userID).Add(float64(len(entry.Line)))
+			return httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidStructuredMetadataErrorMsg(labels, name, reason))
+		}
+	}
+
 	return nil
 }
 
+// invalidStructuredMetadata returns the name of the first invalid structured metadata and the reason why it is invalid.
+// The structured metadata are turned into labels by queries, they follow the same rules as the labels of the streams.
+func (v Validator) invalidStructuredMetadata(userID string, metadata []logproto.LabelPair) (string, string) {
+	maxLabelNameLength := v.MaxLabelNameLength(userID)
+	maxLabelValueLength := v.MaxLabelValueLength(userID)
+	for i, m := range metadata {
+		switch {
+		case !model.LabelName(m.Name).IsValid():
+			return m.Name, "invalid name"
+		case len(m.Name) > maxLabelNameLength:
+			return m.Name, "name too long"
+		case len(m.Value) > maxLabelValueLength:
+			return m.Name, "value too long"
+		}
+		for _, prev := range metadata[:i] {
+			if prev.Name == m.Name {
+				return m.Name, "duplicate name"
+			}
+		}
+	}
+	return "", ""
+}
+
 // Validate labels returns an error if the labels are invalid
 func (v Validator) ValidateLabels(userID string, stream logproto.Stream) error {
 	ls, err := util.ToClientLabels(stream.Labels)
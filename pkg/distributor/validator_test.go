@@ -16,6 +16,14 @@ import (
 var testStreamLabels = "FIXME"
 var testTime = time.Now()
 
+func structuredMetadataLimits(userID string) *validation.Limits {
+	return &validation.Limits{
+		AllowStructuredMetadata: true,
+		MaxLabelNameLength:      10,
+		MaxLabelValueLength:     5,
+	}
+}
+
 func TestValidator_ValidateEntry(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -61,6 +69,41 @@ func TestValidator_ValidateEntry(t *testing.T) {
 			logproto.Entry{Timestamp: testTime, Line: "12345678901"},
 			httpgrpc.Errorf(http.StatusBadRequest, validation.LineTooLongErrorMsg(10, 11, testStreamLabels)),
 		},
+		{
+			"structured metadata not allowed",
+			"test",
+			nil,
+			logproto.Entry{Timestamp: testTime, Line: "test", StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}}},
+			httpgrpc.Errorf(http.StatusBadRequest, validation.DisallowedStructuredMetadataErrorMsg(testStreamLabels)),
+		},
+		{
+			"structured metadata allowed",
+			"test",
+			structuredMetadataLimits,
+			logproto.Entry{Timestamp: testTime, Line: "test", StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}}},
+			nil,
+		},
+		{
+			"invalid structured metadata name",
+			"test",
+			structuredMetadataLimits,
+			logproto.Entry{Timestamp: testTime, Line: "test", StructuredMetadata: []logproto.LabelPair{{Name: "trace-id", Value: "abc"}}},
+			httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidStructuredMetadataErrorMsg(testStreamLabels, "trace-id", "invalid name")),
+		},
+		{
+			"structured metadata value too long",
+			"test",
+			structuredMetadataLimits,
+			logproto.Entry{Timestamp: testTime, Line: "test", StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "0123456789"}}},
+			httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidStructuredMetadataErrorMsg(testStreamLabels, "traceID", "value too long")),
+		},
+		{
+			"duplicate structured metadata",
+			"test",
+			structuredMetadataLimits,
+			logproto.Entry{Timestamp: testTime, Line: "test", StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}, {Name: "traceID", Value: "def"}}},
+			httpgrpc.Errorf(http.StatusBadRequest, validation.InvalidStructuredMetadataErrorMsg(testStreamLabels, "traceID", "duplicate name")),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -0,0 +1,21 @@
+// +build !windows
+
+package ingester
+
+import (
+	"golang.org/x/sys/unix"
+)
+
+// diskUsage returns the ratio of used space of the filesystem containing dir.
+func diskUsage(dir string) (float64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(dir, &st); err != nil {
+		return 0, err
+	}
+	total := uint64(st.Blocks) * uint64(st.Bsize)
+	if total == 0 {
+		return 0, nil
+	}
+	avail := uint64(st.Bavail) * uint64(st.Bsize)
+	return 1 - float64(avail)/float64(total), nil
+}
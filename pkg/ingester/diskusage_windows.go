@@ -0,0 +1,21 @@
+package ingester
+
+import (
+	"golang.org/x/sys/windows"
+)
+
+// diskUsage returns the ratio of used space of the volume containing dir.
+func diskUsage(dir string) (float64, error) {
+	path, err := windows.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, err
+	}
+	var avail, total, free uint64
+	if err := windows.GetDiskFreeSpaceEx(path, &avail, &total, &free); err != nil {
+		return 0, err
+	}
+	if total == 0 {
+		return 0, nil
+	}
+	return 1 - float64(avail)/float64(total), nil
+}
@@ -0,0 +1,291 @@
+package ingester
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// recordType is the type of the records of the WAL and of its checkpoints, written as their first byte.
+// Make sure to preserve the order, as these numeric values are written to the disk!
+type recordType byte
+
+const (
+	_ recordType = iota
+	// walRecordSeries is the type of the WAL records of the streams created by a push.
+	walRecordSeries
+	// walRecordEntries is the type of the WAL records of the entries appended by a push.
+	walRecordEntries
+	// checkpointRecord is the type of the checkpoint records, one per in memory stream.
+	checkpointRecord
+	// walRecordEntriesWithMetadata is the type of the WAL records of the entries appended by a push when some
+	// of them have structured metadata, which follow each entry.
+	walRecordEntriesWithMetadata
+	// walRecordSeriesWithGeneration is the type of the WAL records of the streams created by a push, with their
+	// generation.
+	walRecordSeriesWithGeneration
+	// walRecordEntriesWithGeneration is the type of the WAL records of the entries appended by a push, with the
+	// generation of their stream and the structured metadata of each entry.
+	walRecordEntriesWithGeneration
+	// checkpointRecordWithGeneration is the type of the checkpoint records with the generation of their stream.
+	checkpointRecordWithGeneration
+)
+
+// walRecord holds the streams created and the entries appended by a push of a tenant.
+type walRecord struct {
+	userID  string
+	series  []walSeries
+	entries []walEntries
+}
+
+// walSeries is a stream created by a push, referenced by its fingerprint in the instance.
+type walSeries struct {
+	ref uint64
+	// generation tells apart the streams created with the same fingerprint once the previous one was flushed
+	// and removed, see stream.generation.
+	generation uint64
+	labels     labels.Labels
+}
+
+// walEntries are the entries appended to a stream by a push.
+type walEntries struct {
+	ref        uint64
+	generation uint64
+	// counter is the number of entries appended to the stream once these ones are,
+	// it allows skipping the entries already restored from a checkpoint on replay.
+	counter int64
+	entries []logproto.Entry
+}
+
+func (r *walRecord) isEmpty() bool {
+	return len(r.series) == 0 && len(r.entries) == 0
+}
+
+func (r *walRecord) reset() {
+	r.userID = ""
+	r.series = r.series[:0]
+	r.entries = r.entries[:0]
+}
+
+func (r *walRecord) encodeSeries(b []byte) []byte {
+	buf := encoding.Encbuf{B: b}
+	buf.PutByte(byte(walRecordSeriesWithGeneration))
+	buf.PutUvarintStr(r.userID)
+	buf.PutUvarint(len(r.series))
+	for _, s := range r.series {
+		buf.PutBE64(s.ref)
+		buf.PutUvarint64(s.generation)
+		putLabels(&buf, s.labels)
+	}
+	return buf.Get()
+}
+
+func (r *walRecord) encodeEntries(b []byte) []byte {
+	buf := encoding.Encbuf{B: b}
+	buf.PutByte(byte(walRecordEntriesWithGeneration))
+	buf.PutUvarintStr(r.userID)
+	buf.PutUvarint(len(r.entries))
+	for _, e := range r.entries {
+		buf.PutBE64(e.ref)
+		buf.PutUvarint64(e.generation)
+		buf.PutVarint64(e.counter)
+		buf.PutUvarint(len(e.entries))
+		for _, entry := range e.entries {
+			buf.PutVarint64(entry.Timestamp.UnixNano())
+			buf.PutUvarintStr(entry.Line)
+			buf.PutUvarint(len(entry.StructuredMetadata))
+			for _, m := range entry.StructuredMetadata {
+				buf.PutUvarintStr(m.Name)
+				buf.PutUvarintStr(m.Value)
+			}
+		}
+	}
+	return buf.Get()
+}
+
+// decodeWALRecord decodes a series or an entries record into rec, which is reset first.
+// The records written by previous versions, without generation, are decoded with the generation 0.
+func decodeWALRecord(b []byte, rec *walRecord) error {
+	rec.reset()
+	dec := encoding.Decbuf{B: b}
+	t := recordType(dec.Byte())
+	rec.userID = dec.UvarintStr()
+
+	switch t {
+	case walRecordSeries, walRecordSeriesWithGeneration:
+		for n := dec.Uvarint(); n > 0 && dec.Err() == nil; n-- {
+			s := walSeries{ref: dec.Be64()}
+			if t == walRecordSeriesWithGeneration {
+				s.generation = dec.Uvarint64()
+			}
+			s.labels = getLabels(&dec)
+			rec.series = append(rec.series, s)
+		}
+	case walRecordEntries, walRecordEntriesWithMetadata, walRecordEntriesWithGeneration:
+		for n := dec.Uvarint(); n > 0 && dec.Err() == nil; n-- {
+			e := walEntries{ref: dec.Be64()}
+			if t == walRecordEntriesWithGeneration {
+				e.generation = dec.Uvarint64()
+			}
+			e.counter = dec.Varint64()
+			for m := dec.Uvarint(); m > 0 && dec.Err() == nil; m-- {
+				ts, line := dec.Varint64(), dec.UvarintStr()
+				entry := logproto.Entry{Timestamp: time.Unix(0, ts), Line: line}
+				if t != walRecordEntries {
+					for k := dec.Uvarint(); k > 0 && dec.Err() == nil; k-- {
+						entry.StructuredMetadata = append(entry.StructuredMetadata, logproto.LabelPair{Name: dec.UvarintStr(), Value: dec.UvarintStr()})
+					}
+				}
+				e.entries = append(e.entries, entry)
+			}
+			rec.entries = append(rec.entries, e)
+		}
+	default:
+		return errors.Errorf("unexpected WAL record type %d", t)
+	}
+
+	if dec.Err() != nil {
+		return errors.Wrap(dec.Err(), "decoding WAL record")
+	}
+	if dec.Len() > 0 {
+		return errors.Errorf("unexpected %d bytes left in WAL record", dec.Len())
+	}
+	return nil
+}
+
+// checkpointStream is a checkpoint record, the state of an in memory stream.
+type checkpointStream struct {
+	userID     string
+	fp         uint64
+	generation uint64
+	labels     labels.Labels
+	entryCt    int64
+	lastLine   line
+	chunks     []checkpointChunk
+}
+
+// checkpointChunk is a chunk of a checkpointed stream, with its cut blocks and head block encoded separately.
+type checkpointChunk struct {
+	closed      bool
+	synced      bool
+	flushed     time.Time
+	lastUpdated time.Time
+	chunk       []byte
+	head        []byte
+}
+
+const (
+	checkpointChunkClosed = 1 << iota
+	checkpointChunkSynced
+)
+
+func (s *checkpointStream) encode(b []byte) []byte {
+	buf := encoding.Encbuf{B: b}
+	buf.PutByte(byte(checkpointRecordWithGeneration))
+	buf.PutUvarintStr(s.userID)
+	buf.PutBE64(s.fp)
+	buf.PutUvarint64(s.generation)
+	putLabels(&buf, s.labels)
+	buf.PutVarint64(s.entryCt)
+	putTime(&buf, s.lastLine.ts)
+	buf.PutUvarintStr(s.lastLine.content)
+
+	buf.PutUvarint(len(s.chunks))
+	for _, c := range s.chunks {
+		var flags byte
+		if c.closed {
+			flags |= checkpointChunkClosed
+		}
+		if c.synced {
+			flags |= checkpointChunkSynced
+		}
+		buf.PutByte(flags)
+		putTime(&buf, c.flushed)
+		putTime(&buf, c.lastUpdated)
+		buf.PutUvarint(len(c.chunk))
+		buf.B = append(buf.B, c.chunk...)
+		buf.PutUvarint(len(c.head))
+		buf.B = append(buf.B, c.head...)
+	}
+	return buf.Get()
+}
+
+// decodeCheckpointStream decodes a checkpoint record, the bytes are copied as records are only valid until the next one is read.
+// The records written by previous versions, without generation, are decoded with the generation 0.
+func decodeCheckpointStream(b []byte) (*checkpointStream, error) {
+	dec := encoding.Decbuf{B: b}
+	t := recordType(dec.Byte())
+	if dec.Err() == nil && t != checkpointRecord && t != checkpointRecordWithGeneration {
+		return nil, errors.Errorf("unexpected checkpoint record type %d", t)
+	}
+
+	s := &checkpointStream{
+		userID: dec.UvarintStr(),
+		fp:     dec.Be64(),
+	}
+	if t == checkpointRecordWithGeneration {
+		s.generation = dec.Uvarint64()
+	}
+	s.labels = getLabels(&dec)
+	s.entryCt = dec.Varint64()
+	s.lastLine.ts = getTime(&dec)
+	s.lastLine.content = dec.UvarintStr()
+
+	for n := dec.Uvarint(); n > 0 && dec.Err() == nil; n-- {
+		flags := dec.Byte()
+		c := checkpointChunk{
+			closed:      flags&checkpointChunkClosed != 0,
+			synced:      flags&checkpointChunkSynced != 0,
+			flushed:     getTime(&dec),
+			lastUpdated: getTime(&dec),
+		}
+		c.chunk = append([]byte(nil), dec.UvarintBytes()...)
+		c.head = append([]byte(nil), dec.UvarintBytes()...)
+		s.chunks = append(s.chunks, c)
+	}
+
+	if dec.Err() != nil {
+		return nil, errors.Wrap(dec.Err(), "decoding checkpoint record")
+	}
+	if dec.Len() > 0 {
+		return nil, errors.Errorf("unexpected %d bytes left in checkpoint record", dec.Len())
+	}
+	return s, nil
+}
+
+func putLabels(buf *encoding.Encbuf, ls labels.Labels) {
+	buf.PutUvarint(len(ls))
+	for _, l := range ls {
+		buf.PutUvarintStr(l.Name)
+		buf.PutUvarintStr(l.Value)
+	}
+}
+
+func getLabels(dec *encoding.Decbuf) labels.Labels {
+	var ls labels.Labels
+	for n := dec.Uvarint(); n > 0 && dec.Err() == nil; n-- {
+		ls = append(ls, labels.Label{Name: dec.UvarintStr(), Value: dec.UvarintStr()})
+	}
+	return ls
+}
+
+// putTime writes a timestamp, the zero time is written as 0 as its unix nanoseconds are undefined.
+func putTime(buf *encoding.Encbuf, t time.Time) {
+	if t.IsZero() {
+		buf.PutVarint64(0)
+		return
+	}
+	buf.PutVarint64(t.UnixNano())
+}
+
+func getTime(dec *encoding.Decbuf) time.Time {
+	ts := dec.Varint64()
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
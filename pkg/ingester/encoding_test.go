@@ -0,0 +1,133 @@
+package ingester
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+func Test_EncodingWALRecord(t *testing.T) {
+	record := &walRecord{
+		userID: "123",
+		series: []walSeries{
+			{ref: 456, generation: 1, labels: labels.FromStrings("foo", "bar", "bar", "buzz")},
+			{ref: 789, generation: 2, labels: labels.FromStrings("foo", "baz")},
+		},
+		entries: []walEntries{
+			{
+				ref:        456,
+				generation: 1,
+				counter:    2,
+				entries: []logproto.Entry{
+					{Timestamp: time.Unix(0, 1), Line: "first"},
+					{Timestamp: time.Unix(0, 2), Line: "second"},
+				},
+			},
+			{
+				ref:        789,
+				generation: 2,
+				counter:    1,
+				entries: []logproto.Entry{
+					{Timestamp: time.Unix(0, 3), Line: ""},
+				},
+			},
+		},
+	}
+
+	decoded := &walRecord{}
+	require.NoError(t, decodeWALRecord(record.encodeSeries(nil), decoded))
+	require.Equal(t, record.userID, decoded.userID)
+	require.Equal(t, record.series, decoded.series)
+	require.Empty(t, decoded.entries)
+
+	require.NoError(t, decodeWALRecord(record.encodeEntries(nil), decoded))
+	require.Equal(t, record.userID, decoded.userID)
+	require.Empty(t, decoded.series)
+	require.Equal(t, record.entries, decoded.entries)
+
+	require.Error(t, decodeWALRecord([]byte{byte(checkpointRecord)}, decoded))
+
+	// The structured metadata are kept.
+	record.entries[1].entries = append(record.entries[1].entries, logproto.Entry{
+		Timestamp:          time.Unix(0, 4),
+		Line:               "with metadata",
+		StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}, {Name: "user", Value: "42"}},
+	})
+	require.NoError(t, decodeWALRecord(record.encodeEntries(nil), decoded))
+	require.Equal(t, record.entries, decoded.entries)
+}
+
+func Test_DecodingWALRecordWithoutGeneration(t *testing.T) {
+	// Records written by previous versions.
+	buf := encoding.Encbuf{}
+	buf.PutByte(byte(walRecordSeries))
+	buf.PutUvarintStr("123")
+	buf.PutUvarint(1)
+	buf.PutBE64(456)
+	putLabels(&buf, labels.FromStrings("foo", "bar"))
+
+	decoded := &walRecord{}
+	require.NoError(t, decodeWALRecord(buf.Get(), decoded))
+	require.Equal(t, []walSeries{{ref: 456, labels: labels.FromStrings("foo", "bar")}}, decoded.series)
+
+	for _, typ := range []recordType{walRecordEntries, walRecordEntriesWithMetadata} {
+		buf.Reset()
+		buf.PutByte(byte(typ))
+		buf.PutUvarintStr("123")
+		buf.PutUvarint(1)
+		buf.PutBE64(456)
+		buf.PutVarint64(1)
+		buf.PutUvarint(1)
+		buf.PutVarint64(1)
+		buf.PutUvarintStr("line")
+		if typ == walRecordEntriesWithMetadata {
+			buf.PutUvarint(1)
+			buf.PutUvarintStr("traceID")
+			buf.PutUvarintStr("abc")
+		}
+
+		require.NoError(t, decodeWALRecord(buf.Get(), decoded))
+		require.Len(t, decoded.entries, 1)
+		require.Equal(t, uint64(456), decoded.entries[0].ref)
+		require.Equal(t, uint64(0), decoded.entries[0].generation)
+		require.Equal(t, int64(1), decoded.entries[0].counter)
+		require.Len(t, decoded.entries[0].entries, 1)
+	}
+}
+
+func Test_EncodingCheckpointStream(t *testing.T) {
+	stream := &checkpointStream{
+		userID:     "123",
+		fp:         456,
+		generation: 3,
+		labels:     labels.FromStrings("foo", "bar"),
+		entryCt:    10,
+		lastLine:   line{ts: time.Unix(0, 10), content: "last"},
+		chunks: []checkpointChunk{
+			{
+				closed:      true,
+				flushed:     time.Unix(10, 0),
+				lastUpdated: time.Unix(5, 0),
+				chunk:       []byte("chunk"),
+			},
+			{
+				synced:      true,
+				lastUpdated: time.Unix(20, 0),
+				chunk:       []byte("chunk"),
+				head:        []byte("head"),
+			},
+		},
+	}
+
+	decoded, err := decodeCheckpointStream(stream.encode(nil))
+	require.NoError(t, err)
+	require.Equal(t, stream, decoded)
+
+	_, err = decodeCheckpointStream([]byte{byte(walRecordSeries)})
+	require.Error(t, err)
+}
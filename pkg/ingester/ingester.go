@@ -54,6 +54,7 @@ type Config struct {
 	BlockSize         int           `yaml:"chunk_block_size"`
 	TargetChunkSize   int           `yaml:"chunk_target_size"`
 	ChunkEncoding     string        `yaml:"chunk_encoding"`
+	ChunkBlockFilters bool          `yaml:"chunk_block_filters"`
 	MaxChunkAge       time.Duration `yaml:"max_chunk_age"`
 
 	// Synchronization settings. Used to make sure that ingesters cut their chunks at the same moments.
@@ -67,11 +68,14 @@ type Config struct {
 
 	QueryStore                  bool          `yaml:"-"`
 	QueryStoreMaxLookBackPeriod time.Duration `yaml:"query_store_max_look_back_period"`
+
+	WAL WALConfig `yaml:"wal,omitempty"`
 }
 
 // RegisterFlags registers the flags.
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f)
+	cfg.WAL.RegisterFlags(f)
 
 	f.IntVar(&cfg.MaxTransferRetries, "ingester.max-transfer-retries", 10, "Number of times to try and transfer chunks before falling back to flushing. If set to 0 or negative value, transfers are disabled.")
 	f.IntVar(&cfg.ConcurrentFlushes, "ingester.concurrent-flushes", 16, "")
@@ -82,6 +86,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.BlockSize, "ingester.chunks-block-size", 256*1024, "")
 	f.IntVar(&cfg.TargetChunkSize, "ingester.chunk-target-size", 0, "")
 	f.StringVar(&cfg.ChunkEncoding, "ingester.chunk-encoding", chunkenc.EncGZIP.String(), fmt.Sprintf("The algorithm to use for compressing chunk. (%s)", chunkenc.SupportedEncoding()))
+	f.BoolVar(&cfg.ChunkBlockFilters, "ingester.chunk-block-filters", false, "Store a bloom filter of the tokens of the lines of each block in the chunks, to skip the blocks which can't match the line filters of queries.")
 	f.DurationVar(&cfg.SyncPeriod, "ingester.sync-period", 0, "How often to cut chunks to synchronize ingesters.")
 	f.Float64Var(&cfg.SyncMinUtilization, "ingester.sync-min-utilization", 0, "Minimum utilization of chunk when doing synchronization.")
 	f.IntVar(&cfg.MaxReturnedErrors, "ingester.max-ignored-stream-errors", 10, "Maximum number of ignored stream errors to return. 0 to return all errors.")
@@ -116,7 +121,11 @@ type Ingester struct {
 	flushQueuesDone sync.WaitGroup
 
 	limiter *Limiter
-	factory func() chunkenc.Chunk
+	// factory creates the chunks of a tenant, unordered if it accepts entries out of order.
+	factory func(userID string) chunkenc.Chunk
+
+	// wal logs the pushes to recover the streams which were not flushed when restarting.
+	wal WAL
 }
 
 // ChunkStore is the interface we need to store chunks.
@@ -144,11 +153,35 @@ func New(cfg Config, clientConfig client.Config, store ChunkStore, limits *valid
 		loopQuit:     make(chan struct{}),
 		flushQueues:  make([]*util.PriorityQueue, cfg.ConcurrentFlushes),
 		tailersQuit:  make(chan struct{}),
-		factory: func() chunkenc.Chunk {
-			return chunkenc.NewMemChunk(enc, cfg.BlockSize, cfg.TargetChunkSize)
+		factory: func(userID string) chunkenc.Chunk {
+			enc := enc
+			if name := limits.ChunkEncoding(userID); name != "" {
+				// The overrides are validated when they are loaded.
+				if tenantEnc, err := chunkenc.ParseEncoding(name); err == nil {
+					enc = tenantEnc
+				}
+			}
+			var c *chunkenc.MemChunk
+			if limits.OutOfOrderWindow(userID) > 0 {
+				c = chunkenc.NewUnorderedMemChunk(enc, cfg.BlockSize, cfg.TargetChunkSize)
+			} else {
+				c = chunkenc.NewMemChunk(enc, cfg.BlockSize, cfg.TargetChunkSize)
+			}
+			if cfg.ChunkBlockFilters {
+				c.EnableBlockFilters()
+			}
+			if limits.AllowStructuredMetadata(userID) {
+				c.EnableStructuredMetadata()
+			}
+			return c
 		},
 	}
 
+	i.wal, err = newWAL(cfg.WAL, registerer, i)
+	if err != nil {
+		return nil, err
+	}
+
 	i.lifecycler, err = ring.NewLifecycler(cfg.LifecyclerConfig, i, "ingester", ring.IngesterRingKey, true, registerer)
 	if err != nil {
 		return nil, err
@@ -172,6 +205,12 @@ func (i *Ingester) starting(ctx context.Context) error {
 		go i.flushLoop(j)
 	}
 
+	// The streams are recovered before the ingester joins the ring.
+	if err := i.wal.Replay(); err != nil {
+		return err
+	}
+	i.wal.Start()
+
 	// pass new context to lifecycler, so that it doesn't stop automatically when Ingester's service context is done
 	err := i.lifecycler.StartAsync(context.Background())
 	if err != nil {
@@ -224,6 +263,11 @@ func (i *Ingester) stopping(_ error) error {
 	}
 	i.flushQueuesDone.Wait()
 
+	// The remaining chunks are checkpointed once the flushes are done.
+	if walErr := i.wal.Stop(); walErr != nil && err == nil {
+		err = walErr
+	}
+
 	return err
 }
 
@@ -268,7 +312,10 @@ func (i *Ingester) getOrCreateInstance(instanceID string) *instance {
 	defer i.instancesMtx.Unlock()
 	inst, ok = i.instances[instanceID]
 	if !ok {
-		inst = newInstance(&i.cfg, instanceID, i.factory, i.limiter, i.cfg.SyncPeriod, i.cfg.SyncMinUtilization)
+		factory := func() chunkenc.Chunk {
+			return i.factory(instanceID)
+		}
+		inst = newInstance(&i.cfg, instanceID, factory, i.limiter, i.cfg.SyncPeriod, i.cfg.SyncMinUtilization, i.wal)
 		i.instances[instanceID] = inst
 	}
 	return inst
@@ -419,6 +466,17 @@ func (i *Ingester) Series(ctx context.Context, req *logproto.SeriesRequest) (*lo
 	return instance.Series(ctx, req)
 }
 
+// GetStreamStats returns the stats of the chunks held in memory of the streams matching a set of matchers.
+func (i *Ingester) GetStreamStats(ctx context.Context, req *logproto.StreamStatsRequest) (*logproto.StreamStatsResponse, error) {
+	instanceID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	instance := i.getOrCreateInstance(instanceID)
+	return instance.GetStreamStats(ctx, req)
+}
+
 // Check implements grpc_health_v1.HealthCheck.
 func (*Ingester) Check(ctx context.Context, req *grpc_health_v1.HealthCheckRequest) (*grpc_health_v1.HealthCheckResponse, error) {
 	return &grpc_health_v1.HealthCheckResponse{Status: grpc_health_v1.HealthCheckResponse_SERVING}, nil
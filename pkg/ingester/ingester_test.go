@@ -17,6 +17,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
 
+	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/ingester/client"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
@@ -245,6 +246,74 @@ func TestIngesterStreamLimitExceeded(t *testing.T) {
 	}
 }
 
+func TestIngesterChunkEncodingOverride(t *testing.T) {
+	ingesterConfig := defaultIngesterTestConfig(t)
+	defaultLimits := defaultLimitsTestConfig()
+	overrides, err := validation.NewOverrides(defaultLimits, func(userID string) *validation.Limits {
+		if userID != "zstd" {
+			return nil
+		}
+		limits := defaultLimits
+		limits.ChunkEncoding = "zstd"
+		return &limits
+	})
+	require.NoError(t, err)
+
+	i, err := New(ingesterConfig, client.Config{}, &mockStore{chunks: map[string][]chunk.Chunk{}}, overrides, nil)
+	require.NoError(t, err)
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	for userID, expected := range map[string]chunkenc.Encoding{
+		"test": chunkenc.EncGZIP,
+		"zstd": chunkenc.EncZstd,
+	} {
+		c := i.factory(userID)
+		require.Equal(t, expected, c.(*chunkenc.MemChunk).Encoding(), userID)
+	}
+}
+
+func TestIngesterOutOfOrderWindowOverride(t *testing.T) {
+	var window time.Duration
+	defaultLimits := defaultLimitsTestConfig()
+	overrides, err := validation.NewOverrides(defaultLimits, func(userID string) *validation.Limits {
+		limits := defaultLimits
+		limits.OutOfOrderWindow = window
+		return &limits
+	})
+	require.NoError(t, err)
+
+	i, err := New(defaultIngesterTestConfig(t), client.Config{}, &mockStore{chunks: map[string][]chunk.Chunk{}}, overrides, nil)
+	require.NoError(t, err)
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	push := func(ts ...int64) error {
+		req := &logproto.PushRequest{Streams: []logproto.Stream{{Labels: `{foo="bar"}`}}}
+		for _, t := range ts {
+			req.Streams[0].Entries = append(req.Streams[0].Entries, logproto.Entry{Timestamp: time.Unix(t, 0), Line: fmt.Sprintf("%d", t)})
+		}
+		_, err := i.Push(ctx, req)
+		return err
+	}
+
+	require.NoError(t, push(10, 20))
+
+	// The entries are accepted in any order once the window is enabled.
+	window = time.Minute
+	require.NoError(t, push(15))
+
+	// And rejected again once it is disabled.
+	window = 0
+	require.NoError(t, push(30))
+	require.Error(t, push(25))
+
+	var lines []string
+	for _, e := range queryAll(ctx, t, i)[`{foo="bar"}`] {
+		lines = append(lines, e.Line)
+	}
+	require.Equal(t, []string{"10", "15", "20", "30"}, lines)
+}
+
 type mockStore struct {
 	mtx    sync.Mutex
 	chunks map[string][]chunk.Chunk
@@ -62,6 +62,7 @@ type instance struct {
 	streams    map[model.Fingerprint]*stream // we use 'mapped' fingerprints here.
 	index      *index.InvertedIndex
 	mapper     *fpMapper // using of mapper needs streamsMtx because it calls back
+	generation uint64    // generation of the last stream created, guarded by streamsMtx.
 
 	instanceID string
 
@@ -73,13 +74,14 @@ type instance struct {
 
 	limiter *Limiter
 	factory func() chunkenc.Chunk
+	wal     WAL
 
 	// sync
 	syncPeriod  time.Duration
 	syncMinUtil float64
 }
 
-func newInstance(cfg *Config, instanceID string, factory func() chunkenc.Chunk, limiter *Limiter, syncPeriod time.Duration, syncMinUtil float64) *instance {
+func newInstance(cfg *Config, instanceID string, factory func() chunkenc.Chunk, limiter *Limiter, syncPeriod time.Duration, syncMinUtil float64, wal WAL) *instance {
 	i := &instance{
 		cfg:        cfg,
 		streams:    map[model.Fingerprint]*stream{},
@@ -92,6 +94,7 @@ func newInstance(cfg *Config, instanceID string, factory func() chunkenc.Chunk,
 		factory: factory,
 		tailers: map[uint32]*tailer{},
 		limiter: limiter,
+		wal:     wal,
 
 		syncPeriod:  syncPeriod,
 		syncMinUtil: syncMinUtil,
@@ -111,12 +114,7 @@ func (i *instance) consumeChunk(ctx context.Context, labels []client.LabelAdapte
 
 	stream, ok := i.streams[fp]
 	if !ok {
-		sortedLabels := i.index.Add(labels, fp)
-		stream = newStream(i.cfg, fp, sortedLabels, i.factory)
-		i.streams[fp] = stream
-		i.streamsCreatedTotal.Inc()
-		memoryStreams.WithLabelValues(i.instanceID).Inc()
-		i.addTailersToNewStream(stream)
+		stream = i.createStream(fp, i.nextGeneration(), labels)
 	}
 
 	err := stream.consumeChunk(ctx, chunk)
@@ -131,17 +129,20 @@ func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
 	i.streamsMtx.Lock()
 	defer i.streamsMtx.Unlock()
 
+	record := &walRecord{userID: i.instanceID}
+	outOfOrderWindow := i.limiter.limits.OutOfOrderWindow(i.instanceID)
+
 	var appendErr error
 	for _, s := range req.Streams {
 
-		stream, err := i.getOrCreateStream(s)
+		stream, err := i.getOrCreateStream(s, record)
 		if err != nil {
 			appendErr = err
 			continue
 		}
 
 		prevNumChunks := len(stream.chunks)
-		if err := stream.Push(ctx, s.Entries, i.syncPeriod, i.syncMinUtil); err != nil {
+		if err := stream.Push(ctx, s.Entries, i.syncPeriod, i.syncMinUtil, outOfOrderWindow, record); err != nil {
 			appendErr = err
 			continue
 		}
@@ -149,10 +150,16 @@ func (i *instance) Push(ctx context.Context, req *logproto.PushRequest) error {
 		memoryChunks.Add(float64(len(stream.chunks) - prevNumChunks))
 	}
 
+	// The record is logged while holding the lock so that the records of the streams are ordered in the WAL.
+	if err := i.wal.Log(record); err != nil {
+		return err
+	}
+
 	return appendErr
 }
 
-func (i *instance) getOrCreateStream(pushReqStream logproto.Stream) (*stream, error) {
+// getOrCreateStream returns the stream of a pushed stream, a new stream is added to the record. Must hold streamsMtx.
+func (i *instance) getOrCreateStream(pushReqStream logproto.Stream, record *walRecord) (*stream, error) {
 	labels, err := util.ToClientLabels(pushReqStream.Labels)
 	if err != nil {
 		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
@@ -176,14 +183,64 @@ func (i *instance) getOrCreateStream(pushReqStream logproto.Stream) (*stream, er
 		return nil, httpgrpc.Errorf(http.StatusTooManyRequests, validation.StreamLimitErrorMsg())
 	}
 
+	stream = i.createStream(fp, i.nextGeneration(), labels)
+	if record != nil {
+		record.series = append(record.series, walSeries{ref: uint64(fp), generation: stream.generation, labels: stream.labels})
+	}
+
+	return stream, nil
+}
+
+// restoreStream returns the stream with the given labels, creating it with the given generation without
+// applying the limits as it is restored from the WAL. Must hold streamsMtx.
+func (i *instance) restoreStream(ls labels.Labels, generation uint64) *stream {
+	labels := client.FromLabelsToLabelAdapters(ls)
+	fp := i.mapper.mapFP(client.FastFingerprint(labels), labels)
+
+	if stream, ok := i.streams[fp]; ok {
+		return stream
+	}
+	// The streams created once restored must have a higher generation than the ones logged in the WAL.
+	if generation > i.generation {
+		i.generation = generation
+	}
+	return i.createStream(fp, generation, labels)
+}
+
+// nextGeneration returns the generation of a new stream. Must hold streamsMtx.
+func (i *instance) nextGeneration() uint64 {
+	i.generation++
+	return i.generation
+}
+
+func (i *instance) createStream(fp model.Fingerprint, generation uint64, labels []client.LabelAdapter) *stream {
 	sortedLabels := i.index.Add(labels, fp)
-	stream = newStream(i.cfg, fp, sortedLabels, i.factory)
+	stream := newStream(i.cfg, fp, sortedLabels, i.factory)
+	stream.generation = generation
 	i.streams[fp] = stream
 	memoryStreams.WithLabelValues(i.instanceID).Inc()
 	i.streamsCreatedTotal.Inc()
 	i.addTailersToNewStream(stream)
+	return stream
+}
 
-	return stream, nil
+// removeStream removes a stream from the instance. Must hold streamsMtx.
+func (i *instance) removeStream(s *stream) {
+	delete(i.streams, s.fp)
+	i.index.Delete(s.labels, s.fp)
+	i.streamsRemovedTotal.Inc()
+	memoryStreams.WithLabelValues(i.instanceID).Dec()
+}
+
+// appendCheckpointStreams appends the streams of the instance to checkpoint.
+func (i *instance) appendCheckpointStreams(refs []*checkpointStreamRef) []*checkpointStreamRef {
+	i.streamsMtx.RLock()
+	defer i.streamsMtx.RUnlock()
+
+	for _, s := range i.streams {
+		refs = append(refs, &checkpointStreamRef{instance: i, stream: s})
+	}
+	return refs
 }
 
 // Return labels associated with given fingerprint. Used by fingerprint mapper. Must hold streamsMtx.
@@ -200,7 +257,7 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) ([]iter
 	if err != nil {
 		return nil, err
 	}
-	filter, err := expr.Filter()
+	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -211,7 +268,7 @@ func (i *instance) Query(ctx context.Context, req logql.SelectLogParams) ([]iter
 		expr.Matchers(),
 		func(stream *stream) error {
 			ingStats.TotalChunksMatched += int64(len(stream.chunks))
-			iter, err := stream.Iterator(ctx, req.Start, req.End, req.Direction, filter)
+			iter, err := stream.Iterator(ctx, req.Start, req.End, req.Direction, pipeline)
 			if err != nil {
 				return err
 			}
@@ -231,10 +288,6 @@ func (i *instance) QuerySample(ctx context.Context, req logql.SelectSampleParams
 	if err != nil {
 		return nil, err
 	}
-	filter, err := expr.Selector().Filter()
-	if err != nil {
-		return nil, err
-	}
 	extractor, err := expr.Extractor()
 	if err != nil {
 		return nil, err
@@ -245,7 +298,7 @@ func (i *instance) QuerySample(ctx context.Context, req logql.SelectSampleParams
 		expr.Selector().Matchers(),
 		func(stream *stream) error {
 			ingStats.TotalChunksMatched += int64(len(stream.chunks))
-			iter, err := stream.SampleIterator(ctx, req.Start, req.End, filter, extractor)
+			iter, err := stream.SampleIterator(ctx, req.Start, req.End, extractor)
 			if err != nil {
 				return err
 			}
@@ -335,6 +388,44 @@ func (i *instance) Series(_ context.Context, req *logproto.SeriesRequest) (*logp
 	return &logproto.SeriesResponse{Series: series}, nil
 }
 
+// GetStreamStats returns the stats of the chunks overlapping the request time range of the streams matching the request matchers.
+// Flushed chunks are left out, they are accounted by the store.
+func (i *instance) GetStreamStats(_ context.Context, req *logproto.StreamStatsRequest) (*logproto.StreamStatsResponse, error) {
+	matchers, err := logql.ParseMatchers(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &logproto.StreamStatsResponse{}
+	err = i.forMatchingStreams(matchers, func(stream *stream) error {
+		var (
+			stats       = logproto.StreamStats{Labels: stream.labelsString, Fingerprint: uint64(stream.fp)}
+			overlapping bool
+		)
+		for _, c := range stream.chunks {
+			from, through := c.chunk.Bounds()
+			if !from.Before(req.End) || through.Before(req.Start) {
+				continue
+			}
+			overlapping = true
+			if !c.flushed.IsZero() {
+				continue
+			}
+			stats.Chunks++
+			stats.Bytes += uint64(c.chunk.UncompressedSize())
+			stats.Entries += uint64(c.chunk.Size())
+		}
+		if overlapping {
+			res.Streams = append(res.Streams, stats)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
 // forAllStreams will execute a function for all streams in the instance.
 // It uses a function in order to enable generic stream access without accidentally leaking streams under the mutex.
 func (i *instance) forAllStreams(fn func(*stream) error) error {
@@ -531,4 +622,4 @@ func shouldConsiderStream(stream *stream, req *logproto.SeriesRequest) bool {
 		return true
 	}
 	return false
-}
\ No newline at end of file
+}
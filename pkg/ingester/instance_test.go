@@ -28,7 +28,7 @@ func TestLabelsCollisions(t *testing.T) {
 	require.NoError(t, err)
 	limiter := NewLimiter(limits, &ringCountMock{count: 1}, 1)
 
-	i := newInstance(&Config{}, "test", defaultFactory, limiter, 0, 0)
+	i := newInstance(&Config{}, "test", defaultFactory, limiter, 0, 0, noopWAL{})
 
 	// avoid entries from the future.
 	tt := time.Now().Add(-5 * time.Minute)
@@ -55,7 +55,7 @@ func TestConcurrentPushes(t *testing.T) {
 	require.NoError(t, err)
 	limiter := NewLimiter(limits, &ringCountMock{count: 1}, 1)
 
-	inst := newInstance(&Config{}, "test", defaultFactory, limiter, 0, 0)
+	inst := newInstance(&Config{}, "test", defaultFactory, limiter, 0, 0, noopWAL{})
 
 	const (
 		concurrent          = 10
@@ -113,7 +113,7 @@ func TestSyncPeriod(t *testing.T) {
 		minUtil    = 0.20
 	)
 
-	inst := newInstance(&Config{}, "test", defaultFactory, limiter, syncPeriod, minUtil)
+	inst := newInstance(&Config{}, "test", defaultFactory, limiter, syncPeriod, minUtil, noopWAL{})
 	lbls := makeRandomLabels()
 
 	tt := time.Now()
@@ -128,7 +128,7 @@ func TestSyncPeriod(t *testing.T) {
 	require.NoError(t, err)
 
 	// let's verify results
-	s, err := inst.getOrCreateStream(pr.Streams[0])
+	s, err := inst.getOrCreateStream(pr.Streams[0], nil)
 	require.NoError(t, err)
 
 	// make sure each chunk spans max 'sync period' time
@@ -152,7 +152,7 @@ func Test_SeriesQuery(t *testing.T) {
 	syncPeriod := 1 * time.Minute
 	minUtil := 0.20
 
-	instance := newInstance(&Config{}, "test", defaultFactory, limiter, syncPeriod, minUtil)
+	instance := newInstance(&Config{}, "test", defaultFactory, limiter, syncPeriod, minUtil, noopWAL{})
 
 	currentTime := time.Now()
 
@@ -162,7 +162,7 @@ func Test_SeriesQuery(t *testing.T) {
 	}
 
 	for _, testStream := range testStreams {
-		stream, err := instance.getOrCreateStream(testStream)
+		stream, err := instance.getOrCreateStream(testStream, nil)
 		require.NoError(t, err)
 		chunk := defaultFactory()
 		for _, entry := range testStream.Entries {
@@ -239,6 +239,67 @@ func Test_SeriesQuery(t *testing.T) {
 
 }
 
+func Test_GetStreamStats(t *testing.T) {
+	limits, err := validation.NewOverrides(validation.Limits{MaxLocalStreamsPerUser: 1000}, nil)
+	require.NoError(t, err)
+	limiter := NewLimiter(limits, &ringCountMock{count: 1}, 1)
+
+	instance := newInstance(&Config{}, "test", defaultFactory, limiter, 0, 0, noopWAL{})
+
+	currentTime := time.Now()
+
+	stream, err := instance.getOrCreateStream(logproto.Stream{Labels: `{app="test",job="varlogs"}`}, nil)
+	require.NoError(t, err)
+	for i, flushed := range []time.Time{currentTime, {}, {}} {
+		chunk := defaultFactory()
+		for _, entry := range entries(5, currentTime.Add(time.Duration(i*5)*time.Nanosecond)) {
+			require.NoError(t, chunk.Append(&entry))
+		}
+		stream.chunks = append(stream.chunks, chunkDesc{chunk: chunk, flushed: flushed})
+	}
+	_, err = instance.getOrCreateStream(logproto.Stream{Labels: `{app="test2",job="varlogs"}`, Entries: entries(5, currentTime)}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name     string
+		req      *logproto.StreamStatsRequest
+		expected []logproto.StreamStats
+	}{
+		{
+			"flushed chunks are not accounted",
+			&logproto.StreamStatsRequest{Start: currentTime, End: currentTime.Add(15 * time.Nanosecond), Matchers: `{app="test"}`},
+			[]logproto.StreamStats{
+				{Labels: `{app="test", job="varlogs"}`, Fingerprint: uint64(stream.fp), Chunks: 2, Bytes: 2 * uint64(len("hello 0")*5), Entries: 10},
+			},
+		},
+		{
+			"chunks overlapping the time range",
+			&logproto.StreamStatsRequest{Start: currentTime.Add(7 * time.Nanosecond), End: currentTime.Add(10 * time.Nanosecond), Matchers: `{app="test"}`},
+			[]logproto.StreamStats{
+				{Labels: `{app="test", job="varlogs"}`, Fingerprint: uint64(stream.fp), Chunks: 1, Bytes: uint64(len("hello 0") * 5), Entries: 5},
+			},
+		},
+		{
+			"streams with only flushed chunks",
+			&logproto.StreamStatsRequest{Start: currentTime, End: currentTime.Add(time.Nanosecond), Matchers: `{app="test"}`},
+			[]logproto.StreamStats{
+				{Labels: `{app="test", job="varlogs"}`, Fingerprint: uint64(stream.fp)},
+			},
+		},
+		{
+			"non overlapping request",
+			&logproto.StreamStatsRequest{Start: currentTime.Add(time.Hour), End: currentTime.Add(2 * time.Hour), Matchers: `{job="varlogs"}`},
+			nil,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := instance.GetStreamStats(context.Background(), tc.req)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, resp.Streams)
+		})
+	}
+}
+
 func entries(n int, t time.Time) []logproto.Entry {
 	var result []logproto.Entry
 	for i := 0; i < n; i++ {
@@ -0,0 +1,217 @@
+package ingester
+
+import (
+	"context"
+	"path/filepath"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/prometheus/tsdb/wal"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+)
+
+// recoveredStreams are the streams restored by the replay, by tenant and by their reference in the WAL records.
+type recoveredStreams map[string]map[uint64]*stream
+
+func (r recoveredStreams) set(userID string, ref uint64, s *stream) {
+	refs, ok := r[userID]
+	if !ok {
+		refs = map[uint64]*stream{}
+		r[userID] = refs
+	}
+	refs[ref] = s
+}
+
+// Replay restores the streams of the most recent valid checkpoint, then replays the WAL segments created since.
+// A corrupted segment is repaired by discarding its records following the corruption and the segments after it.
+// A checkpoint is created once the replay is done so that the next replay starts from a state consistent with
+// the records logged by this ingester.
+func (w *walWrapper) Replay() error {
+	start := time.Now()
+	level.Info(util.Logger).Log("msg", "recovering from WAL", "dir", w.wal.Dir())
+
+	streams := recoveredStreams{}
+	firstSegment, err := w.replayCheckpoint(streams)
+	if err != nil {
+		return err
+	}
+
+	if err := w.replaySegments(firstSegment, streams); err != nil {
+		var cerr *wal.CorruptionErr
+		if !errors.As(err, &cerr) {
+			return errors.Wrap(err, "replaying WAL")
+		}
+		walCorruptionsTotal.Inc()
+		level.Error(util.Logger).Log("msg", "WAL is corrupted, repairing it", "segment", cerr.Segment, "offset", cerr.Offset, "err", err)
+		if err := w.wal.Repair(err); err != nil {
+			return errors.Wrap(err, "repairing WAL")
+		}
+	}
+
+	elapsed := time.Since(start)
+	walReplayDuration.Set(elapsed.Seconds())
+	level.Info(util.Logger).Log("msg", "recovered from WAL", "time", elapsed.String())
+
+	return w.checkpoint(true)
+}
+
+// replayCheckpoint restores the streams of the most recent checkpoint which can be read, falling back to the previous
+// one if it is corrupted. It returns the first segment to replay, -1 to replay all of them.
+func (w *walWrapper) replayCheckpoint(streams recoveredStreams) (int, error) {
+	checkpoints, err := listCheckpoints(w.wal.Dir())
+	if err != nil {
+		return 0, err
+	}
+
+	for j := len(checkpoints) - 1; j >= 0; j-- {
+		dir := filepath.Join(w.wal.Dir(), checkpoints[j].name)
+
+		// The checkpoint is read a first time to make sure none of the streams are restored if it is corrupted.
+		if err := readCheckpoint(dir, func(s *checkpointStream) error {
+			_, err := w.checkpointChunks(s)
+			return err
+		}); err != nil {
+			walCorruptionsTotal.Inc()
+			level.Error(util.Logger).Log("msg", "checkpoint is corrupted, falling back to the previous one", "dir", dir, "err", err)
+			continue
+		}
+
+		if err := readCheckpoint(dir, func(s *checkpointStream) error {
+			return w.restoreCheckpointStream(s, streams)
+		}); err != nil {
+			return 0, errors.Wrapf(err, "restoring checkpoint %s", dir)
+		}
+		level.Info(util.Logger).Log("msg", "recovered from checkpoint", "dir", dir)
+		return checkpoints[j].index, nil
+	}
+	return -1, nil
+}
+
+func readCheckpoint(dir string, fn func(*checkpointStream) error) error {
+	segments, err := wal.NewSegmentsReader(dir)
+	if err != nil {
+		return err
+	}
+	defer segments.Close()
+
+	r := wal.NewReader(segments)
+	for r.Next() {
+		s, err := decodeCheckpointStream(r.Record())
+		if err != nil {
+			return err
+		}
+		if err := fn(s); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+func (w *walWrapper) checkpointChunks(s *checkpointStream) ([]chunkDesc, error) {
+	cfg := w.ingester.cfg
+	chunks := make([]chunkDesc, 0, len(s.chunks))
+	for _, c := range s.chunks {
+		mc, err := chunkenc.MemChunkFromCheckpoint(c.chunk, c.head, cfg.BlockSize, cfg.TargetChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		chunks = append(chunks, chunkDesc{
+			chunk:       mc,
+			closed:      c.closed,
+			synced:      c.synced,
+			flushed:     c.flushed,
+			lastUpdated: c.lastUpdated,
+		})
+	}
+	return chunks, nil
+}
+
+func (w *walWrapper) restoreCheckpointStream(s *checkpointStream, streams recoveredStreams) error {
+	chunks, err := w.checkpointChunks(s)
+	if err != nil {
+		return err
+	}
+
+	inst := w.ingester.getOrCreateInstance(s.userID)
+	inst.streamsMtx.Lock()
+	defer inst.streamsMtx.Unlock()
+
+	stream := inst.restoreStream(s.labels, s.generation)
+	stream.chunks = append(stream.chunks, chunks...)
+	for _, c := range chunks {
+		stream.updateHighestTs(c.chunk)
+	}
+	stream.entryCt = s.entryCt
+	stream.lastLine = s.lastLine
+	memoryChunks.Add(float64(len(chunks)))
+	chunksCreatedTotal.Add(float64(len(chunks)))
+
+	streams.set(s.userID, s.fp, stream)
+	return nil
+}
+
+// replaySegments replays the WAL records from the given segment.
+func (w *walWrapper) replaySegments(first int, streams recoveredStreams) error {
+	segments, err := wal.NewSegmentsRangeReader(wal.SegmentRange{Dir: w.wal.Dir(), First: first, Last: -1})
+	if err != nil {
+		return err
+	}
+	defer segments.Close()
+
+	var (
+		r      = wal.NewReader(segments)
+		record walRecord
+	)
+	for r.Next() {
+		if err := decodeWALRecord(r.Record(), &record); err != nil {
+			// The checksum of the record is valid, it can't be repaired.
+			level.Error(util.Logger).Log("msg", "skipping invalid WAL record", "err", err)
+			continue
+		}
+		w.replayRecord(&record, streams)
+	}
+	return r.Err()
+}
+
+func (w *walWrapper) replayRecord(record *walRecord, streams recoveredStreams) {
+	inst := w.ingester.getOrCreateInstance(record.userID)
+	inst.streamsMtx.Lock()
+	defer inst.streamsMtx.Unlock()
+
+	for _, s := range record.series {
+		// The stream may have been restored by the checkpoint. It is replaced if it was flushed and removed,
+		// then created again with a higher generation.
+		if stream, ok := streams[record.userID][s.ref]; ok {
+			if s.generation <= stream.generation {
+				continue
+			}
+			memoryChunks.Sub(float64(len(stream.chunks)))
+			inst.removeStream(stream)
+		}
+		streams.set(record.userID, s.ref, inst.restoreStream(s.labels, s.generation))
+	}
+
+	outOfOrderWindow := inst.limiter.limits.OutOfOrderWindow(record.userID)
+	for _, e := range record.entries {
+		stream, ok := streams[record.userID][e.ref]
+		if !ok {
+			level.Warn(util.Logger).Log("msg", "skipping WAL entries of an unknown stream", "user", record.userID, "ref", e.ref)
+			continue
+		}
+		// These entries were appended to a previous generation of the stream, or before it was checkpointed.
+		if e.generation != stream.generation || e.counter <= stream.entryCt {
+			continue
+		}
+
+		prevNumChunks := len(stream.chunks)
+		// Entries which were not stored when they were pushed fail again, they are not logged.
+		if err := stream.Push(context.Background(), e.entries, inst.syncPeriod, inst.syncMinUtil, outOfOrderWindow, nil); err != nil {
+			level.Debug(util.Logger).Log("msg", "failed to replay WAL entries", "user", record.userID, "stream", stream.labelsString, "err", err)
+		}
+		memoryChunks.Add(float64(len(stream.chunks) - prevNumChunks))
+		stream.entryCt = e.counter
+	}
+}
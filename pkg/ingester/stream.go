@@ -66,6 +66,16 @@ type stream struct {
 	labelsString string
 	factory      func() chunkenc.Chunk
 	lastLine     line
+	// generation tells apart the streams of the instance created with the same fingerprint, once a stream
+	// is flushed and removed the next one gets a higher generation. WAL entries are only replayed to the
+	// stream of their generation, whose entryCt starts from 0.
+	generation uint64
+	// entryCt is the number of entries appended to the stream, used to skip the entries of the WAL
+	// already restored from a checkpoint.
+	entryCt int64
+	// highestTs is the highest timestamp of the entries appended to the stream, entries older than
+	// the out of order window before it are rejected.
+	highestTs time.Time
 
 	tailers   map[uint32]*tailer
 	tailerMtx sync.RWMutex
@@ -107,11 +117,32 @@ func (s *stream) consumeChunk(_ context.Context, chunk *logproto.Chunk) error {
 	s.chunks = append(s.chunks, chunkDesc{
 		chunk: c,
 	})
+	s.updateHighestTs(c)
 	chunksCreatedTotal.Inc()
 	return nil
 }
 
-func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronizePeriod time.Duration, minUtilization float64) error {
+// updateHighestTs makes sure the highest timestamp of the stream includes the entries of a chunk added to it.
+func (s *stream) updateHighestTs(c chunkenc.Chunk) {
+	if _, to := c.Bounds(); to.After(s.highestTs) {
+		s.highestTs = to
+	}
+}
+
+// ordered returns whether the chunks of the stream are ordered, they may overlap otherwise.
+func (s *stream) ordered() bool {
+	for _, c := range s.chunks {
+		if !c.chunk.Ordered() {
+			return false
+		}
+	}
+	return true
+}
+
+// Push appends the entries to the stream, the stored ones are added to the WAL record if it is not nil.
+// If outOfOrderWindow is positive, entries are accepted in any order as long as they are not older than
+// the highest timestamp of the stream by more than outOfOrderWindow.
+func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronizePeriod time.Duration, minUtilization float64, outOfOrderWindow time.Duration, record *walRecord) error {
 	var lastChunkTimestamp time.Time
 	if len(s.chunks) == 0 {
 		s.chunks = append(s.chunks, chunkDesc{
@@ -122,6 +153,16 @@ func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronize
 		_, lastChunkTimestamp = s.chunks[len(s.chunks)-1].chunk.Bounds()
 	}
 
+	// Whether the entries of a chunk are ordered is set when it is created, the head chunk is replaced if the
+	// out of order window was enabled or disabled since.
+	if head := &s.chunks[len(s.chunks)-1]; !head.closed && head.chunk.Ordered() != (outOfOrderWindow <= 0) {
+		if head.chunk.Size() == 0 {
+			head.chunk = s.factory()
+		} else {
+			head.closed = true
+		}
+	}
+
 	storedEntries := []logproto.Entry{}
 	failedEntriesWithError := []entryWithError{}
 
@@ -140,6 +181,11 @@ func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronize
 			continue
 		}
 
+		if outOfOrderWindow > 0 && entries[i].Timestamp.Before(s.highestTs.Add(-outOfOrderWindow)) {
+			failedEntriesWithError = append(failedEntriesWithError, entryWithError{&entries[i], chunkenc.ErrOutOfOrder})
+			continue
+		}
+
 		chunk := &s.chunks[len(s.chunks)-1]
 		if chunk.closed || !chunk.chunk.SpaceFor(&entries[i]) || s.cutChunkForSynchronization(entries[i].Timestamp, lastChunkTimestamp, chunk, synchronizePeriod, minUtilization) {
 			// If the chunk has no more space call Close to make sure anything in the head block is cut and compressed
@@ -168,11 +214,19 @@ func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronize
 			storedEntries = append(storedEntries, entries[i])
 			lastChunkTimestamp = entries[i].Timestamp
 			s.lastLine = line{ts: lastChunkTimestamp, content: entries[i].Line}
+			if lastChunkTimestamp.After(s.highestTs) {
+				s.highestTs = lastChunkTimestamp
+			}
 		}
 		chunk.lastUpdated = time.Now()
 	}
 
 	if len(storedEntries) != 0 {
+		s.entryCt += int64(len(storedEntries))
+		if record != nil {
+			record.entries = append(record.entries, walEntries{ref: uint64(s.fp), generation: s.generation, counter: s.entryCt, entries: storedEntries})
+		}
+
 		go func() {
 			stream := logproto.Stream{Labels: s.labelsString, Entries: storedEntries}
 
@@ -184,7 +238,7 @@ func (s *stream) Push(ctx context.Context, entries []logproto.Entry, synchronize
 					closedTailers = append(closedTailers, tailer.getID())
 					continue
 				}
-				tailer.send(stream)
+				tailer.send(stream, s.labels)
 			}
 			s.tailerMtx.RUnlock()
 
@@ -256,10 +310,11 @@ func (s *stream) cutChunkForSynchronization(entryTimestamp, prevEntryTimestamp t
 }
 
 // Returns an iterator.
-func (s *stream) Iterator(ctx context.Context, from, through time.Time, direction logproto.Direction, filter logql.LineFilter) (iter.EntryIterator, error) {
+func (s *stream) Iterator(ctx context.Context, from, through time.Time, direction logproto.Direction, pipeline logql.Pipeline) (iter.EntryIterator, error) {
+	streamPipeline := pipeline.ForStream(s.labels)
 	iterators := make([]iter.EntryIterator, 0, len(s.chunks))
 	for _, c := range s.chunks {
-		itr, err := c.chunk.Iterator(ctx, from, through, direction, filter)
+		itr, err := c.chunk.Iterator(ctx, from, through, direction, streamPipeline)
 		if err != nil {
 			return nil, err
 		}
@@ -268,25 +323,34 @@ func (s *stream) Iterator(ctx context.Context, from, through time.Time, directio
 		}
 	}
 
+	if !s.ordered() {
+		return iter.NewHeapIterator(ctx, iterators, direction), nil
+	}
+
 	if direction != logproto.FORWARD {
 		for left, right := 0, len(iterators)-1; left < right; left, right = left+1, right-1 {
 			iterators[left], iterators[right] = iterators[right], iterators[left]
 		}
 	}
 
-	return iter.NewNonOverlappingIterator(iterators, s.labelsString), nil
+	return iter.NewNonOverlappingIterator(iterators, ""), nil
 }
 
 // Returns an SampleIterator.
-func (s *stream) SampleIterator(ctx context.Context, from, through time.Time, filter logql.LineFilter, extractor logql.SampleExtractor) (iter.SampleIterator, error) {
+func (s *stream) SampleIterator(ctx context.Context, from, through time.Time, extractor logql.SampleExtractor) (iter.SampleIterator, error) {
+	streamExtractor := extractor.ForStream(s.labels)
 	iterators := make([]iter.SampleIterator, 0, len(s.chunks))
 	for _, c := range s.chunks {
-		if itr := c.chunk.SampleIterator(ctx, from, through, filter, extractor); itr != nil {
+		if itr := c.chunk.SampleIterator(ctx, from, through, streamExtractor); itr != nil {
 			iterators = append(iterators, itr)
 		}
 	}
 
-	return iter.NewNonOverlappingSampleIterator(iterators, s.labelsString), nil
+	if !s.ordered() {
+		return iter.NewHeapSampleIterator(ctx, iterators), nil
+	}
+
+	return iter.NewNonOverlappingSampleIterator(iterators, ""), nil
 }
 
 func (s *stream) addTailer(t *tailer) {
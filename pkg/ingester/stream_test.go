@@ -16,6 +16,7 @@ import (
 
 	"github.com/grafana/loki/pkg/chunkenc"
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 )
 
 func TestMaxReturnedStreamsErrors(t *testing.T) {
@@ -43,7 +44,7 @@ func TestMaxReturnedStreamsErrors(t *testing.T) {
 
 			err := s.Push(context.Background(), []logproto.Entry{
 				{Timestamp: time.Unix(int64(numLogs), 0), Line: "log"},
-			}, 0, 0)
+			}, 0, 0, 0, nil)
 			require.NoError(t, err)
 
 			newLines := make([]logproto.Entry, numLogs)
@@ -62,7 +63,7 @@ func TestMaxReturnedStreamsErrors(t *testing.T) {
 			fmt.Fprintf(&expected, "total ignored: %d out of %d", numLogs, numLogs)
 			expectErr := httpgrpc.Errorf(http.StatusBadRequest, expected.String())
 
-			err = s.Push(context.Background(), newLines, 0, 0)
+			err = s.Push(context.Background(), newLines, 0, 0, 0, nil)
 			require.Error(t, err)
 			require.Equal(t, expectErr.Error(), err.Error())
 		})
@@ -83,13 +84,49 @@ func TestPushDeduplication(t *testing.T) {
 		{Timestamp: time.Unix(1, 0), Line: "test"},
 		{Timestamp: time.Unix(1, 0), Line: "test"},
 		{Timestamp: time.Unix(1, 0), Line: "newer, better test"},
-	}, 0, 0)
+	}, 0, 0, 0, nil)
 	require.NoError(t, err)
 	require.Len(t, s.chunks, 1)
 	require.Equal(t, s.chunks[0].chunk.Size(), 2,
 		"expected exact duplicate to be dropped and newer content with same timestamp to be appended")
 }
 
+func TestPushOutOfOrderWindow(t *testing.T) {
+	s := newStream(
+		&Config{},
+		model.Fingerprint(0),
+		labels.Labels{
+			{Name: "foo", Value: "bar"},
+		},
+		func() chunkenc.Chunk { return chunkenc.NewUnorderedMemChunk(chunkenc.EncGZIP, 256*1024, 0) },
+	)
+
+	err := s.Push(context.Background(), []logproto.Entry{
+		{Timestamp: time.Unix(10, 0), Line: "10"},
+		{Timestamp: time.Unix(5, 0), Line: "5"},
+		{Timestamp: time.Unix(20, 0), Line: "20"},
+		{Timestamp: time.Unix(12, 0), Line: "12"},
+	}, 0, 0, 10*time.Second, nil)
+	require.NoError(t, err)
+
+	// The entries older than the window before the most recent entry are rejected.
+	err = s.Push(context.Background(), []logproto.Entry{
+		{Timestamp: time.Unix(9, 0), Line: "9"},
+		{Timestamp: time.Unix(15, 0), Line: "15"},
+	}, 0, 0, 10*time.Second, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "total ignored: 1 out of 2")
+
+	it, err := s.Iterator(context.Background(), time.Unix(0, 0), time.Unix(100, 0), logproto.FORWARD, logql.NoopPipeline)
+	require.NoError(t, err)
+	var lines []string
+	for it.Next() {
+		lines = append(lines, it.Entry().Line)
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, []string{"5", "10", "12", "15", "20"}, lines)
+}
+
 func TestStreamIterator(t *testing.T) {
 	const chunks = 3
 	const entries = 100
@@ -119,7 +156,7 @@ func TestStreamIterator(t *testing.T) {
 			for i := 0; i < 100; i++ {
 				from := rand.Intn(chunks*entries - 1)
 				len := rand.Intn(chunks*entries-from) + 1
-				iter, err := s.Iterator(context.TODO(), time.Unix(int64(from), 0), time.Unix(int64(from+len), 0), logproto.FORWARD, nil)
+				iter, err := s.Iterator(context.TODO(), time.Unix(int64(from), 0), time.Unix(int64(from+len), 0), logproto.FORWARD, logql.NoopPipeline)
 				require.NotNil(t, iter)
 				require.NoError(t, err)
 				testIteratorForward(t, iter, int64(from), int64(from+len))
@@ -129,7 +166,7 @@ func TestStreamIterator(t *testing.T) {
 			for i := 0; i < 100; i++ {
 				from := rand.Intn(entries - 1)
 				len := rand.Intn(chunks*entries-from) + 1
-				iter, err := s.Iterator(context.TODO(), time.Unix(int64(from), 0), time.Unix(int64(from+len), 0), logproto.BACKWARD, nil)
+				iter, err := s.Iterator(context.TODO(), time.Unix(int64(from), 0), time.Unix(int64(from+len), 0), logproto.BACKWARD, logql.NoopPipeline)
 				require.NotNil(t, iter)
 				require.NoError(t, err)
 				testIteratorBackward(t, iter, int64(from), int64(from+len))
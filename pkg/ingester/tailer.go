@@ -22,7 +22,7 @@ type tailer struct {
 	id       uint32
 	orgID    string
 	matchers []*labels.Matcher
-	filter   logql.LineFilter
+	pipeline logql.Pipeline
 	expr     logql.Expr
 
 	sendChan chan *logproto.Stream
@@ -44,7 +44,7 @@ func newTailer(orgID, query string, conn logproto.Querier_TailServer) (*tailer,
 	if err != nil {
 		return nil, err
 	}
-	filter, err := expr.Filter()
+	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +53,7 @@ func newTailer(orgID, query string, conn logproto.Querier_TailServer) (*tailer,
 	return &tailer{
 		orgID:          orgID,
 		matchers:       matchers,
-		filter:         filter,
+		pipeline:       pipeline,
 		sendChan:       make(chan *logproto.Stream, bufferSizeForTailResponse),
 		conn:           conn,
 		droppedStreams: []*logproto.DroppedStream{},
@@ -103,7 +103,7 @@ func (t *tailer) loop() {
 	}
 }
 
-func (t *tailer) send(stream logproto.Stream) {
+func (t *tailer) send(stream logproto.Stream, lbs labels.Labels) {
 	if t.isClosed() {
 		return
 	}
@@ -118,32 +118,61 @@ func (t *tailer) send(stream logproto.Stream) {
 		return
 	}
 
-	t.filterEntriesInStream(&stream)
-
-	if len(stream.Entries) == 0 {
+	streams := t.processStream(stream, lbs)
+	if len(streams) == 0 {
 		return
 	}
-
-	select {
-	case t.sendChan <- &stream:
-	default:
-		t.dropStream(stream)
+	for _, s := range streams {
+		select {
+		case t.sendChan <- s:
+		default:
+			t.dropStream(*s)
+		}
 	}
 }
 
-func (t *tailer) filterEntriesInStream(stream *logproto.Stream) {
-	// Optimization: skip filtering entirely, if no filter is set
-	if t.filter == nil {
-		return
+// processStream runs the tailer pipeline over the entries of the stream.
+// Since the pipeline can extract labels, entries are grouped by their resulting labels.
+func (t *tailer) processStream(stream logproto.Stream, lbs labels.Labels) []*logproto.Stream {
+	// Optimization: skip processing entirely, if no pipeline is set and the entries have no structured metadata
+	// to turn into labels.
+	if t.pipeline == logql.NoopPipeline && !hasStructuredMetadata(stream.Entries) {
+		return []*logproto.Stream{&stream}
 	}
 
-	var filteredEntries []logproto.Entry
+	streams := map[uint64]*logproto.Stream{}
+	sp := t.pipeline.ForStream(lbs)
 	for _, e := range stream.Entries {
-		if t.filter.Filter([]byte(e.Line)) {
-			filteredEntries = append(filteredEntries, e)
+		newLine, parsedLbs, ok := sp.Process([]byte(e.Line), logproto.StructuredMetadataLabels(e.StructuredMetadata)...)
+		if !ok {
+			continue
+		}
+		var s *logproto.Stream
+		if s, ok = streams[parsedLbs.Hash()]; !ok {
+			s = &logproto.Stream{
+				Labels: parsedLbs.String(),
+			}
+			streams[parsedLbs.Hash()] = s
+		}
+		s.Entries = append(s.Entries, logproto.Entry{
+			Timestamp: e.Timestamp,
+			Line:      string(newLine),
+		})
+	}
+	streamsResult := make([]*logproto.Stream, 0, len(streams))
+	for _, s := range streams {
+		streamsResult = append(streamsResult, s)
+	}
+	return streamsResult
+}
+
+func hasStructuredMetadata(entries []logproto.Entry) bool {
+	for _, e := range entries {
+		if len(e.StructuredMetadata) > 0 {
+			return true
 		}
 	}
-	stream.Entries = filteredEntries
+	return false
 }
 
 // Returns true if tailer is interested in the passed labelset
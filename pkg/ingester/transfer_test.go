@@ -95,7 +95,7 @@ func TestTransferOut(t *testing.T) {
 				time.Unix(0, 0),
 				time.Unix(10, 0),
 				logproto.FORWARD,
-				logql.LineFilterFunc(func([]byte) bool { return true }),
+				logql.NoopPipeline,
 			)
 			if !assert.NoError(t, err) {
 				continue
@@ -0,0 +1,527 @@
+package ingester
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/go-kit/kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	tsdb_errors "github.com/prometheus/prometheus/tsdb/errors"
+	"github.com/prometheus/prometheus/tsdb/fileutil"
+	"github.com/prometheus/prometheus/tsdb/wal"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/pkg/chunkenc"
+)
+
+var (
+	walRecordsLogged = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_wal_records_logged_total",
+		Help:      "Total number of WAL records logged.",
+	})
+	walLoggedBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_wal_logged_bytes_total",
+		Help:      "Total number of bytes written to disk for WAL records.",
+	})
+	walDiskFullFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_wal_disk_full_failures_total",
+		Help:      "Total number of WAL records not logged because the disk was full.",
+	})
+	walCorruptionsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_wal_corruptions_total",
+		Help:      "Total number of corrupted WAL segments and checkpoints found on replay.",
+	})
+	walReplayDuration = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: "loki",
+		Name:      "ingester_wal_replay_duration_seconds",
+		Help:      "Time taken to replay the checkpoint and the WAL.",
+	})
+	checkpointCreationTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_checkpoint_creations_total",
+		Help:      "Total number of checkpoint creations attempted.",
+	})
+	checkpointCreationFail = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_checkpoint_creations_failed_total",
+		Help:      "Total number of checkpoint creations that failed.",
+	})
+	checkpointDeleteTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_checkpoint_deletions_total",
+		Help:      "Total number of checkpoint deletions attempted.",
+	})
+	checkpointDeleteFail = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_checkpoint_deletions_failed_total",
+		Help:      "Total number of checkpoint deletions that failed.",
+	})
+	checkpointDuration = promauto.NewSummary(prometheus.SummaryOpts{
+		Namespace:  "loki",
+		Name:       "ingester_checkpoint_duration_seconds",
+		Help:       "Time taken to create a checkpoint.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	})
+	checkpointLoggedBytesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: "loki",
+		Name:      "ingester_checkpoint_logged_bytes_total",
+		Help:      "Total number of bytes written to disk for checkpointing.",
+	})
+)
+
+const (
+	checkpointPrefix = "checkpoint."
+	// checkpoint records are logged by batches of this size.
+	checkpointBatchSize = 1 << 20
+	// diskUsageCheckPeriod is how often the disk usage is compared to the disk full threshold.
+	diskUsageCheckPeriod = 10 * time.Second
+)
+
+// WALConfig is the config of the write ahead log of the ingester.
+type WALConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	Dir                string        `yaml:"dir"`
+	CheckpointDuration time.Duration `yaml:"checkpoint_duration"`
+	DiskFullThreshold  float64       `yaml:"disk_full_threshold"`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet
+func (cfg *WALConfig) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, "ingester.wal-enabled", false, "Enable writing the pushed entries to a write ahead log, replayed on startup to recover the chunks which were not flushed.")
+	f.StringVar(&cfg.Dir, "ingester.wal-dir", "wal", "Directory to store the write ahead log and its checkpoints in.")
+	f.DurationVar(&cfg.CheckpointDuration, "ingester.checkpoint-duration", 5*time.Minute, "Interval at which the in memory chunks are checkpointed, allowing to delete the older WAL segments.")
+	f.Float64Var(&cfg.DiskFullThreshold, "ingester.wal-disk-full-threshold", 0.9, "Ratio of used disk space above which pushes are no longer written to the WAL, while still accepted. Checkpoints keep being created to reclaim space. 0 to disable.")
+}
+
+// WAL is the write ahead log of the pushes. A noop WAL is used when it is disabled.
+type WAL interface {
+	// Replay restores the streams of the last checkpoint and of the WAL segments following it into the ingester.
+	Replay() error
+	// Start starts checkpointing the in memory streams, once they are recovered.
+	Start()
+	// Log writes the record of a push to the WAL.
+	Log(*walRecord) error
+	// Stop creates a last checkpoint and closes the WAL.
+	Stop() error
+}
+
+type noopWAL struct{}
+
+func (noopWAL) Replay() error        { return nil }
+func (noopWAL) Start()               {}
+func (noopWAL) Log(*walRecord) error { return nil }
+func (noopWAL) Stop() error          { return nil }
+
+type walWrapper struct {
+	cfg      WALConfig
+	wal      *wal.WAL
+	ingester *Ingester
+	diskFull *atomic.Bool
+
+	// checkpointMtx prevents the last checkpoint from racing with a periodic one.
+	checkpointMtx sync.Mutex
+	bytesPool     sync.Pool
+
+	quit chan struct{}
+	wait sync.WaitGroup
+}
+
+// newWAL creates the WAL in the configured directory, its records are written in a new segment.
+// If the WAL is disabled, the returned WAL is a noop WAL.
+func newWAL(cfg WALConfig, registerer prometheus.Registerer, ingester *Ingester) (WAL, error) {
+	if !cfg.Enabled {
+		return noopWAL{}, nil
+	}
+	if cfg.CheckpointDuration <= 0 {
+		return nil, errors.New("the WAL checkpoint duration must be positive")
+	}
+
+	if registerer != nil {
+		registerer = prometheus.WrapRegistererWithPrefix("loki_ingester_", registerer)
+	}
+	tsdbWAL, err := wal.NewSize(util.Logger, registerer, cfg.Dir, wal.DefaultSegmentSize/4, false)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &walWrapper{
+		cfg:      cfg,
+		wal:      tsdbWAL,
+		ingester: ingester,
+		diskFull: atomic.NewBool(false),
+		bytesPool: sync.Pool{
+			New: func() interface{} {
+				return make([]byte, 0, 1024)
+			},
+		},
+		quit: make(chan struct{}),
+	}
+	w.checkDiskUsage()
+	return w, nil
+}
+
+func (w *walWrapper) Start() {
+	w.wait.Add(1)
+	go w.run()
+}
+
+func (w *walWrapper) Log(record *walRecord) error {
+	if record == nil || record.isEmpty() {
+		return nil
+	}
+	if w.diskFull.Load() {
+		walDiskFullFailures.Inc()
+		return nil
+	}
+
+	buf := w.bytesPool.Get().([]byte)[:0]
+	defer func() {
+		w.bytesPool.Put(buf) // nolint:staticcheck
+	}()
+
+	// The series are logged in the same call as the entries, so that they are never
+	// replayed without the streams they are appended to.
+	var records [][]byte
+	if len(record.series) > 0 {
+		buf = record.encodeSeries(buf)
+		records = append(records, buf)
+	}
+	if len(record.entries) > 0 {
+		start := len(buf)
+		buf = record.encodeEntries(buf)
+		records = append(records, buf[start:])
+	}
+
+	if err := w.wal.Log(records...); err != nil {
+		if errors.Is(err, syscall.ENOSPC) {
+			w.diskFull.Store(true)
+			walDiskFullFailures.Inc()
+			return nil
+		}
+		return err
+	}
+	walRecordsLogged.Add(float64(len(records)))
+	walLoggedBytesTotal.Add(float64(len(buf)))
+	return nil
+}
+
+func (w *walWrapper) Stop() error {
+	close(w.quit)
+	w.wait.Wait()
+
+	level.Info(util.Logger).Log("msg", "creating checkpoint before shutdown")
+	if err := w.checkpoint(true); err != nil {
+		level.Error(util.Logger).Log("msg", "error checkpointing streams during shutdown", "err", err)
+	}
+	return w.wal.Close()
+}
+
+func (w *walWrapper) run() {
+	defer w.wait.Done()
+
+	checkpointTicker := time.NewTicker(w.cfg.CheckpointDuration)
+	defer checkpointTicker.Stop()
+	diskTicker := time.NewTicker(diskUsageCheckPeriod)
+	defer diskTicker.Stop()
+
+	for {
+		select {
+		case <-checkpointTicker.C:
+			start := time.Now()
+			level.Info(util.Logger).Log("msg", "starting checkpoint")
+			if err := w.checkpoint(false); err != nil {
+				level.Error(util.Logger).Log("msg", "error checkpointing streams", "err", err)
+				continue
+			}
+			elapsed := time.Since(start)
+			level.Info(util.Logger).Log("msg", "checkpoint done", "time", elapsed.String())
+			checkpointDuration.Observe(elapsed.Seconds())
+		case <-diskTicker.C:
+			w.checkDiskUsage()
+		case <-w.quit:
+			return
+		}
+	}
+}
+
+// checkDiskUsage pauses logging to the WAL while the disk usage is above the threshold.
+func (w *walWrapper) checkDiskUsage() {
+	if w.cfg.DiskFullThreshold <= 0 {
+		return
+	}
+	usage, err := diskUsage(w.wal.Dir())
+	if err != nil {
+		level.Warn(util.Logger).Log("msg", "failed to get the disk usage of the WAL directory", "err", err)
+		return
+	}
+	full := usage >= w.cfg.DiskFullThreshold
+	if prev := w.diskFull.Swap(full); prev != full {
+		if full {
+			level.Warn(util.Logger).Log("msg", "disk usage is above the threshold, pushes are no longer written to the WAL", "usage", usage, "threshold", w.cfg.DiskFullThreshold)
+		} else {
+			level.Info(util.Logger).Log("msg", "disk usage is back below the threshold, resuming writing pushes to the WAL", "usage", usage, "threshold", w.cfg.DiskFullThreshold)
+		}
+	}
+}
+
+// checkpoint writes the in memory streams to a new checkpoint and deletes the WAL segments and the checkpoint
+// which are no longer needed. The checkpoint is named after the segment created when it is started, which the
+// replay starts from. It is spread over the checkpoint duration, unless immediate is set.
+func (w *walWrapper) checkpoint(immediate bool) (err error) {
+	w.checkpointMtx.Lock()
+	defer w.checkpointMtx.Unlock()
+
+	checkpointCreationTotal.Inc()
+	defer func() {
+		if err != nil {
+			checkpointCreationFail.Inc()
+		}
+	}()
+
+	_, lastCheckpoint, err := lastCheckpoint(w.wal.Dir())
+	if err != nil {
+		return err
+	}
+
+	// Every record logged from now on is in the new segment or the following ones.
+	if err := w.wal.NextSegment(); err != nil {
+		return err
+	}
+	_, segment, err := w.wal.Segments()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Join(w.wal.Dir(), fmt.Sprintf(checkpointPrefix+"%06d", segment))
+	level.Info(util.Logger).Log("msg", "attempting checkpoint for", "dir", dir)
+	tmpDir := dir + ".tmp"
+	if err := os.MkdirAll(tmpDir, 0777); err != nil {
+		return errors.Wrap(err, "create checkpoint dir")
+	}
+	checkpoint, err := wal.New(nil, nil, tmpDir, false)
+	if err != nil {
+		return errors.Wrap(err, "open checkpoint")
+	}
+	defer func() {
+		checkpoint.Close()
+		os.RemoveAll(tmpDir)
+	}()
+
+	var streams []*checkpointStreamRef
+	for _, inst := range w.ingester.getInstances() {
+		streams = inst.appendCheckpointStreams(streams)
+	}
+
+	var ticker *time.Ticker
+	if !immediate && len(streams) > 0 {
+		perStreamDuration := (95 * w.cfg.CheckpointDuration) / (100 * time.Duration(len(streams)))
+		if perStreamDuration > 0 {
+			ticker = time.NewTicker(perStreamDuration)
+			defer ticker.Stop()
+		}
+	}
+
+	var (
+		records   [][]byte
+		totalSize int
+	)
+	for _, ref := range streams {
+		record, err := ref.checkpoint(w.bytesPool.Get().([]byte)[:0])
+		if err != nil {
+			return err
+		}
+		records = append(records, record)
+		totalSize += len(record)
+		if totalSize >= checkpointBatchSize {
+			if err := w.logCheckpointRecords(checkpoint, records, totalSize); err != nil {
+				return err
+			}
+			records, totalSize = records[:0], 0
+		}
+
+		if ticker != nil {
+			select {
+			case <-ticker.C:
+			case <-w.quit: // finish the checkpoint as fast as possible when stopping.
+				ticker.Stop()
+				ticker = nil
+			}
+		}
+	}
+	if err := w.logCheckpointRecords(checkpoint, records, totalSize); err != nil {
+		return err
+	}
+
+	if err := checkpoint.Close(); err != nil {
+		return errors.Wrap(err, "close checkpoint")
+	}
+	if err := fileutil.Replace(tmpDir, dir); err != nil {
+		return errors.Wrap(err, "rename checkpoint directory")
+	}
+
+	// The segments and the checkpoint older than the previous checkpoint are deleted, the previous checkpoint
+	// is kept with the segments following it to recover from it if the new one is corrupted.
+	if lastCheckpoint >= 0 {
+		if err := w.wal.Truncate(lastCheckpoint); err != nil {
+			// It is fine to have old WAL segments hanging around if deletion failed.
+			// We can try again next time.
+			level.Error(util.Logger).Log("msg", "error deleting old WAL segments", "err", err)
+		}
+		if err := deleteCheckpoints(w.wal.Dir(), lastCheckpoint); err != nil {
+			// It is fine to have old checkpoints hanging around if deletion failed.
+			// We can try again next time.
+			level.Error(util.Logger).Log("msg", "error deleting old checkpoint", "err", err)
+		}
+	}
+	return nil
+}
+
+func (w *walWrapper) logCheckpointRecords(checkpoint *wal.WAL, records [][]byte, size int) error {
+	if len(records) == 0 {
+		return nil
+	}
+	if err := checkpoint.Log(records...); err != nil {
+		return err
+	}
+	checkpointLoggedBytesTotal.Add(float64(size))
+	for _, r := range records {
+		w.bytesPool.Put(r) // nolint:staticcheck
+	}
+	return nil
+}
+
+// checkpointStreamRef is a stream to checkpoint along with its instance, whose lock is held while the stream is encoded.
+type checkpointStreamRef struct {
+	instance *instance
+	stream   *stream
+}
+
+func (r *checkpointStreamRef) checkpoint(b []byte) ([]byte, error) {
+	r.instance.streamsMtx.RLock()
+	defer r.instance.streamsMtx.RUnlock()
+
+	s := r.stream
+	record := checkpointStream{
+		userID:     r.instance.instanceID,
+		fp:         uint64(s.fp),
+		generation: s.generation,
+		labels:     s.labels,
+		entryCt:    s.entryCt,
+		lastLine:   s.lastLine,
+		chunks:     make([]checkpointChunk, 0, len(s.chunks)),
+	}
+	for _, c := range s.chunks {
+		mc, ok := c.chunk.(*chunkenc.MemChunk)
+		if !ok {
+			return nil, errors.Errorf("chunks of type %T can't be checkpointed", c.chunk)
+		}
+		chk, head, err := mc.CheckpointBytes()
+		if err != nil {
+			return nil, err
+		}
+		record.chunks = append(record.chunks, checkpointChunk{
+			closed:      c.closed,
+			synced:      c.synced,
+			flushed:     c.flushed,
+			lastUpdated: c.lastUpdated,
+			chunk:       chk,
+			head:        head,
+		})
+	}
+	return record.encode(b), nil
+}
+
+// lastCheckpoint returns the directory name and index of the most recent checkpoint.
+// If dir does not contain any checkpoints, -1 is returned as index.
+func lastCheckpoint(dir string) (string, int, error) {
+	checkpoints, err := listCheckpoints(dir)
+	if err != nil || len(checkpoints) == 0 {
+		return "", -1, err
+	}
+	last := checkpoints[len(checkpoints)-1]
+	return filepath.Join(dir, last.name), last.index, nil
+}
+
+type checkpointRef struct {
+	name  string
+	index int
+}
+
+// listCheckpoints returns the complete checkpoints of dir, sorted by index.
+func listCheckpoints(dir string) ([]checkpointRef, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var refs []checkpointRef
+	for _, fi := range files {
+		index, err := checkpointIndex(fi.Name(), false)
+		if err != nil {
+			continue
+		}
+		if !fi.IsDir() {
+			return nil, fmt.Errorf("checkpoint %s is not a directory", fi.Name())
+		}
+		refs = append(refs, checkpointRef{name: fi.Name(), index: index})
+	}
+	// ReadDir sorts by name, which sorts by index as they are zero-padded.
+	return refs, nil
+}
+
+// deleteCheckpoints deletes all checkpoints in a directory whose index is lower than maxIndex.
+func deleteCheckpoints(dir string, maxIndex int) (err error) {
+	checkpointDeleteTotal.Inc()
+	defer func() {
+		if err != nil {
+			checkpointDeleteFail.Inc()
+		}
+	}()
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	var errs tsdb_errors.MultiError
+	for _, fi := range files {
+		index, err := checkpointIndex(fi.Name(), true)
+		if err != nil || index >= maxIndex {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, fi.Name())); err != nil {
+			errs.Add(err)
+		}
+	}
+	return errs.Err()
+}
+
+var checkpointRe = regexp.MustCompile("^" + regexp.QuoteMeta(checkpointPrefix) + "(\\d+)(\\.tmp)?$")
+
+// checkpointIndex returns the index of a given checkpoint file. It handles
+// both regular and temporary checkpoints according to the includeTmp flag. If
+// the file is not a checkpoint it returns an error.
+func checkpointIndex(filename string, includeTmp bool) (int, error) {
+	result := checkpointRe.FindStringSubmatch(filename)
+	if len(result) < 2 {
+		return 0, errors.New("file is not a checkpoint")
+	}
+	// Filter out temporary checkpoints if desired.
+	if !includeTmp && len(result) == 3 && result[2] != "" {
+		return 0, errors.New("temporary checkpoint")
+	}
+	return strconv.Atoi(result[1])
+}
@@ -0,0 +1,183 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/util/services"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/ingester/client"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+func TestIngesterWAL(t *testing.T) {
+	walDir, err := ioutil.TempDir(os.TempDir(), "loki-wal")
+	require.NoError(t, err)
+	defer os.RemoveAll(walDir)
+
+	ingesterConfig := defaultIngesterTestConfig(t)
+	ingesterConfig.MaxTransferRetries = 0
+	ingesterConfig.BlockSize = 256
+	ingesterConfig.WAL = WALConfig{
+		Enabled:            true,
+		Dir:                walDir,
+		CheckpointDuration: time.Hour,
+	}
+	limitsConfig := defaultLimitsTestConfig()
+	limitsConfig.AllowStructuredMetadata = true
+	limits, err := validation.NewOverrides(limitsConfig, nil)
+	require.NoError(t, err)
+
+	store := &mockStore{
+		chunks: map[string][]chunk.Chunk{},
+	}
+
+	i, err := New(ingesterConfig, client.Config{}, store, limits, nil)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	// The entries of the first streams are checkpointed, the other ones are only in the WAL.
+	for j, labels := range []string{`{foo="bar"}`, `{foo="baz"}`, `{foo="buzz"}`} {
+		req := &logproto.PushRequest{Streams: []logproto.Stream{{Labels: labels}}}
+		for k := 0; k < 100; k++ {
+			e := logproto.Entry{
+				Timestamp: time.Unix(int64(100*j+k), 0),
+				Line:      fmt.Sprintf("line %d", k),
+			}
+			if k%10 == 0 {
+				e.StructuredMetadata = []logproto.LabelPair{{Name: "traceID", Value: fmt.Sprintf("trace%d", k)}}
+			}
+			req.Streams[0].Entries = append(req.Streams[0].Entries, e)
+		}
+		_, err = i.Push(ctx, req)
+		require.NoError(t, err)
+
+		if j == 1 {
+			require.NoError(t, i.wal.(*walWrapper).checkpoint(true))
+		}
+	}
+
+	expected := queryAll(ctx, t, i)
+	// The structured metadata are labels of the results.
+	require.Len(t, expected, 3+3*10)
+	require.Len(t, expected[`{foo="buzz", traceID="trace10"}`], 1)
+
+	// Simulate a crash of the ingester, without flushing nor checkpointing.
+	require.NoError(t, i.wal.(*walWrapper).wal.Close())
+
+	i, err = New(ingesterConfig, client.Config{}, store, limits, nil)
+	require.NoError(t, err)
+	require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+	defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+	require.Equal(t, expected, queryAll(ctx, t, i))
+
+	// The recovered streams keep being appended to.
+	_, err = i.Push(ctx, &logproto.PushRequest{Streams: []logproto.Stream{{
+		Labels:  `{foo="bar"}`,
+		Entries: []logproto.Entry{{Timestamp: time.Unix(1000, 0), Line: "new line"}},
+	}}})
+	require.NoError(t, err)
+	expected[`{foo="bar"}`] = append(expected[`{foo="bar"}`], logproto.Entry{Timestamp: time.Unix(1000, 0), Line: "new line"})
+	require.Equal(t, expected, queryAll(ctx, t, i))
+}
+
+func TestIngesterWALReplayRecreatedStream(t *testing.T) {
+	for _, checkpoint := range []bool{false, true} {
+		t.Run(fmt.Sprintf("checkpoint=%v", checkpoint), func(t *testing.T) {
+			walDir, err := ioutil.TempDir(os.TempDir(), "loki-wal")
+			require.NoError(t, err)
+			defer os.RemoveAll(walDir)
+
+			ingesterConfig := defaultIngesterTestConfig(t)
+			ingesterConfig.MaxTransferRetries = 0
+			ingesterConfig.RetainPeriod = 0
+			ingesterConfig.WAL = WALConfig{
+				Enabled:            true,
+				Dir:                walDir,
+				CheckpointDuration: time.Hour,
+			}
+			limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+			require.NoError(t, err)
+
+			store := &mockStore{
+				chunks: map[string][]chunk.Chunk{},
+			}
+
+			i, err := New(ingesterConfig, client.Config{}, store, limits, nil)
+			require.NoError(t, err)
+
+			ctx := user.InjectOrgID(context.Background(), "test")
+			push := func(from, to int) []logproto.Entry {
+				var entries []logproto.Entry
+				for k := from; k < to; k++ {
+					entries = append(entries, logproto.Entry{Timestamp: time.Unix(int64(k), 0), Line: fmt.Sprintf("line %d", k)})
+				}
+				_, err := i.Push(ctx, &logproto.PushRequest{Streams: []logproto.Stream{{Labels: `{foo="bar"}`, Entries: entries}}})
+				require.NoError(t, err)
+				return entries
+			}
+
+			push(0, 3)
+			if checkpoint {
+				require.NoError(t, i.wal.(*walWrapper).checkpoint(true))
+			}
+
+			// The stream is flushed and removed, then created again by the next push whose entries counter
+			// starts from 0.
+			inst := i.getOrCreateInstance("test")
+			inst.streamsMtx.Lock()
+			for _, s := range inst.streams {
+				for k := range s.chunks {
+					s.chunks[k].flushed = time.Now().Add(-time.Minute)
+				}
+				i.removeFlushedChunks(inst, s)
+			}
+			require.Empty(t, inst.streams)
+			inst.streamsMtx.Unlock()
+
+			expected := map[string][]logproto.Entry{`{foo="bar"}`: push(10, 12)}
+			require.Equal(t, expected, queryAll(ctx, t, i))
+
+			// Simulate a crash of the ingester, without flushing nor checkpointing.
+			require.NoError(t, i.wal.(*walWrapper).wal.Close())
+
+			i, err = New(ingesterConfig, client.Config{}, store, limits, nil)
+			require.NoError(t, err)
+			require.NoError(t, services.StartAndAwaitRunning(context.Background(), i))
+			defer services.StopAndAwaitTerminated(context.Background(), i) //nolint:errcheck
+
+			require.Equal(t, expected, queryAll(ctx, t, i))
+		})
+	}
+}
+
+func queryAll(ctx context.Context, t *testing.T, i *Ingester) map[string][]logproto.Entry {
+	result := mockQuerierServer{
+		ctx: ctx,
+	}
+	err := i.Query(&logproto.QueryRequest{
+		Selector:  `{foo=~".+"}`,
+		Limit:     1000,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(2000, 0),
+		Direction: logproto.FORWARD,
+	}, &result)
+	require.NoError(t, err)
+
+	streams := map[string][]logproto.Entry{}
+	for _, resp := range result.resps {
+		for _, s := range resp.Streams {
+			streams[s.Labels] = append(streams[s.Labels], s.Entries...)
+		}
+	}
+	return streams
+}
@@ -254,14 +254,15 @@ func (i *heapIterator) Next() bool {
 	i.currEntry = t.Entry
 	i.currLabels = t.Labels()
 
-	// Requeue the iterators, advancing them if they were consumed.
+	// Requeue the iterators, advancing them if they were consumed. Entries with the same line are only
+	// duplicates if they have the same structured metadata.
 	for j := range i.tuples {
-		if i.tuples[j].Line != i.currEntry.Line {
+		if !i.tuples[j].Entry.Equal(&i.currEntry) {
 			i.requeue(i.tuples[j].EntryIterator, true)
 			continue
 		}
 		// we count as duplicates only if the tuple is not the one (t) used to fill the current entry
-		if i.tuples[j] != t {
+		if i.tuples[j].EntryIterator != t.EntryIterator {
 			i.stats.TotalDuplicates++
 		}
 		i.requeue(i.tuples[j].EntryIterator, false)
@@ -554,25 +555,24 @@ func (i *reverseIterator) Close() error {
 var entryBufferPool = sync.Pool{
 	New: func() interface{} {
 		return &entryBuffer{
-			entries: make([]logproto.Entry, 0, 1024),
+			entries: make([]entryWithLabels, 0, 1024),
 		}
 	},
 }
 
 type entryBuffer struct {
-	entries []logproto.Entry
+	entries []entryWithLabels
 }
 
 type reverseEntryIterator struct {
 	iter EntryIterator
-	cur  logproto.Entry
+	cur  entryWithLabels
 	buf  *entryBuffer
 
 	loaded bool
 }
 
 // NewEntryReversedIter returns an iterator which loads all entries and iterates backward.
-// The labels of entries is always empty.
 func NewEntryReversedIter(it EntryIterator) (EntryIterator, error) {
 	iter, err := &reverseEntryIterator{
 		iter: it,
@@ -590,7 +590,7 @@ func (i *reverseEntryIterator) load() {
 	if !i.loaded {
 		i.loaded = true
 		for i.iter.Next() {
-			i.buf.entries = append(i.buf.entries, i.iter.Entry())
+			i.buf.entries = append(i.buf.entries, entryWithLabels{i.iter.Entry(), i.iter.Labels()})
 		}
 		i.iter.Close()
 	}
@@ -608,11 +608,11 @@ func (i *reverseEntryIterator) Next() bool {
 }
 
 func (i *reverseEntryIterator) Entry() logproto.Entry {
-	return i.cur
+	return i.cur.entry
 }
 
 func (i *reverseEntryIterator) Labels() string {
-	return ""
+	return i.cur.labels
 }
 
 func (i *reverseEntryIterator) Error() error { return nil }
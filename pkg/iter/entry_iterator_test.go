@@ -3,6 +3,7 @@ package iter
 import (
 	"context"
 	"fmt"
+	"sort"
 	"testing"
 	"time"
 
@@ -292,6 +293,33 @@ func TestHeapIteratorDeduplication(t *testing.T) {
 	assertIt(it, true, len(foo.Entries))
 }
 
+func TestHeapIteratorDeduplicationStructuredMetadata(t *testing.T) {
+	entry := func(traceID string) logproto.Entry {
+		return logproto.Entry{
+			Timestamp:          time.Unix(0, 1),
+			Line:               "line",
+			StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: traceID}},
+		}
+	}
+	stream := func(entries ...logproto.Entry) logproto.Stream {
+		return logproto.Stream{Labels: `{app="foo"}`, Entries: entries}
+	}
+
+	it := NewHeapIterator(context.Background(), []EntryIterator{
+		NewStreamIterator(stream(entry("a"))),
+		NewStreamIterator(stream(entry("a"))),
+		NewStreamIterator(stream(entry("b"))),
+	}, logproto.FORWARD)
+
+	var traceIDs []string
+	for it.Next() {
+		traceIDs = append(traceIDs, it.Entry().StructuredMetadata[0].Value)
+	}
+	require.NoError(t, it.Error())
+	sort.Strings(traceIDs)
+	require.Equal(t, []string{"a", "b"}, traceIDs)
+}
+
 func mustReverseStreamIterator(it EntryIterator) EntryIterator {
 	reversed, err := NewReversedIter(it, 0, true)
 	if err != nil {
@@ -331,7 +359,7 @@ func TestReverseEntryIterator(t *testing.T) {
 	for i := int64(testSize - 1); i >= 0; i-- {
 		assert.Equal(t, true, reversedIter.Next())
 		assert.Equal(t, identity(i), reversedIter.Entry(), fmt.Sprintln("iteration", i))
-		assert.Equal(t, reversedIter.Labels(), "")
+		assert.Equal(t, reversedIter.Labels(), defaultLabels)
 	}
 
 	assert.Equal(t, false, reversedIter.Next())
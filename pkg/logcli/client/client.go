@@ -28,6 +28,10 @@ const (
 	labelValuesPath = "/loki/api/v1/label/%s/values"
 	seriesPath      = "/loki/api/v1/series"
 	tailPath        = "/loki/api/v1/tail"
+	explainPath     = "/loki/api/v1/explain"
+	indexStatsPath  = "/loki/api/v1/index/stats"
+	volumePath      = "/loki/api/v1/index/volume"
+	contextPath     = "/loki/api/v1/context"
 )
 
 var (
@@ -42,6 +46,10 @@ type Client interface {
 	ListLabelValues(name string, quiet bool, from, through time.Time) (*loghttp.LabelResponse, error)
 	Series(matchers []string, from, through time.Time, quiet bool) (*loghttp.SeriesResponse, error)
 	LiveTailQueryConn(queryStr string, delayFor int, limit int, from int64, quiet bool) (*websocket.Conn, error)
+	Explain(queryStr string, from, through time.Time, step time.Duration, quiet bool) (*loghttp.ExplainResponse, error)
+	IndexStats(queryStr string, from, through time.Time, quiet bool) (*loghttp.IndexStatsResponse, error)
+	Volume(queryStr string, label string, limit int, from, through time.Time, quiet bool) (*loghttp.VolumeResponse, error)
+	Context(stream string, anchor time.Time, before, after int, from, through time.Time, quiet bool) (*loghttp.ContextResponse, error)
 	GetOrgID() string
 }
 
@@ -130,6 +138,74 @@ func (c *DefaultClient) Series(matchers []string, from, through time.Time, quiet
 	return &seriesResponse, nil
 }
 
+// Explain uses the /loki/api/v1/explain endpoint to explain how a query is executed
+func (c *DefaultClient) Explain(queryStr string, from, through time.Time, step time.Duration, quiet bool) (*loghttp.ExplainResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("query", queryStr)
+	params.SetInt("start", from.UnixNano())
+	params.SetInt("end", through.UnixNano())
+
+	// The step is optional, so we do set it only if provided,
+	// otherwise we do leverage on the API defaults
+	if step != 0 {
+		params.SetInt("step", int64(step.Seconds()))
+	}
+
+	var explainResponse loghttp.ExplainResponse
+	if err := c.doRequest(explainPath, params.Encode(), quiet, &explainResponse); err != nil {
+		return nil, err
+	}
+	return &explainResponse, nil
+}
+
+// IndexStats uses the /loki/api/v1/index/stats endpoint to get the amount of data selected by a stream selector
+func (c *DefaultClient) IndexStats(queryStr string, from, through time.Time, quiet bool) (*loghttp.IndexStatsResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("query", queryStr)
+	params.SetInt("start", from.UnixNano())
+	params.SetInt("end", through.UnixNano())
+
+	var statsResponse loghttp.IndexStatsResponse
+	if err := c.doRequest(indexStatsPath, params.Encode(), quiet, &statsResponse); err != nil {
+		return nil, err
+	}
+	return &statsResponse, nil
+}
+
+// Volume uses the /loki/api/v1/index/volume endpoint to get the amount of data selected by a stream selector
+// grouped by the values of a label
+func (c *DefaultClient) Volume(queryStr string, label string, limit int, from, through time.Time, quiet bool) (*loghttp.VolumeResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("query", queryStr)
+	params.SetString("label", label)
+	params.SetInt("limit", int64(limit))
+	params.SetInt("start", from.UnixNano())
+	params.SetInt("end", through.UnixNano())
+
+	var volumeResponse loghttp.VolumeResponse
+	if err := c.doRequest(volumePath, params.Encode(), quiet, &volumeResponse); err != nil {
+		return nil, err
+	}
+	return &volumeResponse, nil
+}
+
+// Context uses the /loki/api/v1/context endpoint to get the lines surrounding an entry
+func (c *DefaultClient) Context(stream string, anchor time.Time, before, after int, from, through time.Time, quiet bool) (*loghttp.ContextResponse, error) {
+	params := util.NewQueryStringBuilder()
+	params.SetString("stream", stream)
+	params.SetInt("ts", anchor.UnixNano())
+	params.SetInt("before", int64(before))
+	params.SetInt("after", int64(after))
+	params.SetInt("start", from.UnixNano())
+	params.SetInt("end", through.UnixNano())
+
+	var contextResponse loghttp.ContextResponse
+	if err := c.doRequest(contextPath, params.Encode(), quiet, &contextResponse); err != nil {
+		return nil, err
+	}
+	return &contextResponse, nil
+}
+
 // LiveTailQueryConn uses /api/prom/tail to set up a websocket connection and returns it
 func (c *DefaultClient) LiveTailQueryConn(queryStr string, delayFor int, limit int, from int64, quiet bool) (*websocket.Conn, error) {
 	qsb := util.NewQueryStringBuilder()
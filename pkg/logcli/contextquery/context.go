@@ -0,0 +1,63 @@
+package contextquery
+
+import (
+	"log"
+	"sort"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/logcli/output"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// ContextQuery contains all necessary fields to get the lines surrounding an entry and print out the results
+type ContextQuery struct {
+	Stream string
+	Anchor time.Time
+	Before int
+	After  int
+	Start  time.Time
+	End    time.Time
+	Quiet  bool
+}
+
+// DoContext prints out the lines surrounding the entry, sorted by timestamp
+func (q *ContextQuery) DoContext(c client.Client, out output.LogOutput) {
+	streams := q.Context(c)
+	printEntries(streams, out)
+}
+
+// Context returns the streams of the lines surrounding the entry
+func (q *ContextQuery) Context(c client.Client) loghttp.Streams {
+	contextResponse, err := c.Context(q.Stream, q.Anchor, q.Before, q.After, q.Start, q.End, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error doing request: %+v", err)
+	}
+	return contextResponse.Data.Streams
+}
+
+type streamEntryPair struct {
+	entry  loghttp.Entry
+	labels loghttp.LabelSet
+}
+
+func printEntries(streams loghttp.Streams, out output.LogOutput) {
+	maxLabelsLen := 0
+	entries := make([]streamEntryPair, 0)
+	for _, s := range streams {
+		if l := len(s.Labels.String()); l > maxLabelsLen {
+			maxLabelsLen = l
+		}
+		for _, e := range s.Entries {
+			entries = append(entries, streamEntryPair{entry: e, labels: s.Labels})
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].entry.Timestamp.Before(entries[j].entry.Timestamp)
+	})
+
+	for _, e := range entries {
+		out.FormatAndPrintln(e.entry.Timestamp, e.labels, maxLabelsLen, e.entry.Line)
+	}
+}
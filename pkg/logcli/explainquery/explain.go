@@ -0,0 +1,58 @@
+package explainquery
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"time"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// ExplainQuery contains all necessary fields to explain a query and print out the results
+type ExplainQuery struct {
+	QueryString string
+	Start       time.Time
+	End         time.Time
+	Step        time.Duration
+	Quiet       bool
+}
+
+// DoExplain prints out how a query is executed
+func (q *ExplainQuery) DoExplain(c client.Client) {
+	explanation := q.Explain(c)
+	printExplanation(explanation, os.Stdout)
+}
+
+// Explain returns the explanation of the query
+func (q *ExplainQuery) Explain(c client.Client) loghttp.Explanation {
+	explainResponse, err := c.Explain(q.QueryString, q.Start, q.End, q.Step, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error doing request: %+v", err)
+	}
+	return explainResponse.Data
+}
+
+func printExplanation(e loghttp.Explanation, w io.Writer) {
+	fmt.Fprintf(w, "Query: %s\n", e.Query)
+	if len(e.Filters) > 0 {
+		fmt.Fprintln(w, "Line filters:")
+		for _, f := range e.Filters {
+			fmt.Fprintf(w, "  %s => %s\n", f.Expr, f.Simplified)
+		}
+	}
+	fmt.Fprintf(w, "Splits: %d\n", len(e.Splits))
+	for _, s := range e.Splits {
+		fmt.Fprintf(w, "  %s - %s", s.Start.Format(time.RFC3339Nano), s.End.Format(time.RFC3339Nano))
+		if s.Cache != "" {
+			fmt.Fprintf(w, " cache=%s", s.Cache)
+		}
+		if s.Shards > 0 {
+			fmt.Fprintf(w, " shards=%d\n    %s\n", s.Shards, s.Mapped)
+			continue
+		}
+		fmt.Fprintf(w, "\n    %s\n", s.Query)
+	}
+}
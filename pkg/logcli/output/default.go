@@ -11,6 +11,8 @@ import (
 	"github.com/grafana/loki/pkg/loghttp"
 )
 
+var highlightColor = color.New(color.Bold, color.FgHiYellow)
+
 // DefaultOutput provides logs and metadata in human readable format
 type DefaultOutput struct {
 	w       io.Writer
@@ -21,6 +23,9 @@ type DefaultOutput struct {
 func (o *DefaultOutput) FormatAndPrintln(ts time.Time, lbls loghttp.LabelSet, maxLabelsLen int, line string) {
 	timestamp := ts.In(o.options.Timezone).Format(time.RFC3339)
 	line = strings.TrimSpace(line)
+	if !o.options.Highlight.IsZero() && ts.Equal(o.options.Highlight) {
+		line = highlightColor.Sprint(line)
+	}
 
 	if o.options.NoLabels {
 		fmt.Fprintf(o.w, "%s %s\n", color.BlueString(timestamp), line)
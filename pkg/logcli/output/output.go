@@ -36,6 +36,8 @@ type LogOutputOptions struct {
 	Timezone      *time.Location
 	NoLabels      bool
 	ColoredOutput bool
+	// Highlight is the timestamp of the entries to highlight, if not zero.
+	Highlight time.Time
 }
 
 // NewLogOutput creates a log output based on the input mode and options
@@ -8,7 +8,7 @@ import (
 )
 
 func TestNewLogOutput(t *testing.T) {
-	options := &LogOutputOptions{time.UTC, false, false}
+	options := &LogOutputOptions{time.UTC, false, false, time.Time{}}
 
 	out, err := NewLogOutput(nil,"default", options)
 	assert.NoError(t, err)
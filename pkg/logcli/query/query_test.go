@@ -11,6 +11,7 @@ import (
 
 	"github.com/gorilla/websocket"
 	"github.com/stretchr/testify/assert"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logcli/output"
 	"github.com/grafana/loki/pkg/loghttp"
@@ -502,7 +503,7 @@ type testQueryClient struct {
 
 func newTestQueryClient(testStreams ...logproto.Stream) *testQueryClient {
 	q := logql.NewMockQuerier(0, testStreams)
-	e := logql.NewEngine(logql.EngineOpts{}, q)
+	e := logql.NewEngine(logql.EngineOpts{}, q, logql.NoLimits)
 	return &testQueryClient{
 		engine:          e,
 		queryRangeCalls: 0,
@@ -517,7 +518,7 @@ func (t *testQueryClient) QueryRange(queryStr string, limit int, from, through t
 
 	params := logql.NewLiteralParams(queryStr, from, through, step, interval, direction, uint32(limit), nil)
 
-	v, err := t.engine.Query(params).Exec(context.Background())
+	v, err := t.engine.Query(params).Exec(user.InjectOrgID(context.Background(), "fake"))
 	if err != nil {
 		return nil, err
 	}
@@ -555,6 +556,22 @@ func (t *testQueryClient) LiveTailQueryConn(queryStr string, delayFor int, limit
 	panic("implement me")
 }
 
+func (t *testQueryClient) Explain(queryStr string, from, through time.Time, step time.Duration, quiet bool) (*loghttp.ExplainResponse, error) {
+	panic("implement me")
+}
+
+func (t *testQueryClient) IndexStats(queryStr string, from, through time.Time, quiet bool) (*loghttp.IndexStatsResponse, error) {
+	panic("implement me")
+}
+
+func (t *testQueryClient) Volume(queryStr string, label string, limit int, from, through time.Time, quiet bool) (*loghttp.VolumeResponse, error) {
+	panic("implement me")
+}
+
+func (t *testQueryClient) Context(stream string, anchor time.Time, before, after int, from, through time.Time, quiet bool) (*loghttp.ContextResponse, error) {
+	panic("implement me")
+}
+
 func (t *testQueryClient) GetOrgID() string {
 	panic("implement me")
 }
@@ -0,0 +1,79 @@
+package statsquery
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/dustin/go-humanize"
+
+	"github.com/grafana/loki/pkg/logcli/client"
+	"github.com/grafana/loki/pkg/loghttp"
+)
+
+// StatsQuery contains all necessary fields to get the index stats of a stream selector and print out the results
+type StatsQuery struct {
+	QueryString string
+	Start       time.Time
+	End         time.Time
+	Quiet       bool
+}
+
+// DoStats prints out the index stats of the stream selector
+func (q *StatsQuery) DoStats(c client.Client) {
+	stats := q.Stats(c)
+	printStats(stats, os.Stdout)
+}
+
+// Stats returns the index stats of the stream selector
+func (q *StatsQuery) Stats(c client.Client) loghttp.IndexStats {
+	statsResponse, err := c.IndexStats(q.QueryString, q.Start, q.End, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error doing request: %+v", err)
+	}
+	return statsResponse.Data
+}
+
+// VolumeQuery contains all necessary fields to get the volumes of a label and print out the results
+type VolumeQuery struct {
+	QueryString string
+	Label       string
+	Limit       int
+	Start       time.Time
+	End         time.Time
+	Quiet       bool
+}
+
+// DoVolume prints out the volumes of the label
+func (q *VolumeQuery) DoVolume(c client.Client) {
+	volumes := q.Volume(c)
+	printVolumes(volumes, os.Stdout)
+}
+
+// Volume returns the volumes of the label
+func (q *VolumeQuery) Volume(c client.Client) loghttp.VolumeData {
+	volumeResponse, err := c.Volume(q.QueryString, q.Label, q.Limit, q.Start, q.End, q.Quiet)
+	if err != nil {
+		log.Fatalf("Error doing request: %+v", err)
+	}
+	return volumeResponse.Data
+}
+
+func printStats(s loghttp.IndexStats, w io.Writer) {
+	fmt.Fprintf(w, "Streams: %d\n", s.Streams)
+	fmt.Fprintf(w, "Chunks: %d\n", s.Chunks)
+	fmt.Fprintf(w, "Bytes: %s\n", humanize.Bytes(s.Bytes))
+	fmt.Fprintf(w, "Entries: %d\n", s.Entries)
+}
+
+func printVolumes(v loghttp.VolumeData, w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tSTREAMS\tCHUNKS\tBYTES\tENTRIES\n", v.Label)
+	for _, vol := range v.Volumes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%d\n", vol.Value, vol.Streams, vol.Chunks, humanize.Bytes(vol.Bytes), vol.Entries)
+	}
+	tw.Flush()
+}
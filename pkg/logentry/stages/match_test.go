@@ -122,6 +122,10 @@ func TestMatcher(t *testing.T) {
 		{`{foo="bar"} !~ "bar"`, map[string]string{"foo": "bar"}, MatchActionDrop, true, false, false},
 		{`{foo="bar"} != "foo"`, map[string]string{"foo": "bar"}, MatchActionDrop, false, false, false},
 		{`{foo="bar"} !~ "[]"`, map[string]string{"foo": "bar"}, MatchActionDrop, false, false, true},
+		{`{foo="bar"} |= ip("10.0.0.0/8")`, map[string]string{"foo": "bar"}, MatchActionKeep, false, false, false},
+		{`{foo="bar"} != ip("10.0.0.0/8")`, map[string]string{"foo": "bar"}, MatchActionKeep, false, true, false},
+		{`{foo="bar"} != ip("10.0.0.0/8")`, map[string]string{"foo": "bar"}, MatchActionDrop, true, false, false},
+		{`{foo="bar"} |= ip("foo")`, map[string]string{"foo": "bar"}, MatchActionKeep, false, false, true},
 		{"foo", map[string]string{"foo": "bar"}, MatchActionKeep, false, false, true},
 		{"{}", map[string]string{"foo": "bar"}, MatchActionKeep, false, false, true},
 		{"{", map[string]string{"foo": "bar"}, MatchActionKeep, false, false, true},
@@ -0,0 +1,130 @@
+package stages
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// Config Errors
+const (
+	ErrCouldNotCompilePattern  = "could not compile pattern"
+	ErrEmptyPatternStageConfig = "empty pattern stage configuration"
+)
+
+// PatternConfig contains a patternStage configuration
+type PatternConfig struct {
+	Expression string  `mapstructure:"expression"`
+	Source     *string `mapstructure:"source"`
+}
+
+// validatePatternConfig validates the config and return a pattern parser
+func validatePatternConfig(c *PatternConfig) (*logql.PatternParser, error) {
+	if c == nil {
+		return nil, errors.New(ErrEmptyPatternStageConfig)
+	}
+
+	if c.Expression == "" {
+		return nil, errors.New(ErrExpressionRequired)
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, errors.New(ErrEmptyRegexStageSource)
+	}
+
+	parser, err := logql.NewPatternParser(c.Expression)
+	if err != nil {
+		return nil, errors.Wrap(err, ErrCouldNotCompilePattern)
+	}
+	return parser, nil
+}
+
+// patternStage sets extracted data using the same patterns as the LogQL pattern parser.
+type patternStage struct {
+	cfg    *PatternConfig
+	parser *logql.PatternParser
+	logger log.Logger
+}
+
+// newPatternStage creates a newPatternStage
+func newPatternStage(logger log.Logger, config interface{}) (Stage, error) {
+	cfg, err := parsePatternConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	parser, err := validatePatternConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &patternStage{
+		cfg:    cfg,
+		parser: parser,
+		logger: log.With(logger, "component", "stage", "type", "pattern"),
+	}, nil
+}
+
+// parsePatternConfig processes an incoming configuration into a PatternConfig
+func parsePatternConfig(config interface{}) (*PatternConfig, error) {
+	cfg := &PatternConfig{}
+	err := mapstructure.Decode(config, cfg)
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Process implements Stage
+func (p *patternStage) Process(labels model.LabelSet, extracted map[string]interface{}, t *time.Time, entry *string) {
+	// If a source key is provided, the pattern stage should process it
+	// from the extracted map, otherwise should fallback to the entry
+	input := entry
+
+	if p.cfg.Source != nil {
+		if _, ok := extracted[*p.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(p.logger).Log("msg", "source does not exist in the set of extracted values", "source", *p.cfg.Source)
+			}
+			return
+		}
+
+		value, err := getString(extracted[*p.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(p.logger).Log("msg", "failed to convert source value to string", "source", *p.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*p.cfg.Source]))
+			}
+			return
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(p.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return
+	}
+
+	matches := p.parser.Matches([]byte(*input))
+	if matches == nil {
+		if Debug {
+			level.Debug(p.logger).Log("msg", "pattern did not match", "input", *input, "pattern", p.cfg.Expression)
+		}
+		return
+	}
+	for i, name := range p.parser.Names() {
+		extracted[name] = string(matches[i])
+	}
+}
+
+// Name implements Stage
+func (p *patternStage) Name() string {
+	return StageTypePattern
+}
@@ -0,0 +1,150 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPatternYamlSingleStageWithoutSource = `
+pipeline_stages:
+- pattern:
+    expression: "<ip> <_> <user> [<timestamp>] \"<action> <path> <protocol>\" <status> <size> \"<referer>\" \"<useragent>\""
+`
+
+var testPatternYamlMultiStageWithSource = `
+pipeline_stages:
+- pattern:
+    expression: "<ip> <_> <user> [<_>] \"<_> <_> <protocol>\" <_>"
+- pattern:
+    expression: "HTTP/<protocol_version>"
+    source:     "protocol"
+`
+
+func TestPipeline_Pattern(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]interface{}
+	}{
+		"successfully run a pipeline with 1 pattern stage without source": {
+			testPatternYamlSingleStageWithoutSource,
+			testRegexLogLine,
+			map[string]interface{}{
+				"ip":        "11.11.11.11",
+				"user":      "frank",
+				"timestamp": "25/Jan/2000:14:00:01 -0500",
+				"action":    "GET",
+				"path":      "/1986.js",
+				"protocol":  "HTTP/1.1",
+				"status":    "200",
+				"size":      "932",
+				"referer":   "-",
+				"useragent": "Mozilla/5.0 (Windows; U; Windows NT 5.1; de; rv:1.9.1.7) Gecko/20091221 Firefox/3.5.7 GTB6",
+			},
+		},
+		"successfully run a pipeline with 2 pattern stages with source": {
+			testPatternYamlMultiStageWithSource,
+			testRegexLogLine,
+			map[string]interface{}{
+				"ip":               "11.11.11.11",
+				"user":             "frank",
+				"protocol":         "HTTP/1.1",
+				"protocol_version": "1.1",
+			},
+		},
+		"pattern not matching": {
+			testPatternYamlSingleStageWithoutSource,
+			"level=info msg=hello",
+			map[string]interface{}{},
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(util.Logger, loadConfig(testData.config), nil, prometheus.DefaultRegisterer)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			lbls := model.LabelSet{}
+			ts := time.Now()
+			entry := testData.entry
+			extracted := map[string]interface{}{}
+			pl.Process(lbls, extracted, &ts, &entry)
+			assert.Equal(t, testData.expectedExtract, extracted)
+		})
+	}
+}
+
+func TestPatternConfig_validate(t *testing.T) {
+	t.Parallel()
+	tests := map[string]struct {
+		config interface{}
+		err    error
+	}{
+		"empty config": {
+			nil,
+			errors.New(ErrExpressionRequired),
+		},
+		"missing expression": {
+			map[string]interface{}{},
+			errors.New(ErrExpressionRequired),
+		},
+		"invalid expression": {
+			map[string]interface{}{
+				"expression": "<method><path>",
+			},
+			errors.New(ErrCouldNotCompilePattern + ": consecutive captures in pattern must be separated by a literal"),
+		},
+		"empty source": {
+			map[string]interface{}{
+				"expression": "<method> <path>",
+				"source":     "",
+			},
+			errors.New(ErrEmptyRegexStageSource),
+		},
+		"valid without source": {
+			map[string]interface{}{
+				"expression": "<method> <path>",
+			},
+			nil,
+		},
+		"valid with source": {
+			map[string]interface{}{
+				"expression": "<method> <path>",
+				"source":     "log",
+			},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		tt := tt
+		t.Run(tName, func(t *testing.T) {
+			c, err := parsePatternConfig(tt.config)
+			if err != nil {
+				t.Fatalf("failed to create config: %s", err)
+			}
+			_, err = validatePatternConfig(c)
+			if (err != nil) != (tt.err != nil) {
+				t.Errorf("PatternConfig.validate() expected error = %v, actual error = %v", tt.err, err)
+				return
+			}
+			if (err != nil) && (err.Error() != tt.err.Error()) {
+				t.Errorf("PatternConfig.validate() expected error = %v, actual error = %v", tt.err, err)
+				return
+			}
+		})
+	}
+}
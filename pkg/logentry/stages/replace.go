@@ -12,6 +12,8 @@ import (
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logql"
 )
 
 // Config Errors
@@ -131,7 +133,7 @@ func (r *replaceStage) Process(labels model.LabelSet, extracted map[string]inter
 	td := r.getTemplateData(extracted)
 
 	// Initialize the template with the "replace" string defined by user
-	templ, err := template.New("pipeline_template").Funcs(functionMap).Parse(r.cfg.Replace)
+	templ, err := template.New("pipeline_template").Funcs(logql.TemplateFunctionMap).Parse(r.cfg.Replace)
 	if err != nil {
 		if Debug {
 			level.Debug(r.logger).Log("msg", "template initialization error", "err", err)
@@ -12,6 +12,7 @@ import (
 const (
 	StageTypeJSON      = "json"
 	StageTypeRegex     = "regex"
+	StageTypePattern   = "pattern"
 	StageTypeReplace   = "replace"
 	StageTypeMetric    = "metrics"
 	StageTypeLabel     = "labels"
@@ -66,6 +67,11 @@ func New(logger log.Logger, jobName *string, stageType string,
 		if err != nil {
 			return nil, err
 		}
+	case StageTypePattern:
+		s, err = newPatternStage(logger, cfg)
+		if err != nil {
+			return nil, err
+		}
 	case StageTypeMetric:
 		s, err = newMetricStage(logger, cfg, registerer)
 		if err != nil {
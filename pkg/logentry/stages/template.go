@@ -2,12 +2,8 @@ package stages
 
 import (
 	"bytes"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
 	"reflect"
-	"regexp"
-	"strings"
 	"text/template"
 	"time"
 
@@ -15,6 +11,8 @@ import (
 	"github.com/go-kit/kit/log/level"
 	"github.com/mitchellh/mapstructure"
 	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/pkg/logql"
 )
 
 // Config Errors
@@ -23,32 +21,6 @@ const (
 	ErrTemplateSourceRequired   = "template source value is required"
 )
 
-var (
-	functionMap = template.FuncMap{
-		"ToLower":    strings.ToLower,
-		"ToUpper":    strings.ToUpper,
-		"Replace":    strings.Replace,
-		"Trim":       strings.Trim,
-		"TrimLeft":   strings.TrimLeft,
-		"TrimRight":  strings.TrimRight,
-		"TrimPrefix": strings.TrimPrefix,
-		"TrimSuffix": strings.TrimSuffix,
-		"TrimSpace":  strings.TrimSpace,
-		"Sha256": func(salt string, s string) string {
-			hash := sha256.Sum256([]byte(salt + s))
-			return hex.EncodeToString(hash[:])
-		},
-		"regexReplaceAll": func(regex string, s string, repl string) string {
-			r := regexp.MustCompile(regex)
-			return r.ReplaceAllString(s, repl)
-		},
-		"regexReplaceAllLiteral": func(regex string, s string, repl string) string {
-			r := regexp.MustCompile(regex)
-			return r.ReplaceAllLiteralString(s, repl)
-		},
-	}
-)
-
 // TemplateConfig configures template value extraction
 type TemplateConfig struct {
 	Source   string `mapstructure:"source"`
@@ -64,7 +36,7 @@ func validateTemplateConfig(cfg *TemplateConfig) (*template.Template, error) {
 		return nil, errors.New(ErrTemplateSourceRequired)
 	}
 
-	return template.New("pipeline_template").Funcs(functionMap).Parse(cfg.Template)
+	return template.New("pipeline_template").Funcs(logql.TemplateFunctionMap).Parse(cfg.Template)
 }
 
 // newTemplateStage creates a new templateStage
@@ -0,0 +1,89 @@
+package loghttp
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/grafana/loki/pkg/logql"
+)
+
+const (
+	defaultContextLines = 10
+	// defaultContextWindow is the time range looked up before and after the anchor entry by default.
+	defaultContextWindow = time.Hour
+)
+
+var errAnchorOutOfRange = errors.New("ts must be within the start and end timestamps")
+
+// ContextResponse represents the http json response to a context query.
+type ContextResponse struct {
+	Status string      `json:"status"`
+	Data   ContextData `json:"data"`
+}
+
+// ContextData are the entries surrounding an anchor entry, sorted by timestamp.
+type ContextData struct {
+	Anchor  time.Time `json:"anchor"`
+	Streams Streams   `json:"streams"`
+}
+
+// ContextQuery represents a context query, the lines before and after an anchor entry.
+type ContextQuery struct {
+	Stream string
+	Anchor time.Time
+	Before uint32
+	After  uint32
+	// Start and End bound the time range looked up around the anchor entry.
+	Start time.Time
+	End   time.Time
+}
+
+// ParseContextQuery parses a ContextQuery request from an http request.
+func ParseContextQuery(r *http.Request) (*ContextQuery, error) {
+	var err error
+	request := &ContextQuery{
+		Stream: r.Form.Get("stream"),
+	}
+
+	if _, err = logql.ParseMatchers(request.Stream); err != nil {
+		return nil, err
+	}
+
+	request.Anchor, err = parseTimestamp(r.Form.Get("ts"), time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Before, err = contextLines(r.Form.Get("before")); err != nil {
+		return nil, err
+	}
+	if request.After, err = contextLines(r.Form.Get("after")); err != nil {
+		return nil, err
+	}
+
+	request.Start, err = parseTimestamp(r.Form.Get("start"), request.Anchor.Add(-defaultContextWindow))
+	if err != nil {
+		return nil, err
+	}
+	request.End, err = parseTimestamp(r.Form.Get("end"), request.Anchor.Add(defaultContextWindow))
+	if err != nil {
+		return nil, err
+	}
+	if request.Anchor.Before(request.Start) || request.Anchor.After(request.End) {
+		return nil, errAnchorOutOfRange
+	}
+
+	return request, nil
+}
+
+func contextLines(value string) (uint32, error) {
+	l, err := parseInt(value, defaultContextLines)
+	if err != nil {
+		return 0, err
+	}
+	if l < 0 {
+		return 0, errors.New("before and after must not be negative")
+	}
+	return uint32(l), nil
+}
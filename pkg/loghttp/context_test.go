@@ -0,0 +1,68 @@
+package loghttp
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseContextQuery(t *testing.T) {
+	t.Parallel()
+
+	anchor := time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC)
+
+	tests := []struct {
+		name    string
+		r       *http.Request
+		want    *ContextQuery
+		wantErr bool
+	}{
+		{"bad stream", &http.Request{URL: mustParseURL(`?stream={foo="bar"} |= "baz"&ts=2017-06-10T21:42:24.760738998Z`)}, nil, true},
+		{"bad time", &http.Request{URL: mustParseURL(`?stream={foo="bar"}&ts=t`)}, nil, true},
+		{"bad before", &http.Request{URL: mustParseURL(`?stream={foo="bar"}&ts=2017-06-10T21:42:24.760738998Z&before=h`)}, nil, true},
+		{"negative after", &http.Request{URL: mustParseURL(`?stream={foo="bar"}&ts=2017-06-10T21:42:24.760738998Z&after=-1`)}, nil, true},
+		{"anchor out of range",
+			&http.Request{
+				URL: mustParseURL(`?stream={foo="bar"}&ts=2017-06-10T21:42:24.760738998Z&end=2017-06-10T20:00:00Z`),
+			}, nil, true},
+		{"defaults",
+			&http.Request{
+				URL: mustParseURL(`?stream={foo="bar"}&ts=2017-06-10T21:42:24.760738998Z`),
+			}, &ContextQuery{
+				Stream: `{foo="bar"}`,
+				Anchor: anchor,
+				Before: 10,
+				After:  10,
+				Start:  anchor.Add(-time.Hour),
+				End:    anchor.Add(time.Hour),
+			}, false},
+		{"good",
+			&http.Request{
+				URL: mustParseURL(`?stream={foo="bar"}&ts=2017-06-10T21:42:24.760738998Z&before=50&after=0&start=2017-06-10T21:00:00Z&end=2017-06-10T22:00:00Z`),
+			}, &ContextQuery{
+				Stream: `{foo="bar"}`,
+				Anchor: anchor,
+				Before: 50,
+				After:  0,
+				Start:  time.Date(2017, 06, 10, 21, 0, 0, 0, time.UTC),
+				End:    time.Date(2017, 06, 10, 22, 0, 0, 0, time.UTC),
+			}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.ParseForm()
+			require.Nil(t, err)
+			got, err := ParseContextQuery(tt.r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseContextQuery() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseContextQuery() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
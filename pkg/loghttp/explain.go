@@ -0,0 +1,44 @@
+package loghttp
+
+import (
+	"time"
+
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// Cache statuses of a split of an explained query.
+const (
+	CacheStatusHit      = "hit"
+	CacheStatusPartial  = "partial"
+	CacheStatusMiss     = "miss"
+	CacheStatusTooFresh = "too_fresh"
+)
+
+// ExplainResponse represents the http json response to an explain query.
+type ExplainResponse struct {
+	Status string      `json:"status"`
+	Data   Explanation `json:"data"`
+}
+
+// Explanation describes how a query would be executed.
+type Explanation struct {
+	// Query is the normalized query.
+	Query string `json:"query"`
+	// Filters are the line filters of the query and their simplified form.
+	Filters []logql.LineFilterExplanation `json:"filters,omitempty"`
+	// Splits are the sub-queries executed by the query frontend, or the query itself when it is not split.
+	Splits []Split `json:"splits"`
+}
+
+// Split is a time based sub-query.
+type Split struct {
+	Query string    `json:"query"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Shards is the amount of shards used to execute the split, 0 when it is not sharded.
+	Shards int `json:"shards"`
+	// Mapped is the sharded form of the query.
+	Mapped string `json:"mapped,omitempty"`
+	// Cache is the status of the split in the results cache, empty when the results cache is not used.
+	Cache string `json:"cache,omitempty"`
+}
@@ -0,0 +1,95 @@
+package loghttp
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// IndexStatsResponse represents the http json response to an index stats query.
+type IndexStatsResponse struct {
+	Status string     `json:"status"`
+	Data   IndexStats `json:"data"`
+}
+
+// IndexStats are the amount of streams, chunks, bytes and entries selected by a stream selector.
+type IndexStats struct {
+	Streams uint64 `json:"streams"`
+	Chunks  uint64 `json:"chunks"`
+	Bytes   uint64 `json:"bytes"`
+	Entries uint64 `json:"entries"`
+}
+
+// VolumeResponse represents the http json response to a volume query.
+type VolumeResponse struct {
+	Status string     `json:"status"`
+	Data   VolumeData `json:"data"`
+}
+
+// VolumeData are the volumes of a label, sorted by decreasing bytes.
+type VolumeData struct {
+	Label   string   `json:"label"`
+	Volumes []Volume `json:"volumes"`
+}
+
+// Volume are the index stats of the streams having the same value for a label.
+type Volume struct {
+	Value   string `json:"value"`
+	Streams uint64 `json:"streams"`
+	Chunks  uint64 `json:"chunks"`
+	Bytes   uint64 `json:"bytes"`
+	Entries uint64 `json:"entries"`
+}
+
+// VolumeQuery represents a volume query.
+type VolumeQuery struct {
+	logproto.StreamStatsRequest
+	Label string
+	Limit uint32
+}
+
+// ParseIndexStatsQuery parses an index stats request from an http request.
+func ParseIndexStatsQuery(r *http.Request) (*logproto.StreamStatsRequest, error) {
+	start, end, err := bounds(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// ensure the selector is valid before fanning out to ingesters/store.
+	selector := query(r)
+	if _, err := logql.ParseMatchers(selector); err != nil {
+		return nil, err
+	}
+
+	return &logproto.StreamStatsRequest{
+		Start:    start,
+		End:      end,
+		Matchers: selector,
+	}, nil
+}
+
+// ParseVolumeQuery parses a VolumeQuery request from an http request.
+func ParseVolumeQuery(r *http.Request) (*VolumeQuery, error) {
+	req, err := ParseIndexStatsQuery(r)
+	if err != nil {
+		return nil, err
+	}
+
+	label := r.Form.Get("label")
+	if label == "" {
+		return nil, errors.New("label must be set")
+	}
+
+	l, err := limit(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &VolumeQuery{
+		StreamStatsRequest: *req,
+		Label:              label,
+		Limit:              l,
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 	"unsafe"
@@ -98,10 +99,12 @@ type Stream struct {
 	Entries []Entry  `json:"values"`
 }
 
-//Entry represents a log entry.  It includes a log message and the time it occurred at.
+//Entry represents a log entry.  It includes a log message, the time it occurred at and its structured metadata.
+// It must keep the same layout as logproto.Entry, see Streams.ToProto.
 type Entry struct {
-	Timestamp time.Time
-	Line      string
+	Timestamp          time.Time
+	Line               string
+	StructuredMetadata []logproto.LabelPair
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -153,30 +156,65 @@ func (q *QueryResponseData) UnmarshalJSON(data []byte) error {
 }
 
 // MarshalJSON implements the json.Marshaler interface.
+// The structured metadata, if any, are written as an object following the line.
 func (e *Entry) MarshalJSON() ([]byte, error) {
 	l, err := json.Marshal(e.Line)
 	if err != nil {
 		return nil, err
 	}
-	return []byte(fmt.Sprintf("[\"%d\",%s]", e.Timestamp.UnixNano(), l)), nil
+	if len(e.StructuredMetadata) == 0 {
+		return []byte(fmt.Sprintf("[\"%d\",%s]", e.Timestamp.UnixNano(), l)), nil
+	}
+	metadata := make(map[string]string, len(e.StructuredMetadata))
+	for _, m := range e.StructuredMetadata {
+		metadata[m.Name] = m.Value
+	}
+	m, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(fmt.Sprintf("[\"%d\",%s,%s]", e.Timestamp.UnixNano(), l, m)), nil
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (e *Entry) UnmarshalJSON(data []byte) error {
-	var unmarshal []string
+	var unmarshal []json.RawMessage
 
 	err := json.Unmarshal(data, &unmarshal)
 	if err != nil {
 		return err
 	}
+	if len(unmarshal) != 2 && len(unmarshal) != 3 {
+		return fmt.Errorf("an entry must have a timestamp, a line and optional structured metadata, got %d values", len(unmarshal))
+	}
 
-	t, err := strconv.ParseInt(unmarshal[0], 10, 64)
+	var ts string
+	if err := json.Unmarshal(unmarshal[0], &ts); err != nil {
+		return err
+	}
+	t, err := strconv.ParseInt(ts, 10, 64)
 	if err != nil {
 		return err
 	}
 
 	e.Timestamp = time.Unix(0, t)
-	e.Line = unmarshal[1]
+	if err := json.Unmarshal(unmarshal[1], &e.Line); err != nil {
+		return err
+	}
+
+	e.StructuredMetadata = nil
+	if len(unmarshal) == 3 {
+		var metadata map[string]string
+		if err := json.Unmarshal(unmarshal[2], &metadata); err != nil {
+			return err
+		}
+		for name, value := range metadata {
+			e.StructuredMetadata = append(e.StructuredMetadata, logproto.LabelPair{Name: name, Value: value})
+		}
+		sort.Slice(e.StructuredMetadata, func(i, j int) bool {
+			return e.StructuredMetadata[i].Name < e.StructuredMetadata[j].Name
+		})
+	}
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package loghttp
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+// NDJSONContentType is the content type of streamed query responses, a stream of newline delimited
+// json records: one Stream record per entry, in the order they are produced by the query, followed by
+// a trailing StreamedStats record, or a StreamedError record when the query fails after the first entry.
+const NDJSONContentType = "application/x-ndjson"
+
+// StreamedStats is the trailing record of a successful streamed query response.
+type StreamedStats struct {
+	Statistics stats.Result `json:"stats"`
+}
+
+// StreamedError is the trailing record of a failed streamed query response.
+type StreamedError struct {
+	Error string `json:"error"`
+}
+
+// AcceptsJSON returns true if the client accepts a json response, which it does when it doesn't set the Accept header.
+func AcceptsJSON(r *http.Request) bool {
+	header := r.Header.Get("Accept")
+	if header == "" {
+		return true
+	}
+	for _, accept := range strings.Split(header, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && (mediaType == "application/json" || mediaType == "application/*" || mediaType == "*/*") {
+			return true
+		}
+	}
+	return false
+}
+
+// AcceptsNDJSON returns true if the client asks for a streamed response.
+func AcceptsNDJSON(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == NDJSONContentType {
+			return true
+		}
+	}
+	return false
+}
@@ -17,3 +17,15 @@ type Streams []Stream
 func (xs Streams) Len() int           { return len(xs) }
 func (xs Streams) Swap(i, j int)      { xs[i], xs[j] = xs[j], xs[i] }
 func (xs Streams) Less(i, j int) bool { return xs[i].Labels <= xs[j].Labels }
+
+// StructuredMetadataLabels returns the structured metadata of an entry as labels, nil if it has none.
+func StructuredMetadataLabels(metadata []LabelPair) labels.Labels {
+	if len(metadata) == 0 {
+		return nil
+	}
+	lbs := make(labels.Labels, 0, len(metadata))
+	for _, m := range metadata {
+		lbs = append(lbs, labels.Label{Name: m.Name, Value: m.Value})
+	}
+	return lbs
+}
@@ -510,8 +510,9 @@ func (m *StreamAdapter) GetEntries() []EntryAdapter {
 }
 
 type EntryAdapter struct {
-	Timestamp time.Time `protobuf:"bytes,1,opt,name=timestamp,proto3,stdtime" json:"ts"`
-	Line      string    `protobuf:"bytes,2,opt,name=line,proto3" json:"line"`
+	Timestamp          time.Time   `protobuf:"bytes,1,opt,name=timestamp,proto3,stdtime" json:"ts"`
+	Line               string      `protobuf:"bytes,2,opt,name=line,proto3" json:"line"`
+	StructuredMetadata []LabelPair `protobuf:"bytes,3,rep,name=structuredMetadata,proto3" json:"structuredMetadata,omitempty"`
 }
 
 func (m *EntryAdapter) Reset()      { *m = EntryAdapter{} }
@@ -560,6 +561,13 @@ func (m *EntryAdapter) GetLine() string {
 	return ""
 }
 
+func (m *EntryAdapter) GetStructuredMetadata() []LabelPair {
+	if m != nil {
+		return m.StructuredMetadata
+	}
+	return nil
+}
+
 type Sample struct {
 	Timestamp int64   `protobuf:"varint,1,opt,name=timestamp,proto3" json:"ts"`
 	Value     float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value"`
@@ -926,6 +934,184 @@ func (m *SeriesIdentifier) GetLabels() map[string]string {
 	return nil
 }
 
+type StreamStatsRequest struct {
+	Start    time.Time `protobuf:"bytes,1,opt,name=start,proto3,stdtime" json:"start"`
+	End      time.Time `protobuf:"bytes,2,opt,name=end,proto3,stdtime" json:"end"`
+	Matchers string    `protobuf:"bytes,3,opt,name=matchers,proto3" json:"matchers,omitempty"`
+}
+
+func (m *StreamStatsRequest) Reset()      { *m = StreamStatsRequest{} }
+func (*StreamStatsRequest) ProtoMessage() {}
+func (*StreamStatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
+}
+func (m *StreamStatsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamStatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamStatsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamStatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamStatsRequest.Merge(m, src)
+}
+func (m *StreamStatsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamStatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamStatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamStatsRequest proto.InternalMessageInfo
+
+func (m *StreamStatsRequest) GetStart() time.Time {
+	if m != nil {
+		return m.Start
+	}
+	return time.Time{}
+}
+
+func (m *StreamStatsRequest) GetEnd() time.Time {
+	if m != nil {
+		return m.End
+	}
+	return time.Time{}
+}
+
+func (m *StreamStatsRequest) GetMatchers() string {
+	if m != nil {
+		return m.Matchers
+	}
+	return ""
+}
+
+type StreamStatsResponse struct {
+	Streams []StreamStats `protobuf:"bytes,1,rep,name=streams,proto3" json:"streams"`
+}
+
+func (m *StreamStatsResponse) Reset()      { *m = StreamStatsResponse{} }
+func (*StreamStatsResponse) ProtoMessage() {}
+func (*StreamStatsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
+}
+func (m *StreamStatsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamStatsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamStatsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamStatsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamStatsResponse.Merge(m, src)
+}
+func (m *StreamStatsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamStatsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamStatsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamStatsResponse proto.InternalMessageInfo
+
+func (m *StreamStatsResponse) GetStreams() []StreamStats {
+	if m != nil {
+		return m.Streams
+	}
+	return nil
+}
+
+// StreamStats are the stats of the chunks of a stream not yet flushed to the store.
+type StreamStats struct {
+	Labels      string `protobuf:"bytes,1,opt,name=labels,proto3" json:"labels,omitempty"`
+	Fingerprint uint64 `protobuf:"varint,2,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`
+	Chunks      uint64 `protobuf:"varint,3,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	Bytes       uint64 `protobuf:"varint,4,opt,name=bytes,proto3" json:"bytes,omitempty"`
+	Entries     uint64 `protobuf:"varint,5,opt,name=entries,proto3" json:"entries,omitempty"`
+}
+
+func (m *StreamStats) Reset()      { *m = StreamStats{} }
+func (*StreamStats) ProtoMessage() {}
+func (*StreamStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
+}
+func (m *StreamStats) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *StreamStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_StreamStats.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *StreamStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamStats.Merge(m, src)
+}
+func (m *StreamStats) XXX_Size() int {
+	return m.Size()
+}
+func (m *StreamStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamStats.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamStats proto.InternalMessageInfo
+
+func (m *StreamStats) GetLabels() string {
+	if m != nil {
+		return m.Labels
+	}
+	return ""
+}
+
+func (m *StreamStats) GetFingerprint() uint64 {
+	if m != nil {
+		return m.Fingerprint
+	}
+	return 0
+}
+
+func (m *StreamStats) GetChunks() uint64 {
+	if m != nil {
+		return m.Chunks
+	}
+	return 0
+}
+
+func (m *StreamStats) GetBytes() uint64 {
+	if m != nil {
+		return m.Bytes
+	}
+	return 0
+}
+
+func (m *StreamStats) GetEntries() uint64 {
+	if m != nil {
+		return m.Entries
+	}
+	return 0
+}
+
 type DroppedStream struct {
 	From   time.Time `protobuf:"bytes,1,opt,name=from,proto3,stdtime" json:"from"`
 	To     time.Time `protobuf:"bytes,2,opt,name=to,proto3,stdtime" json:"to"`
@@ -935,7 +1121,7 @@ type DroppedStream struct {
 func (m *DroppedStream) Reset()      { *m = DroppedStream{} }
 func (*DroppedStream) ProtoMessage() {}
 func (*DroppedStream) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{17}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
 }
 func (m *DroppedStream) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -995,7 +1181,7 @@ type TimeSeriesChunk struct {
 func (m *TimeSeriesChunk) Reset()      { *m = TimeSeriesChunk{} }
 func (*TimeSeriesChunk) ProtoMessage() {}
 func (*TimeSeriesChunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{18}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
 }
 func (m *TimeSeriesChunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1060,7 +1246,7 @@ type LabelPair struct {
 func (m *LabelPair) Reset()      { *m = LabelPair{} }
 func (*LabelPair) ProtoMessage() {}
 func (*LabelPair) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{19}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
 }
 func (m *LabelPair) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1110,7 +1296,7 @@ type Chunk struct {
 func (m *Chunk) Reset()      { *m = Chunk{} }
 func (*Chunk) ProtoMessage() {}
 func (*Chunk) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{20}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
 }
 func (m *Chunk) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1152,7 +1338,7 @@ type TransferChunksResponse struct {
 func (m *TransferChunksResponse) Reset()      { *m = TransferChunksResponse{} }
 func (*TransferChunksResponse) ProtoMessage() {}
 func (*TransferChunksResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{21}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{24}
 }
 func (m *TransferChunksResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1187,7 +1373,7 @@ type TailersCountRequest struct {
 func (m *TailersCountRequest) Reset()      { *m = TailersCountRequest{} }
 func (*TailersCountRequest) ProtoMessage() {}
 func (*TailersCountRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{22}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{25}
 }
 func (m *TailersCountRequest) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1223,7 +1409,7 @@ type TailersCountResponse struct {
 func (m *TailersCountResponse) Reset()      { *m = TailersCountResponse{} }
 func (*TailersCountResponse) ProtoMessage() {}
 func (*TailersCountResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c28a5f14f1f4c79a, []int{23}
+	return fileDescriptor_c28a5f14f1f4c79a, []int{26}
 }
 func (m *TailersCountResponse) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -1279,6 +1465,9 @@ func init() {
 	proto.RegisterType((*SeriesResponse)(nil), "logproto.SeriesResponse")
 	proto.RegisterType((*SeriesIdentifier)(nil), "logproto.SeriesIdentifier")
 	proto.RegisterMapType((map[string]string)(nil), "logproto.SeriesIdentifier.LabelsEntry")
+	proto.RegisterType((*StreamStatsRequest)(nil), "logproto.StreamStatsRequest")
+	proto.RegisterType((*StreamStatsResponse)(nil), "logproto.StreamStatsResponse")
+	proto.RegisterType((*StreamStats)(nil), "logproto.StreamStats")
 	proto.RegisterType((*DroppedStream)(nil), "logproto.DroppedStream")
 	proto.RegisterType((*TimeSeriesChunk)(nil), "logproto.TimeSeriesChunk")
 	proto.RegisterType((*LabelPair)(nil), "logproto.LabelPair")
@@ -1291,89 +1480,98 @@ func init() {
 func init() { proto.RegisterFile("pkg/logproto/logproto.proto", fileDescriptor_c28a5f14f1f4c79a) }
 
 var fileDescriptor_c28a5f14f1f4c79a = []byte{
-	// 1302 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x57, 0x4b, 0x6f, 0xdb, 0x46,
-	0x10, 0xd6, 0x4a, 0x14, 0x25, 0x8d, 0x1e, 0x11, 0x36, 0x8e, 0xad, 0x2a, 0x09, 0x25, 0x10, 0x41,
-	0x22, 0xb4, 0xa9, 0xd4, 0xaa, 0xaf, 0x3c, 0xfa, 0x80, 0x15, 0x37, 0x88, 0xdd, 0xa2, 0x4e, 0x68,
-	0x03, 0x01, 0x02, 0x14, 0x01, 0x6d, 0xae, 0x25, 0xc2, 0x12, 0xa9, 0x90, 0x94, 0x01, 0xdf, 0xfa,
-	0x03, 0x5a, 0x20, 0xb7, 0x1e, 0x02, 0xf4, 0x5c, 0xf4, 0xd0, 0xdf, 0xe1, 0xa3, 0xd1, 0x53, 0xd0,
-	0x83, 0x5a, 0xcb, 0x97, 0xc2, 0xa7, 0xfc, 0x84, 0x62, 0x1f, 0x24, 0x57, 0x8a, 0xdd, 0x5a, 0xbe,
-	0x88, 0x3b, 0xb3, 0x33, 0xb3, 0xb3, 0xdf, 0x7e, 0x33, 0xbb, 0x82, 0xab, 0xc3, 0xdd, 0x6e, 0xab,
-	0xef, 0x76, 0x87, 0x9e, 0x1b, 0xb8, 0xd1, 0xa0, 0xc9, 0x7e, 0x71, 0x36, 0x94, 0xab, 0xb5, 0xae,
-	0xeb, 0x76, 0xfb, 0xa4, 0xc5, 0xa4, 0xad, 0xd1, 0x4e, 0x2b, 0xb0, 0x07, 0xc4, 0x0f, 0xcc, 0xc1,
-	0x90, 0x9b, 0x56, 0xdf, 0xef, 0xda, 0x41, 0x6f, 0xb4, 0xd5, 0xdc, 0x76, 0x07, 0xad, 0xae, 0xdb,
-	0x75, 0x63, 0x4b, 0x2a, 0xf1, 0xe8, 0x74, 0xc4, 0xcd, 0xf5, 0xa7, 0x90, 0x7f, 0x3c, 0xf2, 0x7b,
-	0x06, 0x79, 0x31, 0x22, 0x7e, 0x80, 0x1f, 0x41, 0xc6, 0x0f, 0x3c, 0x62, 0x0e, 0xfc, 0x0a, 0xaa,
-	0xa7, 0x1a, 0xf9, 0xf6, 0x52, 0x33, 0x4a, 0x65, 0x83, 0x4d, 0x2c, 0x5b, 0xe6, 0x30, 0x20, 0x5e,
-	0xe7, 0xca, 0x9f, 0xe3, 0x9a, 0xca, 0x55, 0x27, 0xe3, 0x5a, 0xe8, 0x65, 0x84, 0x03, 0xbd, 0x04,
-	0x05, 0x1e, 0xd8, 0x1f, 0xba, 0x8e, 0x4f, 0xf4, 0x57, 0x49, 0x28, 0x3c, 0x19, 0x11, 0x6f, 0x3f,
-	0x5c, 0xaa, 0x0a, 0x59, 0x9f, 0xf4, 0xc9, 0x76, 0xe0, 0x7a, 0x15, 0x54, 0x47, 0x8d, 0x9c, 0x11,
-	0xc9, 0x78, 0x01, 0xd2, 0x7d, 0x7b, 0x60, 0x07, 0x95, 0x64, 0x1d, 0x35, 0x8a, 0x06, 0x17, 0xf0,
-	0x3d, 0x48, 0xfb, 0x81, 0xe9, 0x05, 0x95, 0x54, 0x1d, 0x35, 0xf2, 0xed, 0x6a, 0x93, 0x63, 0xd1,
-	0x0c, 0x77, 0xd8, 0xdc, 0x0c, 0xb1, 0xe8, 0x64, 0x0f, 0xc6, 0xb5, 0xc4, 0xcb, 0xbf, 0x6a, 0xc8,
-	0xe0, 0x2e, 0xf8, 0x53, 0x48, 0x11, 0xc7, 0xaa, 0x28, 0x73, 0x78, 0x52, 0x07, 0xfc, 0x21, 0xe4,
-	0x2c, 0xdb, 0x23, 0xdb, 0x81, 0xed, 0x3a, 0x95, 0x74, 0x1d, 0x35, 0x4a, 0xed, 0xcb, 0x31, 0x24,
-	0x2b, 0xe1, 0x94, 0x11, 0x5b, 0xe1, 0xdb, 0xa0, 0xfa, 0x3d, 0xd3, 0xb3, 0xfc, 0x4a, 0xa6, 0x9e,
-	0x6a, 0xe4, 0x3a, 0x0b, 0x27, 0xe3, 0x5a, 0x99, 0x6b, 0x6e, 0xbb, 0x03, 0x3b, 0x20, 0x83, 0x61,
-	0xb0, 0x6f, 0x08, 0x9b, 0x35, 0x25, 0xab, 0x96, 0x33, 0xfa, 0x1f, 0x08, 0xf0, 0x86, 0x39, 0x18,
-	0xf6, 0xc9, 0xb9, 0x31, 0x8a, 0xd0, 0x48, 0x5e, 0x18, 0x8d, 0xd4, 0xbc, 0x68, 0xc4, 0x5b, 0x53,
-	0xfe, 0x7f, 0x6b, 0xfa, 0x3a, 0x5c, 0x9e, 0xda, 0x13, 0x67, 0x02, 0xbe, 0x03, 0xaa, 0x4f, 0x3c,
-	0x9b, 0x84, 0x14, 0x2b, 0x4b, 0x14, 0x63, 0xfa, 0x4e, 0xe9, 0x60, 0x5c, 0x43, 0x8c, 0x5f, 0x4c,
-	0x36, 0x84, 0xbd, 0x6e, 0x40, 0x71, 0x3a, 0xd4, 0xf2, 0xb9, 0xe9, 0x1a, 0x87, 0x64, 0xea, 0x98,
-	0xa7, 0xbf, 0x23, 0x28, 0x7c, 0x6b, 0x6e, 0x91, 0x7e, 0x88, 0x39, 0x06, 0xc5, 0x31, 0x07, 0x44,
-	0xe0, 0xcd, 0xc6, 0x78, 0x11, 0xd4, 0x3d, 0xb3, 0x3f, 0x22, 0x3e, 0x03, 0x3b, 0x6b, 0x08, 0x69,
-	0x5e, 0x46, 0xa2, 0x0b, 0x33, 0x12, 0x45, 0x67, 0xa0, 0xdf, 0x82, 0xa2, 0xc8, 0x57, 0x80, 0x10,
-	0x27, 0x47, 0x31, 0xc8, 0x85, 0xc9, 0xe9, 0x7b, 0x50, 0x9c, 0xc2, 0x00, 0xeb, 0xa0, 0xf6, 0xa9,
-	0xa7, 0xcf, 0xf7, 0xd6, 0x81, 0x93, 0x71, 0x4d, 0x68, 0x0c, 0xf1, 0xa5, 0x88, 0x12, 0x27, 0x60,
-	0xa7, 0x93, 0x64, 0x88, 0x2e, 0xc6, 0x88, 0x7e, 0xed, 0x04, 0xde, 0x7e, 0x08, 0xe8, 0x25, 0xca,
-	0x0c, 0x5a, 0xf9, 0xc2, 0xdc, 0x08, 0x07, 0xfa, 0x1e, 0x14, 0x64, 0x4b, 0xfc, 0x08, 0x72, 0x51,
-	0x93, 0x62, 0x2b, 0xff, 0xf7, 0x76, 0x4b, 0x22, 0x70, 0x32, 0xf0, 0xd9, 0xa6, 0x63, 0x67, 0x7c,
-	0x0d, 0x94, 0xbe, 0xed, 0x10, 0x76, 0x08, 0xb9, 0x4e, 0xf6, 0x64, 0x5c, 0x63, 0xb2, 0xc1, 0x7e,
-	0xf5, 0x01, 0xa8, 0x9c, 0x6e, 0xf8, 0xc6, 0xec, 0x8a, 0xa9, 0x8e, 0xca, 0x23, 0xca, 0xd1, 0x6a,
-	0x90, 0x66, 0x48, 0xb1, 0x70, 0xa8, 0x93, 0x3b, 0x19, 0xd7, 0xb8, 0xc2, 0xe0, 0x1f, 0xba, 0x5c,
-	0xcf, 0xf4, 0x7b, 0xec, 0x70, 0x15, 0xbe, 0x1c, 0x95, 0x0d, 0xf6, 0xab, 0xdb, 0x20, 0xe8, 0x79,
-	0x2e, 0x5c, 0xef, 0x43, 0xc6, 0x67, 0xc9, 0x85, 0xb8, 0xca, 0xac, 0x67, 0x13, 0x31, 0xa2, 0xc2,
-	0xd0, 0x08, 0x07, 0xfa, 0xcf, 0x08, 0xf2, 0x9b, 0xa6, 0x1d, 0x51, 0x74, 0x01, 0xd2, 0x2f, 0x68,
-	0x1d, 0x08, 0x8e, 0x72, 0x81, 0x36, 0x0b, 0x8b, 0xf4, 0xcd, 0xfd, 0x87, 0xae, 0xc7, 0x52, 0x2e,
-	0x1a, 0x91, 0x1c, 0x37, 0x54, 0xe5, 0xd4, 0x86, 0x9a, 0x9e, 0xbb, 0x85, 0xac, 0x29, 0xd9, 0x64,
-	0x39, 0xa5, 0xff, 0x88, 0xa0, 0xc0, 0x33, 0x13, 0x64, 0xbc, 0x0f, 0x2a, 0xaf, 0x2c, 0x71, 0xd2,
-	0x67, 0x16, 0x24, 0x48, 0xc5, 0x28, 0x5c, 0xf0, 0x57, 0x50, 0xb2, 0x3c, 0x77, 0x38, 0x24, 0xd6,
-	0x86, 0xa8, 0xea, 0xe4, 0x6c, 0x55, 0xaf, 0xc8, 0xf3, 0xc6, 0x8c, 0xb9, 0xfe, 0x0a, 0x41, 0x51,
-	0xf4, 0x0c, 0x01, 0x55, 0xb4, 0x45, 0x74, 0xe1, 0x2e, 0x99, 0x9c, 0xb7, 0x4b, 0x2e, 0x82, 0xda,
-	0xf5, 0xdc, 0xd1, 0xd0, 0xaf, 0xa4, 0x78, 0x41, 0x72, 0x49, 0x5f, 0x83, 0x52, 0x98, 0xdc, 0x19,
-	0xad, 0xb0, 0x3a, 0xdb, 0x0a, 0x57, 0x2d, 0xe2, 0x04, 0xf6, 0x8e, 0x4d, 0xbc, 0x8e, 0x42, 0x17,
-	0x89, 0x5a, 0xe1, 0x4f, 0x08, 0xca, 0xb3, 0x26, 0xf8, 0x4b, 0x89, 0x88, 0x34, 0xdc, 0xcd, 0xb3,
-	0xc3, 0x35, 0x59, 0x0f, 0xf1, 0x59, 0xa1, 0x86, 0x24, 0xad, 0xde, 0x85, 0xbc, 0xa4, 0xc6, 0x65,
-	0x48, 0xed, 0x92, 0x90, 0x64, 0x74, 0x48, 0x69, 0x14, 0x97, 0x4c, 0x4e, 0xd4, 0xc9, 0xbd, 0xe4,
-	0x1d, 0x44, 0x29, 0x5a, 0x9c, 0x3a, 0x1b, 0x7c, 0x07, 0x94, 0x1d, 0xcf, 0x1d, 0xcc, 0x05, 0x3c,
-	0xf3, 0xc0, 0x1f, 0x43, 0x32, 0x70, 0xe7, 0x82, 0x3d, 0x19, 0xb8, 0x14, 0x75, 0xb1, 0xf9, 0x14,
-	0x4b, 0x4e, 0x48, 0xfa, 0x6f, 0x08, 0x2e, 0x51, 0x1f, 0x8e, 0xc0, 0x83, 0xde, 0xc8, 0xd9, 0xc5,
-	0x0d, 0x28, 0xd3, 0x95, 0x9e, 0xdb, 0x4e, 0x97, 0xf8, 0x01, 0xf1, 0x9e, 0xdb, 0x96, 0xd8, 0x66,
-	0x89, 0xea, 0x57, 0x85, 0x7a, 0xd5, 0xc2, 0x4b, 0x90, 0x19, 0xf9, 0xdc, 0x80, 0xef, 0x59, 0xa5,
-	0xe2, 0xaa, 0x85, 0xdf, 0x93, 0x96, 0xa3, 0x58, 0x4b, 0xaf, 0x02, 0x86, 0xe1, 0x63, 0xd3, 0xf6,
-	0xa2, 0xea, 0xbf, 0x05, 0xea, 0x36, 0x5d, 0x98, 0xdf, 0x9b, 0xf9, 0xf6, 0xa5, 0xd8, 0x98, 0x25,
-	0x64, 0x88, 0x69, 0xfd, 0x13, 0xc8, 0x45, 0xde, 0xa7, 0xde, 0x44, 0xa7, 0x9e, 0x80, 0x7e, 0x15,
-	0xd2, 0x7c, 0x63, 0x18, 0x14, 0xcb, 0x0c, 0x4c, 0xe6, 0x52, 0x30, 0xd8, 0x58, 0xaf, 0xc0, 0xe2,
-	0xa6, 0x67, 0x3a, 0xfe, 0x0e, 0xf1, 0x98, 0x51, 0x44, 0x3f, 0xfd, 0x0a, 0x5c, 0xa6, 0xc5, 0x4b,
-	0x3c, 0xff, 0x81, 0x3b, 0x72, 0x02, 0x51, 0x33, 0xfa, 0x6d, 0x58, 0x98, 0x56, 0x0b, 0xb6, 0x2e,
-	0x40, 0x7a, 0x9b, 0x2a, 0x58, 0xf4, 0xa2, 0xc1, 0x85, 0x77, 0x6f, 0x42, 0x2e, 0x7a, 0x06, 0xe1,
-	0x3c, 0x64, 0x1e, 0xae, 0x1b, 0x4f, 0x97, 0x8d, 0x95, 0x72, 0x02, 0x17, 0x20, 0xdb, 0x59, 0x7e,
-	0xf0, 0x0d, 0x93, 0x50, 0x7b, 0x19, 0x54, 0xfa, 0x20, 0x24, 0x1e, 0xfe, 0x0c, 0x14, 0x3a, 0xc2,
-	0x57, 0x62, 0x14, 0xa4, 0x37, 0x68, 0x75, 0x71, 0x56, 0x2d, 0xb2, 0x4d, 0xb4, 0x7f, 0x49, 0x41,
-	0x86, 0x3e, 0x00, 0x28, 0xd7, 0x3f, 0x87, 0x34, 0x7b, 0x0b, 0x60, 0xc9, 0x5c, 0x7e, 0x3b, 0x55,
-	0x97, 0xde, 0xd2, 0x87, 0x71, 0x3e, 0x40, 0xf8, 0x3b, 0xc8, 0x33, 0xa5, 0xb8, 0x30, 0xae, 0xcd,
-	0x36, 0xe3, 0xa9, 0x48, 0xd7, 0xcf, 0x98, 0x95, 0xe2, 0xdd, 0x83, 0x34, 0x3b, 0x37, 0x39, 0x1b,
-	0xf9, 0x55, 0x21, 0x67, 0x33, 0x75, 0x7b, 0xeb, 0x09, 0x7c, 0x17, 0x14, 0x0a, 0xb7, 0x0c, 0x87,
-	0xd4, 0xec, 0x65, 0x38, 0xe4, 0x4e, 0xcb, 0x96, 0xfd, 0x22, 0xba, 0x83, 0x96, 0x66, 0x4b, 0x3d,
-	0x74, 0xaf, 0xbc, 0x3d, 0x11, 0xad, 0xbc, 0xce, 0x9b, 0x77, 0x78, 0xd0, 0xf8, 0xfa, 0xf4, 0x52,
-	0x33, 0xbc, 0xa8, 0x6a, 0x67, 0x4d, 0x47, 0x07, 0xf4, 0x3d, 0x64, 0xc3, 0xda, 0xc1, 0x4f, 0xa0,
-	0x34, 0x4d, 0x3b, 0xfc, 0x8e, 0xe4, 0x3f, 0x5d, 0x90, 0xd5, 0xba, 0x34, 0x75, 0x3a, 0x57, 0x13,
-	0x0d, 0xd4, 0x79, 0x76, 0x78, 0xa4, 0x25, 0x5e, 0x1f, 0x69, 0x89, 0x37, 0x47, 0x1a, 0xfa, 0x61,
-	0xa2, 0xa1, 0x5f, 0x27, 0x1a, 0x3a, 0x98, 0x68, 0xe8, 0x70, 0xa2, 0xa1, 0xbf, 0x27, 0x1a, 0xfa,
-	0x67, 0xa2, 0x25, 0xde, 0x4c, 0x34, 0xf4, 0xf2, 0x58, 0x4b, 0x1c, 0x1e, 0x6b, 0x89, 0xd7, 0xc7,
-	0x5a, 0xe2, 0xd9, 0x0d, 0xf9, 0x1f, 0x91, 0x67, 0xee, 0x98, 0x8e, 0xd9, 0xea, 0xbb, 0xbb, 0x76,
-	0x4b, 0xfe, 0xc7, 0xb5, 0xa5, 0xb2, 0xcf, 0x47, 0xff, 0x06, 0x00, 0x00, 0xff, 0xff, 0x38, 0x69,
-	0xf4, 0xf4, 0x88, 0x0d, 0x00, 0x00,
+	// 1447 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xc4, 0x57, 0xcf, 0x6f, 0x13, 0xc7,
+	0x17, 0xf7, 0xd8, 0x6b, 0xc7, 0x7e, 0xfe, 0x81, 0x35, 0x09, 0x89, 0xbf, 0x26, 0xac, 0xad, 0x15,
+	0x02, 0xeb, 0x5b, 0x9a, 0xb4, 0x69, 0x69, 0xf9, 0xd1, 0x1f, 0x8a, 0xa1, 0x94, 0x50, 0x5a, 0x60,
+	0x83, 0x84, 0x84, 0x54, 0xa1, 0x8d, 0x3d, 0xb1, 0x57, 0xb1, 0x77, 0xcd, 0xec, 0x18, 0x29, 0xb7,
+	0xfe, 0x01, 0xad, 0xca, 0xad, 0x07, 0xae, 0x3d, 0x54, 0x3d, 0xf4, 0xef, 0xe0, 0x88, 0x7a, 0x42,
+	0x3d, 0xb8, 0x8d, 0xb9, 0x54, 0xb9, 0x94, 0x3f, 0xa1, 0x9a, 0x1f, 0xbb, 0x3b, 0x76, 0x1c, 0x81,
+	0xb9, 0xf4, 0x62, 0xcf, 0x7b, 0xf3, 0xde, 0xcc, 0x9b, 0xcf, 0xfb, 0xbc, 0x37, 0xb3, 0x70, 0x6a,
+	0xb0, 0xd7, 0x59, 0xef, 0xf9, 0x9d, 0x01, 0xf5, 0x99, 0x1f, 0x0d, 0xd6, 0xc4, 0x2f, 0xce, 0x86,
+	0x72, 0xb5, 0xd6, 0xf1, 0xfd, 0x4e, 0x8f, 0xac, 0x0b, 0x69, 0x67, 0xb8, 0xbb, 0xce, 0xdc, 0x3e,
+	0x09, 0x98, 0xd3, 0x1f, 0x48, 0xd3, 0xea, 0xbb, 0x1d, 0x97, 0x75, 0x87, 0x3b, 0x6b, 0x2d, 0xbf,
+	0xbf, 0xde, 0xf1, 0x3b, 0x7e, 0x6c, 0xc9, 0x25, 0xb9, 0x3a, 0x1f, 0x49, 0x73, 0xeb, 0x3e, 0xe4,
+	0xef, 0x0c, 0x83, 0xae, 0x4d, 0x1e, 0x0d, 0x49, 0xc0, 0xf0, 0x0d, 0x58, 0x08, 0x18, 0x25, 0x4e,
+	0x3f, 0xa8, 0xa0, 0x7a, 0xaa, 0x91, 0xdf, 0x58, 0x59, 0x8b, 0x42, 0xd9, 0x16, 0x13, 0x9b, 0x6d,
+	0x67, 0xc0, 0x08, 0x6d, 0x9e, 0xfc, 0x63, 0x54, 0xcb, 0x48, 0xd5, 0xe1, 0xa8, 0x16, 0x7a, 0xd9,
+	0xe1, 0xc0, 0x2a, 0x41, 0x41, 0x2e, 0x1c, 0x0c, 0x7c, 0x2f, 0x20, 0xd6, 0xd3, 0x24, 0x14, 0xee,
+	0x0e, 0x09, 0xdd, 0x0f, 0xb7, 0xaa, 0x42, 0x36, 0x20, 0x3d, 0xd2, 0x62, 0x3e, 0xad, 0xa0, 0x3a,
+	0x6a, 0xe4, 0xec, 0x48, 0xc6, 0x4b, 0x90, 0xee, 0xb9, 0x7d, 0x97, 0x55, 0x92, 0x75, 0xd4, 0x28,
+	0xda, 0x52, 0xc0, 0x97, 0x21, 0x1d, 0x30, 0x87, 0xb2, 0x4a, 0xaa, 0x8e, 0x1a, 0xf9, 0x8d, 0xea,
+	0x9a, 0xc4, 0x62, 0x2d, 0x3c, 0xe1, 0xda, 0xbd, 0x10, 0x8b, 0x66, 0xf6, 0xd9, 0xa8, 0x96, 0x78,
+	0xf2, 0x67, 0x0d, 0xd9, 0xd2, 0x05, 0x7f, 0x04, 0x29, 0xe2, 0xb5, 0x2b, 0xc6, 0x1c, 0x9e, 0xdc,
+	0x01, 0xbf, 0x0f, 0xb9, 0xb6, 0x4b, 0x49, 0x8b, 0xb9, 0xbe, 0x57, 0x49, 0xd7, 0x51, 0xa3, 0xb4,
+	0xb1, 0x18, 0x43, 0x72, 0x2d, 0x9c, 0xb2, 0x63, 0x2b, 0x7c, 0x1e, 0x32, 0x41, 0xd7, 0xa1, 0xed,
+	0xa0, 0xb2, 0x50, 0x4f, 0x35, 0x72, 0xcd, 0xa5, 0xc3, 0x51, 0xad, 0x2c, 0x35, 0xe7, 0xfd, 0xbe,
+	0xcb, 0x48, 0x7f, 0xc0, 0xf6, 0x6d, 0x65, 0x73, 0xd3, 0xc8, 0x66, 0xca, 0x0b, 0xd6, 0xef, 0x08,
+	0xf0, 0xb6, 0xd3, 0x1f, 0xf4, 0xc8, 0x1b, 0x63, 0x14, 0xa1, 0x91, 0x7c, 0x6b, 0x34, 0x52, 0xf3,
+	0xa2, 0x11, 0x1f, 0xcd, 0x78, 0xfd, 0xd1, 0xac, 0xdb, 0xb0, 0x38, 0x71, 0x26, 0xc9, 0x04, 0x7c,
+	0x11, 0x32, 0x01, 0xa1, 0x2e, 0x09, 0x29, 0x56, 0xd6, 0x28, 0x26, 0xf4, 0xcd, 0xd2, 0xb3, 0x51,
+	0x0d, 0x09, 0x7e, 0x09, 0xd9, 0x56, 0xf6, 0x96, 0x0d, 0xc5, 0xc9, 0xa5, 0x36, 0xdf, 0x98, 0xae,
+	0xf1, 0x92, 0x42, 0x1d, 0xf3, 0xf4, 0x37, 0x04, 0x85, 0x5b, 0xce, 0x0e, 0xe9, 0x85, 0x98, 0x63,
+	0x30, 0x3c, 0xa7, 0x4f, 0x14, 0xde, 0x62, 0x8c, 0x97, 0x21, 0xf3, 0xd8, 0xe9, 0x0d, 0x49, 0x20,
+	0xc0, 0xce, 0xda, 0x4a, 0x9a, 0x97, 0x91, 0xe8, 0xad, 0x19, 0x89, 0xa2, 0x1c, 0x58, 0xe7, 0xa0,
+	0xa8, 0xe2, 0x55, 0x20, 0xc4, 0xc1, 0x71, 0x0c, 0x72, 0x61, 0x70, 0xd6, 0x63, 0x28, 0x4e, 0x60,
+	0x80, 0x2d, 0xc8, 0xf4, 0xb8, 0x67, 0x20, 0xcf, 0xd6, 0x84, 0xc3, 0x51, 0x4d, 0x69, 0x6c, 0xf5,
+	0xcf, 0x11, 0x25, 0x1e, 0x13, 0xd9, 0x49, 0x0a, 0x44, 0x97, 0x63, 0x44, 0xbf, 0xf0, 0x18, 0xdd,
+	0x0f, 0x01, 0x3d, 0xc1, 0x99, 0xc1, 0x2b, 0x5f, 0x99, 0xdb, 0xe1, 0xc0, 0x3a, 0x40, 0x50, 0xd0,
+	0x4d, 0xf1, 0x0d, 0xc8, 0x45, 0x5d, 0xaa, 0x82, 0x5e, 0x7b, 0xde, 0x92, 0x5a, 0x39, 0xc9, 0x02,
+	0x71, 0xea, 0xd8, 0x19, 0xaf, 0x82, 0xd1, 0x73, 0x3d, 0x22, 0xb2, 0x90, 0x6b, 0x66, 0x0f, 0x47,
+	0x35, 0x21, 0xdb, 0xe2, 0x17, 0xbb, 0x80, 0x03, 0x46, 0x87, 0x2d, 0x36, 0xa4, 0xa4, 0xfd, 0x35,
+	0x61, 0x4e, 0xdb, 0x61, 0x4e, 0x25, 0x25, 0x8e, 0xa1, 0x15, 0xad, 0x40, 0xef, 0x8e, 0xe3, 0xd2,
+	0xe6, 0x19, 0xb5, 0xd3, 0xea, 0x51, 0x37, 0x8d, 0xce, 0x33, 0x16, 0xb5, 0xfa, 0x90, 0x91, 0xd4,
+	0xc6, 0x67, 0xa6, 0x0f, 0x97, 0x6a, 0x66, 0x64, 0xf0, 0x7a, 0xe0, 0x35, 0x48, 0x8b, 0xac, 0x88,
+	0xc8, 0x51, 0x33, 0x77, 0x38, 0xaa, 0x49, 0x85, 0x2d, 0xff, 0xf8, 0xc9, 0xba, 0x4e, 0xd0, 0x15,
+	0x44, 0x32, 0xe4, 0xc9, 0xb8, 0x6c, 0x8b, 0x5f, 0xcb, 0x05, 0x55, 0x0a, 0x6f, 0x94, 0xc3, 0x2b,
+	0xb0, 0x10, 0x88, 0xe0, 0xc2, 0x1c, 0xea, 0x15, 0x26, 0x26, 0xe2, 0xec, 0x29, 0x43, 0x3b, 0x1c,
+	0x58, 0x3f, 0x21, 0xc8, 0xdf, 0x73, 0xdc, 0xa8, 0x1c, 0x96, 0x20, 0xfd, 0x88, 0xd7, 0x9c, 0xaa,
+	0x07, 0x29, 0xf0, 0xc6, 0xd4, 0x26, 0x3d, 0x67, 0xff, 0xba, 0x4f, 0x45, 0xc8, 0x45, 0x3b, 0x92,
+	0xe3, 0xe6, 0x6d, 0xcc, 0x6c, 0xde, 0xe9, 0xb9, 0xdb, 0xd5, 0x4d, 0x23, 0x9b, 0x2c, 0xa7, 0xac,
+	0xef, 0x11, 0x14, 0x64, 0x64, 0x8a, 0xf8, 0x57, 0x20, 0x23, 0xab, 0x58, 0x91, 0xea, 0xd8, 0xe2,
+	0x07, 0xad, 0xf0, 0x95, 0x0b, 0xfe, 0x1c, 0x4a, 0x6d, 0xea, 0x0f, 0x06, 0xa4, 0xbd, 0xad, 0x3a,
+	0x48, 0x72, 0xba, 0x83, 0x5c, 0xd3, 0xe7, 0xed, 0x29, 0x73, 0xeb, 0x29, 0x82, 0xa2, 0xea, 0x4f,
+	0x0a, 0xaa, 0xe8, 0x88, 0xe8, 0xad, 0x3b, 0x72, 0x72, 0xde, 0x8e, 0xbc, 0x0c, 0x99, 0x0e, 0xf5,
+	0x87, 0x83, 0x40, 0xf0, 0x3c, 0x67, 0x2b, 0xc9, 0xba, 0x09, 0xa5, 0x30, 0xb8, 0x63, 0xda, 0x6e,
+	0x75, 0xba, 0xed, 0x6e, 0xb5, 0x89, 0xc7, 0xdc, 0x5d, 0x97, 0xd0, 0xa6, 0xc1, 0x37, 0x89, 0xda,
+	0xee, 0x0f, 0x08, 0xca, 0xd3, 0x26, 0xf8, 0x33, 0x8d, 0x88, 0x7c, 0xb9, 0xb3, 0xc7, 0x2f, 0x27,
+	0x2b, 0x2e, 0x10, 0x3d, 0x21, 0x24, 0x69, 0xf5, 0x12, 0xe4, 0x35, 0x35, 0x2e, 0x43, 0x6a, 0x8f,
+	0x84, 0x24, 0xe3, 0x43, 0x4e, 0xa3, 0xb8, 0x64, 0x72, 0xaa, 0x4e, 0x2e, 0x27, 0x2f, 0x22, 0xeb,
+	0x67, 0x7e, 0x59, 0x8a, 0x2c, 0x6c, 0x33, 0x87, 0xfd, 0xa7, 0xf0, 0x57, 0x21, 0xdb, 0x77, 0x58,
+	0xab, 0x4b, 0x68, 0x20, 0xea, 0x20, 0x67, 0x47, 0xb2, 0x75, 0x0b, 0x16, 0x27, 0xa2, 0x54, 0x79,
+	0xb8, 0x30, 0x7d, 0x67, 0x9d, 0x9c, 0xa6, 0xad, 0xb0, 0x57, 0x39, 0x88, 0xee, 0xa9, 0x1f, 0x11,
+	0xe4, 0xb5, 0x69, 0x9e, 0x78, 0xbd, 0x11, 0x44, 0xc5, 0x5f, 0x87, 0xfc, 0xae, 0xeb, 0x75, 0x08,
+	0x1d, 0x50, 0xd7, 0x93, 0x8f, 0x03, 0xc3, 0xd6, 0x55, 0xdc, 0xb3, 0xd5, 0x1d, 0x7a, 0x7b, 0x32,
+	0x62, 0xc3, 0x56, 0x12, 0x07, 0x7c, 0x67, 0x9f, 0x91, 0x40, 0xd4, 0xad, 0x61, 0x4b, 0x01, 0x57,
+	0xe2, 0x0b, 0x21, 0x2d, 0xf4, 0xa1, 0xc8, 0x3b, 0x45, 0x71, 0xa2, 0x44, 0xf0, 0x45, 0x30, 0x76,
+	0xa9, 0xdf, 0x9f, 0x2b, 0x01, 0xc2, 0x03, 0x7f, 0x08, 0x49, 0xe6, 0xcf, 0x05, 0x7f, 0x92, 0xf9,
+	0x1a, 0x06, 0x29, 0x1d, 0x03, 0xeb, 0x57, 0x04, 0x27, 0xb8, 0x8f, 0x24, 0xe2, 0x55, 0x7e, 0x3c,
+	0xdc, 0x80, 0x32, 0xdf, 0xe9, 0x21, 0x07, 0x22, 0x60, 0x84, 0x3e, 0x74, 0xdb, 0x0a, 0xb9, 0x12,
+	0xd7, 0x6f, 0x29, 0xf5, 0x56, 0x1b, 0xaf, 0xc0, 0xc2, 0x30, 0x90, 0x06, 0x92, 0x7a, 0x19, 0x2e,
+	0x6e, 0xb5, 0xf1, 0x3b, 0xda, 0x76, 0xc7, 0xdd, 0x29, 0x51, 0x1e, 0xce, 0x45, 0x28, 0x1b, 0xc2,
+	0xf8, 0x44, 0x6c, 0x2c, 0x02, 0x0a, 0x61, 0xb7, 0x2e, 0x40, 0x2e, 0xf2, 0x9e, 0xf9, 0xf8, 0x98,
+	0x59, 0x08, 0xd6, 0x29, 0x48, 0xcb, 0x83, 0x61, 0x30, 0xc4, 0x3d, 0xc7, 0x5d, 0x0a, 0xb6, 0x18,
+	0x5b, 0x15, 0x58, 0xbe, 0x47, 0x1d, 0x2f, 0xd8, 0x25, 0x54, 0x18, 0x45, 0xec, 0xb3, 0x4e, 0xc2,
+	0x22, 0xef, 0xa1, 0x84, 0x06, 0x57, 0xfd, 0xa1, 0xc7, 0x54, 0xed, 0x58, 0xe7, 0x61, 0x69, 0x52,
+	0xad, 0xc8, 0xba, 0x04, 0xe9, 0x16, 0x57, 0x88, 0xd5, 0x8b, 0xb6, 0x14, 0xfe, 0x7f, 0x16, 0x72,
+	0xd1, 0xcb, 0x17, 0xe7, 0x61, 0xe1, 0xfa, 0x6d, 0xfb, 0xfe, 0xa6, 0x7d, 0xad, 0x9c, 0xc0, 0x05,
+	0xc8, 0x36, 0x37, 0xaf, 0x7e, 0x25, 0x24, 0xb4, 0xb1, 0x09, 0x19, 0xfe, 0x0d, 0x40, 0x28, 0xfe,
+	0x18, 0x0c, 0x3e, 0xc2, 0x1a, 0xd7, 0xb5, 0xcf, 0x8e, 0xea, 0xf2, 0xb4, 0x5a, 0x45, 0x9b, 0xd8,
+	0xf8, 0x27, 0x05, 0x0b, 0xfc, 0xcd, 0xc7, 0x5b, 0xce, 0x27, 0x90, 0xbe, 0x2b, 0x6e, 0x1f, 0xcd,
+	0x5c, 0x7f, 0x2e, 0x57, 0x57, 0x8e, 0xe8, 0xc3, 0x75, 0xde, 0x43, 0xf8, 0x1b, 0xc8, 0x0b, 0xa5,
+	0xba, 0xb7, 0x57, 0xa7, 0xef, 0xc4, 0x89, 0x95, 0x4e, 0x1f, 0x33, 0xab, 0xad, 0x77, 0x19, 0xd2,
+	0x22, 0x6f, 0x7a, 0x34, 0xfa, 0x43, 0xb2, 0xba, 0x72, 0x44, 0x1f, 0x7a, 0xe3, 0x4b, 0x60, 0x70,
+	0xb8, 0x75, 0x38, 0xb4, 0x3b, 0xb7, 0xba, 0x3c, 0xad, 0xd6, 0xb6, 0xfd, 0x34, 0x7a, 0x0a, 0xac,
+	0x4c, 0x77, 0xdc, 0xd0, 0xbd, 0x72, 0x74, 0x22, 0xda, 0xf9, 0x36, 0x14, 0xf4, 0x44, 0xe3, 0xd3,
+	0x93, 0x5b, 0x4d, 0xf1, 0xa2, 0x6a, 0x1e, 0x37, 0xad, 0x2d, 0x58, 0xfa, 0x92, 0x30, 0xbd, 0x33,
+	0xad, 0xce, 0xec, 0x67, 0xb3, 0x90, 0x3d, 0xda, 0x1d, 0xad, 0xc4, 0xc6, 0xb7, 0x90, 0x0d, 0x8b,
+	0x11, 0xdf, 0x85, 0xd2, 0x24, 0x8f, 0xf1, 0xff, 0xb4, 0x80, 0x26, 0x2b, 0xbc, 0x5a, 0xd7, 0xa6,
+	0x66, 0x93, 0x3f, 0xd1, 0x40, 0xcd, 0x07, 0xcf, 0x0f, 0xcc, 0xc4, 0x8b, 0x03, 0x33, 0xf1, 0xea,
+	0xc0, 0x44, 0xdf, 0x8d, 0x4d, 0xf4, 0xcb, 0xd8, 0x44, 0xcf, 0xc6, 0x26, 0x7a, 0x3e, 0x36, 0xd1,
+	0x5f, 0x63, 0x13, 0xfd, 0x3d, 0x36, 0x13, 0xaf, 0xc6, 0x26, 0x7a, 0xf2, 0xd2, 0x4c, 0x3c, 0x7f,
+	0x69, 0x26, 0x5e, 0xbc, 0x34, 0x13, 0x0f, 0xce, 0xe8, 0x5f, 0xd5, 0xd4, 0xd9, 0x75, 0x3c, 0x67,
+	0xbd, 0xe7, 0xef, 0xb9, 0xeb, 0xfa, 0x57, 0xfb, 0x4e, 0x46, 0xfc, 0x7d, 0xf0, 0xef, 0x00, 0x24,
+	0xee, 0x10, 0x54, 0xcc, 0x0f, 0x00, 0x00,
 }
 
 func (x Direction) String() string {
@@ -1700,6 +1898,14 @@ func (this *EntryAdapter) Equal(that interface{}) bool {
 	if this.Line != that1.Line {
 		return false
 	}
+	if len(this.StructuredMetadata) != len(that1.StructuredMetadata) {
+		return false
+	}
+	for i := range this.StructuredMetadata {
+		if !this.StructuredMetadata[i].Equal(&that1.StructuredMetadata[i]) {
+			return false
+		}
+	}
 	return true
 }
 func (this *Sample) Equal(that interface{}) bool {
@@ -1926,6 +2132,101 @@ func (this *SeriesIdentifier) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *StreamStatsRequest) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*StreamStatsRequest)
+	if !ok {
+		that2, ok := that.(StreamStatsRequest)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if !this.Start.Equal(that1.Start) {
+		return false
+	}
+	if !this.End.Equal(that1.End) {
+		return false
+	}
+	if this.Matchers != that1.Matchers {
+		return false
+	}
+	return true
+}
+func (this *StreamStatsResponse) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*StreamStatsResponse)
+	if !ok {
+		that2, ok := that.(StreamStatsResponse)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if len(this.Streams) != len(that1.Streams) {
+		return false
+	}
+	for i := range this.Streams {
+		if !this.Streams[i].Equal(&that1.Streams[i]) {
+			return false
+		}
+	}
+	return true
+}
+func (this *StreamStats) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*StreamStats)
+	if !ok {
+		that2, ok := that.(StreamStats)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Labels != that1.Labels {
+		return false
+	}
+	if this.Fingerprint != that1.Fingerprint {
+		return false
+	}
+	if this.Chunks != that1.Chunks {
+		return false
+	}
+	if this.Bytes != that1.Bytes {
+		return false
+	}
+	if this.Entries != that1.Entries {
+		return false
+	}
+	return true
+}
 func (this *DroppedStream) Equal(that interface{}) bool {
 	if that == nil {
 		return this == nil
@@ -2227,10 +2528,17 @@ func (this *EntryAdapter) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 6)
+	s := make([]string, 0, 7)
 	s = append(s, "&logproto.EntryAdapter{")
 	s = append(s, "Timestamp: "+fmt.Sprintf("%#v", this.Timestamp)+",\n")
 	s = append(s, "Line: "+fmt.Sprintf("%#v", this.Line)+",\n")
+	if this.StructuredMetadata != nil {
+		vs := make([]*LabelPair, len(this.StructuredMetadata))
+		for i := range vs {
+			vs[i] = &this.StructuredMetadata[i]
+		}
+		s = append(s, "StructuredMetadata: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -2339,6 +2647,48 @@ func (this *SeriesIdentifier) GoString() string {
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
+func (this *StreamStatsRequest) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 7)
+	s = append(s, "&logproto.StreamStatsRequest{")
+	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
+	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
+	s = append(s, "Matchers: "+fmt.Sprintf("%#v", this.Matchers)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *StreamStatsResponse) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 5)
+	s = append(s, "&logproto.StreamStatsResponse{")
+	if this.Streams != nil {
+		vs := make([]*StreamStats, len(this.Streams))
+		for i := range vs {
+			vs[i] = &this.Streams[i]
+		}
+		s = append(s, "Streams: "+fmt.Sprintf("%#v", vs)+",\n")
+	}
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
+func (this *StreamStats) GoString() string {
+	if this == nil {
+		return "nil"
+	}
+	s := make([]string, 0, 9)
+	s = append(s, "&logproto.StreamStats{")
+	s = append(s, "Labels: "+fmt.Sprintf("%#v", this.Labels)+",\n")
+	s = append(s, "Fingerprint: "+fmt.Sprintf("%#v", this.Fingerprint)+",\n")
+	s = append(s, "Chunks: "+fmt.Sprintf("%#v", this.Chunks)+",\n")
+	s = append(s, "Bytes: "+fmt.Sprintf("%#v", this.Bytes)+",\n")
+	s = append(s, "Entries: "+fmt.Sprintf("%#v", this.Entries)+",\n")
+	s = append(s, "}")
+	return strings.Join(s, "")
+}
 func (this *DroppedStream) GoString() string {
 	if this == nil {
 		return "nil"
@@ -2508,6 +2858,7 @@ type QuerierClient interface {
 	Tail(ctx context.Context, in *TailRequest, opts ...grpc.CallOption) (Querier_TailClient, error)
 	Series(ctx context.Context, in *SeriesRequest, opts ...grpc.CallOption) (*SeriesResponse, error)
 	TailersCount(ctx context.Context, in *TailersCountRequest, opts ...grpc.CallOption) (*TailersCountResponse, error)
+	GetStreamStats(ctx context.Context, in *StreamStatsRequest, opts ...grpc.CallOption) (*StreamStatsResponse, error)
 }
 
 type querierClient struct {
@@ -2641,6 +2992,15 @@ func (c *querierClient) TailersCount(ctx context.Context, in *TailersCountReques
 	return out, nil
 }
 
+func (c *querierClient) GetStreamStats(ctx context.Context, in *StreamStatsRequest, opts ...grpc.CallOption) (*StreamStatsResponse, error) {
+	out := new(StreamStatsResponse)
+	err := c.cc.Invoke(ctx, "/logproto.Querier/GetStreamStats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // QuerierServer is the server API for Querier service.
 type QuerierServer interface {
 	Query(*QueryRequest, Querier_QueryServer) error
@@ -2649,6 +3009,7 @@ type QuerierServer interface {
 	Tail(*TailRequest, Querier_TailServer) error
 	Series(context.Context, *SeriesRequest) (*SeriesResponse, error)
 	TailersCount(context.Context, *TailersCountRequest) (*TailersCountResponse, error)
+	GetStreamStats(context.Context, *StreamStatsRequest) (*StreamStatsResponse, error)
 }
 
 func RegisterQuerierServer(s *grpc.Server, srv QuerierServer) {
@@ -2772,8 +3133,26 @@ func _Querier_TailersCount_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
-var _Querier_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "logproto.Querier",
+func _Querier_GetStreamStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StreamStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(QuerierServer).GetStreamStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/logproto.Querier/GetStreamStats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(QuerierServer).GetStreamStats(ctx, req.(*StreamStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _Querier_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "logproto.Querier",
 	HandlerType: (*QuerierServer)(nil),
 	Methods: []grpc.MethodDesc{
 		{
@@ -2788,6 +3167,10 @@ var _Querier_serviceDesc = grpc.ServiceDesc{
 			MethodName: "TailersCount",
 			Handler:    _Querier_TailersCount_Handler,
 		},
+		{
+			MethodName: "GetStreamStats",
+			Handler:    _Querier_GetStreamStats_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -3287,6 +3670,18 @@ func (m *EntryAdapter) MarshalTo(dAtA []byte) (int, error) {
 		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Line)))
 		i += copy(dAtA[i:], m.Line)
 	}
+	if len(m.StructuredMetadata) > 0 {
+		for _, msg := range m.StructuredMetadata {
+			dAtA[i] = 0x1a
+			i++
+			i = encodeVarintLogproto(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
 	return i, nil
 }
 
@@ -3556,6 +3951,120 @@ func (m *SeriesIdentifier) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *StreamStatsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StreamStatsRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	dAtA[i] = 0xa
+	i++
+	i = encodeVarintLogproto(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdTime(m.Start)))
+	n12, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n12
+	dAtA[i] = 0x12
+	i++
+	i = encodeVarintLogproto(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdTime(m.End)))
+	n13, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.End, dAtA[i:])
+	if err != nil {
+		return 0, err
+	}
+	i += n13
+	if len(m.Matchers) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Matchers)))
+		i += copy(dAtA[i:], m.Matchers)
+	}
+	return i, nil
+}
+
+func (m *StreamStatsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StreamStatsResponse) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Streams) > 0 {
+		for _, msg := range m.Streams {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintLogproto(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *StreamStats) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *StreamStats) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Labels) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Labels)))
+		i += copy(dAtA[i:], m.Labels)
+	}
+	if m.Fingerprint != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(m.Fingerprint))
+	}
+	if m.Chunks != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(m.Chunks))
+	}
+	if m.Bytes != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(m.Bytes))
+	}
+	if m.Entries != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintLogproto(dAtA, i, uint64(m.Entries))
+	}
+	return i, nil
+}
+
 func (m *DroppedStream) Marshal() (dAtA []byte, err error) {
 	size := m.Size()
 	dAtA = make([]byte, size)
@@ -3574,19 +4083,19 @@ func (m *DroppedStream) MarshalTo(dAtA []byte) (int, error) {
 	dAtA[i] = 0xa
 	i++
 	i = encodeVarintLogproto(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdTime(m.From)))
-	n12, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.From, dAtA[i:])
+	n14, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.From, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n12
+	i += n14
 	dAtA[i] = 0x12
 	i++
 	i = encodeVarintLogproto(dAtA, i, uint64(github_com_gogo_protobuf_types.SizeOfStdTime(m.To)))
-	n13, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.To, dAtA[i:])
+	n15, err := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.To, dAtA[i:])
 	if err != nil {
 		return 0, err
 	}
-	i += n13
+	i += n15
 	if len(m.Labels) > 0 {
 		dAtA[i] = 0x1a
 		i++
@@ -3948,6 +4457,12 @@ func (m *EntryAdapter) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovLogproto(uint64(l))
 	}
+	if len(m.StructuredMetadata) > 0 {
+		for _, e := range m.StructuredMetadata {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
 	return n
 }
 
@@ -4079,6 +4594,63 @@ func (m *SeriesIdentifier) Size() (n int) {
 	return n
 }
 
+func (m *StreamStatsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.Start)
+	n += 1 + l + sovLogproto(uint64(l))
+	l = github_com_gogo_protobuf_types.SizeOfStdTime(m.End)
+	n += 1 + l + sovLogproto(uint64(l))
+	l = len(m.Matchers)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	return n
+}
+
+func (m *StreamStatsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Streams) > 0 {
+		for _, e := range m.Streams {
+			l = e.Size()
+			n += 1 + l + sovLogproto(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *StreamStats) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Labels)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	if m.Fingerprint != 0 {
+		n += 1 + sovLogproto(uint64(m.Fingerprint))
+	}
+	if m.Chunks != 0 {
+		n += 1 + sovLogproto(uint64(m.Chunks))
+	}
+	if m.Bytes != 0 {
+		n += 1 + sovLogproto(uint64(m.Bytes))
+	}
+	if m.Entries != 0 {
+		n += 1 + sovLogproto(uint64(m.Entries))
+	}
+	return n
+}
+
 func (m *DroppedStream) Size() (n int) {
 	if m == nil {
 		return 0
@@ -4306,6 +4878,7 @@ func (this *EntryAdapter) String() string {
 	s := strings.Join([]string{`&EntryAdapter{`,
 		`Timestamp:` + strings.Replace(strings.Replace(this.Timestamp.String(), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
 		`Line:` + fmt.Sprintf("%v", this.Line) + `,`,
+		`StructuredMetadata:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.StructuredMetadata), "LabelPair", "LabelPair", 1), `&`, ``, 1) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -4399,6 +4972,42 @@ func (this *SeriesIdentifier) String() string {
 	}, "")
 	return s
 }
+func (this *StreamStatsRequest) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&StreamStatsRequest{`,
+		`Start:` + strings.Replace(strings.Replace(this.Start.String(), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`End:` + strings.Replace(strings.Replace(this.End.String(), "Timestamp", "types.Timestamp", 1), `&`, ``, 1) + `,`,
+		`Matchers:` + fmt.Sprintf("%v", this.Matchers) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamStatsResponse) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&StreamStatsResponse{`,
+		`Streams:` + strings.Replace(strings.Replace(fmt.Sprintf("%v", this.Streams), "StreamStats", "StreamStats", 1), `&`, ``, 1) + `,`,
+		`}`,
+	}, "")
+	return s
+}
+func (this *StreamStats) String() string {
+	if this == nil {
+		return "nil"
+	}
+	s := strings.Join([]string{`&StreamStats{`,
+		`Labels:` + fmt.Sprintf("%v", this.Labels) + `,`,
+		`Fingerprint:` + fmt.Sprintf("%v", this.Fingerprint) + `,`,
+		`Chunks:` + fmt.Sprintf("%v", this.Chunks) + `,`,
+		`Bytes:` + fmt.Sprintf("%v", this.Bytes) + `,`,
+		`Entries:` + fmt.Sprintf("%v", this.Entries) + `,`,
+		`}`,
+	}, "")
+	return s
+}
 func (this *DroppedStream) String() string {
 	if this == nil {
 		return "nil"
@@ -5674,6 +6283,40 @@ func (m *EntryAdapter) Unmarshal(dAtA []byte) error {
 			}
 			m.Line = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field StructuredMetadata", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.StructuredMetadata = append(m.StructuredMetadata, LabelPair{})
+			if err := m.StructuredMetadata[len(m.StructuredMetadata)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLogproto(dAtA[iNdEx:])
@@ -6616,6 +7259,405 @@ func (m *SeriesIdentifier) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *StreamStatsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StreamStatsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StreamStatsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Start", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.Start, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field End", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdTimeUnmarshal(&m.End, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StreamStatsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StreamStatsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StreamStatsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Streams", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Streams = append(m.Streams, StreamStats{})
+			if err := m.Streams[len(m.Streams)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *StreamStats) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowLogproto
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: StreamStats: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: StreamStats: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Labels", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Labels = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Fingerprint", wireType)
+			}
+			m.Fingerprint = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Fingerprint |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Chunks", wireType)
+			}
+			m.Chunks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Chunks |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Bytes", wireType)
+			}
+			m.Bytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Bytes |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entries", wireType)
+			}
+			m.Entries = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Entries |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipLogproto(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func (m *DroppedStream) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
@@ -13,19 +13,19 @@ var (
 	stream = Stream{
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Entries: []Entry{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, nil},
+			{now.Add(1 * time.Second), line, nil},
+			{now.Add(2 * time.Second), line, nil},
+			{now.Add(3 * time.Second), line, []LabelPair{{Name: "traceID", Value: "2c9a6a4c5d7e1f30"}}},
 		},
 	}
 	streamAdapter = StreamAdapter{
 		Labels: `{job="foobar", cluster="foo-central1", namespace="bar", container_name="buzz"}`,
 		Entries: []EntryAdapter{
-			{now, line},
-			{now.Add(1 * time.Second), line},
-			{now.Add(2 * time.Second), line},
-			{now.Add(3 * time.Second), line},
+			{now, line, nil},
+			{now.Add(1 * time.Second), line, nil},
+			{now.Add(2 * time.Second), line, nil},
+			{now.Add(3 * time.Second), line, []LabelPair{{Name: "traceID", Value: "2c9a6a4c5d7e1f30"}}},
 		},
 	}
 )
@@ -3,6 +3,7 @@ package logql
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -68,8 +69,13 @@ type Querier interface {
 
 // LogSelectorExpr is a LogQL expression filtering and returning logs.
 type LogSelectorExpr interface {
+	// Filter returns the line filters of the expression, which are always applied on the original log line.
 	Filter() (LineFilter, error)
 	Matchers() []*labels.Matcher
+	// Pipeline returns the pipeline of stages (line filters, label parsers...) to apply on each log line.
+	Pipeline() (Pipeline, error)
+	// HasFilter returns true if the expression can filter out log lines, using line or label filters.
+	HasFilter() bool
 	Expr
 }
 
@@ -102,6 +108,14 @@ func (e *matchersExpr) Filter() (LineFilter, error) {
 	return nil, nil
 }
 
+func (e *matchersExpr) Pipeline() (Pipeline, error) {
+	return NoopPipeline, nil
+}
+
+func (e *matchersExpr) HasFilter() bool {
+	return false
+}
+
 // impl Expr
 func (e *matchersExpr) logQLExpr() {}
 
@@ -109,6 +123,7 @@ type filterExpr struct {
 	left  LogSelectorExpr
 	ty    labels.MatchType
 	match string
+	ip    bool // match is an ip range, e.g. `|= ip("10.0.0.0/8")`.
 }
 
 // NewFilterExpr wraps an existing Expr with a next filter expression.
@@ -120,6 +135,39 @@ func NewFilterExpr(left LogSelectorExpr, ty labels.MatchType, match string) LogS
 	}
 }
 
+// mustNewIPFilterExpr wraps an existing Expr with a filter matching the addresses within an ip range.
+func mustNewIPFilterExpr(left LogSelectorExpr, ty labels.MatchType, rng string) LogSelectorExpr {
+	e := &filterExpr{
+		left:  left,
+		ty:    ty,
+		match: rng,
+		ip:    true,
+	}
+	if _, err := e.filter(); err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return e
+}
+
+// filter returns the line filter of this expression only.
+func (e *filterExpr) filter() (LineFilter, error) {
+	if !e.ip {
+		return newFilter(e.match, e.ty)
+	}
+	f, err := newIPFilter(e.match)
+	if err != nil {
+		return nil, err
+	}
+	switch e.ty {
+	case labels.MatchEqual:
+		return f, nil
+	case labels.MatchNotEqual:
+		return newNotFilter(f), nil
+	default:
+		return nil, fmt.Errorf("ip() is only supported by the |= and != line filters")
+	}
+}
+
 func (e *filterExpr) Matchers() []*labels.Matcher {
 	return e.left.Matchers()
 }
@@ -137,23 +185,25 @@ func (e *filterExpr) String() string {
 	case labels.MatchNotEqual:
 		sb.WriteString("!=")
 	}
+	if e.ip {
+		sb.WriteString(OpFilterIP + "(" + strconv.Quote(e.match) + ")")
+		return sb.String()
+	}
 	sb.WriteString(strconv.Quote(e.match))
 	return sb.String()
 }
 
 func (e *filterExpr) Filter() (LineFilter, error) {
-	f, err := newFilter(e.match, e.ty)
+	f, err := e.filter()
 	if err != nil {
 		return nil, err
 	}
-	if nextExpr, ok := e.left.(*filterExpr); ok {
-		nextFilter, err := nextExpr.Filter()
-		if err != nil {
-			return nil, err
-		}
-		if nextFilter != nil {
-			f = newAndFilter(nextFilter, f)
-		}
+	nextFilter, err := e.left.Filter()
+	if err != nil {
+		return nil, err
+	}
+	if nextFilter != nil {
+		f = newAndFilter(nextFilter, f)
 	}
 
 	if f == TrueFilter {
@@ -163,9 +213,295 @@ func (e *filterExpr) Filter() (LineFilter, error) {
 	return f, nil
 }
 
+func (e *filterExpr) Pipeline() (Pipeline, error) {
+	return newPipelineFromExpr(e)
+}
+
+func (e *filterExpr) HasFilter() bool {
+	if e.left.HasFilter() {
+		return true
+	}
+	f, err := newFilter(e.match, e.ty)
+	return err == nil && f != TrueFilter
+}
+
 // impl Expr
 func (e *filterExpr) logQLExpr() {}
 
+type labelParserExpr struct {
+	left  LogSelectorExpr
+	op    string
+	param string
+}
+
+func mustNewLabelParserExpr(op, param string) *labelParserExpr {
+	e := &labelParserExpr{
+		op:    op,
+		param: param,
+	}
+	// validate the parser at parse time, e.g. the regexp must compile.
+	if _, err := e.parser(); err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return e
+}
+
+func addParserToLogExpr(left LogSelectorExpr, p *labelParserExpr) LogSelectorExpr {
+	p.left = left
+	return p
+}
+
+func (e *labelParserExpr) parser() (Stage, error) {
+	switch e.op {
+	case OpParserTypeJSON:
+		return NewJSONParser(), nil
+	case OpParserTypeLogfmt:
+		return NewLogfmtParser(), nil
+	case OpParserTypeRegexp:
+		return NewRegexpParser(e.param)
+	case OpParserTypePattern:
+		return NewPatternParser(e.param)
+	default:
+		return nil, fmt.Errorf("unknown parser operator: %s", e.op)
+	}
+}
+
+func (e *labelParserExpr) Matchers() []*labels.Matcher {
+	return e.left.Matchers()
+}
+
+func (e *labelParserExpr) Filter() (LineFilter, error) {
+	return e.left.Filter()
+}
+
+func (e *labelParserExpr) Pipeline() (Pipeline, error) {
+	return newPipelineFromExpr(e)
+}
+
+func (e *labelParserExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.left.String())
+	sb.WriteString(" | ")
+	sb.WriteString(e.op)
+	if e.param != "" {
+		sb.WriteString(" ")
+		sb.WriteString(strconv.Quote(e.param))
+	}
+	return sb.String()
+}
+
+func (e *labelParserExpr) HasFilter() bool {
+	return e.left.HasFilter()
+}
+
+// impl Expr
+func (e *labelParserExpr) logQLExpr() {}
+
+type labelFilterExpr struct {
+	left LogSelectorExpr
+	LabelFilterer
+}
+
+func addLabelFilterToLogExpr(left LogSelectorExpr, filter LabelFilterer) LogSelectorExpr {
+	return &labelFilterExpr{
+		left:          left,
+		LabelFilterer: filter,
+	}
+}
+
+func (e *labelFilterExpr) Matchers() []*labels.Matcher {
+	return e.left.Matchers()
+}
+
+func (e *labelFilterExpr) Filter() (LineFilter, error) {
+	return e.left.Filter()
+}
+
+func (e *labelFilterExpr) Pipeline() (Pipeline, error) {
+	return newPipelineFromExpr(e)
+}
+
+func (e *labelFilterExpr) HasFilter() bool {
+	return true
+}
+
+func (e *labelFilterExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.left.String())
+	sb.WriteString(" " + OpPipe + " ")
+	sb.WriteString(e.LabelFilterer.String())
+	return sb.String()
+}
+
+// impl Expr
+func (e *labelFilterExpr) logQLExpr() {}
+
+type lineFmtExpr struct {
+	left  LogSelectorExpr
+	value string
+}
+
+func mustNewLineFmtExpr(value string) *lineFmtExpr {
+	// validate the template at parse time.
+	if _, err := NewFormatter(value); err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return &lineFmtExpr{
+		value: value,
+	}
+}
+
+func addLineFmtToLogExpr(left LogSelectorExpr, f *lineFmtExpr) LogSelectorExpr {
+	f.left = left
+	return f
+}
+
+func (e *lineFmtExpr) Matchers() []*labels.Matcher {
+	return e.left.Matchers()
+}
+
+// Filter returns an error since the following line filters would apply on the formatted line.
+func (e *lineFmtExpr) Filter() (LineFilter, error) {
+	return nil, fmt.Errorf("line filters can't be applied to the original log line after %s", OpFmtLine)
+}
+
+func (e *lineFmtExpr) Pipeline() (Pipeline, error) {
+	return newPipelineFromExpr(e)
+}
+
+func (e *lineFmtExpr) HasFilter() bool {
+	return e.left.HasFilter()
+}
+
+func (e *lineFmtExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.left.String())
+	sb.WriteString(" " + OpPipe + " " + OpFmtLine + " ")
+	sb.WriteString(strconv.Quote(e.value))
+	return sb.String()
+}
+
+// impl Expr
+func (e *lineFmtExpr) logQLExpr() {}
+
+type labelFmtExpr struct {
+	left    LogSelectorExpr
+	formats []LabelFmt
+}
+
+func mustNewLabelFmtExpr(fmts []LabelFmt) *labelFmtExpr {
+	// validate the label formats and their templates at parse time.
+	if _, err := NewLabelsFormatter(fmts); err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return &labelFmtExpr{
+		formats: fmts,
+	}
+}
+
+func addLabelFmtToLogExpr(left LogSelectorExpr, f *labelFmtExpr) LogSelectorExpr {
+	f.left = left
+	return f
+}
+
+func (e *labelFmtExpr) Matchers() []*labels.Matcher {
+	return e.left.Matchers()
+}
+
+func (e *labelFmtExpr) Filter() (LineFilter, error) {
+	return e.left.Filter()
+}
+
+func (e *labelFmtExpr) Pipeline() (Pipeline, error) {
+	return newPipelineFromExpr(e)
+}
+
+func (e *labelFmtExpr) HasFilter() bool {
+	return e.left.HasFilter()
+}
+
+func (e *labelFmtExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.left.String())
+	sb.WriteString(" " + OpPipe + " " + OpFmtLabel + " ")
+	for i, f := range e.formats {
+		sb.WriteString(f.String())
+		if i+1 != len(e.formats) {
+			sb.WriteString(",")
+		}
+	}
+	return sb.String()
+}
+
+// impl Expr
+func (e *labelFmtExpr) logQLExpr() {}
+
+// newPipelineFromExpr creates the pipeline of a log selector expression.
+func newPipelineFromExpr(e LogSelectorExpr) (Pipeline, error) {
+	stages, err := pipelineStages(e)
+	if err != nil {
+		return nil, err
+	}
+	return NewPipeline(stages), nil
+}
+
+// pipelineStages returns in order all the stages of a log selector expression.
+func pipelineStages(e LogSelectorExpr) ([]Stage, error) {
+	switch expr := e.(type) {
+	case *filterExpr:
+		stages, err := pipelineStages(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		f, err := expr.filter()
+		if err != nil {
+			return nil, err
+		}
+		if f == TrueFilter {
+			return stages, nil
+		}
+		return append(stages, lineFilterStage{LineFilter: f}), nil
+	case *labelParserExpr:
+		stages, err := pipelineStages(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		p, err := expr.parser()
+		if err != nil {
+			return nil, err
+		}
+		return append(stages, p), nil
+	case *labelFilterExpr:
+		stages, err := pipelineStages(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		return append(stages, expr.LabelFilterer), nil
+	case *lineFmtExpr:
+		stages, err := pipelineStages(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		f, err := NewFormatter(expr.value)
+		if err != nil {
+			return nil, err
+		}
+		return append(stages, f), nil
+	case *labelFmtExpr:
+		stages, err := pipelineStages(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		f, err := NewLabelsFormatter(expr.formats)
+		if err != nil {
+			return nil, err
+		}
+		return append(stages, f), nil
+	default:
+		return nil, nil
+	}
+}
+
 func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
 	m, err := labels.NewMatcher(t, n, v)
 	if err != nil {
@@ -177,20 +513,87 @@ func mustNewMatcher(t labels.MatchType, n, v string) *labels.Matcher {
 type logRange struct {
 	left     LogSelectorExpr
 	interval time.Duration
+	offset   time.Duration
+
+	unwrap *unwrapExpr
 }
 
 // impls Stringer
 func (r logRange) String() string {
 	var sb strings.Builder
 	sb.WriteString(r.left.String())
+	if r.unwrap != nil {
+		sb.WriteString(r.unwrap.String())
+	}
 	sb.WriteString(fmt.Sprintf("[%v]", model.Duration(r.interval)))
+	if r.offset != 0 {
+		sb.WriteString(fmt.Sprintf(" %s %v", OpOffset, model.Duration(r.offset)))
+	}
 	return sb.String()
 }
 
-func newLogRange(left LogSelectorExpr, interval time.Duration) *logRange {
+func newLogRange(left LogSelectorExpr, interval time.Duration, u *unwrapExpr, offset time.Duration) *logRange {
 	return &logRange{
 		left:     left,
 		interval: interval,
+		unwrap:   u,
+		offset:   offset,
+	}
+}
+
+// RemoveOffset removes the offset shared by all the range aggregations of a sample expression and returns it.
+// The expression without offset evaluated over a time range shifted back by the offset returns the same samples,
+// shifted back by the offset too.
+// The expression is not modified and false is returned when there's no offset or when offsets are different.
+func RemoveOffset(expr SampleExpr) (time.Duration, bool) {
+	ranges, ok := logRanges(expr)
+	if !ok || len(ranges) == 0 {
+		return 0, false
+	}
+	offset := ranges[0].offset
+	for _, r := range ranges[1:] {
+		if r.offset != offset {
+			return 0, false
+		}
+	}
+	if offset == 0 {
+		return 0, false
+	}
+	for _, r := range ranges {
+		r.offset = 0
+	}
+	return offset, true
+}
+
+// logRanges returns all the log ranges of a sample expression.
+// It returns false if the expression contains an unknown node.
+func logRanges(expr SampleExpr) ([]*logRange, bool) {
+	switch e := expr.(type) {
+	case *literalExpr, *vectorExpr:
+		return nil, true
+	case *rangeAggregationExpr:
+		return []*logRange{e.left}, true
+	case *vectorAggregationExpr:
+		return logRanges(e.left)
+	case *labelReplaceExpr:
+		return logRanges(e.left)
+	case *functionExpr:
+		return logRanges(e.left)
+	case *binOpExpr:
+		lhs, ok := logRanges(e.SampleExpr)
+		if !ok {
+			return nil, false
+		}
+		rhs, ok := logRanges(e.RHS)
+		if !ok {
+			return nil, false
+		}
+		return append(lhs, rhs...), true
+	case *subqueryAggregationExpr:
+		// the steps of subqueries are aligned on absolute time, they can't be shifted by any offset.
+		return nil, false
+	default:
+		return nil, false
 	}
 }
 
@@ -203,6 +606,63 @@ func addFilterToLogRangeExpr(left *logRange, ty labels.MatchType, match string)
 	return left
 }
 
+func addIPFilterToLogRangeExpr(left *logRange, ty labels.MatchType, rng string) *logRange {
+	left.left = mustNewIPFilterExpr(left.left, ty, rng)
+	return left
+}
+
+func addParserToLogRangeExpr(left *logRange, p *labelParserExpr) *logRange {
+	left.left = addParserToLogExpr(left.left, p)
+	return left
+}
+
+func addLabelFilterToLogRangeExpr(left *logRange, filter LabelFilterer) *logRange {
+	left.left = addLabelFilterToLogExpr(left.left, filter)
+	return left
+}
+
+func addLineFmtToLogRangeExpr(left *logRange, f *lineFmtExpr) *logRange {
+	left.left = addLineFmtToLogExpr(left.left, f)
+	return left
+}
+
+func addLabelFmtToLogRangeExpr(left *logRange, f *labelFmtExpr) *logRange {
+	left.left = addLabelFmtToLogExpr(left.left, f)
+	return left
+}
+
+// unwrapExpr extracts the value of a label as the sample value of a range.
+type unwrapExpr struct {
+	identifier string
+	operation  string
+
+	postFilters []LabelFilterer
+}
+
+func newUnwrapExpr(id string, operation string) *unwrapExpr {
+	return &unwrapExpr{identifier: id, operation: operation}
+}
+
+func (u *unwrapExpr) addPostFilter(f LabelFilterer) *unwrapExpr {
+	u.postFilters = append(u.postFilters, f)
+	return u
+}
+
+func (u unwrapExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(" " + OpPipe + " " + OpUnwrap + " ")
+	if u.operation != "" {
+		sb.WriteString(fmt.Sprintf("%s(%s)", u.operation, u.identifier))
+	} else {
+		sb.WriteString(u.identifier)
+	}
+	for _, f := range u.postFilters {
+		sb.WriteString(" " + OpPipe + " ")
+		sb.WriteString(f.String())
+	}
+	return sb.String()
+}
+
 const (
 	// vector ops
 	OpTypeSum     = "sum"
@@ -220,6 +680,14 @@ const (
 	OpRangeTypeRate      = "rate"
 	OpRangeTypeBytes     = "bytes_over_time"
 	OpRangeTypeBytesRate = "bytes_rate"
+	OpRangeTypeAvg       = "avg_over_time"
+	OpRangeTypeSum       = "sum_over_time"
+	OpRangeTypeMin       = "min_over_time"
+	OpRangeTypeMax       = "max_over_time"
+	OpRangeTypeStdvar    = "stdvar_over_time"
+	OpRangeTypeStddev    = "stddev_over_time"
+	OpRangeTypeQuantile  = "quantile_over_time"
+	OpRangeTypeAbsent    = "absent_over_time"
 
 	// binops - logical/set
 	OpTypeOr     = "or"
@@ -241,6 +709,48 @@ const (
 	OpTypeGTE   = ">="
 	OpTypeLT    = "<"
 	OpTypeLTE   = "<="
+
+	// parsers
+	OpParserTypeJSON    = "json"
+	OpParserTypeLogfmt  = "logfmt"
+	OpParserTypeRegexp  = "regexp"
+	OpParserTypePattern = "pattern"
+
+	// formatters
+	OpFmtLine  = "line_format"
+	OpFmtLabel = "label_format"
+
+	OpPipe   = "|"
+	OpUnwrap = "unwrap"
+	OpOffset = "offset"
+
+	// OpFilterIP matches ip ranges in line and label filters.
+	OpFilterIP = "ip"
+
+	// OpSubquery is the operation of subqueries in the list of operations of an expression, it's not a keyword.
+	OpSubquery = "subquery"
+
+	// vector matching
+	OpOn         = "on"
+	OpIgnoring   = "ignoring"
+	OpGroupLeft  = "group_left"
+	OpGroupRight = "group_right"
+
+	// conversion ops of unwrapped labels
+	OpConvBytes    = "bytes"
+	OpConvDuration = "duration"
+
+	// functions
+	OpFuncLabelReplace = "label_replace"
+	OpFuncVector       = "vector"
+	OpFuncClampMin     = "clamp_min"
+	OpFuncClampMax     = "clamp_max"
+	OpFuncAbs          = "abs"
+	OpFuncRound        = "round"
+	OpFuncCeil         = "ceil"
+	OpFuncFloor        = "floor"
+	OpFuncSort         = "sort"
+	OpFuncSortDesc     = "sort_desc"
 )
 
 func IsComparisonOperator(op string) bool {
@@ -275,6 +785,8 @@ type SampleExpr interface {
 type rangeAggregationExpr struct {
 	left      *logRange
 	operation string
+
+	params *float64
 }
 
 func newRangeAggregationExpr(left *logRange, operation string) SampleExpr {
@@ -284,6 +796,52 @@ func newRangeAggregationExpr(left *logRange, operation string) SampleExpr {
 	}
 }
 
+func mustNewRangeAggregationExpr(left *logRange, operation string, params *string) SampleExpr {
+	e := &rangeAggregationExpr{
+		left:      left,
+		operation: operation,
+	}
+	if operation == OpRangeTypeQuantile {
+		if params == nil {
+			panic(newParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0))
+		}
+		p, err := strconv.ParseFloat(*params, 64)
+		if err != nil {
+			panic(newParseError(fmt.Sprintf("invalid parameter %s(%s,", operation, *params), 0, 0))
+		}
+		e.params = &p
+	} else if params != nil {
+		panic(newParseError(fmt.Sprintf("unsupported parameter for operation %s(%s,", operation, *params), 0, 0))
+	}
+	if err := e.validate(); err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return e
+}
+
+// validate checks that the operation is used with an unwrapped range when it aggregates sample values,
+// and without one when it counts log lines or bytes.
+func (e *rangeAggregationExpr) validate() error {
+	switch e.operation {
+	case OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeBytes, OpRangeTypeBytesRate:
+		if e.left.unwrap != nil {
+			return fmt.Errorf("invalid aggregation %s with %s", e.operation, OpUnwrap)
+		}
+		return nil
+	case OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin,
+		OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile:
+		if e.left.unwrap == nil {
+			return fmt.Errorf("invalid aggregation %s without %s", e.operation, OpUnwrap)
+		}
+		return nil
+	case OpRangeTypeAbsent:
+		// absent_over_time only checks for the presence of samples, with or without unwrap.
+		return nil
+	default:
+		return fmt.Errorf(unsupportedErr, e.operation)
+	}
+}
+
 func (e *rangeAggregationExpr) Selector() LogSelectorExpr {
 	return e.left.left
 }
@@ -293,6 +851,9 @@ func (e *rangeAggregationExpr) logQLExpr() {}
 
 // impls Stringer
 func (e *rangeAggregationExpr) String() string {
+	if e.params != nil {
+		return formatOperation(e.operation, nil, strconv.FormatFloat(*e.params, 'f', -1, 64), e.left.String())
+	}
 	return formatOperation(e.operation, nil, e.left.String())
 }
 
@@ -301,6 +862,109 @@ func (e *rangeAggregationExpr) Operations() []string {
 	return []string{e.operation}
 }
 
+// subqueryRange is the range and the resolution of a subquery, e.g. `[1h:1m]`.
+type subqueryRange struct {
+	interval time.Duration
+	// step is the resolution of the subquery, zero uses the query step.
+	step time.Duration
+}
+
+// subqueryExpr evaluates a metric query over a range at a given resolution, e.g. `sum(rate({app="foo"}[1m]))[1h:1m]`.
+type subqueryExpr struct {
+	left   SampleExpr
+	rng    subqueryRange
+	offset time.Duration
+}
+
+func newSubqueryExpr(left SampleExpr, rng subqueryRange, offset time.Duration) *subqueryExpr {
+	return &subqueryExpr{
+		left:   left,
+		rng:    rng,
+		offset: offset,
+	}
+}
+
+// impls Stringer
+func (e subqueryExpr) String() string {
+	var sb strings.Builder
+	if _, ok := e.left.(*binOpExpr); ok {
+		sb.WriteString("(")
+		sb.WriteString(e.left.String())
+		sb.WriteString(")")
+	} else {
+		sb.WriteString(e.left.String())
+	}
+	sb.WriteString(fmt.Sprintf("[%v:", model.Duration(e.rng.interval)))
+	if e.rng.step != 0 {
+		sb.WriteString(model.Duration(e.rng.step).String())
+	}
+	sb.WriteString("]")
+	if e.offset != 0 {
+		sb.WriteString(fmt.Sprintf(" %s %v", OpOffset, model.Duration(e.offset)))
+	}
+	return sb.String()
+}
+
+// subqueryAggregationExpr aggregates the samples of a subquery over its range,
+// e.g. `max_over_time(sum(rate({app="foo"}[1m]))[1h:1m])`.
+type subqueryAggregationExpr struct {
+	left      *subqueryExpr
+	operation string
+
+	params *float64
+}
+
+func mustNewSubqueryAggregationExpr(left *subqueryExpr, operation string, params *string) SampleExpr {
+	e := &subqueryAggregationExpr{
+		left:      left,
+		operation: operation,
+	}
+	if operation == OpRangeTypeQuantile {
+		if params == nil {
+			panic(newParseError(fmt.Sprintf("parameter required for operation %s", operation), 0, 0))
+		}
+		p, err := strconv.ParseFloat(*params, 64)
+		if err != nil {
+			panic(newParseError(fmt.Sprintf("invalid parameter %s(%s,", operation, *params), 0, 0))
+		}
+		e.params = &p
+	} else if params != nil {
+		panic(newParseError(fmt.Sprintf("unsupported parameter for operation %s(%s,", operation, *params), 0, 0))
+	}
+	switch operation {
+	case OpRangeTypeCount, OpRangeTypeAvg, OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin,
+		OpRangeTypeStddev, OpRangeTypeStdvar, OpRangeTypeQuantile, OpRangeTypeAbsent:
+	default:
+		// rate, bytes_rate and bytes_over_time are about log lines, not samples.
+		panic(newParseError(fmt.Sprintf("invalid aggregation %s over a subquery", operation), 0, 0))
+	}
+	return e
+}
+
+func (e *subqueryAggregationExpr) Selector() LogSelectorExpr {
+	return e.left.left.Selector()
+}
+
+func (e *subqueryAggregationExpr) Extractor() (SampleExtractor, error) {
+	return e.left.left.Extractor()
+}
+
+// impl Expr
+func (e *subqueryAggregationExpr) logQLExpr() {}
+
+// impls Stringer
+func (e *subqueryAggregationExpr) String() string {
+	if e.params != nil {
+		return formatOperation(e.operation, nil, strconv.FormatFloat(*e.params, 'f', -1, 64), e.left.String())
+	}
+	return formatOperation(e.operation, nil, e.left.String())
+}
+
+// impl SampleExpr
+func (e *subqueryAggregationExpr) Operations() []string {
+	return append(e.left.left.Operations(), OpSubquery, e.operation)
+}
+
 type grouping struct {
 	groups  []string
 	without bool
@@ -387,7 +1051,75 @@ func (e *vectorAggregationExpr) Operations() []string {
 }
 
 type BinOpOptions struct {
-	ReturnBool bool
+	ReturnBool     bool
+	VectorMatching *VectorMatching
+}
+
+// VectorMatchCardinality describes the cardinality relationship
+// of two vectors in a binary operation.
+type VectorMatchCardinality int
+
+const (
+	CardOneToOne VectorMatchCardinality = iota
+	CardManyToOne
+	CardOneToMany
+	CardManyToMany
+)
+
+func (vmc VectorMatchCardinality) String() string {
+	switch vmc {
+	case CardOneToOne:
+		return "one-to-one"
+	case CardManyToOne:
+		return "many-to-one"
+	case CardOneToMany:
+		return "one-to-many"
+	case CardManyToMany:
+		return "many-to-many"
+	}
+	panic("logql.VectorMatchCardinality.String: unknown match cardinality")
+}
+
+// VectorMatching describes how elements from two vectors in a binary
+// operation are supposed to be matched.
+type VectorMatching struct {
+	// The cardinality of the two vectors.
+	Card VectorMatchCardinality
+	// MatchingLabels contains the labels which define equality of a pair of
+	// elements from the vectors.
+	MatchingLabels []string
+	// On includes the given label names from matching,
+	// rather than excluding them.
+	On bool
+	// Include contains additional labels that should be included in
+	// the result from the side with the lower cardinality.
+	Include []string
+}
+
+func (m *VectorMatching) String() string {
+	var sb strings.Builder
+	if m.On {
+		sb.WriteString(OpOn)
+	} else {
+		sb.WriteString(OpIgnoring)
+	}
+	sb.WriteString("(")
+	sb.WriteString(strings.Join(m.MatchingLabels, ","))
+	sb.WriteString(")")
+	switch m.Card {
+	case CardManyToOne:
+		sb.WriteString(" ")
+		sb.WriteString(OpGroupLeft)
+	case CardOneToMany:
+		sb.WriteString(" ")
+		sb.WriteString(OpGroupRight)
+	default:
+		return sb.String()
+	}
+	sb.WriteString("(")
+	sb.WriteString(strings.Join(m.Include, ","))
+	sb.WriteString(")")
+	return sb.String()
 }
 
 type binOpExpr struct {
@@ -398,15 +1130,38 @@ type binOpExpr struct {
 }
 
 func (e *binOpExpr) String() string {
+	var sb strings.Builder
+	sb.WriteString(e.SampleExpr.String())
+	sb.WriteString(" ")
+	sb.WriteString(e.op)
+	sb.WriteString(" ")
 	if e.opts.ReturnBool {
-		return fmt.Sprintf("%s %s bool %s", e.SampleExpr.String(), e.op, e.RHS.String())
+		sb.WriteString("bool ")
+	}
+	if e.opts.VectorMatching != nil {
+		sb.WriteString(e.opts.VectorMatching.String())
+		sb.WriteString(" ")
 	}
-	return fmt.Sprintf("%s %s %s", e.SampleExpr.String(), e.op, e.RHS.String())
+	sb.WriteString(e.RHS.String())
+	return sb.String()
 }
 
 // impl SampleExpr
 func (e *binOpExpr) Operations() []string {
 	ops := append(e.SampleExpr.Operations(), e.RHS.Operations()...)
+	if m := e.opts.VectorMatching; m != nil {
+		if m.On {
+			ops = append(ops, OpOn)
+		} else {
+			ops = append(ops, OpIgnoring)
+		}
+		switch m.Card {
+		case CardManyToOne:
+			ops = append(ops, OpGroupLeft)
+		case CardOneToMany:
+			ops = append(ops, OpGroupRight)
+		}
+	}
 	return append(ops, e.op)
 }
 
@@ -450,6 +1205,36 @@ func mustNewBinOpExpr(op string, opts BinOpOptions, lhs, rhs Expr) SampleExpr {
 		}
 	}
 
+	if opts.VectorMatching != nil {
+		if lOk || rOk {
+			panic(newParseError(fmt.Sprintf(
+				"vector matching only allowed between vectors in binary operation (%s)",
+				op,
+			), 0, 0))
+		}
+		if IsLogicalBinOp(op) {
+			if opts.VectorMatching.Card != CardOneToOne {
+				panic(newParseError(fmt.Sprintf(
+					"no grouping allowed for logical/set binary operation (%s)",
+					op,
+				), 0, 0))
+			}
+			opts.VectorMatching.Card = CardManyToMany
+		}
+		if opts.VectorMatching.On {
+			for _, l := range opts.VectorMatching.Include {
+				for _, m := range opts.VectorMatching.MatchingLabels {
+					if l == m {
+						panic(newParseError(fmt.Sprintf(
+							"label %s must not occur in on and group clauses at once",
+							l,
+						), 0, 0))
+					}
+				}
+			}
+		}
+	}
+
 	// map expr like (1+1) -> 2
 	if lOk && rOk {
 		return reduceBinOp(op, leftLit, rightLit)
@@ -496,6 +1281,14 @@ func mustNewLiteralExpr(s string, invert bool) *literalExpr {
 	}
 }
 
+func mustNewFloat(s string) float64 {
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		panic(newParseError(fmt.Sprintf("unable to parse float: %s", err.Error()), 0, 0))
+	}
+	return n
+}
+
 func (e *literalExpr) logQLExpr() {}
 
 func (e *literalExpr) String() string {
@@ -509,8 +1302,156 @@ func (e *literalExpr) Selector() LogSelectorExpr           { return e }
 func (e *literalExpr) Operations() []string                { return nil }
 func (e *literalExpr) Filter() (LineFilter, error)         { return nil, nil }
 func (e *literalExpr) Matchers() []*labels.Matcher         { return nil }
+func (e *literalExpr) Pipeline() (Pipeline, error)         { return NoopPipeline, nil }
+func (e *literalExpr) HasFilter() bool                     { return false }
 func (e *literalExpr) Extractor() (SampleExtractor, error) { return nil, nil }
 
+// vectorExpr returns a vector with a single sample without labels at each step, e.g. `vector(1)`.
+type vectorExpr struct {
+	value float64
+}
+
+func mustNewVectorExpr(lit *literalExpr) *vectorExpr {
+	return &vectorExpr{
+		value: lit.value,
+	}
+}
+
+func (e *vectorExpr) logQLExpr() {}
+
+func (e *vectorExpr) String() string {
+	return formatOperation(OpFuncVector, nil, strconv.FormatFloat(e.value, 'f', -1, 64))
+}
+
+// vectorExpr impls SampleExpr & LogSelectorExpr like literalExpr does, it doesn't select any logs.
+func (e *vectorExpr) Selector() LogSelectorExpr           { return e }
+func (e *vectorExpr) Operations() []string                { return []string{OpFuncVector} }
+func (e *vectorExpr) Filter() (LineFilter, error)         { return nil, nil }
+func (e *vectorExpr) Matchers() []*labels.Matcher         { return nil }
+func (e *vectorExpr) Pipeline() (Pipeline, error)         { return NoopPipeline, nil }
+func (e *vectorExpr) HasFilter() bool                     { return false }
+func (e *vectorExpr) Extractor() (SampleExtractor, error) { return nil, nil }
+
+// labelReplaceExpr sets the destination label of each series to the expanded replacement
+// when the regular expression matches the value of the source label.
+type labelReplaceExpr struct {
+	left SampleExpr
+
+	dst         string
+	replacement string
+	src         string
+	regex       string
+	re          *regexp.Regexp
+}
+
+func mustNewLabelReplaceExpr(left SampleExpr, dst, replacement, src, regex string) *labelReplaceExpr {
+	re, err := regexp.Compile("^(?:" + regex + ")$")
+	if err != nil {
+		panic(newParseError(fmt.Sprintf("invalid regular expression in %s(): %s", OpFuncLabelReplace, regex), 0, 0))
+	}
+	if !model.LabelNameRE.MatchString(dst) {
+		panic(newParseError(fmt.Sprintf("invalid destination label name in %s(): %s", OpFuncLabelReplace, dst), 0, 0))
+	}
+	return &labelReplaceExpr{
+		left:        left,
+		dst:         dst,
+		replacement: replacement,
+		src:         src,
+		regex:       regex,
+		re:          re,
+	}
+}
+
+func (e *labelReplaceExpr) Selector() LogSelectorExpr {
+	return e.left.Selector()
+}
+
+func (e *labelReplaceExpr) Extractor() (SampleExtractor, error) {
+	return e.left.Extractor()
+}
+
+// impl Expr
+func (e *labelReplaceExpr) logQLExpr() {}
+
+func (e *labelReplaceExpr) String() string {
+	return formatOperation(
+		OpFuncLabelReplace,
+		nil,
+		e.left.String(),
+		strconv.Quote(e.dst),
+		strconv.Quote(e.replacement),
+		strconv.Quote(e.src),
+		strconv.Quote(e.regex),
+	)
+}
+
+// impl SampleExpr
+func (e *labelReplaceExpr) Operations() []string {
+	return append(e.left.Operations(), OpFuncLabelReplace)
+}
+
+// functionExpr applies a math or sort function to the samples of each step, e.g. `abs(x)` or `clamp_min(x, 0)`.
+type functionExpr struct {
+	left SampleExpr
+
+	function string
+	param    *float64
+}
+
+func mustNewFunctionExpr(left SampleExpr, function string, param *literalExpr) *functionExpr {
+	e := &functionExpr{
+		left:     left,
+		function: function,
+	}
+	switch function {
+	case OpFuncClampMin, OpFuncClampMax:
+		if param == nil {
+			panic(newParseError(fmt.Sprintf("parameter required for function %s", function), 0, 0))
+		}
+		e.param = &param.value
+	case OpFuncRound:
+		if param != nil {
+			e.param = &param.value
+		}
+	default:
+		if param != nil {
+			panic(newParseError(fmt.Sprintf("unsupported parameter for function %s", function), 0, 0))
+		}
+	}
+	return e
+}
+
+func (e *functionExpr) Selector() LogSelectorExpr {
+	return e.left.Selector()
+}
+
+func (e *functionExpr) Extractor() (SampleExtractor, error) {
+	return e.left.Extractor()
+}
+
+// impl Expr
+func (e *functionExpr) logQLExpr() {}
+
+func (e *functionExpr) String() string {
+	if e.param != nil {
+		return formatOperation(e.function, nil, e.left.String(), strconv.FormatFloat(*e.param, 'f', -1, 64))
+	}
+	return formatOperation(e.function, nil, e.left.String())
+}
+
+// impl SampleExpr
+func (e *functionExpr) Operations() []string {
+	return append(e.left.Operations(), e.function)
+}
+
+// isSortExpr tells whether the samples of the expression are ordered by value instead of by labels.
+func isSortExpr(expr SampleExpr) bool {
+	if e, ok := expr.(*functionExpr); ok {
+		return e.function == OpFuncSort || e.function == OpFuncSortDesc
+	}
+	return false
+}
+
 // helper used to impl Stringer for vector and range aggregations
 // nolint:interfacer
 func formatOperation(op string, grouping *grouping, params ...string) string {
@@ -69,6 +69,34 @@ func Test_SampleExpr_String(t *testing.T) {
 		/
 			count_over_time({namespace="tns"}[5m])
 		)`,
+		`sum by (status) (count_over_time({app="api"} | json [5m]))`,
+		`sum by (level) (rate({app="api"} |= "error" | logfmt [5m]))`,
+		`count_over_time({app="api"} | regexp "(?P<method>\\w+) (?P<path>[\\w|/]+)" |= "GET" [5m])`,
+		`sum by (method) (rate({app="api"} | json | status >= 500 and (latency > 250ms or size > 10KB) [5m]))`,
+		`count_over_time({app="api"} | logfmt | level=~"warn|error" or __error__!="" [1m])`,
+		`sum by (env) (count_over_time({app="api"} | json | label_format env="{{.cluster}}-{{.namespace}}",level=lvl [5m]))`,
+		`bytes_over_time({app="api"} | logfmt | line_format "{{.method}} {{.path}}" [1m])`,
+		`sum by (method) (sum_over_time({app="api"} | json | unwrap latency [5m]))`,
+		`avg_over_time({app="api"} | logfmt | unwrap duration(response_time) | __error__="" [1m])`,
+		`max_over_time({app="api"} | json | unwrap bytes(size) [1m])`,
+		`quantile_over_time(0.99, {app="api"} | json | unwrap duration(latency) [5m])`,
+		`stddev_over_time({app="api"} | json | duration > 1s | unwrap duration [5m])`,
+		`sum by (app) (rate({app="api"}[5m])) / on(app) group_left(team) sum by (app, team, pod) (rate({app="api"}[5m]))`,
+		`sum by (app, pod) (rate({app="api"}[5m])) > bool ignoring(pod) group_right() sum by (app) (rate({app="api"}[5m]))`,
+		`count_over_time({app="api"}[5m]) unless on() count_over_time({app="db"}[5m])`,
+		`sum(count_over_time({app="api"}[5m] offset 1w)) / sum(count_over_time({app="api"}[5m]))`,
+		`avg_over_time({app="api"} | json | unwrap latency [5m] offset 1d)`,
+		`absent_over_time({app="api"} |= "error" [5m])`,
+		`sum(rate({app="api"}[5m])) or vector(0)`,
+		`label_replace(rate({app="api"}[5m]), "service", "$1-svc", "app", "(.*)")`,
+		`clamp_max(round(sum by (app) (rate({app="api"}[5m])), 0.1), 10)`,
+		`sort_desc(abs(ceil(floor(rate({app="api"}[5m])))))`,
+		`sum by (status) (count_over_time({app="nginx"} | pattern "<_> \"<method> <path> <_>\" <status> <_>" [5m]))`,
+		`count_over_time({app="api"} |= ip("10.0.0.0/8") != ip("10.0.0.1") [5m])`,
+		`{app="api"} | json | addr != ip("192.168.0.1-192.168.0.255") and addr == ip("2001:db8::/32")`,
+		`max_over_time(sum(rate({app="api"}[1m]))[1h:1m])`,
+		`avg_over_time((sum(rate({app="api"}[1m])) / sum(rate({app="db"}[1m])))[1h:] offset 1d)`,
+		`quantile_over_time(0.99, max_over_time(rate({app="api"}[1m])[10m:1m])[1h:5m])`,
 	} {
 		t.Run(tc, func(t *testing.T) {
 			expr, err := ParseExpr(tc)
@@ -81,6 +109,32 @@ func Test_SampleExpr_String(t *testing.T) {
 	}
 }
 
+func Test_labelParserExpr_String(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []string{
+		`{app="foo"} | json`,
+		`{app="foo"} |= "bar" | logfmt`,
+		`{app="foo"} | regexp "(?P<foo>\\w+)" != "buzz"`,
+		`{app="foo"} | json | logfmt`,
+		`{app="foo"} | json | status == 500 or latency <= 1m30s | size > 1234B`,
+		`{app="foo"} | logfmt | (level="error" and (duration >= 2s or size < 1.5MiB)) != "debug"`,
+		`{app="foo"} | json | line_format "{{.status}} \"{{.message | ToUpper}}\""`,
+		`{app="foo"} | logfmt | label_format dst=src | line_format "{{.dst}}" |= "error"`,
+		`{app="foo"} | label_format foo="{{ Replace .bar \"-\" \"_\" -1 }}",baz=buzz`,
+	} {
+		tc := tc
+		t.Run(tc, func(t *testing.T) {
+			t.Parallel()
+			expr, err := ParseLogSelector(tc)
+			require.Nil(t, err)
+
+			expr2, err := ParseLogSelector(expr.String())
+			require.Nil(t, err)
+			require.Equal(t, expr, expr2)
+		})
+	}
+}
+
 func Test_NilFilterDoesntPanic(t *testing.T) {
 	t.Parallel()
 	for _, tc := range []string{
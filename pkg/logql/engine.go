@@ -16,6 +16,7 @@ import (
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/promql"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/helpers"
 	"github.com/grafana/loki/pkg/iter"
@@ -66,11 +67,14 @@ type EngineOpts struct {
 	// MaxLookBackPeriod is the maximum amount of time to look back for log lines.
 	// only used for instant log queries.
 	MaxLookBackPeriod time.Duration `yaml:"max_look_back_period"`
+	// MaxSubquerySteps is the maximum number of steps evaluated by a subquery.
+	MaxSubquerySteps int `yaml:"max_subquery_steps"`
 }
 
 func (opts *EngineOpts) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
 	f.DurationVar(&opts.Timeout, prefix+".engine.timeout", 5*time.Minute, "Timeout for query execution.")
 	f.DurationVar(&opts.MaxLookBackPeriod, prefix+".engine.max-lookback-period", 30*time.Second, "The maximum amount of time to look back for log lines. Used only for instant log queries.")
+	f.IntVar(&opts.MaxSubquerySteps, prefix+".engine.max-subquery-steps", 11000, "The maximum number of steps evaluated by a subquery.")
 }
 
 func (opts *EngineOpts) applyDefault() {
@@ -80,20 +84,25 @@ func (opts *EngineOpts) applyDefault() {
 	if opts.MaxLookBackPeriod == 0 {
 		opts.MaxLookBackPeriod = 30 * time.Second
 	}
+	if opts.MaxSubquerySteps == 0 {
+		opts.MaxSubquerySteps = 11000
+	}
 }
 
 // Engine is the LogQL engine.
 type Engine struct {
 	timeout   time.Duration
 	evaluator Evaluator
+	limits    Limits
 }
 
 // NewEngine creates a new LogQL Engine.
-func NewEngine(opts EngineOpts, q Querier) *Engine {
+func NewEngine(opts EngineOpts, q Querier, l Limits) *Engine {
 	opts.applyDefault()
 	return &Engine{
 		timeout:   opts.Timeout,
-		evaluator: NewDefaultEvaluator(q, opts.MaxLookBackPeriod),
+		evaluator: NewDefaultEvaluator(q, opts.MaxLookBackPeriod, opts.MaxSubquerySteps),
+		limits:    l,
 	}
 }
 
@@ -106,6 +115,7 @@ func (ng *Engine) Query(params Params) Query {
 		parse: func(_ context.Context, query string) (Expr, error) {
 			return ParseExpr(query)
 		},
+		limits: ng.limits,
 		record: true,
 	}
 }
@@ -121,6 +131,7 @@ type query struct {
 	params    Params
 	parse     func(context.Context, string) (Expr, error)
 	evaluator Evaluator
+	limits    Limits
 	record    bool
 }
 
@@ -146,7 +157,7 @@ func (q *query) Exec(ctx context.Context) (Result, error) {
 	status := "200"
 	if err != nil {
 		status = "500"
-		if IsParseError(err) {
+		if IsParseError(err) || IsLimitError(err) {
 			status = "400"
 		}
 	}
@@ -169,6 +180,7 @@ func (q *query) Eval(ctx context.Context) (parser.Value, error) {
 	if err != nil {
 		return nil, err
 	}
+	ctx = InjectPolicyQuery(ctx, expr, q.params.Start(), q.params.End())
 
 	switch e := expr.(type) {
 	case SampleExpr:
@@ -195,6 +207,16 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (parser.Value,
 		return q.evalLiteral(ctx, lit)
 	}
 
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	limiter := &queryLimiter{
+		maxSeries:  q.limits.MaxQuerySeries(userID),
+		maxSamples: q.limits.MaxSamplesPerQuery(userID),
+	}
+	ctx = injectQueryLimiter(ctx, limiter)
+
 	stepEvaluator, err := q.evaluator.StepEvaluator(ctx, q.evaluator, expr, q.params)
 	if err != nil {
 		return nil, err
@@ -206,8 +228,17 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (parser.Value,
 	next, ts, vec := stepEvaluator.Next()
 
 	if GetRangeType(q.params) == InstantType {
-		sort.Slice(vec, func(i, j int) bool { return labels.Compare(vec[i].Metric, vec[j].Metric) < 0 })
-		return vec, nil
+		if err := limiter.checkSeries(len(vec)); err != nil {
+			return nil, err
+		}
+		if err := limiter.addSamples(len(vec)); err != nil {
+			return nil, err
+		}
+		// sort and sort_desc already order the samples by value.
+		if !isSortExpr(expr) {
+			sort.Slice(vec, func(i, j int) bool { return labels.Compare(vec[i].Metric, vec[j].Metric) < 0 })
+		}
+		return vec, stepEvaluator.Error()
 	}
 
 	stepCount := int(math.Ceil(float64(q.params.End().Sub(q.params.Start()).Nanoseconds()) / float64(q.params.Step().Nanoseconds())))
@@ -216,6 +247,10 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (parser.Value,
 	}
 
 	for next {
+		// samples are accounted before being merged so an oversized step never gets buffered.
+		if err := limiter.addSamples(len(vec)); err != nil {
+			return nil, err
+		}
 		for _, p := range vec {
 			var (
 				series *promql.Series
@@ -236,6 +271,9 @@ func (q *query) evalSample(ctx context.Context, expr SampleExpr) (parser.Value,
 				V: p.V,
 			})
 		}
+		if err := limiter.checkSeries(len(seriesIndex)); err != nil {
+			return nil, err
+		}
 		next, ts, vec = stepEvaluator.Next()
 	}
 
@@ -290,6 +328,28 @@ func PopulateMatrixFromScalar(data promql.Scalar, params Params) promql.Matrix {
 
 func readStreams(i iter.EntryIterator, size uint32, dir logproto.Direction, interval time.Duration) (Streams, error) {
 	streams := map[string]*logproto.Stream{}
+	err := forEachEntry(i, size, dir, interval, func(labels string, entry logproto.Entry) error {
+		stream, ok := streams[labels]
+		if !ok {
+			stream = &logproto.Stream{
+				Labels: labels,
+			}
+			streams[labels] = stream
+		}
+		stream.Entries = append(stream.Entries, entry)
+		return nil
+	})
+
+	result := make(Streams, 0, len(streams))
+	for _, stream := range streams {
+		result = append(result, *stream)
+	}
+	sort.Sort(result)
+	return result, err
+}
+
+// forEachEntry calls fn for each entry of the iterator selected by the query, up to size entries.
+func forEachEntry(i iter.EntryIterator, size uint32, dir logproto.Direction, interval time.Duration, fn func(labels string, entry logproto.Entry) error) error {
 	respSize := uint32(0)
 	// lastEntry should be a really old time so that the first comparison is always true, we use a negative
 	// value here because many unit tests start at time.Unix(0,0)
@@ -304,25 +364,14 @@ func readStreams(i iter.EntryIterator, size uint32, dir logproto.Direction, inte
 		// If lastEntry.Unix < 0 this is the first pass through the loop and we should output the line.
 		// Then check to see if the entry is equal to, or past a forward or reverse step
 		if interval == 0 || lastEntry.Unix() < 0 || forwardShouldOutput || backwardShouldOutput {
-			stream, ok := streams[labels]
-			if !ok {
-				stream = &logproto.Stream{
-					Labels: labels,
-				}
-				streams[labels] = stream
+			if err := fn(labels, entry); err != nil {
+				return err
 			}
-			stream.Entries = append(stream.Entries, entry)
 			lastEntry = i.Entry().Timestamp
 			respSize++
 		}
 	}
-
-	result := make(Streams, 0, len(streams))
-	for _, stream := range streams {
-		result = append(result, *stream)
-	}
-	sort.Sort(result)
-	return result, i.Error()
+	return i.Error()
 }
 
 type groupedAggregation struct {
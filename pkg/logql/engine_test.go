@@ -16,6 +16,7 @@ import (
 	"github.com/prometheus/prometheus/promql/parser"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
@@ -94,6 +95,16 @@ func TestEngine_LogsInstantQuery(t *testing.T) {
 			},
 			promql.Vector{promql.Sample{Point: promql.Point{T: 60 * 1000, V: 6}, Metric: labels.Labels{labels.Label{Name: "app", Value: "foo"}}}},
 		},
+		{
+			`count_over_time({app="foo"} |~".+bar" [1m] offset 1m)`, time.Unix(120, 0), logproto.BACKWARD, 10,
+			[][]logproto.Series{
+				{newSeries(testSize, factor(10, identity), `{app="foo"}`)}, // 10 , 20 , 30 .. 60 = 6 total
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}|~".+bar"[1m] offset 1m)`}},
+			},
+			promql.Vector{promql.Sample{Point: promql.Point{T: 120 * 1000, V: 6}, Metric: labels.Labels{labels.Label{Name: "app", Value: "foo"}}}},
+		},
 		{
 			`count_over_time(({app="foo"} |~".+bar")[5m])`, time.Unix(5*60, 0), logproto.BACKWARD, 10,
 			[][]logproto.Series{
@@ -455,12 +466,137 @@ func TestEngine_LogsInstantQuery(t *testing.T) {
 				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 60}, Metric: labels.Labels{}},
 			},
 		},
+		{
+			`sum by (app, pod) (count_over_time({app="foo"}[1m])) / on(app) group_left sum by (app) (count_over_time({app="foo"}[1m]))`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo", pod="a"}`), newSeries(testSize, factor(10, identity), `{app="foo", pod="b"}`)},
+				{newSeries(testSize, identity, `{app="foo", pod="a"}`), newSeries(testSize, factor(10, identity), `{app="foo", pod="b"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 60. / 66.}, Metric: labels.Labels{{Name: "app", Value: "foo"}, {Name: "pod", Value: "a"}}},
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 6. / 66.}, Metric: labels.Labels{{Name: "app", Value: "foo"}, {Name: "pod", Value: "b"}}},
+			},
+		},
+		{
+			`sum by (app, pod) (count_over_time({app="foo"}[1m])) + on(app) group_left(team) sum by (app, team) (count_over_time({app=~"foo"}[1m]))`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo", pod="a"}`), newSeries(testSize, factor(10, identity), `{app="foo", pod="b"}`)},
+				{newSeries(testSize, factor(10, identity), `{app="foo", team="x"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app=~"foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 66}, Metric: labels.Labels{{Name: "app", Value: "foo"}, {Name: "pod", Value: "a"}, {Name: "team", Value: "x"}}},
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 12}, Metric: labels.Labels{{Name: "app", Value: "foo"}, {Name: "pod", Value: "b"}, {Name: "team", Value: "x"}}},
+			},
+		},
+		{
+			`absent_over_time({app="foo", env="prod", env=~"p.*"}[1m])`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `absent_over_time({app="foo",env="prod",env=~"p.*"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 1}, Metric: labels.Labels{{Name: "app", Value: "foo"}}},
+			},
+		},
+		{
+			`absent_over_time({app="foo"}[1m])`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `absent_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{},
+		},
+		{
+			`sum(count_over_time({app="foo"}[1m])) or vector(0)`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 0}, Metric: labels.Labels{}},
+			},
+		},
+		{
+			`label_replace(count_over_time({app="foo"}[1m]), "svc", "$1-svc", "app", "(.*)")`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 60}, Metric: labels.Labels{{Name: "app", Value: "foo"}, {Name: "svc", Value: "foo-svc"}}},
+			},
+		},
+		{
+			`clamp_max(count_over_time({app="foo"}[1m]), 10)`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 10}, Metric: labels.Labels{{Name: "app", Value: "foo"}}},
+			},
+		},
+		{
+			`sort(sum by (pod) (count_over_time({app="foo"}[1m])))`,
+			time.Unix(60, 0),
+			logproto.FORWARD,
+			0,
+			[][]logproto.Series{
+				{newSeries(testSize, identity, `{app="foo", pod="a"}`), newSeries(testSize, factor(10, identity), `{app="foo", pod="b"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(60, 0), Selector: `count_over_time({app="foo"}[1m])`}},
+			},
+			promql.Vector{
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 6}, Metric: labels.Labels{{Name: "pod", Value: "b"}}},
+				promql.Sample{Point: promql.Point{T: 60 * 1000, V: 60}, Metric: labels.Labels{{Name: "pod", Value: "a"}}},
+			},
+		},
 	} {
 		test := test
 		t.Run(fmt.Sprintf("%s %s", test.qs, test.direction), func(t *testing.T) {
 			t.Parallel()
 
-			eng := NewEngine(EngineOpts{}, newQuerierRecorder(t, test.data, test.params))
+			eng := NewEngine(EngineOpts{}, newQuerierRecorder(t, test.data, test.params), NoLimits)
 			q := eng.Query(LiteralParams{
 				qs:        test.qs,
 				start:     test.ts,
@@ -468,7 +604,7 @@ func TestEngine_LogsInstantQuery(t *testing.T) {
 				direction: test.direction,
 				limit:     test.limit,
 			})
-			res, err := q.Exec(context.Background())
+			res, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -590,6 +726,36 @@ func TestEngine_RangeQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`sum_over_time({app="foo"} | logfmt | unwrap latency [1m])`, time.Unix(60, 0), time.Unix(120, 0), 30 * time.Second, 0, logproto.BACKWARD, 10,
+			[][]logproto.Series{
+				{newSeries(testSize, factor(10, identity), `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(120, 0), Selector: `sum_over_time({app="foo"} | logfmt | unwrap latency[1m])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.Labels{{Name: "app", Value: "foo"}},
+					Points: []promql.Point{{T: 60 * 1000, V: 6}, {T: 90 * 1000, V: 6}, {T: 120 * 1000, V: 6}},
+				},
+			},
+		},
+		{
+			`quantile_over_time(0.99, {app="foo"} | logfmt | unwrap duration(latency) [1m])`, time.Unix(60, 0), time.Unix(120, 0), 30 * time.Second, 0, logproto.BACKWARD, 10,
+			[][]logproto.Series{
+				{newSeries(testSize, factor(10, identity), `{app="foo"}`)},
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(0, 0), End: time.Unix(120, 0), Selector: `quantile_over_time(0.99,{app="foo"} | logfmt | unwrap duration(latency)[1m])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.Labels{{Name: "app", Value: "foo"}},
+					Points: []promql.Point{{T: 60 * 1000, V: 1}, {T: 90 * 1000, V: 1}, {T: 120 * 1000, V: 1}},
+				},
+			},
+		},
 		{
 			`count_over_time(({app="foo"} |~".+bar")[5m])`, time.Unix(5*60, 0), time.Unix(5*120, 0), 30 * time.Second, 0, logproto.BACKWARD, 10,
 			[][]logproto.Series{
@@ -1508,12 +1674,42 @@ func TestEngine_RangeQuery(t *testing.T) {
 				},
 			},
 		},
+		{
+			`absent_over_time({app="foo"}[30s])`, time.Unix(60, 0), time.Unix(120, 0), 15 * time.Second, 0, logproto.FORWARD, 10,
+			[][]logproto.Series{
+				{newSeries(70, identity, `{app="foo"}`)}, // no logs after 69s
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(30, 0), End: time.Unix(120, 0), Selector: `absent_over_time({app="foo"}[30s])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.Labels{{Name: "app", Value: "foo"}},
+					Points: []promql.Point{{T: 105 * 1000, V: 1}, {T: 120 * 1000, V: 1}},
+				},
+			},
+		},
+		{
+			`max_over_time(sum(count_over_time({app="foo"}[30s]))[1m:15s])`, time.Unix(60, 0), time.Unix(120, 0), 15 * time.Second, 0, logproto.FORWARD, 10,
+			[][]logproto.Series{
+				{newSeries(70, identity, `{app="foo"}`)}, // no logs after 69s
+			},
+			[]SelectSampleParams{
+				{&logproto.SampleQueryRequest{Start: time.Unix(-30, 0), End: time.Unix(120, 0), Selector: `count_over_time({app="foo"}[30s])`}},
+			},
+			promql.Matrix{
+				promql.Series{
+					Metric: labels.Labels{},
+					Points: []promql.Point{{T: 60 * 1000, V: 30}, {T: 75 * 1000, V: 30}, {T: 90 * 1000, V: 30}, {T: 105 * 1000, V: 30}, {T: 120 * 1000, V: 24}},
+				},
+			},
+		},
 	} {
 		test := test
 		t.Run(fmt.Sprintf("%s %s", test.qs, test.direction), func(t *testing.T) {
 			t.Parallel()
 
-			eng := NewEngine(EngineOpts{}, newQuerierRecorder(t, test.data, test.params))
+			eng := NewEngine(EngineOpts{}, newQuerierRecorder(t, test.data, test.params), NoLimits)
 
 			q := eng.Query(LiteralParams{
 				qs:        test.qs,
@@ -1524,7 +1720,7 @@ func TestEngine_RangeQuery(t *testing.T) {
 				direction: test.direction,
 				limit:     test.limit,
 			})
-			res, err := q.Exec(context.Background())
+			res, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -1549,7 +1745,7 @@ func (statsQuerier) SelectSamples(ctx context.Context, p SelectSampleParams) (it
 
 func TestEngine_Stats(t *testing.T) {
 
-	eng := NewEngine(EngineOpts{}, &statsQuerier{})
+	eng := NewEngine(EngineOpts{}, &statsQuerier{}, NoLimits)
 
 	q := eng.Query(LiteralParams{
 		qs:        `{foo="bar"}`,
@@ -1558,7 +1754,7 @@ func TestEngine_Stats(t *testing.T) {
 		direction: logproto.BACKWARD,
 		limit:     1000,
 	})
-	r, err := q.Exec(context.Background())
+	r, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
 	require.NoError(t, err)
 	require.Equal(t, int64(1), r.Statistics.Store.DecompressedBytes)
 }
@@ -1615,21 +1811,132 @@ func TestStepEvaluator_Error(t *testing.T) {
 			},
 			ErrMockMultiple,
 		},
+		{
+			"subqueryAggEvaluator",
+			`max_over_time(count_over_time({app="foo"}[1m])[1h:1s])`,
+			&errorIteratorQuerier{
+				samples: []iter.SampleIterator{
+					iter.NewSeriesIterator(newSeries(testSize, identity, `{app="foo"}`)),
+				},
+			},
+			errors.New(`subquery count_over_time({app="foo"}[1m])[1h:1s] has 3781 steps, exceeding the maximum of 1000`),
+		},
 	}
 
 	for _, tc := range tests {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
 			tc := tc
-			eng := NewEngine(EngineOpts{}, tc.querier)
+			eng := NewEngine(EngineOpts{MaxSubquerySteps: 1000}, tc.querier, NoLimits)
 			q := eng.Query(LiteralParams{
 				qs:    tc.qs,
 				start: time.Unix(0, 0),
 				end:   time.Unix(180, 0),
 				step:  1 * time.Second,
 			})
-			_, err := q.Exec(context.Background())
+			_, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
+func TestEngine_Limits(t *testing.T) {
+	querier := func() Querier {
+		return &errorIteratorQuerier{
+			samples: []iter.SampleIterator{
+				iter.NewSeriesIterator(newSeries(testSize, identity, `{app="foo"}`)),
+				iter.NewSeriesIterator(newSeries(testSize, identity, `{app="bar"}`)),
+				iter.NewSeriesIterator(newSeries(testSize, identity, `{app="baz"}`)),
+			},
+		}
+	}
+	for _, tc := range []struct {
+		name   string
+		qs     string
+		start  time.Time
+		end    time.Time
+		limits Limits
+		err    error
+	}{
+		{
+			"series under limit",
+			`count_over_time({app=~"foo|bar|baz"}[1m])`,
+			time.Unix(0, 0),
+			time.Unix(180, 0),
+			&fakeLimits{maxSeries: 3},
+			nil,
+		},
+		{
+			"series over limit",
+			`count_over_time({app=~"foo|bar|baz"}[1m])`,
+			time.Unix(0, 0),
+			time.Unix(180, 0),
+			&fakeLimits{maxSeries: 2},
+			newLimitError("max_query_series", 2),
+		},
+		{
+			"aggregated series over limit",
+			`sum(count_over_time({app=~"foo|bar|baz"}[1m]))`,
+			time.Unix(0, 0),
+			time.Unix(180, 0),
+			&fakeLimits{maxSeries: 2},
+			newLimitError("max_query_series", 2),
+		},
+		{
+			"aggregated instant series over limit",
+			`topk(1, count_over_time({app=~"foo|bar|baz"}[1m]))`,
+			time.Unix(60, 0),
+			time.Unix(60, 0),
+			&fakeLimits{maxSeries: 2},
+			newLimitError("max_query_series", 2),
+		},
+		{
+			"instant series over limit",
+			`count_over_time({app=~"foo|bar|baz"}[1m])`,
+			time.Unix(60, 0),
+			time.Unix(60, 0),
+			&fakeLimits{maxSeries: 2},
+			newLimitError("max_query_series", 2),
+		},
+		{
+			"samples under limit",
+			`sum(count_over_time({app=~"foo|bar|baz"}[1m]))`,
+			time.Unix(0, 0),
+			time.Unix(180, 0),
+			&fakeLimits{maxSamples: 181},
+			nil,
+		},
+		{
+			"samples over limit",
+			`count_over_time({app=~"foo|bar|baz"}[1m])`,
+			time.Unix(0, 0),
+			time.Unix(180, 0),
+			&fakeLimits{maxSamples: 181},
+			newLimitError("max_samples_per_query", 181),
+		},
+		{
+			"range samples over limit",
+			`sum(count_over_time({app=~"foo|bar|baz"}[1m]))`,
+			time.Unix(60, 0),
+			time.Unix(60, 0),
+			&fakeLimits{maxSamples: 100},
+			newLimitError("max_samples_per_query", 100),
+		},
+	} {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			eng := NewEngine(EngineOpts{}, querier(), tc.limits)
+			q := eng.Query(LiteralParams{
+				qs:    tc.qs,
+				start: tc.start,
+				end:   tc.end,
+				step:  1 * time.Second,
+			})
+			_, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
 			require.Equal(t, tc.err, err)
+			if err != nil {
+				require.True(t, IsLimitError(err))
+			}
 		})
 	}
 }
@@ -1653,7 +1960,7 @@ var result parser.Value
 
 func benchmarkRangeQuery(testsize int64, b *testing.B) {
 	b.ReportAllocs()
-	eng := NewEngine(EngineOpts{}, getLocalQuerier(testsize))
+	eng := NewEngine(EngineOpts{}, getLocalQuerier(testsize), NoLimits)
 	start := time.Unix(0, 0)
 	end := time.Unix(testsize, 0)
 	b.ResetTimer()
@@ -1692,7 +1999,7 @@ func benchmarkRangeQuery(testsize int64, b *testing.B) {
 				direction: test.direction,
 				limit:     1000,
 			})
-			res, err := q.Exec(context.Background())
+			res, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
 			if err != nil {
 				b.Fatal(err)
 			}
@@ -12,6 +12,7 @@ import (
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/promql"
 
+	"github.com/grafana/loki/pkg/helpers"
 	"github.com/grafana/loki/pkg/iter"
 	"github.com/grafana/loki/pkg/logproto"
 )
@@ -116,14 +117,16 @@ func EvaluatorUnsupportedType(expr Expr, ev Evaluator) error {
 
 type DefaultEvaluator struct {
 	maxLookBackPeriod time.Duration
+	maxSubquerySteps  int
 	querier           Querier
 }
 
 // NewDefaultEvaluator constructs a DefaultEvaluator
-func NewDefaultEvaluator(querier Querier, maxLookBackPeriod time.Duration) *DefaultEvaluator {
+func NewDefaultEvaluator(querier Querier, maxLookBackPeriod time.Duration, maxSubquerySteps int) *DefaultEvaluator {
 	return &DefaultEvaluator{
 		querier:           querier,
 		maxLookBackPeriod: maxLookBackPeriod,
+		maxSubquerySteps:  maxSubquerySteps,
 	}
 
 }
@@ -160,8 +163,9 @@ func (ev *DefaultEvaluator) StepEvaluator(
 	case *rangeAggregationExpr:
 		it, err := ev.querier.SelectSamples(ctx, SelectSampleParams{
 			&logproto.SampleQueryRequest{
-				Start:    q.Start().Add(-e.left.interval),
-				End:      q.End(),
+				// extends the query range back to include samples of the first range and of the offset.
+				Start:    q.Start().Add(-e.left.interval).Add(-e.left.offset),
+				End:      q.End().Add(-e.left.offset),
 				Selector: expr.String(),
 				Shards:   q.Shards(),
 			},
@@ -169,9 +173,17 @@ func (ev *DefaultEvaluator) StepEvaluator(
 		if err != nil {
 			return nil, err
 		}
-		return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), e, q)
+		return rangeAggEvaluator(iter.NewPeekingSampleIterator(it), getQueryLimiter(ctx), e, q)
 	case *binOpExpr:
 		return binOpStepEvaluator(ctx, nextEv, e, q)
+	case *vectorExpr:
+		return vectorStepEvaluator(e, q)
+	case *labelReplaceExpr:
+		return labelReplaceEvaluator(ctx, nextEv, e, q)
+	case *functionExpr:
+		return functionEvaluator(ctx, nextEv, e, q)
+	case *subqueryAggregationExpr:
+		return subqueryAggEvaluator(ctx, nextEv, e, q, ev.maxSubquerySteps)
 	default:
 		return nil, EvaluatorUnsupportedType(e, ev)
 	}
@@ -190,12 +202,19 @@ func vectorAggEvaluator(
 	lb := labels.NewBuilder(nil)
 	buf := make([]byte, 0, 1024)
 	sort.Strings(expr.grouping.groups)
+	limiter := getQueryLimiter(ctx)
+	var lastErr error
 	return newStepEvaluator(func() (bool, int64, promql.Vector) {
 		next, ts, vec := nextEvaluator.Next()
 
 		if !next {
 			return false, 0, promql.Vector{}
 		}
+		// the series aggregated at each step are held in memory, even if the result has fewer series.
+		if err := limiter.checkSeries(len(vec)); err != nil {
+			lastErr = err
+			return false, 0, promql.Vector{}
+		}
 		result := map[uint64]*groupedAggregation{}
 		if expr.operation == OpTypeTopK || expr.operation == OpTypeBottomK {
 			if expr.params < 1 {
@@ -371,26 +390,40 @@ func vectorAggEvaluator(
 		}
 		return next, ts, vec
 
-	}, nextEvaluator.Close, nextEvaluator.Error)
+	}, nextEvaluator.Close, func() error {
+		if lastErr != nil {
+			return lastErr
+		}
+		return nextEvaluator.Error()
+	})
 }
 
 func rangeAggEvaluator(
 	it iter.PeekingSampleIterator,
+	limiter *queryLimiter,
 	expr *rangeAggregationExpr,
 	q Params,
 ) (StepEvaluator, error) {
+	rangeIter := newRangeVectorIterator(
+		it,
+		limiter,
+		expr.left.interval.Nanoseconds(),
+		q.Step().Nanoseconds(),
+		q.Start().UnixNano(), q.End().UnixNano(), expr.left.offset.Nanoseconds(),
+	)
+	if expr.operation == OpRangeTypeAbsent {
+		return &absentRangeVectorEvaluator{
+			iter: rangeIter,
+			lbs:  absentLabels(expr),
+		}, nil
+	}
 	agg, err := expr.aggregator()
 	if err != nil {
 		return nil, err
 	}
 	return rangeVectorEvaluator{
-		iter: newRangeVectorIterator(
-			it,
-			expr.left.interval.Nanoseconds(),
-			q.Step().Nanoseconds(),
-			q.Start().UnixNano(), q.End().UnixNano(),
-		),
-		agg: agg,
+		iter: rangeIter,
+		agg:  agg,
 	}, nil
 }
 
@@ -412,6 +445,257 @@ func (r rangeVectorEvaluator) Close() error { return r.iter.Close() }
 
 func (r rangeVectorEvaluator) Error() error { return r.iter.Error() }
 
+// absentRangeVectorEvaluator returns a single sample with the value 1 at each step without any sample in range,
+// and nothing otherwise.
+type absentRangeVectorEvaluator struct {
+	iter RangeVectorIterator
+	lbs  labels.Labels
+}
+
+func (r *absentRangeVectorEvaluator) Next() (bool, int64, promql.Vector) {
+	next := r.iter.Next()
+	if !next {
+		return false, 0, promql.Vector{}
+	}
+	ts, vec := r.iter.At(countOverTime)
+	if len(vec) > 0 {
+		return next, ts, promql.Vector{}
+	}
+	return next, ts, promql.Vector{
+		promql.Sample{
+			Point: promql.Point{
+				T: ts,
+				V: 1,
+			},
+			Metric: r.lbs,
+		},
+	}
+}
+
+func (r *absentRangeVectorEvaluator) Close() error { return r.iter.Close() }
+
+func (r *absentRangeVectorEvaluator) Error() error { return r.iter.Error() }
+
+// absentLabels returns the labels of the absent_over_time samples, like Prometheus does they are
+// taken from the equality matchers of the selector. Labels matched more than once are dropped.
+func absentLabels(expr *rangeAggregationExpr) labels.Labels {
+	lbs := labels.NewBuilder(nil)
+	set := map[string]struct{}{}
+	for _, m := range expr.left.left.Matchers() {
+		if _, ok := set[m.Name]; !ok && m.Type == labels.MatchEqual {
+			lbs.Set(m.Name, m.Value)
+		} else {
+			lbs.Del(m.Name)
+		}
+		set[m.Name] = struct{}{}
+	}
+	return lbs.Labels()
+}
+
+// vectorStepEvaluator returns the same sample without labels at each step.
+func vectorStepEvaluator(expr *vectorExpr, q Params) (StepEvaluator, error) {
+	var (
+		start = q.Start().UnixNano()
+		end   = q.End().UnixNano()
+		step  = q.Step().Nanoseconds()
+	)
+	// forces at least one step.
+	if step == 0 {
+		step = 1
+	}
+	current := start - step
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		current += step
+		if current > end {
+			return false, 0, promql.Vector{}
+		}
+		// convert ts from nano to milli seconds
+		ts := current / 1e+6
+		return true, ts, promql.Vector{
+			promql.Sample{
+				Point: promql.Point{
+					T: ts,
+					V: expr.value,
+				},
+				Metric: labels.Labels{},
+			},
+		}
+	}, nil, nil)
+}
+
+// labelReplaceEvaluator replaces the destination label of the series matching the regular expression,
+// the destination label is removed when the replacement is empty.
+func labelReplaceEvaluator(
+	ctx context.Context,
+	ev Evaluator,
+	expr *labelReplaceExpr,
+	q Params,
+) (StepEvaluator, error) {
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.left, q)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		lastErr    error
+		labelCache = map[uint64]labels.Labels{}
+	)
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		next, ts, vec := nextEvaluator.Next()
+		if !next {
+			return false, 0, promql.Vector{}
+		}
+		seen := make(map[uint64]struct{}, len(vec))
+		for i, s := range vec {
+			hash := s.Metric.Hash()
+			lbs, ok := labelCache[hash]
+			if !ok {
+				lbs = s.Metric
+				src := s.Metric.Get(expr.src)
+				if indexes := expr.re.FindStringSubmatchIndex(src); indexes != nil {
+					res := expr.re.ExpandString([]byte{}, expr.replacement, src, indexes)
+					lb := labels.NewBuilder(s.Metric).Del(expr.dst)
+					if len(res) > 0 {
+						lb.Set(expr.dst, string(res))
+					}
+					lbs = lb.Labels()
+				}
+				labelCache[hash] = lbs
+			}
+			outHash := lbs.Hash()
+			if _, ok := seen[outHash]; ok {
+				lastErr = fmt.Errorf("vector cannot contain metrics with the same labelset: %s", lbs)
+				return false, ts, nil
+			}
+			seen[outHash] = struct{}{}
+			vec[i].Metric = lbs
+		}
+		return next, ts, vec
+	}, nextEvaluator.Close, func() error {
+		if lastErr != nil {
+			return lastErr
+		}
+		return nextEvaluator.Error()
+	})
+}
+
+// functionEvaluator applies a math or sort function to the vector of each step.
+func functionEvaluator(
+	ctx context.Context,
+	ev Evaluator,
+	expr *functionExpr,
+	q Params,
+) (StepEvaluator, error) {
+	fn, err := expr.vectorFunction()
+	if err != nil {
+		return nil, err
+	}
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.left, q)
+	if err != nil {
+		return nil, err
+	}
+	return newStepEvaluator(func() (bool, int64, promql.Vector) {
+		next, ts, vec := nextEvaluator.Next()
+		if !next {
+			return false, 0, promql.Vector{}
+		}
+		return next, ts, fn(vec)
+	}, nextEvaluator.Close, nextEvaluator.Error)
+}
+
+// defaultSubqueryStep is the resolution of subqueries without resolution in instant queries.
+const defaultSubqueryStep = time.Minute
+
+// subqueryAggEvaluator evaluates a subquery at its resolution over the query range extended back by the subquery range,
+// then aggregates its samples over the subquery range at each step like log ranges.
+// The subquery steps are aligned on multiples of its resolution, so that the result doesn't depend on the query start,
+// e.g. when the query frontend splits the query by interval.
+func subqueryAggEvaluator(
+	ctx context.Context,
+	ev Evaluator,
+	expr *subqueryAggregationExpr,
+	q Params,
+	maxSteps int,
+) (StepEvaluator, error) {
+	step := expr.left.rng.step
+	if step == 0 {
+		step = q.Step()
+	}
+	if step == 0 {
+		step = defaultSubqueryStep
+	}
+	var (
+		start = q.Start().Add(-expr.left.rng.interval).Add(-expr.left.offset).UnixNano()
+		end   = q.End().Add(-expr.left.offset).UnixNano()
+	)
+	alignedStart := start - start%step.Nanoseconds()
+	if alignedStart < start {
+		alignedStart += step.Nanoseconds()
+	}
+	if steps := (end-alignedStart)/step.Nanoseconds() + 1; maxSteps > 0 && steps > int64(maxSteps) {
+		return nil, fmt.Errorf("subquery %s has %d steps, exceeding the maximum of %d", expr.left, steps, maxSteps)
+	}
+
+	nextEvaluator, err := ev.StepEvaluator(ctx, ev, expr.left.left, NewLiteralParams(
+		expr.left.left.String(),
+		time.Unix(0, alignedStart),
+		time.Unix(0, end),
+		step,
+		q.Interval(),
+		q.Direction(),
+		q.Limit(),
+		q.Shards(),
+	))
+	if err != nil {
+		return nil, err
+	}
+	defer helpers.LogErrorWithContext(ctx, "closing subquery", nextEvaluator.Close)
+
+	// the samples of the subquery are iterated like the samples of logs.
+	index := map[uint64]int{}
+	var series []logproto.Series
+	for next, ts, vec := nextEvaluator.Next(); next; next, ts, vec = nextEvaluator.Next() {
+		for _, s := range vec {
+			hash := s.Metric.Hash()
+			i, ok := index[hash]
+			if !ok {
+				i = len(series)
+				index[hash] = i
+				series = append(series, logproto.Series{Labels: s.Metric.String()})
+			}
+			series[i].Samples = append(series[i].Samples, logproto.Sample{
+				Timestamp: ts * int64(time.Millisecond),
+				Value:     s.V,
+			})
+		}
+	}
+	if err := nextEvaluator.Error(); err != nil {
+		return nil, err
+	}
+
+	rangeIter := newRangeVectorIterator(
+		iter.NewPeekingSampleIterator(iter.NewMultiSeriesIterator(ctx, series)),
+		getQueryLimiter(ctx),
+		expr.left.rng.interval.Nanoseconds(),
+		q.Step().Nanoseconds(),
+		q.Start().UnixNano(), q.End().UnixNano(), expr.left.offset.Nanoseconds(),
+	)
+	if expr.operation == OpRangeTypeAbsent {
+		// the labels of absent series can't be inferred from a subquery.
+		return &absentRangeVectorEvaluator{
+			iter: rangeIter,
+			lbs:  labels.Labels{},
+		}, nil
+	}
+	agg, err := expr.aggregator()
+	if err != nil {
+		return nil, err
+	}
+	return rangeVectorEvaluator{
+		iter: rangeIter,
+		agg:  agg,
+	}, nil
+}
+
 // binOpExpr explicitly does not handle when both legs are literals as
 // it makes the type system simpler and these are reduced in mustNewBinOpExpr
 func binOpStepEvaluator(
@@ -462,41 +746,40 @@ func binOpStepEvaluator(
 		return nil, err
 	}
 
+	var lastErr error
 	return newStepEvaluator(func() (bool, int64, promql.Vector) {
-		pairs := map[uint64][2]*promql.Sample{}
-		var ts int64
-
-		// populate pairs
-		for i, eval := range []StepEvaluator{lhs, rhs} {
-			next, timestamp, vec := eval.Next()
-
-			ts = timestamp
-
-			// These should _always_ happen at the same step on each evaluator.
-			if !next {
-				return next, ts, nil
-			}
+		var (
+			ts       int64
+			next     bool
+			lhsVec   promql.Vector
+			rhsVec   promql.Vector
+			matching = expr.opts.VectorMatching
+		)
+		// These should _always_ happen at the same step on each evaluator.
+		if next, ts, lhsVec = lhs.Next(); !next {
+			return next, ts, nil
+		}
+		if next, ts, rhsVec = rhs.Next(); !next {
+			return next, ts, nil
+		}
 
-			for _, sample := range vec {
-				// TODO(owen-d): this seems wildly inefficient: we're calculating
-				// the hash on each sample & step per evaluator.
-				// We seem limited to this approach due to using the StepEvaluator ifc.
-				hash := sample.Metric.Hash()
-				pair := pairs[hash]
-				pair[i] = &promql.Sample{
-					Metric: sample.Metric,
-					Point:  sample.Point,
-				}
-				pairs[hash] = pair
+		if matching == nil {
+			// without modifiers series are matched using all their labels.
+			matching = &VectorMatching{Card: CardOneToOne}
+			if IsLogicalBinOp(expr.op) {
+				matching.Card = CardManyToMany
 			}
 		}
 
-		results := make(promql.Vector, 0, len(pairs))
-		for _, pair := range pairs {
-
-			// merge
-			if merged := mergeBinOp(expr.op, pair[0], pair[1], !expr.opts.ReturnBool, IsComparisonOperator(expr.op)); merged != nil {
-				results = append(results, *merged)
+		var results promql.Vector
+		if matching.Card == CardManyToMany {
+			results = vectorSetBinop(expr.op, matching, lhsVec, rhsVec)
+		} else {
+			var err error
+			results, err = vectorBinop(expr.op, expr.opts.ReturnBool, matching, lhsVec, rhsVec)
+			if err != nil {
+				lastErr = err
+				return false, ts, nil
 			}
 		}
 
@@ -510,6 +793,9 @@ func binOpStepEvaluator(
 		return lastError
 	}, func() error {
 		var errs []error
+		if lastErr != nil {
+			errs = append(errs, lastErr)
+		}
 		for _, ev := range []StepEvaluator{lhs, rhs} {
 			if err := ev.Error(); err != nil {
 				errs = append(errs, err)
@@ -526,6 +812,171 @@ func binOpStepEvaluator(
 	})
 }
 
+// vectorSetBinop evaluates a logical/set binary operation (and, or, unless) between two vectors,
+// series are matched by their signature and the result keeps the labels of the matched series.
+func vectorSetBinop(op string, matching *VectorMatching, lhs, rhs promql.Vector) promql.Vector {
+	var buf []byte
+	rightSigs := make(map[uint64]struct{}, len(rhs))
+	for _, s := range rhs {
+		var sig uint64
+		sig, buf = matchingSignature(s.Metric, matching, buf)
+		rightSigs[sig] = struct{}{}
+	}
+
+	results := make(promql.Vector, 0, len(lhs))
+	switch op {
+	case OpTypeAnd:
+		for _, s := range lhs {
+			var sig uint64
+			sig, buf = matchingSignature(s.Metric, matching, buf)
+			if _, ok := rightSigs[sig]; ok {
+				results = append(results, s)
+			}
+		}
+	case OpTypeUnless:
+		for _, s := range lhs {
+			var sig uint64
+			sig, buf = matchingSignature(s.Metric, matching, buf)
+			if _, ok := rightSigs[sig]; !ok {
+				results = append(results, s)
+			}
+		}
+	case OpTypeOr:
+		leftSigs := make(map[uint64]struct{}, len(lhs))
+		for _, s := range lhs {
+			var sig uint64
+			sig, buf = matchingSignature(s.Metric, matching, buf)
+			leftSigs[sig] = struct{}{}
+			results = append(results, s)
+		}
+		for _, s := range rhs {
+			var sig uint64
+			sig, buf = matchingSignature(s.Metric, matching, buf)
+			if _, ok := leftSigs[sig]; !ok {
+				results = append(results, s)
+			}
+		}
+	}
+	return results
+}
+
+// vectorBinop evaluates an arithmetic or comparison binary operation between two vectors.
+// Each sample of the "many" side is matched with at most one sample of the "one" side,
+// an error is returned when the matching is ambiguous.
+func vectorBinop(op string, returnBool bool, matching *VectorMatching, lhs, rhs promql.Vector) (promql.Vector, error) {
+	// the "one" side is always on the right, swap the legs for group_right.
+	if matching.Card == CardOneToMany {
+		lhs, rhs = rhs, lhs
+	}
+
+	var buf []byte
+	rightSigs := make(map[uint64]*promql.Sample, len(rhs))
+	for i := range rhs {
+		var sig uint64
+		sig, buf = matchingSignature(rhs[i].Metric, matching, buf)
+		if _, ok := rightSigs[sig]; ok {
+			side := "right"
+			if matching.Card == CardOneToMany {
+				side = "left"
+			}
+			return nil, fmt.Errorf("found duplicate series for the match group %s on the %s hand-side of the operation: %s", matchingGroup(rhs[i].Metric, matching), side, rhs[i].Metric)
+		}
+		rightSigs[sig] = &rhs[i]
+	}
+
+	var (
+		results      = make(promql.Vector, 0, len(lhs))
+		matchedSigs  = map[uint64]struct{}{}
+		insertedSigs = map[uint64]struct{}{}
+	)
+	for i := range lhs {
+		var sig uint64
+		sig, buf = matchingSignature(lhs[i].Metric, matching, buf)
+		left, right := &lhs[i], rightSigs[sig]
+		if matching.Card == CardOneToMany {
+			left, right = right, left
+		}
+		merged := mergeBinOp(op, left, right, !returnBool, IsComparisonOperator(op))
+		if merged == nil {
+			continue
+		}
+		metric := resultMetric(lhs[i].Metric, rightSigs[sig], matching)
+
+		if matching.Card == CardOneToOne {
+			if rightSigs[sig] != nil {
+				if _, ok := matchedSigs[sig]; ok {
+					return nil, fmt.Errorf("multiple matches for labels: many-to-one matching must be explicit (%s/%s)", OpGroupLeft, OpGroupRight)
+				}
+				matchedSigs[sig] = struct{}{}
+			}
+		} else {
+			// many-to-one matches must produce distinct series.
+			insertSig := metric.Hash()
+			if _, ok := insertedSigs[insertSig]; ok {
+				return nil, errors.New("multiple matches for labels: grouping labels must ensure unique matches")
+			}
+			insertedSigs[insertSig] = struct{}{}
+		}
+
+		results = append(results, promql.Sample{
+			Metric: metric,
+			Point:  merged.Point,
+		})
+	}
+	return results, nil
+}
+
+// matchingSignature returns the hash of the labels used to match series in a binary operation.
+func matchingSignature(lbs labels.Labels, matching *VectorMatching, buf []byte) (uint64, []byte) {
+	if matching.On {
+		return lbs.HashForLabels(buf, matching.MatchingLabels...)
+	}
+	return lbs.HashWithoutLabels(buf, matching.MatchingLabels...)
+}
+
+// matchingGroup returns the labels of a series used to match it in a binary operation.
+func matchingGroup(lbs labels.Labels, matching *VectorMatching) labels.Labels {
+	if matching.On {
+		return lbs.WithLabels(matching.MatchingLabels...)
+	}
+	return lbs.WithoutLabels(matching.MatchingLabels...)
+}
+
+// resultMetric returns the labels of the result of a binary operation between a sample of
+// the "many" side and its match of the "one" side, which can be nil when there's no match.
+func resultMetric(lhs labels.Labels, rhs *promql.Sample, matching *VectorMatching) labels.Labels {
+	lb := labels.NewBuilder(lhs)
+
+	if matching.Card == CardOneToOne {
+		if matching.On {
+		Outer:
+			for _, l := range lhs {
+				for _, n := range matching.MatchingLabels {
+					if l.Name == n {
+						continue Outer
+					}
+				}
+				lb.Del(l.Name)
+			}
+		} else {
+			lb.Del(matching.MatchingLabels...)
+		}
+	}
+	// labels included by group_left/group_right are taken from the "one" side.
+	for _, ln := range matching.Include {
+		var v string
+		if rhs != nil {
+			v = rhs.Metric.Get(ln)
+		}
+		if v != "" {
+			lb.Set(ln, v)
+		} else {
+			lb.Del(ln)
+		}
+	}
+	return lb.Labels()
+}
+
 func mergeBinOp(op string, left, right *promql.Sample, filter, isVectorComparison bool) *promql.Sample {
 	var merger func(left, right *promql.Sample) *promql.Sample
 
@@ -248,3 +248,126 @@ func Test_MergeBinOpVectors_Filter(t *testing.T) {
 		Point: promql.Point{V: 2},
 	}, res)
 }
+
+func Test_vectorBinop(t *testing.T) {
+	sample := func(v float64, lbs string) promql.Sample {
+		return promql.Sample{Point: promql.Point{V: v}, Metric: mustParseLabels(lbs)}
+	}
+	for _, tc := range []struct {
+		desc     string
+		op       string
+		matching *VectorMatching
+		lhs, rhs promql.Vector
+		expected promql.Vector
+		err      string
+	}{
+		{
+			"one-to-one on",
+			OpTypeAdd,
+			&VectorMatching{Card: CardOneToOne, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`), sample(2, `{app="bar", pod="b"}`)},
+			promql.Vector{sample(10, `{app="foo", team="x"}`)},
+			promql.Vector{sample(11, `{app="foo"}`)},
+			"",
+		},
+		{
+			"one-to-one ignoring",
+			OpTypeAdd,
+			&VectorMatching{Card: CardOneToOne, MatchingLabels: []string{"pod"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`)},
+			promql.Vector{sample(10, `{app="foo", pod="b"}`)},
+			promql.Vector{sample(11, `{app="foo"}`)},
+			"",
+		},
+		{
+			"many-to-one",
+			OpTypeDiv,
+			&VectorMatching{Card: CardManyToOne, On: true, MatchingLabels: []string{"app"}, Include: []string{"team"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`), sample(3, `{app="foo", pod="b"}`)},
+			promql.Vector{sample(4, `{app="foo", team="x"}`)},
+			promql.Vector{sample(0.25, `{app="foo", pod="a", team="x"}`), sample(0.75, `{app="foo", pod="b", team="x"}`)},
+			"",
+		},
+		{
+			"one-to-many",
+			OpTypeSub,
+			&VectorMatching{Card: CardOneToMany, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(10, `{app="foo"}`)},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`), sample(3, `{app="foo", pod="b"}`)},
+			promql.Vector{sample(9, `{app="foo", pod="a"}`), sample(7, `{app="foo", pod="b"}`)},
+			"",
+		},
+		{
+			"many-to-one filter keeps the left value",
+			OpTypeGT,
+			&VectorMatching{Card: CardManyToOne, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`), sample(3, `{app="foo", pod="b"}`)},
+			promql.Vector{sample(2, `{app="foo"}`)},
+			promql.Vector{sample(3, `{app="foo", pod="b"}`)},
+			"",
+		},
+		{
+			"duplicate series on the one side",
+			OpTypeDiv,
+			&VectorMatching{Card: CardManyToOne, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`)},
+			promql.Vector{sample(1, `{app="foo", team="x"}`), sample(1, `{app="foo", team="y"}`)},
+			nil,
+			`found duplicate series for the match group {app="foo"} on the right hand-side of the operation: {app="foo", team="y"}`,
+		},
+		{
+			"implicit many-to-one",
+			OpTypeDiv,
+			&VectorMatching{Card: CardOneToOne, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(1, `{app="foo", pod="a"}`), sample(1, `{app="foo", pod="b"}`)},
+			promql.Vector{sample(1, `{app="foo"}`)},
+			nil,
+			"multiple matches for labels: many-to-one matching must be explicit (group_left/group_right)",
+		},
+		{
+			"many-to-one keeps the labels of the many side",
+			OpTypeDiv,
+			&VectorMatching{Card: CardManyToOne, On: true, MatchingLabels: []string{"app"}},
+			promql.Vector{sample(1, `{app="foo", pod="a", team="x"}`), sample(1, `{app="foo", pod="a", team="y"}`)},
+			promql.Vector{sample(1, `{app="foo"}`)},
+			promql.Vector{sample(1, `{app="foo", pod="a", team="x"}`), sample(1, `{app="foo", pod="a", team="y"}`)},
+			"",
+		},
+		{
+			"included labels overriding the left labels",
+			OpTypeDiv,
+			&VectorMatching{Card: CardManyToOne, On: true, MatchingLabels: []string{"app"}, Include: []string{"team"}},
+			promql.Vector{sample(1, `{app="foo", pod="a", team="x"}`), sample(1, `{app="foo", pod="a", team="y"}`)},
+			promql.Vector{sample(1, `{app="foo", team="z"}`)},
+			nil,
+			"multiple matches for labels: grouping labels must ensure unique matches",
+		},
+	} {
+		tc := tc
+		t.Run(tc.desc, func(t *testing.T) {
+			res, err := vectorBinop(tc.op, false, tc.matching, tc.lhs, tc.rhs)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, res)
+		})
+	}
+}
+
+func Test_vectorSetBinop(t *testing.T) {
+	lhs := promql.Vector{
+		{Point: promql.Point{V: 1}, Metric: mustParseLabels(`{app="foo", pod="a"}`)},
+		{Point: promql.Point{V: 2}, Metric: mustParseLabels(`{app="bar", pod="b"}`)},
+	}
+	rhs := promql.Vector{
+		{Point: promql.Point{V: 3}, Metric: mustParseLabels(`{app="foo", pod="c"}`)},
+		{Point: promql.Point{V: 4}, Metric: mustParseLabels(`{app="buzz", pod="d"}`)},
+	}
+	matching := &VectorMatching{Card: CardManyToMany, On: true, MatchingLabels: []string{"app"}}
+
+	require.Equal(t, promql.Vector{lhs[0]}, vectorSetBinop(OpTypeAnd, matching, lhs, rhs))
+	require.Equal(t, promql.Vector{lhs[1]}, vectorSetBinop(OpTypeUnless, matching, lhs, rhs))
+	require.Equal(t, promql.Vector{lhs[0], lhs[1], rhs[1]}, vectorSetBinop(OpTypeOr, matching, lhs, rhs))
+}
@@ -0,0 +1,106 @@
+package logql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LineFilterExplanation describes a line filter stage of a query and the filter it is executed as,
+// after the simplification of regular expressions into literal filters.
+type LineFilterExplanation struct {
+	Expr       string `json:"expr"`
+	Simplified string `json:"simplified"`
+}
+
+// ExplainLineFilters returns in order the line filters of all the log selectors of an expression.
+func ExplainLineFilters(expr Expr) ([]LineFilterExplanation, error) {
+	var res []LineFilterExplanation
+	for _, sel := range logSelectors(expr) {
+		filters, err := explainLineFilters(sel)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, filters...)
+	}
+	return res, nil
+}
+
+func explainLineFilters(e LogSelectorExpr) ([]LineFilterExplanation, error) {
+	var left LogSelectorExpr
+	switch expr := e.(type) {
+	case *filterExpr:
+		filters, err := explainLineFilters(expr.left)
+		if err != nil {
+			return nil, err
+		}
+		f, err := expr.filter()
+		if err != nil {
+			return nil, err
+		}
+		return append(filters, LineFilterExplanation{
+			Expr:       strings.TrimPrefix(expr.String(), expr.left.String()),
+			Simplified: lineFilterString(f),
+		}), nil
+	case *labelParserExpr:
+		left = expr.left
+	case *labelFilterExpr:
+		left = expr.left
+	case *lineFmtExpr:
+		left = expr.left
+	case *labelFmtExpr:
+		left = expr.left
+	default:
+		return nil, nil
+	}
+	return explainLineFilters(left)
+}
+
+// logSelectors returns all the log selectors of an expression.
+func logSelectors(expr Expr) []LogSelectorExpr {
+	switch e := expr.(type) {
+	case *literalExpr, *vectorExpr:
+		return nil
+	case *rangeAggregationExpr:
+		return []LogSelectorExpr{e.left.left}
+	case *vectorAggregationExpr:
+		return logSelectors(e.left)
+	case *labelReplaceExpr:
+		return logSelectors(e.left)
+	case *functionExpr:
+		return logSelectors(e.left)
+	case *subqueryAggregationExpr:
+		return logSelectors(e.left.left)
+	case *binOpExpr:
+		return append(logSelectors(e.SampleExpr), logSelectors(e.RHS)...)
+	case LogSelectorExpr:
+		return []LogSelectorExpr{e}
+	default:
+		return nil
+	}
+}
+
+// lineFilterString returns a human readable representation of a line filter.
+func lineFilterString(f LineFilter) string {
+	switch filter := f.(type) {
+	case trueFilter:
+		return "true"
+	case containsFilter:
+		if filter.caseInsensitive {
+			return fmt.Sprintf("contains_i(%s)", strconv.Quote(string(filter.match)))
+		}
+		return fmt.Sprintf("contains(%s)", strconv.Quote(string(filter.match)))
+	case regexpFilter:
+		return fmt.Sprintf("regexp(%s)", strconv.Quote(filter.String()))
+	case ipFilter:
+		return fmt.Sprintf("ip(%s)", strconv.Quote(filter.matcher.start.String()+"-"+filter.matcher.end.String()))
+	case notFilter:
+		return fmt.Sprintf("not(%s)", lineFilterString(filter.LineFilter))
+	case andFilter:
+		return fmt.Sprintf("(%s and %s)", lineFilterString(filter.left), lineFilterString(filter.right))
+	case orFilter:
+		return fmt.Sprintf("(%s or %s)", lineFilterString(filter.left), lineFilterString(filter.right))
+	default:
+		return fmt.Sprintf("%T", f)
+	}
+}
@@ -0,0 +1,69 @@
+package logql
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainLineFilters(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected []LineFilterExplanation
+	}{
+		{`{app="foo"}`, nil},
+		{
+			`{app="foo"} |= "bar" != "buzz"`,
+			[]LineFilterExplanation{
+				{Expr: `|="bar"`, Simplified: `contains("bar")`},
+				{Expr: `!="buzz"`, Simplified: `not(contains("buzz"))`},
+			},
+		},
+		{
+			`{app="foo"} |~ "foo|bar" !~ "(?i)error"`,
+			[]LineFilterExplanation{
+				{Expr: `|~"foo|bar"`, Simplified: `(contains("foo") or contains("bar"))`},
+				{Expr: `!~"(?i)error"`, Simplified: `not(contains_i("error"))`},
+			},
+		},
+		{
+			`{app="foo"} |~ "f.*o[0-9]+"`,
+			[]LineFilterExplanation{
+				{Expr: `|~"f.*o[0-9]+"`, Simplified: `regexp("f.*o[0-9]+")`},
+			},
+		},
+		{
+			`{app="foo"} |= ip("10.0.0.0/30")`,
+			[]LineFilterExplanation{
+				{Expr: `|=ip("10.0.0.0/30")`, Simplified: `ip("10.0.0.0-10.0.0.3")`},
+			},
+		},
+		{
+			`{app="foo"} |= "bar" | json | line_format "{{.foo}}"`,
+			[]LineFilterExplanation{
+				{Expr: `|="bar"`, Simplified: `contains("bar")`},
+			},
+		},
+		{
+			`sum(rate({app="foo"} |= "bar"[1m])) / sum(rate({app="foo"} |~ "foo|buzz"[1m]))`,
+			[]LineFilterExplanation{
+				{Expr: `|="bar"`, Simplified: `contains("bar")`},
+				{Expr: `|~"foo|buzz"`, Simplified: `(contains("foo") or contains("buzz"))`},
+			},
+		},
+		{
+			`max_over_time(count_over_time({app="foo"} != "bar"[1m])[1h:1m])`,
+			[]LineFilterExplanation{
+				{Expr: `!="bar"`, Simplified: `not(contains("bar"))`},
+			},
+		},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseExpr(tc.query)
+			require.NoError(t, err)
+			filters, err := ExplainLineFilters(expr)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, filters)
+		})
+	}
+}
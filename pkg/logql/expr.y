@@ -28,6 +28,20 @@ import (
   duration                time.Duration
   LiteralExpr             *literalExpr
   BinOpModifier           BinOpOptions
+  LabelParser             *labelParserExpr
+  LabelFilter             LabelFilterer
+  UnitFilter              LabelFilterer
+  bytes                   uint64
+  LineFormatExpr          *lineFmtExpr
+  LabelFormatExpr         *labelFmtExpr
+  LabelFormat             LabelFmt
+  LabelsFormat            []LabelFmt
+  UnwrapExpr              *unwrapExpr
+  ConvOp                  string
+  FunctionExpr            SampleExpr
+  FunctionOp              string
+  subqueryRange           subqueryRange
+  SubqueryExpr            *subqueryExpr
 }
 
 %start root
@@ -48,15 +62,40 @@ import (
 %type <VectorOp>              vectorOp
 %type <BinOpExpr>             binOpExpr
 %type <LiteralExpr>           literalExpr
-%type <BinOpModifier>         binOpModifier
+%type <BinOpModifier>         binOpModifier boolModifier onOrIgnoringModifier
+%type <LabelParser>           labelParser
+%type <LabelFilter>           labelFilter ipLabelFilter
+%type <UnitFilter>            unitFilter durationFilter bytesFilter numberFilter
+%type <LineFormatExpr>        lineFormatExpr
+%type <LabelFormatExpr>       labelFormatExpr
+%type <LabelFormat>           labelFormat
+%type <LabelsFormat>          labelsFormat
+%type <UnwrapExpr>            unwrapExpr
+%type <ConvOp>                convOp
+%type <duration>              offsetExpr
+%type <FunctionExpr>          functionExpr
+%type <FunctionOp>            functionOp
+%type <SubqueryExpr>          subqueryExpr
 
 %token <str>      IDENTIFIER STRING NUMBER
 %token <duration> DURATION
-%token <val>      MATCHERS LABELS EQ RE NRE OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET COMMA DOT PIPE_MATCH PIPE_EXACT
-                  OPEN_PARENTHESIS CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE SUM AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
-                  BYTES_OVER_TIME BYTES_RATE BOOL
+%token <subqueryRange> SUBQUERY_RANGE
+%token <bytes>    BYTES
+%token <val>      MATCHERS LABELS EQ RE NRE OPEN_BRACE CLOSE_BRACE OPEN_BRACKET CLOSE_BRACKET DOT PIPE_MATCH PIPE_EXACT
+                  CLOSE_PARENTHESIS BY WITHOUT COUNT_OVER_TIME RATE SUM AVG MAX MIN COUNT STDDEV STDVAR BOTTOMK TOPK
+                  BYTES_OVER_TIME BYTES_RATE BOOL JSON LOGFMT REGEXP PATTERN LINE_FMT UNWRAP BYTES_CONV DURATION_CONV IP
+                  AVG_OVER_TIME SUM_OVER_TIME MIN_OVER_TIME MAX_OVER_TIME STDVAR_OVER_TIME STDDEV_OVER_TIME QUANTILE_OVER_TIME
+                  ON IGNORING OFFSET ABSENT_OVER_TIME LABEL_REPLACE VECTOR CLAMP_MIN CLAMP_MAX ABS ROUND CEIL FLOOR SORT SORT_DESC
 
 // Operators are listed with increasing precedence.
+// PIPE has the lowest precedence so that `and`/`or` after a label filter are always part of it.
+// GROUP_LEFT/GROUP_RIGHT are lower than OPEN_PARENTHESIS so that a parenthesis after them is always shifted.
+// LABEL_FMT is lower than COMMA so that a comma after a label format is always part of it, e.g. in function arguments.
+%nonassoc <val> LABEL_FMT
+%nonassoc <val> COMMA
+%nonassoc <val> GROUP_LEFT GROUP_RIGHT
+%nonassoc <val> OPEN_PARENTHESIS
+%nonassoc <val> PIPE
 %left <binOp> OR
 %left <binOp> AND UNLESS
 %left <binOp> CMP_EQ NEQ LT LTE GT GTE
@@ -78,26 +117,58 @@ metricExpr:
     | vectorAggregationExpr                         { $$ = $1 }
     | binOpExpr                                     { $$ = $1 }
     | literalExpr                                   { $$ = $1 }
+    | functionExpr                                  { $$ = $1 }
     | OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS { $$ = $2 }
     ;
 
 logExpr:
       selector                                    { $$ = newMatcherExpr($1)}
     | logExpr filter STRING                       { $$ = NewFilterExpr( $1, $2, $3 ) }
+    | logExpr filter IP OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS { $$ = mustNewIPFilterExpr( $1, $2, $5 ) }
+    | logExpr PIPE labelParser                    { $$ = addParserToLogExpr( $1, $3 ) }
+    | logExpr PIPE labelFilter                    { $$ = addLabelFilterToLogExpr( $1, $3 ) }
+    | logExpr PIPE lineFormatExpr                 { $$ = addLineFmtToLogExpr( $1, $3 ) }
+    | logExpr PIPE labelFormatExpr                { $$ = addLabelFmtToLogExpr( $1, $3 ) }
     | OPEN_PARENTHESIS logExpr CLOSE_PARENTHESIS  { $$ = $2 }
     | logExpr filter error
     | logExpr error
     ;
 
 logRangeExpr:
-      logExpr DURATION { $$ = newLogRange($1, $2) } // <selector> <filters> <range>
+      logExpr DURATION { $$ = newLogRange($1, $2, nil, 0) } // <selector> <filters> <range>
+    | logExpr DURATION offsetExpr { $$ = newLogRange($1, $2, nil, $3) } // <selector> <filters> <range> <offset>
+    | logExpr unwrapExpr DURATION { $$ = newLogRange($1, $3, $2, 0) } // <selector> <filters> <unwrap> <range>
+    | logExpr unwrapExpr DURATION offsetExpr { $$ = newLogRange($1, $3, $2, $4) } // <selector> <filters> <unwrap> <range> <offset>
     | logRangeExpr filter STRING                       { $$ = addFilterToLogRangeExpr( $1, $2, $3 ) }
+    | logRangeExpr filter IP OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS { $$ = addIPFilterToLogRangeExpr( $1, $2, $5 ) }
+    | logRangeExpr PIPE labelParser                    { $$ = addParserToLogRangeExpr( $1, $3 ) }
+    | logRangeExpr PIPE labelFilter                    { $$ = addLabelFilterToLogRangeExpr( $1, $3 ) }
+    | logRangeExpr PIPE lineFormatExpr                 { $$ = addLineFmtToLogRangeExpr( $1, $3 ) }
+    | logRangeExpr PIPE labelFormatExpr                { $$ = addLabelFmtToLogRangeExpr( $1, $3 ) }
     | OPEN_PARENTHESIS logRangeExpr CLOSE_PARENTHESIS  { $$ = $2 }
     | logRangeExpr filter error
     | logRangeExpr error
     ;
 
-rangeAggregationExpr: rangeOp OPEN_PARENTHESIS logRangeExpr CLOSE_PARENTHESIS { $$ = newRangeAggregationExpr($3,$1) };
+rangeAggregationExpr:
+      rangeOp OPEN_PARENTHESIS logRangeExpr CLOSE_PARENTHESIS                    { $$ = mustNewRangeAggregationExpr($3, $1, nil) }
+    | rangeOp OPEN_PARENTHESIS NUMBER COMMA logRangeExpr CLOSE_PARENTHESIS       { $$ = mustNewRangeAggregationExpr($5, $1, &$3) }
+    | rangeOp OPEN_PARENTHESIS subqueryExpr CLOSE_PARENTHESIS                    { $$ = mustNewSubqueryAggregationExpr($3, $1, nil) }
+    | rangeOp OPEN_PARENTHESIS NUMBER COMMA subqueryExpr CLOSE_PARENTHESIS       { $$ = mustNewSubqueryAggregationExpr($5, $1, &$3) }
+    ;
+
+subqueryExpr:
+      metricExpr SUBQUERY_RANGE              { $$ = newSubqueryExpr($1, $2, 0) }
+    | metricExpr SUBQUERY_RANGE offsetExpr   { $$ = newSubqueryExpr($1, $2, $3) }
+    ;
+
+functionExpr:
+      LABEL_REPLACE OPEN_PARENTHESIS metricExpr COMMA STRING COMMA STRING COMMA STRING COMMA STRING CLOSE_PARENTHESIS
+                                                                               { $$ = mustNewLabelReplaceExpr($3, $5, $7, $9, $11) }
+    | VECTOR OPEN_PARENTHESIS literalExpr CLOSE_PARENTHESIS                    { $$ = mustNewVectorExpr($3) }
+    | functionOp OPEN_PARENTHESIS metricExpr CLOSE_PARENTHESIS                 { $$ = mustNewFunctionExpr($3, $1, nil) }
+    | functionOp OPEN_PARENTHESIS metricExpr COMMA literalExpr CLOSE_PARENTHESIS { $$ = mustNewFunctionExpr($3, $1, $5) }
+    ;
 
 vectorAggregationExpr:
     // Aggregations with 1 argument.
@@ -109,6 +180,93 @@ vectorAggregationExpr:
     | vectorOp OPEN_PARENTHESIS NUMBER COMMA metricExpr CLOSE_PARENTHESIS grouping        { $$ = mustNewVectorAggregationExpr($5, $1, $7, &$3) }
     ;
 
+labelParser:
+      JSON           { $$ = mustNewLabelParserExpr(OpParserTypeJSON, "") }
+    | LOGFMT         { $$ = mustNewLabelParserExpr(OpParserTypeLogfmt, "") }
+    | REGEXP STRING  { $$ = mustNewLabelParserExpr(OpParserTypeRegexp, $2) }
+    | PATTERN STRING { $$ = mustNewLabelParserExpr(OpParserTypePattern, $2) }
+    ;
+
+lineFormatExpr: LINE_FMT STRING { $$ = mustNewLineFmtExpr($2) };
+
+labelFormat:
+      IDENTIFIER EQ IDENTIFIER { $$ = NewRenameLabelFmt($1, $3) }
+    | IDENTIFIER EQ STRING     { $$ = NewTemplateLabelFmt($1, $3) }
+    ;
+
+labelsFormat:
+      labelFormat                    { $$ = []LabelFmt{ $1 } }
+    | labelsFormat COMMA labelFormat { $$ = append($1, $3) }
+    ;
+
+labelFormatExpr: LABEL_FMT labelsFormat { $$ = mustNewLabelFmtExpr($2) };
+
+unwrapExpr:
+      PIPE UNWRAP IDENTIFIER                                           { $$ = newUnwrapExpr($3, "") }
+    | PIPE UNWRAP convOp OPEN_PARENTHESIS IDENTIFIER CLOSE_PARENTHESIS { $$ = newUnwrapExpr($5, $3) }
+    | unwrapExpr PIPE labelFilter                                      { $$ = $1.addPostFilter($3) }
+    ;
+
+convOp:
+      BYTES_CONV     { $$ = OpConvBytes }
+    | DURATION_CONV  { $$ = OpConvDuration }
+    ;
+
+offsetExpr:
+      OFFSET DURATION { $$ = $2 }
+    ;
+
+labelFilter:
+      matcher                                        { $$ = NewStringLabelFilter($1) }
+    | unitFilter                                     { $$ = $1 }
+    | numberFilter                                   { $$ = $1 }
+    | ipLabelFilter                                  { $$ = $1 }
+    | OPEN_PARENTHESIS labelFilter CLOSE_PARENTHESIS { $$ = $2 }
+    | labelFilter AND labelFilter                    { $$ = NewAndLabelFilter($1, $3 ) }
+    | labelFilter OR labelFilter                     { $$ = NewOrLabelFilter($1, $3 ) }
+    ;
+
+unitFilter:
+      durationFilter { $$ = $1 }
+    | bytesFilter    { $$ = $1 }
+    ;
+
+durationFilter:
+      IDENTIFIER GT DURATION      { $$ = NewDurationLabelFilter(LabelFilterGreaterThan, $1, $3) }
+    | IDENTIFIER GTE DURATION     { $$ = NewDurationLabelFilter(LabelFilterGreaterThanOrEqual, $1, $3) }
+    | IDENTIFIER LT DURATION      { $$ = NewDurationLabelFilter(LabelFilterLesserThan, $1, $3) }
+    | IDENTIFIER LTE DURATION     { $$ = NewDurationLabelFilter(LabelFilterLesserThanOrEqual, $1, $3) }
+    | IDENTIFIER NEQ DURATION     { $$ = NewDurationLabelFilter(LabelFilterNotEqual, $1, $3) }
+    | IDENTIFIER EQ DURATION      { $$ = NewDurationLabelFilter(LabelFilterEqual, $1, $3) }
+    | IDENTIFIER CMP_EQ DURATION  { $$ = NewDurationLabelFilter(LabelFilterEqual, $1, $3) }
+    ;
+
+bytesFilter:
+      IDENTIFIER GT BYTES      { $$ = NewBytesLabelFilter(LabelFilterGreaterThan, $1, $3) }
+    | IDENTIFIER GTE BYTES     { $$ = NewBytesLabelFilter(LabelFilterGreaterThanOrEqual, $1, $3) }
+    | IDENTIFIER LT BYTES      { $$ = NewBytesLabelFilter(LabelFilterLesserThan, $1, $3) }
+    | IDENTIFIER LTE BYTES     { $$ = NewBytesLabelFilter(LabelFilterLesserThanOrEqual, $1, $3) }
+    | IDENTIFIER NEQ BYTES     { $$ = NewBytesLabelFilter(LabelFilterNotEqual, $1, $3) }
+    | IDENTIFIER EQ BYTES      { $$ = NewBytesLabelFilter(LabelFilterEqual, $1, $3) }
+    | IDENTIFIER CMP_EQ BYTES  { $$ = NewBytesLabelFilter(LabelFilterEqual, $1, $3) }
+    ;
+
+ipLabelFilter:
+      IDENTIFIER EQ IP OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS      { $$ = mustNewIPLabelFilter(LabelFilterEqual, $1, $5) }
+    | IDENTIFIER CMP_EQ IP OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS  { $$ = mustNewIPLabelFilter(LabelFilterEqual, $1, $5) }
+    | IDENTIFIER NEQ IP OPEN_PARENTHESIS STRING CLOSE_PARENTHESIS     { $$ = mustNewIPLabelFilter(LabelFilterNotEqual, $1, $5) }
+    ;
+
+numberFilter:
+      IDENTIFIER GT NUMBER      { $$ = NewNumericLabelFilter(LabelFilterGreaterThan, $1, mustNewFloat($3)) }
+    | IDENTIFIER GTE NUMBER     { $$ = NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, $1, mustNewFloat($3)) }
+    | IDENTIFIER LT NUMBER      { $$ = NewNumericLabelFilter(LabelFilterLesserThan, $1, mustNewFloat($3)) }
+    | IDENTIFIER LTE NUMBER     { $$ = NewNumericLabelFilter(LabelFilterLesserThanOrEqual, $1, mustNewFloat($3)) }
+    | IDENTIFIER NEQ NUMBER     { $$ = NewNumericLabelFilter(LabelFilterNotEqual, $1, mustNewFloat($3)) }
+    | IDENTIFIER EQ NUMBER      { $$ = NewNumericLabelFilter(LabelFilterEqual, $1, mustNewFloat($3)) }
+    | IDENTIFIER CMP_EQ NUMBER  { $$ = NewNumericLabelFilter(LabelFilterEqual, $1, mustNewFloat($3)) }
+    ;
+
 filter:
       PIPE_MATCH                       { $$ = labels.MatchRegexp }
     | PIPE_EXACT                       { $$ = labels.MatchEqual }
@@ -134,7 +292,6 @@ matcher:
     | IDENTIFIER NRE STRING            { $$ = mustNewMatcher(labels.MatchNotRegexp, $1, $3) }
     ;
 
-// TODO(owen-d): add (on,ignoring) clauses to binOpExpr
 // Operator precedence only works if each of these is listed separately.
 binOpExpr:
          expr OR binOpModifier expr          { $$ = mustNewBinOpExpr("or", $3, $1, $4) }
@@ -154,11 +311,32 @@ binOpExpr:
          | expr LTE binOpModifier expr       { $$ = mustNewBinOpExpr("<=", $3, $1, $4) }
          ;
 
-binOpModifier:
+boolModifier:
            { $$ = BinOpOptions{} }
            | BOOL { $$ = BinOpOptions{ ReturnBool: true } }
            ;
 
+onOrIgnoringModifier:
+           boolModifier ON OPEN_PARENTHESIS labels CLOSE_PARENTHESIS          { $$ = $1; $$.VectorMatching = &VectorMatching{On: true, MatchingLabels: $4} }
+           | boolModifier ON OPEN_PARENTHESIS CLOSE_PARENTHESIS               { $$ = $1; $$.VectorMatching = &VectorMatching{On: true} }
+           | boolModifier IGNORING OPEN_PARENTHESIS labels CLOSE_PARENTHESIS  { $$ = $1; $$.VectorMatching = &VectorMatching{MatchingLabels: $4} }
+           | boolModifier IGNORING OPEN_PARENTHESIS CLOSE_PARENTHESIS         { $$ = $1; $$.VectorMatching = &VectorMatching{} }
+           ;
+
+// A parenthesis following group_left/group_right is always the list of included labels, as in PromQL.
+binOpModifier:
+           boolModifier                                                            { $$ = $1 }
+           | onOrIgnoringModifier                                                  { $$ = $1 }
+           | onOrIgnoringModifier GROUP_LEFT                                       { $$ = $1; $$.VectorMatching.Card = CardManyToOne }
+           | onOrIgnoringModifier GROUP_LEFT OPEN_PARENTHESIS CLOSE_PARENTHESIS    { $$ = $1; $$.VectorMatching.Card = CardManyToOne }
+           | onOrIgnoringModifier GROUP_LEFT OPEN_PARENTHESIS labels CLOSE_PARENTHESIS
+             { $$ = $1; $$.VectorMatching.Card = CardManyToOne; $$.VectorMatching.Include = $4 }
+           | onOrIgnoringModifier GROUP_RIGHT                                      { $$ = $1; $$.VectorMatching.Card = CardOneToMany }
+           | onOrIgnoringModifier GROUP_RIGHT OPEN_PARENTHESIS CLOSE_PARENTHESIS   { $$ = $1; $$.VectorMatching.Card = CardOneToMany }
+           | onOrIgnoringModifier GROUP_RIGHT OPEN_PARENTHESIS labels CLOSE_PARENTHESIS
+             { $$ = $1; $$.VectorMatching.Card = CardOneToMany; $$.VectorMatching.Include = $4 }
+           ;
+
 literalExpr:
            NUMBER         { $$ = mustNewLiteralExpr( $1, false ) }
            | ADD NUMBER   { $$ = mustNewLiteralExpr( $2, false ) }
@@ -182,6 +360,25 @@ rangeOp:
     | RATE            { $$ = OpRangeTypeRate }
     | BYTES_OVER_TIME { $$ = OpRangeTypeBytes }
     | BYTES_RATE      { $$ = OpRangeTypeBytesRate }
+    | AVG_OVER_TIME   { $$ = OpRangeTypeAvg }
+    | SUM_OVER_TIME   { $$ = OpRangeTypeSum }
+    | MIN_OVER_TIME   { $$ = OpRangeTypeMin }
+    | MAX_OVER_TIME   { $$ = OpRangeTypeMax }
+    | STDVAR_OVER_TIME    { $$ = OpRangeTypeStdvar }
+    | STDDEV_OVER_TIME    { $$ = OpRangeTypeStddev }
+    | QUANTILE_OVER_TIME  { $$ = OpRangeTypeQuantile }
+    | ABSENT_OVER_TIME    { $$ = OpRangeTypeAbsent }
+    ;
+
+functionOp:
+      CLAMP_MIN { $$ = OpFuncClampMin }
+    | CLAMP_MAX { $$ = OpFuncClampMax }
+    | ABS       { $$ = OpFuncAbs }
+    | ROUND     { $$ = OpFuncRound }
+    | CEIL      { $$ = OpFuncCeil }
+    | FLOOR     { $$ = OpFuncFloor }
+    | SORT      { $$ = OpFuncSort }
+    | SORT_DESC { $$ = OpFuncSortDesc }
     ;
 
 
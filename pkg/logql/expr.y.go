@@ -32,26 +32,40 @@ type exprSymType struct {
 	duration              time.Duration
 	LiteralExpr           *literalExpr
 	BinOpModifier         BinOpOptions
+	LabelParser           *labelParserExpr
+	LabelFilter           LabelFilterer
+	UnitFilter            LabelFilterer
+	bytes                 uint64
+	LineFormatExpr        *lineFmtExpr
+	LabelFormatExpr       *labelFmtExpr
+	LabelFormat           LabelFmt
+	LabelsFormat          []LabelFmt
+	UnwrapExpr            *unwrapExpr
+	ConvOp                string
+	FunctionExpr          SampleExpr
+	FunctionOp            string
+	subqueryRange         subqueryRange
+	SubqueryExpr          *subqueryExpr
 }
 
 const IDENTIFIER = 57346
 const STRING = 57347
 const NUMBER = 57348
 const DURATION = 57349
-const MATCHERS = 57350
-const LABELS = 57351
-const EQ = 57352
-const RE = 57353
-const NRE = 57354
-const OPEN_BRACE = 57355
-const CLOSE_BRACE = 57356
-const OPEN_BRACKET = 57357
-const CLOSE_BRACKET = 57358
-const COMMA = 57359
-const DOT = 57360
-const PIPE_MATCH = 57361
-const PIPE_EXACT = 57362
-const OPEN_PARENTHESIS = 57363
+const SUBQUERY_RANGE = 57350
+const BYTES = 57351
+const MATCHERS = 57352
+const LABELS = 57353
+const EQ = 57354
+const RE = 57355
+const NRE = 57356
+const OPEN_BRACE = 57357
+const CLOSE_BRACE = 57358
+const OPEN_BRACKET = 57359
+const CLOSE_BRACKET = 57360
+const DOT = 57361
+const PIPE_MATCH = 57362
+const PIPE_EXACT = 57363
 const CLOSE_PARENTHESIS = 57364
 const BY = 57365
 const WITHOUT = 57366
@@ -69,21 +83,57 @@ const TOPK = 57377
 const BYTES_OVER_TIME = 57378
 const BYTES_RATE = 57379
 const BOOL = 57380
-const OR = 57381
-const AND = 57382
-const UNLESS = 57383
-const CMP_EQ = 57384
-const NEQ = 57385
-const LT = 57386
-const LTE = 57387
-const GT = 57388
-const GTE = 57389
-const ADD = 57390
-const SUB = 57391
-const MUL = 57392
-const DIV = 57393
-const MOD = 57394
-const POW = 57395
+const JSON = 57381
+const LOGFMT = 57382
+const REGEXP = 57383
+const PATTERN = 57384
+const LINE_FMT = 57385
+const UNWRAP = 57386
+const BYTES_CONV = 57387
+const DURATION_CONV = 57388
+const IP = 57389
+const AVG_OVER_TIME = 57390
+const SUM_OVER_TIME = 57391
+const MIN_OVER_TIME = 57392
+const MAX_OVER_TIME = 57393
+const STDVAR_OVER_TIME = 57394
+const STDDEV_OVER_TIME = 57395
+const QUANTILE_OVER_TIME = 57396
+const ON = 57397
+const IGNORING = 57398
+const OFFSET = 57399
+const ABSENT_OVER_TIME = 57400
+const LABEL_REPLACE = 57401
+const VECTOR = 57402
+const CLAMP_MIN = 57403
+const CLAMP_MAX = 57404
+const ABS = 57405
+const ROUND = 57406
+const CEIL = 57407
+const FLOOR = 57408
+const SORT = 57409
+const SORT_DESC = 57410
+const LABEL_FMT = 57411
+const COMMA = 57412
+const GROUP_LEFT = 57413
+const GROUP_RIGHT = 57414
+const OPEN_PARENTHESIS = 57415
+const PIPE = 57416
+const OR = 57417
+const AND = 57418
+const UNLESS = 57419
+const CMP_EQ = 57420
+const NEQ = 57421
+const LT = 57422
+const LTE = 57423
+const GT = 57424
+const GTE = 57425
+const ADD = 57426
+const SUB = 57427
+const MUL = 57428
+const DIV = 57429
+const MOD = 57430
+const POW = 57431
 
 var exprToknames = [...]string{
 	"$end",
@@ -93,6 +143,8 @@ var exprToknames = [...]string{
 	"STRING",
 	"NUMBER",
 	"DURATION",
+	"SUBQUERY_RANGE",
+	"BYTES",
 	"MATCHERS",
 	"LABELS",
 	"EQ",
@@ -102,11 +154,9 @@ var exprToknames = [...]string{
 	"CLOSE_BRACE",
 	"OPEN_BRACKET",
 	"CLOSE_BRACKET",
-	"COMMA",
 	"DOT",
 	"PIPE_MATCH",
 	"PIPE_EXACT",
-	"OPEN_PARENTHESIS",
 	"CLOSE_PARENTHESIS",
 	"BY",
 	"WITHOUT",
@@ -124,6 +174,42 @@ var exprToknames = [...]string{
 	"BYTES_OVER_TIME",
 	"BYTES_RATE",
 	"BOOL",
+	"JSON",
+	"LOGFMT",
+	"REGEXP",
+	"PATTERN",
+	"LINE_FMT",
+	"UNWRAP",
+	"BYTES_CONV",
+	"DURATION_CONV",
+	"IP",
+	"AVG_OVER_TIME",
+	"SUM_OVER_TIME",
+	"MIN_OVER_TIME",
+	"MAX_OVER_TIME",
+	"STDVAR_OVER_TIME",
+	"STDDEV_OVER_TIME",
+	"QUANTILE_OVER_TIME",
+	"ON",
+	"IGNORING",
+	"OFFSET",
+	"ABSENT_OVER_TIME",
+	"LABEL_REPLACE",
+	"VECTOR",
+	"CLAMP_MIN",
+	"CLAMP_MAX",
+	"ABS",
+	"ROUND",
+	"CEIL",
+	"FLOOR",
+	"SORT",
+	"SORT_DESC",
+	"LABEL_FMT",
+	"COMMA",
+	"GROUP_LEFT",
+	"GROUP_RIGHT",
+	"OPEN_PARENTHESIS",
+	"PIPE",
 	"OR",
 	"AND",
 	"UNLESS",
@@ -140,6 +226,7 @@ var exprToknames = [...]string{
 	"MOD",
 	"POW",
 }
+
 var exprStatenames = [...]string{}
 
 const exprEofCode = 1
@@ -153,169 +240,312 @@ var exprExca = [...]int{
 	-2, 0,
 	-1, 3,
 	1, 2,
+	8, 2,
 	22, 2,
-	39, 2,
-	40, 2,
-	41, 2,
-	42, 2,
-	44, 2,
-	45, 2,
-	46, 2,
-	47, 2,
-	48, 2,
-	49, 2,
-	50, 2,
-	51, 2,
-	52, 2,
-	53, 2,
+	70, 2,
+	75, 2,
+	76, 2,
+	77, 2,
+	78, 2,
+	80, 2,
+	81, 2,
+	82, 2,
+	83, 2,
+	84, 2,
+	85, 2,
+	86, 2,
+	87, 2,
+	88, 2,
+	89, 2,
+	-2, 0,
+	-1, 73,
+	75, 2,
+	76, 2,
+	77, 2,
+	78, 2,
+	80, 2,
+	81, 2,
+	82, 2,
+	83, 2,
+	84, 2,
+	85, 2,
+	86, 2,
+	87, 2,
+	88, 2,
+	89, 2,
+	-2, 0,
+	-1, 141,
+	75, 2,
+	76, 2,
+	77, 2,
+	78, 2,
+	80, 2,
+	81, 2,
+	82, 2,
+	83, 2,
+	84, 2,
+	85, 2,
+	86, 2,
+	87, 2,
+	88, 2,
+	89, 2,
 	-2, 0,
-	-1, 52,
-	39, 2,
-	40, 2,
-	41, 2,
-	42, 2,
-	44, 2,
-	45, 2,
-	46, 2,
-	47, 2,
-	48, 2,
-	49, 2,
-	50, 2,
-	51, 2,
-	52, 2,
-	53, 2,
+	-1, 202,
+	75, 2,
+	76, 2,
+	77, 2,
+	78, 2,
+	80, 2,
+	81, 2,
+	82, 2,
+	83, 2,
+	84, 2,
+	85, 2,
+	86, 2,
+	87, 2,
+	88, 2,
+	89, 2,
 	-2, 0,
 }
 
 const exprPrivate = 57344
 
-const exprLast = 279
+const exprLast = 579
 
 var exprAct = [...]int{
-
-	60, 4, 45, 130, 56, 3, 93, 38, 51, 53,
-	2, 66, 52, 30, 31, 32, 39, 40, 43, 44,
-	41, 42, 33, 34, 35, 36, 37, 38, 14, 33,
-	34, 35, 36, 37, 38, 11, 35, 36, 37, 38,
-	89, 91, 92, 6, 61, 62, 142, 17, 18, 21,
-	22, 24, 25, 23, 26, 27, 28, 29, 19, 20,
-	59, 96, 61, 62, 94, 138, 127, 139, 11, 139,
-	15, 16, 141, 90, 140, 101, 95, 102, 103, 104,
-	105, 106, 107, 108, 109, 110, 111, 112, 113, 114,
-	115, 83, 116, 100, 99, 98, 121, 58, 128, 88,
-	129, 125, 126, 64, 132, 31, 32, 39, 40, 43,
-	44, 41, 42, 33, 34, 35, 36, 37, 38, 134,
-	63, 120, 133, 86, 46, 97, 10, 143, 136, 121,
-	137, 119, 11, 82, 49, 85, 81, 118, 87, 117,
-	6, 47, 48, 144, 17, 18, 21, 22, 24, 25,
-	23, 26, 27, 28, 29, 19, 20, 65, 55, 131,
-	57, 57, 9, 13, 8, 50, 5, 15, 16, 39,
-	40, 43, 44, 41, 42, 33, 34, 35, 36, 37,
-	38, 12, 7, 54, 1, 0, 0, 0, 0, 67,
-	68, 69, 70, 71, 72, 73, 74, 75, 76, 77,
-	78, 79, 80, 46, 0, 0, 0, 122, 124, 0,
-	0, 0, 122, 49, 0, 0, 0, 49, 0, 0,
-	47, 48, 49, 84, 47, 48, 0, 135, 46, 47,
-	48, 0, 123, 124, 0, 0, 0, 46, 49, 0,
-	0, 0, 0, 0, 50, 47, 48, 49, 50, 0,
-	0, 0, 0, 50, 47, 48, 0, 84, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 50,
-	0, 0, 0, 0, 0, 0, 0, 0, 50,
+	81, 111, 179, 259, 208, 65, 3, 4, 113, 10,
+	118, 58, 138, 73, 72, 140, 112, 110, 55, 56,
+	57, 58, 15, 77, 53, 54, 55, 56, 57, 58,
+	222, 74, 2, 50, 51, 52, 59, 60, 63, 64,
+	61, 62, 53, 54, 55, 56, 57, 58, 51, 52,
+	59, 60, 63, 64, 61, 62, 53, 54, 55, 56,
+	57, 58, 59, 60, 63, 64, 61, 62, 53, 54,
+	55, 56, 57, 58, 134, 136, 137, 173, 172, 172,
+	261, 321, 310, 173, 172, 288, 141, 143, 144, 318,
+	186, 136, 137, 67, 149, 287, 151, 150, 200, 125,
+	16, 17, 82, 83, 286, 70, 285, 218, 217, 216,
+	215, 68, 69, 129, 171, 155, 156, 260, 148, 147,
+	146, 152, 88, 87, 176, 157, 158, 159, 160, 161,
+	162, 163, 164, 165, 166, 167, 168, 169, 170, 86,
+	79, 135, 305, 188, 193, 304, 303, 262, 131, 202,
+	72, 300, 80, 210, 207, 203, 187, 185, 183, 184,
+	181, 182, 130, 125, 195, 199, 223, 302, 122, 270,
+	71, 211, 206, 197, 220, 221, 70, 153, 154, 269,
+	92, 133, 68, 69, 289, 213, 323, 189, 244, 241,
+	268, 242, 319, 268, 268, 316, 253, 315, 114, 115,
+	116, 117, 123, 255, 141, 143, 265, 314, 264, 193,
+	256, 254, 252, 257, 266, 268, 132, 268, 313, 195,
+	273, 275, 278, 280, 272, 15, 282, 268, 124, 243,
+	301, 70, 122, 214, 12, 298, 194, 68, 69, 263,
+	290, 71, 281, 267, 21, 22, 33, 34, 36, 37,
+	35, 38, 39, 40, 41, 23, 24, 82, 83, 190,
+	240, 237, 193, 238, 297, 296, 212, 25, 26, 27,
+	28, 29, 30, 31, 205, 209, 209, 32, 18, 19,
+	42, 43, 44, 45, 46, 47, 48, 49, 15, 125,
+	198, 194, 6, 279, 277, 128, 71, 12, 224, 311,
+	204, 239, 295, 16, 17, 283, 284, 21, 22, 33,
+	34, 36, 37, 35, 38, 39, 40, 41, 23, 24,
+	248, 245, 291, 246, 114, 115, 116, 117, 123, 258,
+	25, 26, 27, 28, 29, 30, 31, 85, 84, 209,
+	32, 18, 19, 42, 43, 44, 45, 46, 47, 48,
+	49, 145, 322, 320, 124, 142, 251, 276, 122, 249,
+	12, 247, 312, 293, 294, 309, 16, 17, 308, 209,
+	21, 22, 33, 34, 36, 37, 35, 38, 39, 40,
+	41, 23, 24, 109, 307, 306, 107, 274, 236, 234,
+	271, 235, 219, 25, 26, 27, 28, 29, 30, 31,
+	192, 250, 191, 32, 18, 19, 42, 43, 44, 45,
+	46, 47, 48, 49, 139, 317, 233, 231, 6, 232,
+	299, 230, 228, 12, 229, 190, 189, 177, 108, 16,
+	17, 175, 174, 21, 22, 33, 34, 36, 37, 35,
+	38, 39, 40, 41, 23, 24, 227, 225, 76, 226,
+	78, 180, 209, 78, 20, 11, 25, 26, 27, 28,
+	29, 30, 31, 292, 201, 178, 32, 18, 19, 42,
+	43, 44, 45, 46, 47, 48, 49, 67, 89, 195,
+	120, 142, 200, 127, 126, 119, 121, 91, 67, 70,
+	90, 70, 16, 17, 9, 68, 69, 68, 69, 196,
+	70, 67, 14, 8, 5, 13, 68, 69, 129, 7,
+	75, 1, 0, 70, 0, 0, 0, 0, 0, 68,
+	69, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	93, 94, 95, 96, 97, 98, 99, 100, 101, 102,
+	103, 104, 105, 106, 0, 0, 0, 0, 0, 199,
+	0, 194, 0, 0, 71, 0, 71, 0, 0, 0,
+	66, 0, 0, 0, 0, 71, 0, 0, 0, 0,
+	0, 0, 0, 66, 0, 0, 0, 0, 71,
 }
-var exprPact = [...]int{
 
-	22, -1000, -26, 122, -1000, -1000, 22, -1000, -1000, -1000,
-	-1000, 156, 76, 39, -1000, 114, 97, -1000, -1000, -1000,
+var exprPact = [...]int{
+	219, -1000, -42, 499, -1000, -1000, 219, -1000, -1000, -1000,
+	-1000, -1000, 446, 67, 79, -1000, 332, 331, 66, 50,
+	49, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
 	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000,
-	-27, -27, -27, -27, -27, -27, -27, -27, -27, -27,
-	-27, -27, -27, -27, -27, 131, -1000, -1000, -1000, -1000,
-	-1000, 69, 235, -26, 121, 85, -1000, 30, 55, 119,
-	74, 73, 72, -1000, -1000, 22, -1000, 22, 22, 22,
-	22, 22, 22, 22, 22, 22, 22, 22, 22, 22,
-	22, -1000, -1000, -1000, -1000, -1000, -1000, 157, -1000, 134,
-	132, 126, 116, 210, 226, 55, 44, 81, 22, 155,
-	155, 65, 127, 127, -14, -14, -46, -46, -46, -46,
-	-19, -19, -19, -19, -19, -19, -1000, -1000, -1000, -1000,
-	-1000, 117, -1000, -1000, -1000, 201, 205, 21, 22, 43,
-	52, -1000, 50, -1000, -1000, -1000, -1000, 24, -1000, 123,
-	-1000, -1000, 21, -1000, -1000,
+	142, 142, 142, 142, 142, 142, 142, 142, 142, 142,
+	142, 142, 142, 142, 142, 381, 159, -1000, -1000, -1000,
+	-1000, -1000, 273, 486, -42, 146, 165, -1000, 62, 408,
+	345, 47, 46, 45, -1000, -1000, 219, 16, 219, 219,
+	122, 44, -1000, 219, 219, 219, 219, 219, 219, 219,
+	219, 219, 219, 219, 219, 219, 219, -1000, 41, -1000,
+	-1000, 2, -1000, -1000, -1000, -1000, 427, 426, -1000, -1000,
+	-1000, -1000, 95, 422, 447, 78, -1000, -1000, -1000, -1000,
+	-1000, -1000, 449, -1000, 421, 420, 397, 395, 477, 103,
+	268, 475, 282, 292, 252, 102, 219, 448, 448, 101,
+	244, 163, -28, 37, 36, 35, 34, -16, -16, -68,
+	-68, -78, -78, -78, -78, -60, -60, -60, -60, -60,
+	-60, 387, 95, 95, -1000, -1000, 8, -1000, 96, -1000,
+	286, 440, 415, 410, 382, 254, 182, 314, -1000, -1000,
+	-1000, -1000, -1000, 354, 159, -1000, -1000, 282, -1000, 285,
+	60, 73, 91, 217, 60, 234, 219, 221, 157, -1000,
+	147, 385, -1000, -1000, 16, 365, 335, 272, 271, 220,
+	-1000, 3, -1000, 447, 301, -1000, -1000, -1000, -1000, -1000,
+	-1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, -1000, 33,
+	-1000, -1000, -1000, 31, -1000, -1000, -1000, 22, -1000, -1000,
+	12, -1000, -1000, 2, -1000, -1000, 162, 218, 318, -1000,
+	295, 60, 95, -1000, -1000, -1000, 213, -1000, 416, -1000,
+	-1000, 81, 208, 145, -1000, 124, -1000, -1000, 123, -1000,
+	120, -1000, -1000, -1000, -1000, 380, 379, 363, 360, -1000,
+	-1000, -1000, 9, -1000, -1000, -1000, -1000, 2, 234, -1000,
+	357, -1000, -1000, -1000, -1000, -1000, 196, 185, 175, 173,
+	411, -1000, 19, -1000, -1000, -1000, -1000, 170, 348, -1000,
+	11, 347, 164, -1000,
 }
-var exprPgo = [...]int{
 
-	0, 184, 9, 2, 0, 3, 5, 1, 6, 4,
-	183, 182, 181, 166, 164, 163, 162, 126, 157,
+var exprPgo = [...]int{
+	0, 511, 31, 5, 0, 4, 6, 7, 12, 10,
+	510, 509, 505, 504, 503, 502, 494, 9, 478, 490,
+	487, 17, 1, 486, 485, 484, 483, 480, 16, 8,
+	2, 465, 464, 463, 3, 455, 454, 15,
 }
-var exprR1 = [...]int{
 
-	0, 1, 2, 2, 7, 7, 7, 7, 7, 6,
-	6, 6, 6, 6, 8, 8, 8, 8, 8, 11,
-	14, 14, 14, 14, 14, 3, 3, 3, 3, 13,
-	13, 13, 10, 10, 9, 9, 9, 9, 16, 16,
+var exprR1 = [...]int{
+	0, 1, 2, 2, 7, 7, 7, 7, 7, 7,
+	6, 6, 6, 6, 6, 6, 6, 6, 6, 6,
+	8, 8, 8, 8, 8, 8, 8, 8, 8, 8,
+	8, 8, 8, 11, 11, 11, 11, 37, 37, 35,
+	35, 35, 35, 14, 14, 14, 14, 14, 21, 21,
+	21, 21, 28, 30, 30, 31, 31, 29, 32, 32,
+	32, 33, 33, 34, 22, 22, 22, 22, 22, 22,
+	22, 24, 24, 25, 25, 25, 25, 25, 25, 25,
+	26, 26, 26, 26, 26, 26, 26, 23, 23, 23,
+	27, 27, 27, 27, 27, 27, 27, 3, 3, 3,
+	3, 13, 13, 13, 10, 10, 9, 9, 9, 9,
 	16, 16, 16, 16, 16, 16, 16, 16, 16, 16,
-	16, 16, 16, 18, 18, 17, 17, 17, 15, 15,
-	15, 15, 15, 15, 15, 15, 15, 12, 12, 12,
-	12, 5, 5, 4, 4,
+	16, 16, 16, 16, 16, 19, 19, 20, 20, 20,
+	20, 18, 18, 18, 18, 18, 18, 18, 18, 17,
+	17, 17, 15, 15, 15, 15, 15, 15, 15, 15,
+	15, 12, 12, 12, 12, 12, 12, 12, 12, 12,
+	12, 12, 12, 36, 36, 36, 36, 36, 36, 36,
+	36, 5, 5, 4, 4,
 }
-var exprR2 = [...]int{
 
-	0, 1, 1, 1, 1, 1, 1, 1, 3, 1,
-	3, 3, 3, 2, 2, 3, 3, 3, 2, 4,
-	4, 5, 5, 6, 7, 1, 1, 1, 1, 3,
-	3, 3, 1, 3, 3, 3, 3, 3, 4, 4,
+var exprR2 = [...]int{
+	0, 1, 1, 1, 1, 1, 1, 1, 1, 3,
+	1, 3, 6, 3, 3, 3, 3, 3, 3, 2,
+	2, 3, 3, 4, 3, 6, 3, 3, 3, 3,
+	3, 3, 2, 4, 6, 4, 6, 2, 3, 12,
+	4, 4, 6, 4, 5, 5, 6, 7, 1, 1,
+	2, 2, 2, 3, 3, 1, 3, 2, 3, 6,
+	3, 1, 1, 2, 1, 1, 1, 1, 3, 3,
+	3, 1, 1, 3, 3, 3, 3, 3, 3, 3,
+	3, 3, 3, 3, 3, 3, 3, 6, 6, 6,
+	3, 3, 3, 3, 3, 3, 3, 1, 1, 1,
+	1, 3, 3, 3, 1, 3, 3, 3, 3, 3,
 	4, 4, 4, 4, 4, 4, 4, 4, 4, 4,
-	4, 4, 4, 0, 1, 1, 2, 2, 1, 1,
+	4, 4, 4, 4, 4, 0, 1, 5, 4, 5,
+	4, 1, 1, 2, 4, 5, 2, 4, 5, 1,
+	2, 2, 1, 1, 1, 1, 1, 1, 1, 1,
+	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
 	1, 1, 3, 4, 4,
 }
-var exprChk = [...]int{
 
-	-1000, -1, -2, -6, -7, -13, 21, -11, -14, -16,
-	-17, 13, -12, -15, 6, 48, 49, 25, 26, 36,
-	37, 27, 28, 31, 29, 30, 32, 33, 34, 35,
-	39, 40, 41, 48, 49, 50, 51, 52, 53, 42,
-	43, 46, 47, 44, 45, -3, 2, 19, 20, 12,
-	43, -7, -6, -2, -10, 2, -9, 4, 21, 21,
-	-4, 23, 24, 6, 6, -18, 38, -18, -18, -18,
-	-18, -18, -18, -18, -18, -18, -18, -18, -18, -18,
-	-18, 5, 2, 22, 22, 14, 2, 17, 14, 10,
-	43, 11, 12, -8, -6, 21, -7, 6, 21, 21,
-	21, -2, -2, -2, -2, -2, -2, -2, -2, -2,
-	-2, -2, -2, -2, -2, -2, -9, 5, 5, 5,
-	5, -3, 2, 22, 7, -6, -8, 22, 17, -7,
-	-5, 4, -5, 5, 2, 22, -4, -7, 22, 17,
-	22, 22, 22, 4, -4,
+var exprChk = [...]int{
+	-1000, -1, -2, -6, -7, -13, 73, -11, -14, -16,
+	-17, -35, 15, -12, -15, 6, 84, 85, 59, 60,
+	-36, 25, 26, 36, 37, 48, 49, 50, 51, 52,
+	53, 54, 58, 27, 28, 31, 29, 30, 32, 33,
+	34, 35, 61, 62, 63, 64, 65, 66, 67, 68,
+	75, 76, 77, 84, 85, 86, 87, 88, 89, 78,
+	79, 82, 83, 80, 81, -3, 74, 2, 20, 21,
+	14, 79, -7, -6, -2, -10, 2, -9, 4, 73,
+	73, -4, 23, 24, 6, 6, 73, 73, 73, -18,
+	-19, -20, 38, -18, -18, -18, -18, -18, -18, -18,
+	-18, -18, -18, -18, -18, -18, -18, 5, 47, 2,
+	-21, -22, -28, -29, 39, 40, 41, 42, -9, -24,
+	-27, -23, 73, 43, 69, 4, -25, -26, 22, 22,
+	16, 2, 70, 16, 12, 79, 13, 14, -8, 6,
+	-37, -6, 73, -7, -7, 6, 73, 73, 73, -7,
+	-17, -7, -2, 55, 56, 71, 72, -2, -2, -2,
+	-2, -2, -2, -2, -2, -2, -2, -2, -2, -2,
+	-2, 73, 76, 75, 5, 5, -22, 5, -31, -30,
+	4, 82, 83, 80, 81, 79, 12, 78, -9, 5,
+	5, 5, 5, -3, 74, 2, 22, 70, 22, 74,
+	7, -32, -6, -8, 8, 22, 70, -7, -5, 4,
+	-5, 70, 22, 22, 70, 73, 73, 73, 73, 5,
+	-22, -22, 22, 70, 12, 7, 9, 6, 7, 9,
+	6, 7, 9, 6, 7, 9, 6, 7, 9, 47,
+	6, 7, 9, 47, 6, 7, 9, 47, 6, 5,
+	47, 2, -21, -22, -28, -29, -8, -37, 44, -34,
+	57, 7, 74, 22, -34, -4, -7, 22, 70, 22,
+	22, 5, -17, -5, 22, -5, 22, 22, -5, 22,
+	-5, 22, -30, 4, 5, 73, 73, 73, 73, 22,
+	22, 4, -33, 45, 46, 7, -34, -22, 22, 4,
+	70, 22, 22, 22, 22, 22, 5, 5, 5, 5,
+	73, -4, 5, 22, 22, 22, 22, 4, 70, 22,
+	5, 70, 5, 22,
 }
-var exprDef = [...]int{
 
-	0, -2, 1, -2, 3, 9, 0, 4, 5, 6,
-	7, 0, 0, 0, 55, 0, 0, 67, 68, 69,
-	70, 58, 59, 60, 61, 62, 63, 64, 65, 66,
-	53, 53, 53, 53, 53, 53, 53, 53, 53, 53,
-	53, 53, 53, 53, 53, 0, 13, 25, 26, 27,
-	28, 3, -2, 0, 0, 0, 32, 0, 0, 0,
-	0, 0, 0, 56, 57, 0, 54, 0, 0, 0,
-	0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
-	0, 10, 12, 8, 11, 29, 30, 0, 31, 0,
-	0, 0, 0, 0, 0, 0, 3, 55, 0, 0,
-	0, 38, 39, 40, 41, 42, 43, 44, 45, 46,
-	47, 48, 49, 50, 51, 52, 33, 34, 35, 36,
-	37, 0, 18, 19, 14, 0, 0, 20, 0, 3,
-	0, 71, 0, 15, 17, 16, 22, 3, 21, 0,
-	73, 74, 23, 72, 24,
+var exprDef = [...]int{
+	0, -2, 1, -2, 3, 10, 0, 4, 5, 6,
+	7, 8, 0, 0, 0, 139, 0, 0, 0, 0,
+	0, 151, 152, 153, 154, 155, 156, 157, 158, 159,
+	160, 161, 162, 142, 143, 144, 145, 146, 147, 148,
+	149, 150, 163, 164, 165, 166, 167, 168, 169, 170,
+	125, 125, 125, 125, 125, 125, 125, 125, 125, 125,
+	125, 125, 125, 125, 125, 0, 0, 19, 97, 98,
+	99, 100, 3, -2, 0, 0, 0, 104, 0, 0,
+	0, 0, 0, 0, 140, 141, 0, 0, 0, 0,
+	131, 132, 126, 0, 0, 0, 0, 0, 0, 0,
+	0, 0, 0, 0, 0, 0, 0, 11, 0, 18,
+	13, 14, 15, 16, 48, 49, 0, 0, 64, 65,
+	66, 67, 0, 0, 0, 0, 71, 72, 9, 17,
+	101, 102, 0, 103, 0, 0, 0, 0, 0, 139,
+	0, -2, 0, 3, 3, 139, 0, 0, 0, 3,
+	0, 3, 110, 0, 0, 133, 136, 111, 112, 113,
+	114, 115, 116, 117, 118, 119, 120, 121, 122, 123,
+	124, 0, 0, 0, 50, 51, 0, 52, 57, 55,
+	0, 0, 0, 0, 0, 0, 0, 0, 105, 106,
+	107, 108, 109, 0, 0, 32, 33, 0, 35, 0,
+	20, 0, -2, 0, 37, 43, 0, 3, 0, 171,
+	0, 0, 40, 41, 0, 0, 0, 0, 0, 0,
+	69, 70, 68, 0, 0, 73, 80, 90, 74, 81,
+	91, 75, 82, 92, 76, 83, 93, 77, 84, 0,
+	94, 78, 85, 0, 95, 79, 86, 0, 96, 24,
+	0, 31, 26, 27, 28, 29, 0, 0, 0, 21,
+	0, 22, 0, 30, 38, 45, 3, 44, 0, 173,
+	174, 0, 0, 0, 128, 0, 130, 134, 0, 137,
+	0, 12, 56, 53, 54, 0, 0, 0, 0, 34,
+	36, 58, 0, 61, 62, 63, 23, 60, 46, 172,
+	0, 42, 127, 129, 135, 138, 0, 0, 0, 0,
+	0, 47, 0, 89, 87, 88, 25, 0, 0, 59,
+	0, 0, 0, 39,
 }
-var exprTok1 = [...]int{
 
+var exprTok1 = [...]int{
 	1,
 }
-var exprTok2 = [...]int{
 
+var exprTok2 = [...]int{
 	2, 3, 4, 5, 6, 7, 8, 9, 10, 11,
 	12, 13, 14, 15, 16, 17, 18, 19, 20, 21,
 	22, 23, 24, 25, 26, 27, 28, 29, 30, 31,
 	32, 33, 34, 35, 36, 37, 38, 39, 40, 41,
 	42, 43, 44, 45, 46, 47, 48, 49, 50, 51,
-	52, 53,
+	52, 53, 54, 55, 56, 57, 58, 59, 60, 61,
+	62, 63, 64, 65, 66, 67, 68, 69, 70, 71,
+	72, 73, 74, 75, 76, 77, 78, 79, 80, 81,
+	82, 83, 84, 85, 86, 87, 88, 89,
 }
+
 var exprTok3 = [...]int{
 	0,
 }
@@ -692,315 +922,827 @@ exprdefault:
 			exprVAL.MetricExpr = exprDollar[1].LiteralExpr
 		}
 	case 8:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.MetricExpr = exprDollar[1].FunctionExpr
+		}
+	case 9:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.MetricExpr = exprDollar[2].MetricExpr
 		}
-	case 9:
+	case 10:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LogExpr = newMatcherExpr(exprDollar[1].Selector)
 		}
-	case 10:
+	case 11:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogExpr = NewFilterExpr(exprDollar[1].LogExpr, exprDollar[2].Filter, exprDollar[3].str)
 		}
-	case 11:
+	case 12:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.LogExpr = mustNewIPFilterExpr(exprDollar[1].LogExpr, exprDollar[2].Filter, exprDollar[5].str)
+		}
+	case 13:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
-			exprVAL.LogExpr = exprDollar[2].LogExpr
+			exprVAL.LogExpr = addParserToLogExpr(exprDollar[1].LogExpr, exprDollar[3].LabelParser)
 		}
 	case 14:
-		exprDollar = exprS[exprpt-2 : exprpt+1]
+		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
-			exprVAL.LogRangeExpr = newLogRange(exprDollar[1].LogExpr, exprDollar[2].duration)
+			exprVAL.LogExpr = addLabelFilterToLogExpr(exprDollar[1].LogExpr, exprDollar[3].LabelFilter)
 		}
 	case 15:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
-			exprVAL.LogRangeExpr = addFilterToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[2].Filter, exprDollar[3].str)
+			exprVAL.LogExpr = addLineFmtToLogExpr(exprDollar[1].LogExpr, exprDollar[3].LineFormatExpr)
 		}
 	case 16:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogExpr = addLabelFmtToLogExpr(exprDollar[1].LogExpr, exprDollar[3].LabelFormatExpr)
+		}
+	case 17:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogExpr = exprDollar[2].LogExpr
+		}
+	case 20:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = newLogRange(exprDollar[1].LogExpr, exprDollar[2].duration, nil, 0)
+		}
+	case 21:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = newLogRange(exprDollar[1].LogExpr, exprDollar[2].duration, nil, exprDollar[3].duration)
+		}
+	case 22:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = newLogRange(exprDollar[1].LogExpr, exprDollar[3].duration, exprDollar[2].UnwrapExpr, 0)
+		}
+	case 23:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = newLogRange(exprDollar[1].LogExpr, exprDollar[3].duration, exprDollar[2].UnwrapExpr, exprDollar[4].duration)
+		}
+	case 24:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addFilterToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[2].Filter, exprDollar[3].str)
+		}
+	case 25:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addIPFilterToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[2].Filter, exprDollar[5].str)
+		}
+	case 26:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addParserToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[3].LabelParser)
+		}
+	case 27:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addLabelFilterToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[3].LabelFilter)
+		}
+	case 28:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addLineFmtToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[3].LineFormatExpr)
+		}
+	case 29:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LogRangeExpr = addLabelFmtToLogRangeExpr(exprDollar[1].LogRangeExpr, exprDollar[3].LabelFormatExpr)
+		}
+	case 30:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.LogRangeExpr = exprDollar[2].LogRangeExpr
 		}
-	case 19:
+	case 33:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
-			exprVAL.RangeAggregationExpr = newRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp)
+			exprVAL.RangeAggregationExpr = mustNewRangeAggregationExpr(exprDollar[3].LogRangeExpr, exprDollar[1].RangeOp, nil)
 		}
-	case 20:
+	case 34:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.RangeAggregationExpr = mustNewRangeAggregationExpr(exprDollar[5].LogRangeExpr, exprDollar[1].RangeOp, &exprDollar[3].str)
+		}
+	case 35:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.RangeAggregationExpr = mustNewSubqueryAggregationExpr(exprDollar[3].SubqueryExpr, exprDollar[1].RangeOp, nil)
+		}
+	case 36:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.RangeAggregationExpr = mustNewSubqueryAggregationExpr(exprDollar[5].SubqueryExpr, exprDollar[1].RangeOp, &exprDollar[3].str)
+		}
+	case 37:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[1].MetricExpr, exprDollar[2].subqueryRange, 0)
+		}
+	case 38:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.SubqueryExpr = newSubqueryExpr(exprDollar[1].MetricExpr, exprDollar[2].subqueryRange, exprDollar[3].duration)
+		}
+	case 39:
+		exprDollar = exprS[exprpt-12 : exprpt+1]
+		{
+			exprVAL.FunctionExpr = mustNewLabelReplaceExpr(exprDollar[3].MetricExpr, exprDollar[5].str, exprDollar[7].str, exprDollar[9].str, exprDollar[11].str)
+		}
+	case 40:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.FunctionExpr = mustNewVectorExpr(exprDollar[3].LiteralExpr)
+		}
+	case 41:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.FunctionExpr = mustNewFunctionExpr(exprDollar[3].MetricExpr, exprDollar[1].FunctionOp, nil)
+		}
+	case 42:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.FunctionExpr = mustNewFunctionExpr(exprDollar[3].MetricExpr, exprDollar[1].FunctionOp, exprDollar[5].LiteralExpr)
+		}
+	case 43:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, nil, nil)
 		}
-	case 21:
+	case 44:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[4].MetricExpr, exprDollar[1].VectorOp, exprDollar[2].Grouping, nil)
 		}
-	case 22:
+	case 45:
 		exprDollar = exprS[exprpt-5 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[3].MetricExpr, exprDollar[1].VectorOp, exprDollar[5].Grouping, nil)
 		}
-	case 23:
+	case 46:
 		exprDollar = exprS[exprpt-6 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, nil, &exprDollar[3].str)
 		}
-	case 24:
+	case 47:
 		exprDollar = exprS[exprpt-7 : exprpt+1]
 		{
 			exprVAL.VectorAggregationExpr = mustNewVectorAggregationExpr(exprDollar[5].MetricExpr, exprDollar[1].VectorOp, exprDollar[7].Grouping, &exprDollar[3].str)
 		}
-	case 25:
+	case 48:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelParser = mustNewLabelParserExpr(OpParserTypeJSON, "")
+		}
+	case 49:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelParser = mustNewLabelParserExpr(OpParserTypeLogfmt, "")
+		}
+	case 50:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.LabelParser = mustNewLabelParserExpr(OpParserTypeRegexp, exprDollar[2].str)
+		}
+	case 51:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.LabelParser = mustNewLabelParserExpr(OpParserTypePattern, exprDollar[2].str)
+		}
+	case 52:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.LineFormatExpr = mustNewLineFmtExpr(exprDollar[2].str)
+		}
+	case 53:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelFormat = NewRenameLabelFmt(exprDollar[1].str, exprDollar[3].str)
+		}
+	case 54:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelFormat = NewTemplateLabelFmt(exprDollar[1].str, exprDollar[3].str)
+		}
+	case 55:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelsFormat = []LabelFmt{exprDollar[1].LabelFormat}
+		}
+	case 56:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelsFormat = append(exprDollar[1].LabelsFormat, exprDollar[3].LabelFormat)
+		}
+	case 57:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.LabelFormatExpr = mustNewLabelFmtExpr(exprDollar[2].LabelsFormat)
+		}
+	case 58:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[3].str, "")
+		}
+	case 59:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.UnwrapExpr = newUnwrapExpr(exprDollar[5].str, exprDollar[3].ConvOp)
+		}
+	case 60:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnwrapExpr = exprDollar[1].UnwrapExpr.addPostFilter(exprDollar[3].LabelFilter)
+		}
+	case 61:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.ConvOp = OpConvBytes
+		}
+	case 62:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.ConvOp = OpConvDuration
+		}
+	case 63:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.duration = exprDollar[2].duration
+		}
+	case 64:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelFilter = NewStringLabelFilter(exprDollar[1].Matcher)
+		}
+	case 65:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelFilter = exprDollar[1].UnitFilter
+		}
+	case 66:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelFilter = exprDollar[1].UnitFilter
+		}
+	case 67:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.LabelFilter = exprDollar[1].LabelFilter
+		}
+	case 68:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelFilter = exprDollar[2].LabelFilter
+		}
+	case 69:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelFilter = NewAndLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
+		}
+	case 70:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.LabelFilter = NewOrLabelFilter(exprDollar[1].LabelFilter, exprDollar[3].LabelFilter)
+		}
+	case 71:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.UnitFilter = exprDollar[1].UnitFilter
+		}
+	case 72:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.UnitFilter = exprDollar[1].UnitFilter
+		}
+	case 73:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 74:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 75:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 76:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 77:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 78:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 79:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewDurationLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[3].duration)
+		}
+	case 80:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterGreaterThan, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 81:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterGreaterThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 82:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterLesserThan, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 83:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterLesserThanOrEqual, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 84:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterNotEqual, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 85:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 86:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewBytesLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[3].bytes)
+		}
+	case 87:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.LabelFilter = mustNewIPLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[5].str)
+		}
+	case 88:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.LabelFilter = mustNewIPLabelFilter(LabelFilterEqual, exprDollar[1].str, exprDollar[5].str)
+		}
+	case 89:
+		exprDollar = exprS[exprpt-6 : exprpt+1]
+		{
+			exprVAL.LabelFilter = mustNewIPLabelFilter(LabelFilterNotEqual, exprDollar[1].str, exprDollar[5].str)
+		}
+	case 90:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterGreaterThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 91:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 92:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterLesserThan, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 93:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterLesserThanOrEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 94:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterNotEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 95:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 96:
+		exprDollar = exprS[exprpt-3 : exprpt+1]
+		{
+			exprVAL.UnitFilter = NewNumericLabelFilter(LabelFilterEqual, exprDollar[1].str, mustNewFloat(exprDollar[3].str))
+		}
+	case 97:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchRegexp
 		}
-	case 26:
+	case 98:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchEqual
 		}
-	case 27:
+	case 99:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchNotRegexp
 		}
-	case 28:
+	case 100:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Filter = labels.MatchNotEqual
 		}
-	case 29:
+	case 101:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Selector = exprDollar[2].Matchers
 		}
-	case 30:
+	case 102:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Selector = exprDollar[2].Matchers
 		}
-	case 31:
+	case 103:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 		}
-	case 32:
+	case 104:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Matchers = []*labels.Matcher{exprDollar[1].Matcher}
 		}
-	case 33:
+	case 105:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matchers = append(exprDollar[1].Matchers, exprDollar[3].Matcher)
 		}
-	case 34:
+	case 106:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 35:
+	case 107:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotEqual, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 36:
+	case 108:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 37:
+	case 109:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Matcher = mustNewMatcher(labels.MatchNotRegexp, exprDollar[1].str, exprDollar[3].str)
 		}
-	case 38:
+	case 110:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("or", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 39:
+	case 111:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("and", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 40:
+	case 112:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("unless", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 41:
+	case 113:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("+", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 42:
+	case 114:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("-", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 43:
+	case 115:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("*", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 44:
+	case 116:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("/", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 45:
+	case 117:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("%", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 46:
+	case 118:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("^", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 47:
+	case 119:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("==", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 48:
+	case 120:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("!=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 49:
+	case 121:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 50:
+	case 122:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr(">=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 51:
+	case 123:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 52:
+	case 124:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.BinOpExpr = mustNewBinOpExpr("<=", exprDollar[3].BinOpModifier, exprDollar[1].Expr, exprDollar[4].Expr)
 		}
-	case 53:
+	case 125:
 		exprDollar = exprS[exprpt-0 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = BinOpOptions{}
 		}
-	case 54:
+	case 126:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.BinOpModifier = BinOpOptions{ReturnBool: true}
 		}
-	case 55:
+	case 127:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching = &VectorMatching{On: true, MatchingLabels: exprDollar[4].Labels}
+		}
+	case 128:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching = &VectorMatching{On: true}
+		}
+	case 129:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching = &VectorMatching{MatchingLabels: exprDollar[4].Labels}
+		}
+	case 130:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching = &VectorMatching{}
+		}
+	case 131:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+		}
+	case 132:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+		}
+	case 133:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
+		}
+	case 134:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
+		}
+	case 135:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardManyToOne
+			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
+		}
+	case 136:
+		exprDollar = exprS[exprpt-2 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
+		}
+	case 137:
+		exprDollar = exprS[exprpt-4 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
+		}
+	case 138:
+		exprDollar = exprS[exprpt-5 : exprpt+1]
+		{
+			exprVAL.BinOpModifier = exprDollar[1].BinOpModifier
+			exprVAL.BinOpModifier.VectorMatching.Card = CardOneToMany
+			exprVAL.BinOpModifier.VectorMatching.Include = exprDollar[4].Labels
+		}
+	case 139:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[1].str, false)
 		}
-	case 56:
+	case 140:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, false)
 		}
-	case 57:
+	case 141:
 		exprDollar = exprS[exprpt-2 : exprpt+1]
 		{
 			exprVAL.LiteralExpr = mustNewLiteralExpr(exprDollar[2].str, true)
 		}
-	case 58:
+	case 142:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeSum
 		}
-	case 59:
+	case 143:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeAvg
 		}
-	case 60:
+	case 144:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeCount
 		}
-	case 61:
+	case 145:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMax
 		}
-	case 62:
+	case 146:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeMin
 		}
-	case 63:
+	case 147:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStddev
 		}
-	case 64:
+	case 148:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeStdvar
 		}
-	case 65:
+	case 149:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeBottomK
 		}
-	case 66:
+	case 150:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.VectorOp = OpTypeTopK
 		}
-	case 67:
+	case 151:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeCount
 		}
-	case 68:
+	case 152:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeRate
 		}
-	case 69:
+	case 153:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytes
 		}
-	case 70:
+	case 154:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.RangeOp = OpRangeTypeBytesRate
 		}
-	case 71:
+	case 155:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeAvg
+		}
+	case 156:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeSum
+		}
+	case 157:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeMin
+		}
+	case 158:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeMax
+		}
+	case 159:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeStdvar
+		}
+	case 160:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeStddev
+		}
+	case 161:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeQuantile
+		}
+	case 162:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.RangeOp = OpRangeTypeAbsent
+		}
+	case 163:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncClampMin
+		}
+	case 164:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncClampMax
+		}
+	case 165:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncAbs
+		}
+	case 166:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncRound
+		}
+	case 167:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncCeil
+		}
+	case 168:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncFloor
+		}
+	case 169:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncSort
+		}
+	case 170:
+		exprDollar = exprS[exprpt-1 : exprpt+1]
+		{
+			exprVAL.FunctionOp = OpFuncSortDesc
+		}
+	case 171:
 		exprDollar = exprS[exprpt-1 : exprpt+1]
 		{
 			exprVAL.Labels = []string{exprDollar[1].str}
 		}
-	case 72:
+	case 172:
 		exprDollar = exprS[exprpt-3 : exprpt+1]
 		{
 			exprVAL.Labels = append(exprDollar[1].Labels, exprDollar[3].str)
 		}
-	case 73:
+	case 173:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &grouping{without: false, groups: exprDollar[3].Labels}
 		}
-	case 74:
+	case 174:
 		exprDollar = exprS[exprpt-4 : exprpt+1]
 		{
 			exprVAL.Grouping = &grouping{without: true, groups: exprDollar[3].Labels}
@@ -3,8 +3,10 @@ package logql
 import (
 	"bytes"
 	"fmt"
+	"net"
 	"regexp"
 	"regexp/syntax"
+	"strings"
 
 	"github.com/prometheus/prometheus/pkg/labels"
 )
@@ -143,6 +145,22 @@ func (l containsFilter) String() string {
 	return string(l.match)
 }
 
+// filterLiterals returns the literals a line must contain to pass the filter.
+// Case insensitive literals are ignored, lines can match them with different bytes.
+func filterLiterals(f LineFilter) [][]byte {
+	switch f := f.(type) {
+	case containsFilter:
+		if f.caseInsensitive {
+			return nil
+		}
+		return [][]byte{f.match}
+	case andFilter:
+		return append(filterLiterals(f.left), filterLiterals(f.right)...)
+	default:
+		return nil
+	}
+}
+
 func newContainsFilter(match []byte, caseInsensitive bool) LineFilter {
 	if len(match) == 0 {
 		return TrueFilter
@@ -156,6 +174,102 @@ func newContainsFilter(match []byte, caseInsensitive bool) LineFilter {
 	}
 }
 
+// ipMatcher matches addresses within a single address (10.0.0.1), a CIDR range (10.0.0.0/8)
+// or an inclusive address range (192.168.0.1-192.168.0.255).
+type ipMatcher struct {
+	start, end net.IP
+}
+
+func newIPMatcher(pattern string) (*ipMatcher, error) {
+	pattern = strings.TrimSpace(pattern)
+	if strings.Contains(pattern, "/") {
+		_, cidr, err := net.ParseCIDR(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ip range %s: %w", pattern, err)
+		}
+		end := make(net.IP, len(cidr.IP))
+		for i := range cidr.IP {
+			end[i] = cidr.IP[i] | ^cidr.Mask[i]
+		}
+		return &ipMatcher{start: cidr.IP.To16(), end: end.To16()}, nil
+	}
+	from, to := pattern, pattern
+	if i := strings.Index(pattern, "-"); i >= 0 {
+		from, to = strings.TrimSpace(pattern[:i]), strings.TrimSpace(pattern[i+1:])
+	}
+	start, end := net.ParseIP(from), net.ParseIP(to)
+	if start == nil || end == nil {
+		return nil, fmt.Errorf("invalid ip range %s", pattern)
+	}
+	if (start.To4() == nil) != (end.To4() == nil) || bytes.Compare(start.To16(), end.To16()) > 0 {
+		return nil, fmt.Errorf("invalid ip range %s: start and end must be ordered addresses of the same family", pattern)
+	}
+	return &ipMatcher{start: start.To16(), end: end.To16()}, nil
+}
+
+func (m *ipMatcher) contains(ip net.IP) bool {
+	ip = ip.To16()
+	return ip != nil && bytes.Compare(ip, m.start) >= 0 && bytes.Compare(ip, m.end) <= 0
+}
+
+// ipFilter matches lines containing at least one IPv4 or IPv6 address within a range.
+type ipFilter struct {
+	matcher *ipMatcher
+}
+
+// newIPFilter creates a new line filter matching addresses within a range, see ipMatcher.
+func newIPFilter(pattern string) (LineFilter, error) {
+	m, err := newIPMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return ipFilter{matcher: m}, nil
+}
+
+func (f ipFilter) Filter(line []byte) bool {
+	for start := 0; start < len(line); {
+		if !isIPByte(line[start]) {
+			start++
+			continue
+		}
+		end := start + 1
+		for end < len(line) && isIPByte(line[end]) {
+			end++
+		}
+		if f.matchCandidate(line[start:end]) {
+			return true
+		}
+		start = end
+	}
+	return false
+}
+
+// matchCandidate tries to match a sequence of bytes that can be found in addresses, e.g. `10.0.0.1:8080`.
+func (f ipFilter) matchCandidate(b []byte) bool {
+	// sentences can end with an address.
+	b = bytes.TrimRight(b, ".")
+	if bytes.IndexByte(b, '.') < 0 && bytes.IndexByte(b, ':') < 0 {
+		return false
+	}
+	if ip := net.ParseIP(string(b)); ip != nil {
+		return f.matcher.contains(ip)
+	}
+	// IPv4 addresses can be followed by a port.
+	for _, part := range bytes.Split(b, []byte(":")) {
+		if bytes.IndexByte(part, '.') < 0 {
+			continue
+		}
+		if ip := net.ParseIP(string(part)); ip != nil && f.matcher.contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func isIPByte(b byte) bool {
+	return (b >= '0' && b <= '9') || (b >= 'a' && b <= 'f') || (b >= 'A' && b <= 'F') || b == '.' || b == ':'
+}
+
 // newFilter creates a new line filter from a match string and type.
 func newFilter(match string, mt labels.MatchType) (LineFilter, error) {
 	switch mt {
@@ -126,6 +126,56 @@ func Test_TrueFilter(t *testing.T) {
 	}
 }
 
+func Test_IPFilter(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		line    string
+		want    bool
+	}{
+		{"10.0.0.0/8", `level=info addr=10.1.2.3 msg="hello"`, true},
+		{"10.0.0.0/8", `level=info addr=11.1.2.3 msg="hello"`, false},
+		{"10.0.0.0/8", `connection from 10.0.0.1:8080 closed`, true},
+		{"10.0.0.0/8", `request from 10.255.255.255.`, true},
+		{"10.0.0.0/8", `version 10.0.1 released`, false},
+		{"10.0.0.0/8", `no address here`, false},
+		{"192.168.0.1-192.168.0.255", `192.168.0.0 192.168.1.0`, false},
+		{"192.168.0.1-192.168.0.255", `src=192.168.1.0 dst=192.168.0.10`, true},
+		{"192.168.0.1", `"192.168.0.1"`, true},
+		{"2001:db8::/32", `client [2001:db8::ff00:42:8329]:443`, true},
+		{"2001:db8::/32", `client [2001:db9::1]:443`, false},
+		{"::1", `listening on ::1`, true},
+		{"::ffff:0:0/96", `ipv4 10.0.0.1`, true},
+	} {
+		t.Run(tc.pattern+" "+tc.line, func(t *testing.T) {
+			f, err := newIPFilter(tc.pattern)
+			require.NoError(t, err)
+			require.Equal(t, tc.want, f.Filter([]byte(tc.line)))
+		})
+	}
+}
+
+func Test_newIPMatcher(t *testing.T) {
+	for _, tc := range []struct {
+		pattern string
+		wantErr bool
+	}{
+		{"10.0.0.0/8", false},
+		{"2001:db8::/32", false},
+		{"192.168.0.1", false},
+		{"192.168.0.1 - 192.168.0.255", false},
+		{"10.0.0.0/33", true},
+		{"192.168.0.255-192.168.0.1", true},
+		{"192.168.0.1-::1", true},
+		{"foo", true},
+		{"", true},
+	} {
+		t.Run(tc.pattern, func(t *testing.T) {
+			_, err := newIPMatcher(tc.pattern)
+			require.Equal(t, tc.wantErr, err != nil, "error: %v", err)
+		})
+	}
+}
+
 func Benchmark_LineFilter(b *testing.B) {
 	b.ReportAllocs()
 	logline := `level=bar ts=2020-02-22T14:57:59.398312973Z caller=logging.go:44 traceID=2107b6b551458908 msg="GET /buzz (200) 4.599635ms`
@@ -177,3 +227,37 @@ func benchmarkRegex(b *testing.B, re, line string, match bool) {
 	})
 	res = m
 }
+
+func Test_RequiredLiterals(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected [][]byte
+	}{
+		{`{app="foo"}`, nil},
+		{`{app="foo"} |= "bar"`, [][]byte{[]byte("bar")}},
+		{`{app="foo"} |= "bar" |~ "buzz" != "fizz"`, [][]byte{[]byte("bar"), []byte("buzz")}},
+		{`{app="foo"} |~ "(?i)bar"`, nil},
+		{`{app="foo"} |~ "bar|buzz"`, nil},
+		{`{app="foo"} |= "bar" | json | status="500" |= "buzz"`, [][]byte{[]byte("bar"), []byte("buzz")}},
+		{`{app="foo"} |= "bar" | line_format "{{.status}}" |= "buzz"`, [][]byte{[]byte("bar")}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseLogSelector(tc.query)
+			require.NoError(t, err)
+			p, err := expr.Pipeline()
+			require.NoError(t, err)
+
+			var literals [][]byte
+			if f, ok := p.ForStream(nil).(LiteralFilterer); ok {
+				literals = f.RequiredLiterals()
+			}
+			require.Equal(t, tc.expected, literals)
+		})
+	}
+
+	expr, err := ParseSampleExpr(`sum_over_time({app="foo"} |= "bar" | logfmt | unwrap latency [1m])`)
+	require.NoError(t, err)
+	extractor, err := expr.Extractor()
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("bar")}, extractor.ForStream(nil).(LiteralFilterer).RequiredLiterals())
+}
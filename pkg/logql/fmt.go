@@ -0,0 +1,190 @@
+package logql
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Value of the ErrorLabel set when a template can't be executed.
+const errTemplateFormat = "TemplateFormatErr"
+
+var (
+	_ Stage = &LineFormatter{}
+	_ Stage = &LabelsFormatter{}
+
+	// TemplateFunctionMap is the set of functions available in templates,
+	// it is shared by LogQL formatters and Promtail's template stage.
+	TemplateFunctionMap = template.FuncMap{
+		"ToLower":    strings.ToLower,
+		"ToUpper":    strings.ToUpper,
+		"Replace":    strings.Replace,
+		"Trim":       strings.Trim,
+		"TrimLeft":   strings.TrimLeft,
+		"TrimRight":  strings.TrimRight,
+		"TrimPrefix": strings.TrimPrefix,
+		"TrimSuffix": strings.TrimSuffix,
+		"TrimSpace":  strings.TrimSpace,
+		"Sha256": func(salt string, s string) string {
+			hash := sha256.Sum256([]byte(salt + s))
+			return hex.EncodeToString(hash[:])
+		},
+		"regexReplaceAll": func(regex string, s string, repl string) string {
+			r := regexp.MustCompile(regex)
+			return r.ReplaceAllString(s, repl)
+		},
+		"regexReplaceAllLiteral": func(regex string, s string, repl string) string {
+			r := regexp.MustCompile(regex)
+			return r.ReplaceAllLiteralString(s, repl)
+		},
+	}
+)
+
+// LineFormatter rewrites the log line using a template executed with the line labels.
+type LineFormatter struct {
+	*template.Template
+	buf *bytes.Buffer
+}
+
+// NewFormatter creates a new log line formatter from a given text template.
+func NewFormatter(tmpl string) (*LineFormatter, error) {
+	t, err := template.New(OpFmtLine).Option("missingkey=zero").Funcs(TemplateFunctionMap).Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid line template: %s", err)
+	}
+	return &LineFormatter{
+		Template: t,
+		buf:      bytes.NewBuffer(make([]byte, 0, 4096)),
+	}, nil
+}
+
+func (lf *LineFormatter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	lf.buf.Reset()
+	if err := lf.Template.Execute(lf.buf, lbs.Labels().Map()); err != nil {
+		lbs.SetErr(errTemplateFormat)
+		return line, true
+	}
+	// the buffer is reused for the next line, the result must be copied.
+	res := make([]byte, len(lf.buf.Bytes()))
+	copy(res, lf.buf.Bytes())
+	return res, true
+}
+
+// LabelFmt is a configuration struct for formatting a label.
+type LabelFmt struct {
+	Name  string
+	Value string
+
+	Rename bool
+}
+
+// NewRenameLabelFmt creates a configuration to rename a label.
+func NewRenameLabelFmt(dst, target string) LabelFmt {
+	return LabelFmt{
+		Name:   dst,
+		Rename: true,
+		Value:  target,
+	}
+}
+
+// NewTemplateLabelFmt creates a configuration to format a label using text template.
+func NewTemplateLabelFmt(dst, template string) LabelFmt {
+	return LabelFmt{
+		Name:   dst,
+		Rename: false,
+		Value:  template,
+	}
+}
+
+func (f LabelFmt) String() string {
+	if f.Rename {
+		return fmt.Sprintf("%s=%s", f.Name, f.Value)
+	}
+	return fmt.Sprintf("%s=%s", f.Name, strconv.Quote(f.Value))
+}
+
+type labelFormatter struct {
+	tmpl *template.Template
+	LabelFmt
+}
+
+// LabelsFormatter renames and formats labels.
+type LabelsFormatter struct {
+	formats      []labelFormatter
+	hasTemplates bool
+	buf          *bytes.Buffer
+}
+
+// NewLabelsFormatter creates a new formatter that can format multiple labels at once.
+// Either by renaming or using text template.
+// It is not allowed to reformat the same label twice within the same formatter.
+func NewLabelsFormatter(fmts []LabelFmt) (*LabelsFormatter, error) {
+	if err := validateLabelFmts(fmts); err != nil {
+		return nil, err
+	}
+	formats := make([]labelFormatter, 0, len(fmts))
+	var hasTemplates bool
+	for _, fm := range fmts {
+		toAdd := labelFormatter{LabelFmt: fm}
+		if !fm.Rename {
+			t, err := template.New(OpFmtLabel).Option("missingkey=zero").Funcs(TemplateFunctionMap).Parse(fm.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid template for label '%s': %s", fm.Name, err)
+			}
+			toAdd.tmpl = t
+			hasTemplates = true
+		}
+		formats = append(formats, toAdd)
+	}
+	return &LabelsFormatter{
+		formats:      formats,
+		hasTemplates: hasTemplates,
+		buf:          bytes.NewBuffer(make([]byte, 0, 1024)),
+	}, nil
+}
+
+func validateLabelFmts(fmts []LabelFmt) error {
+	// it would be too confusing to rename and change the same label value.
+	// To avoid confusion we allow to have a label name only once per stage.
+	uniqueLabelName := map[string]struct{}{}
+	for _, f := range fmts {
+		if f.Name == ErrorLabel {
+			return fmt.Errorf("%s cannot be formatted", f.Name)
+		}
+		if _, ok := uniqueLabelName[f.Name]; ok {
+			return fmt.Errorf("multiple label name '%s' not allowed in a single format operation", f.Name)
+		}
+		uniqueLabelName[f.Name] = struct{}{}
+	}
+	return nil
+}
+
+func (lf *LabelsFormatter) Process(l []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	var data interface{}
+	if lf.hasTemplates {
+		// templates are all executed with the labels as they were before this stage.
+		data = lbs.Labels().Map()
+	}
+	for _, f := range lf.formats {
+		if f.Rename {
+			v, ok := lbs.Get(f.Value)
+			if ok && f.Name != f.Value {
+				lbs.Del(f.Value)
+				lbs.Set(f.Name, v)
+			}
+			continue
+		}
+		lf.buf.Reset()
+		if err := f.tmpl.Execute(lf.buf, data); err != nil {
+			lbs.SetErr(errTemplateFormat)
+			continue
+		}
+		lbs.Set(f.Name, lf.buf.String())
+	}
+	return l, true
+}
@@ -0,0 +1,160 @@
+package logql
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_lineFormatter_Format(t *testing.T) {
+	tests := []struct {
+		name  string
+		fmter *LineFormatter
+		lbs   labels.Labels
+
+		want    []byte
+		wantLbs labels.Labels
+	}{
+		{
+			"combining",
+			newMustLineFormatter("foo{{.foo}}buzz{{  .bar  }}"),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+			[]byte("fooblipbuzzblop"),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+		},
+		{
+			"missing",
+			newMustLineFormatter("foo {{.foo}}buzz{{  .bar  }}"),
+			labels.Labels{{Name: "bar", Value: "blop"}},
+			[]byte("foo buzzblop"),
+			labels.Labels{{Name: "bar", Value: "blop"}},
+		},
+		{
+			"function",
+			newMustLineFormatter("foo {{.foo | ToUpper }} buzz{{  .bar  }}"),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+			[]byte("foo BLIP buzzblop"),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+		},
+		{
+			"template error",
+			newMustLineFormatter("foo {{ Replace .foo }}"),
+			labels.Labels{{Name: "foo", Value: "blip"}},
+			[]byte("original"),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: ErrorLabel, Value: errTemplateFormat}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.Sort(tt.lbs)
+			sort.Sort(tt.wantLbs)
+			builder := NewLabelsBuilder(tt.lbs)
+			builder.Reset()
+			outLine, ok := tt.fmter.Process([]byte("original"), builder)
+			require.True(t, ok)
+			require.Equal(t, tt.want, outLine)
+			require.Equal(t, tt.wantLbs, builder.Labels())
+		})
+	}
+}
+
+func newMustLineFormatter(tmpl string) *LineFormatter {
+	l, err := NewFormatter(tmpl)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+func Test_labelsFormatter_Format(t *testing.T) {
+	tests := []struct {
+		name  string
+		fmter *LabelsFormatter
+
+		in   labels.Labels
+		want labels.Labels
+	}{
+		{
+			"combined with template",
+			mustNewLabelsFormatter([]LabelFmt{NewTemplateLabelFmt("foo", "{{.foo}} and {{.bar}}")}),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+			labels.Labels{{Name: "foo", Value: "blip and blop"}, {Name: "bar", Value: "blop"}},
+		},
+		{
+			"combined with template and rename",
+			mustNewLabelsFormatter([]LabelFmt{
+				NewTemplateLabelFmt("blip", "{{.foo}} and {{.bar}}"),
+				NewRenameLabelFmt("bar", "foo"),
+			}),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+			labels.Labels{{Name: "blip", Value: "blip and blop"}, {Name: "bar", Value: "blip"}},
+		},
+		{
+			"templates use the labels before renaming",
+			mustNewLabelsFormatter([]LabelFmt{
+				NewRenameLabelFmt("baz", "foo"),
+				NewTemplateLabelFmt("blip", "{{.foo}}-{{.baz}}"),
+			}),
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "bar", Value: "blop"}},
+			labels.Labels{{Name: "baz", Value: "blip"}, {Name: "bar", Value: "blop"}, {Name: "blip", Value: "blip-"}},
+		},
+		{
+			"rename missing label",
+			mustNewLabelsFormatter([]LabelFmt{NewRenameLabelFmt("bar", "foo")}),
+			labels.Labels{{Name: "bar", Value: "blop"}},
+			labels.Labels{{Name: "bar", Value: "blop"}},
+		},
+		{
+			"rename to itself",
+			mustNewLabelsFormatter([]LabelFmt{NewRenameLabelFmt("foo", "foo")}),
+			labels.Labels{{Name: "foo", Value: "blip"}},
+			labels.Labels{{Name: "foo", Value: "blip"}},
+		},
+		{
+			"function",
+			mustNewLabelsFormatter([]LabelFmt{NewTemplateLabelFmt("blip", `{{ Replace .foo "i" "o" -1 }}`)}),
+			labels.Labels{{Name: "foo", Value: "blip"}},
+			labels.Labels{{Name: "foo", Value: "blip"}, {Name: "blip", Value: "blop"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			builder := NewLabelsBuilder(tt.in)
+			builder.Reset()
+			_, _ = tt.fmter.Process(nil, builder)
+			sort.Sort(tt.want)
+			require.Equal(t, tt.want, builder.Labels())
+		})
+	}
+}
+
+func mustNewLabelsFormatter(fmts []LabelFmt) *LabelsFormatter {
+	lf, err := NewLabelsFormatter(fmts)
+	if err != nil {
+		panic(err)
+	}
+	return lf
+}
+
+func Test_validateLabelFmts(t *testing.T) {
+	tests := []struct {
+		name    string
+		fmts    []LabelFmt
+		wantErr bool
+	}{
+		{"no dup", []LabelFmt{NewRenameLabelFmt("foo", "bar"), NewRenameLabelFmt("bar", "foo")}, false},
+		{"dup", []LabelFmt{NewRenameLabelFmt("foo", "bar"), NewRenameLabelFmt("foo", "blip")}, true},
+		{"dup with template", []LabelFmt{NewRenameLabelFmt("foo", "bar"), NewTemplateLabelFmt("foo", "{{.bar}}")}, true},
+		{"no error label", []LabelFmt{NewTemplateLabelFmt(ErrorLabel, "{{.bar}}")}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateLabelFmts(tt.fmts); (err != nil) != tt.wantErr {
+				t.Errorf("validateLabelFmts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
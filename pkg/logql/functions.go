@@ -2,36 +2,74 @@ package logql
 
 import (
 	"fmt"
+	"math"
+	"sort"
 	"time"
 
+	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/prometheus/prometheus/promql"
 )
 
 const unsupportedErr = "unsupported range vector aggregation operation: %s"
 
 func (r rangeAggregationExpr) Extractor() (SampleExtractor, error) {
+	stages, err := pipelineStages(r.left.left)
+	if err != nil {
+		return nil, err
+	}
+	if r.left.unwrap != nil {
+		postFilters := make([]Stage, 0, len(r.left.unwrap.postFilters))
+		for _, f := range r.left.unwrap.postFilters {
+			postFilters = append(postFilters, f)
+		}
+		return LabelExtractorWithStages(r.left.unwrap.identifier, r.left.unwrap.operation, stages, postFilters)
+	}
 	switch r.operation {
-	case OpRangeTypeRate, OpRangeTypeCount:
-		return ExtractCount, nil
+	case OpRangeTypeRate, OpRangeTypeCount, OpRangeTypeAbsent:
+		return ExtractCount.ToSampleExtractor(stages...), nil
 	case OpRangeTypeBytes, OpRangeTypeBytesRate:
-		return ExtractBytes, nil
+		return ExtractBytes.ToSampleExtractor(stages...), nil
 	default:
 		return nil, fmt.Errorf(unsupportedErr, r.operation)
 	}
 }
 
 func (r rangeAggregationExpr) aggregator() (RangeVectorAggregator, error) {
-	switch r.operation {
+	return rangeAggregator(r.operation, r.left.interval, r.params)
+}
+
+func (e subqueryAggregationExpr) aggregator() (RangeVectorAggregator, error) {
+	return rangeAggregator(e.operation, e.left.rng.interval, e.params)
+}
+
+// rangeAggregator returns the function aggregating the samples of a range.
+func rangeAggregator(operation string, interval time.Duration, params *float64) (RangeVectorAggregator, error) {
+	switch operation {
 	case OpRangeTypeRate:
-		return rateLogs(r.left.interval), nil
+		return rateLogs(interval), nil
 	case OpRangeTypeCount:
 		return countOverTime, nil
 	case OpRangeTypeBytesRate:
-		return rateLogBytes(r.left.interval), nil
-	case OpRangeTypeBytes:
+		return rateLogBytes(interval), nil
+	case OpRangeTypeBytes, OpRangeTypeSum:
 		return sumOverTime, nil
+	case OpRangeTypeAvg:
+		return avgOverTime, nil
+	case OpRangeTypeMax:
+		return maxOverTime, nil
+	case OpRangeTypeMin:
+		return minOverTime, nil
+	case OpRangeTypeStddev:
+		return stddevOverTime, nil
+	case OpRangeTypeStdvar:
+		return stdvarOverTime, nil
+	case OpRangeTypeQuantile:
+		if params == nil {
+			return nil, fmt.Errorf("parameter required for operation %s", operation)
+		}
+		return quantileOverTime(*params), nil
 	default:
-		return nil, fmt.Errorf(unsupportedErr, r.operation)
+		return nil, fmt.Errorf(unsupportedErr, operation)
 	}
 }
 
@@ -54,6 +92,7 @@ func countOverTime(samples []promql.Point) float64 {
 	return float64(len(samples))
 }
 
+// sumOverTime sums the sample values.
 func sumOverTime(samples []promql.Point) float64 {
 	var sum float64
 	for _, v := range samples {
@@ -61,3 +100,178 @@ func sumOverTime(samples []promql.Point) float64 {
 	}
 	return sum
 }
+
+// avgOverTime calculates the average of the sample values.
+func avgOverTime(samples []promql.Point) float64 {
+	var mean, count float64
+	for _, v := range samples {
+		count++
+		if math.IsInf(mean, 0) {
+			if math.IsInf(v.V, 0) && (mean > 0) == (v.V > 0) {
+				// The `mean` and `v.V` values are `Inf` of the same sign. They
+				// can't be subtracted, but the value of `mean` is correct
+				// already.
+				continue
+			}
+			if !math.IsInf(v.V, 0) && !math.IsNaN(v.V) {
+				// At this stage, the mean is an infinite. If the added
+				// value is neither an Inf or a Nan, we can keep that mean
+				// value.
+				// This is required because our calculation below removes
+				// the mean value, which would look like Inf += x - Inf and
+				// end up as a NaN.
+				continue
+			}
+		}
+		mean += v.V/count - mean/count
+	}
+	return mean
+}
+
+// maxOverTime returns the maximum sample value.
+func maxOverTime(samples []promql.Point) float64 {
+	max := samples[0].V
+	for _, v := range samples {
+		if v.V > max || math.IsNaN(max) {
+			max = v.V
+		}
+	}
+	return max
+}
+
+// minOverTime returns the minimum sample value.
+func minOverTime(samples []promql.Point) float64 {
+	min := samples[0].V
+	for _, v := range samples {
+		if v.V < min || math.IsNaN(min) {
+			min = v.V
+		}
+	}
+	return min
+}
+
+// stdvarOverTime calculates the population standard variance of the sample values.
+func stdvarOverTime(samples []promql.Point) float64 {
+	var aux, count, mean float64
+	for _, v := range samples {
+		count++
+		delta := v.V - mean
+		mean += delta / count
+		aux += delta * (v.V - mean)
+	}
+	return aux / count
+}
+
+// stddevOverTime calculates the population standard deviation of the sample values.
+func stddevOverTime(samples []promql.Point) float64 {
+	return math.Sqrt(stdvarOverTime(samples))
+}
+
+// quantileOverTime calculates the φ-quantile (0 ≤ φ ≤ 1) of the sample values.
+func quantileOverTime(q float64) func(samples []promql.Point) float64 {
+	return func(samples []promql.Point) float64 {
+		values := make([]float64, 0, len(samples))
+		for _, v := range samples {
+			values = append(values, v.V)
+		}
+		return quantile(q, values)
+	}
+}
+
+// quantile calculates the given quantile of a slice of values.
+// It is the same implementation as Prometheus' quantile_over_time,
+// NaN is returned when the slice is empty and -Inf or +Inf when q is out of range.
+func quantile(q float64, values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	if q < 0 {
+		return math.Inf(-1)
+	}
+	if q > 1 {
+		return math.Inf(+1)
+	}
+	sort.Float64s(values)
+
+	n := float64(len(values))
+	// When the quantile lies between two samples,
+	// we use a weighted average of the two samples.
+	rank := q * (n - 1)
+
+	lowerIndex := math.Max(0, math.Floor(rank))
+	upperIndex := math.Min(n-1, lowerIndex+1)
+
+	weight := rank - math.Floor(rank)
+	return values[int(lowerIndex)]*(1-weight) + values[int(upperIndex)]*weight
+}
+
+// vectorFunction returns the function to apply to the vector of each step.
+func (e *functionExpr) vectorFunction() (func(promql.Vector) promql.Vector, error) {
+	switch e.function {
+	case OpFuncAbs:
+		return mapValues(math.Abs), nil
+	case OpFuncCeil:
+		return mapValues(math.Ceil), nil
+	case OpFuncFloor:
+		return mapValues(math.Floor), nil
+	case OpFuncRound:
+		toNearest := 1.
+		if e.param != nil {
+			toNearest = *e.param
+		}
+		return mapValues(round(toNearest)), nil
+	case OpFuncClampMin:
+		min := *e.param
+		return mapValues(func(v float64) float64 { return math.Max(min, v) }), nil
+	case OpFuncClampMax:
+		max := *e.param
+		return mapValues(func(v float64) float64 { return math.Min(max, v) }), nil
+	case OpFuncSort:
+		return sortByValue(false), nil
+	case OpFuncSortDesc:
+		return sortByValue(true), nil
+	default:
+		return nil, fmt.Errorf("unsupported function: %s", e.function)
+	}
+}
+
+// mapValues applies a function to the value of each sample.
+func mapValues(fn func(float64) float64) func(promql.Vector) promql.Vector {
+	return func(vec promql.Vector) promql.Vector {
+		for i := range vec {
+			vec[i].V = fn(vec[i].V)
+		}
+		return vec
+	}
+}
+
+// round rounds a value to the nearest multiple of toNearest, ties are rounded up.
+// It is the same implementation as Prometheus' round function.
+func round(toNearest float64) func(float64) float64 {
+	// Inverting the multiple to divide by it avoids floating point artifacts, e.g. 0.1.
+	toNearestInverse := 1.0 / toNearest
+	return func(v float64) float64 {
+		return math.Floor(v*toNearestInverse+0.5) / toNearestInverse
+	}
+}
+
+// sortByValue sorts the samples by value, samples with the same value are sorted by labels.
+// NaN values are always sorted last.
+func sortByValue(desc bool) func(promql.Vector) promql.Vector {
+	return func(vec promql.Vector) promql.Vector {
+		sort.Slice(vec, func(i, j int) bool {
+			a, b := vec[i].V, vec[j].V
+			switch {
+			case math.IsNaN(a) || math.IsNaN(b):
+				return !math.IsNaN(a) && math.IsNaN(b)
+			case a == b:
+				return labels.Compare(vec[i].Metric, vec[j].Metric) < 0
+			case desc:
+				return a > b
+			default:
+				return a < b
+			}
+		})
+		return vec
+	}
+}
@@ -0,0 +1,94 @@
+package logql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RangeAggregations(t *testing.T) {
+	points := []promql.Point{
+		{T: 1, V: 4},
+		{T: 2, V: 1},
+		{T: 3, V: 2},
+		{T: 4, V: 5},
+		{T: 5, V: 3},
+	}
+	for _, tc := range []struct {
+		op     string
+		params *float64
+		want   float64
+	}{
+		{OpRangeTypeSum, nil, 15},
+		{OpRangeTypeAvg, nil, 3},
+		{OpRangeTypeMax, nil, 5},
+		{OpRangeTypeMin, nil, 1},
+		{OpRangeTypeStdvar, nil, 2},
+		{OpRangeTypeStddev, nil, math.Sqrt(2)},
+		{OpRangeTypeQuantile, float64Ptr(0.5), 3},
+		{OpRangeTypeQuantile, float64Ptr(0.99), 4.96},
+		{OpRangeTypeQuantile, float64Ptr(0), 1},
+		{OpRangeTypeQuantile, float64Ptr(1.5), math.Inf(+1)},
+	} {
+		tc := tc
+		t.Run(tc.op, func(t *testing.T) {
+			expr := rangeAggregationExpr{
+				left:      &logRange{interval: time.Minute, unwrap: newUnwrapExpr("foo", "")},
+				operation: tc.op,
+				params:    tc.params,
+			}
+			agg, err := expr.aggregator()
+			require.NoError(t, err)
+			require.InDelta(t, tc.want, agg(points), 1e-9)
+		})
+	}
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func Test_VectorFunctions(t *testing.T) {
+	for _, tc := range []struct {
+		function string
+		param    *float64
+		in       []float64
+		want     []float64
+	}{
+		{OpFuncAbs, nil, []float64{-1.5, 0, 2}, []float64{1.5, 0, 2}},
+		{OpFuncCeil, nil, []float64{-1.5, 0.2, 2}, []float64{-1, 1, 2}},
+		{OpFuncFloor, nil, []float64{-1.5, 0.7, 2}, []float64{-2, 0, 2}},
+		{OpFuncRound, nil, []float64{-1.5, 0.5, 2.4}, []float64{-1, 1, 2}},
+		{OpFuncRound, float64Ptr(0.1), []float64{1.24, 1.25, 2.01}, []float64{1.2, 1.3, 2}},
+		{OpFuncRound, float64Ptr(5), []float64{1, 7.5, 13}, []float64{0, 10, 15}},
+		{OpFuncClampMin, float64Ptr(0), []float64{-1, 0, 2}, []float64{0, 0, 2}},
+		{OpFuncClampMax, float64Ptr(1), []float64{-1, 0, 2}, []float64{-1, 0, 1}},
+		{OpFuncSort, nil, []float64{3, math.NaN(), 1, 2}, []float64{1, 2, 3, math.NaN()}},
+		{OpFuncSortDesc, nil, []float64{3, math.NaN(), 1, 2}, []float64{3, 2, 1, math.NaN()}},
+	} {
+		tc := tc
+		t.Run(tc.function, func(t *testing.T) {
+			expr := &functionExpr{function: tc.function, param: tc.param}
+			fn, err := expr.vectorFunction()
+			require.NoError(t, err)
+
+			vec := make(promql.Vector, 0, len(tc.in))
+			for _, v := range tc.in {
+				vec = append(vec, promql.Sample{Point: promql.Point{V: v}})
+			}
+			vec = fn(vec)
+
+			require.Len(t, vec, len(tc.want))
+			for i, want := range tc.want {
+				if math.IsNaN(want) {
+					require.True(t, math.IsNaN(vec[i].V))
+					continue
+				}
+				require.InDelta(t, want, vec[i].V, 1e-9)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package logql
+
+import (
+	"time"
+)
+
+// InstantSplit is a sub-range of the range aggregation of an instant query.
+// Its query selects the entries of the sub-range when it is evaluated at Ts.
+type InstantSplit struct {
+	Query string
+	Ts    time.Time
+	Range time.Duration
+}
+
+// splittableRangeOps maps the range aggregations which results can be merged across sub-ranges
+// to the vector aggregation merging them.
+var splittableRangeOps = map[string]string{
+	OpRangeTypeCount: OpTypeSum,
+	OpRangeTypeBytes: OpTypeSum,
+	OpRangeTypeSum:   OpTypeSum,
+	OpRangeTypeMax:   OpTypeMax,
+	OpRangeTypeMin:   OpTypeMin,
+}
+
+// SplitInstantQuery splits the range of an instant query evaluated at ts into sub-ranges ending on multiples of interval.
+// It returns the sub-queries, in time order, and the operation (sum, max or min) merging their results per series.
+// Only queries made of a single range aggregation, optionally within vector aggregations of the same merging
+// operation, can be split, false is returned for other queries or when the range is not longer than the interval.
+func SplitInstantQuery(expr SampleExpr, ts time.Time, interval time.Duration) ([]InstantSplit, string, bool) {
+	if interval <= 0 {
+		return nil, "", false
+	}
+	r, merge, ok := splittableRange(expr)
+	if !ok || r.left.interval <= interval {
+		return nil, "", false
+	}
+
+	selRange := r.left.interval
+	defer func() { r.left.interval = selRange }()
+
+	var (
+		splits []InstantSplit
+		start  = ts.Add(-selRange)
+	)
+	for start.Before(ts) {
+		end := start.Truncate(interval).Add(interval)
+		if end.After(ts) {
+			end = ts
+		}
+		r.left.interval = end.Sub(start)
+		splits = append(splits, InstantSplit{
+			Query: expr.String(),
+			Ts:    end,
+			Range: r.left.interval,
+		})
+		start = end
+	}
+	return splits, merge, true
+}
+
+// splittableRange returns the range aggregation of a splittable expression and the operation merging its results.
+func splittableRange(expr SampleExpr) (*rangeAggregationExpr, string, bool) {
+	switch e := expr.(type) {
+	case *rangeAggregationExpr:
+		merge, ok := splittableRangeOps[e.operation]
+		if !ok || e.params != nil {
+			return nil, "", false
+		}
+		return e, merge, true
+	case *vectorAggregationExpr:
+		r, merge, ok := splittableRange(e.left)
+		if !ok || e.operation != merge || e.params != 0 {
+			return nil, "", false
+		}
+		return r, merge, true
+	default:
+		return nil, "", false
+	}
+}
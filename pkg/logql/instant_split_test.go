@@ -0,0 +1,84 @@
+package logql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitInstantQuery(t *testing.T) {
+	ts := time.Date(2020, 10, 10, 12, 30, 0, 0, time.UTC)
+
+	for _, tc := range []struct {
+		query  string
+		splits []InstantSplit
+		merge  string
+	}{
+		{
+			`count_over_time({app="foo"}[3h])`,
+			[]InstantSplit{
+				{`count_over_time({app="foo"}[30m])`, ts.Add(-150 * time.Minute), 30 * time.Minute},
+				{`count_over_time({app="foo"}[1h])`, ts.Add(-90 * time.Minute), time.Hour},
+				{`count_over_time({app="foo"}[1h])`, ts.Add(-30 * time.Minute), time.Hour},
+				{`count_over_time({app="foo"}[30m])`, ts, 30 * time.Minute},
+			},
+			OpTypeSum,
+		},
+		{
+			`sum by (level) (bytes_over_time({app="foo"} |= "bar"[2h] offset 1h))`,
+			[]InstantSplit{
+				{`sum by(level)(bytes_over_time({app="foo"} |= "bar"[30m] offset 1h))`, ts.Add(-90 * time.Minute), 30 * time.Minute},
+				{`sum by(level)(bytes_over_time({app="foo"} |= "bar"[1h] offset 1h))`, ts.Add(-30 * time.Minute), time.Hour},
+				{`sum by(level)(bytes_over_time({app="foo"} |= "bar"[30m] offset 1h))`, ts, 30 * time.Minute},
+			},
+			OpTypeSum,
+		},
+		{
+			`max(max_over_time({app="foo"} | unwrap latency [90m]))`,
+			[]InstantSplit{
+				{`max(max_over_time({app="foo"} | unwrap latency[1h]))`, ts.Add(-30 * time.Minute), time.Hour},
+				{`max(max_over_time({app="foo"} | unwrap latency[30m]))`, ts, 30 * time.Minute},
+			},
+			OpTypeMax,
+		},
+		{
+			`min_over_time({app="foo"} | unwrap latency [2h])`,
+			[]InstantSplit{
+				{`min_over_time({app="foo"} | unwrap latency[30m])`, ts.Add(-90 * time.Minute), 30 * time.Minute},
+				{`min_over_time({app="foo"} | unwrap latency[1h])`, ts.Add(-30 * time.Minute), time.Hour},
+				{`min_over_time({app="foo"} | unwrap latency[30m])`, ts, 30 * time.Minute},
+			},
+			OpTypeMin,
+		},
+		// not splittable.
+		{`count_over_time({app="foo"}[1h])`, nil, ""},
+		{`rate({app="foo"}[3h])`, nil, ""},
+		{`avg_over_time({app="foo"} | unwrap latency [3h])`, nil, ""},
+		{`max(count_over_time({app="foo"}[3h]))`, nil, ""},
+		{`sum(max_over_time({app="foo"} | unwrap latency [3h]))`, nil, ""},
+		{`topk(2, count_over_time({app="foo"}[3h]))`, nil, ""},
+		{`quantile_over_time(0.99, {app="foo"} | unwrap latency [3h])`, nil, ""},
+		{`count_over_time({app="foo"}[3h]) / count_over_time({app="bar"}[3h])`, nil, ""},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseSampleExpr(tc.query)
+			require.NoError(t, err)
+			original := expr.String()
+
+			splits, merge, ok := SplitInstantQuery(expr, ts, time.Hour)
+			require.Equal(t, tc.splits != nil, ok)
+			require.Equal(t, tc.merge, merge)
+			require.Equal(t, original, expr.String())
+			for i := range tc.splits {
+				// splits are expected to be valid queries.
+				_, err := ParseSampleExpr(splits[i].Query)
+				require.NoError(t, err)
+				e, err := ParseSampleExpr(tc.splits[i].Query)
+				require.NoError(t, err)
+				tc.splits[i].Query = e.String()
+			}
+			require.Equal(t, tc.splits, splits)
+		})
+	}
+}
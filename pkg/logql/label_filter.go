@@ -0,0 +1,353 @@
+package logql
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// Value of the ErrorLabel set when a label value can't be converted for a comparison.
+const errLabelFilter = "LabelFilterErr"
+
+var (
+	_ LabelFilterer = &BinaryLabelFilter{}
+	_ LabelFilterer = &BytesLabelFilter{}
+	_ LabelFilterer = &DurationLabelFilter{}
+	_ LabelFilterer = &NumericLabelFilter{}
+	_ LabelFilterer = &StringLabelFilter{}
+	_ LabelFilterer = &IPLabelFilter{}
+)
+
+// LabelFilterType is an enum for label filtering types.
+type LabelFilterType int
+
+// Possible LabelFilterType.
+const (
+	LabelFilterEqual LabelFilterType = iota
+	LabelFilterNotEqual
+	LabelFilterGreaterThan
+	LabelFilterGreaterThanOrEqual
+	LabelFilterLesserThan
+	LabelFilterLesserThanOrEqual
+)
+
+func (f LabelFilterType) String() string {
+	switch f {
+	case LabelFilterEqual:
+		return "=="
+	case LabelFilterNotEqual:
+		return "!="
+	case LabelFilterGreaterThan:
+		return ">"
+	case LabelFilterGreaterThanOrEqual:
+		return ">="
+	case LabelFilterLesserThan:
+		return "<"
+	case LabelFilterLesserThanOrEqual:
+		return "<="
+	default:
+		return ""
+	}
+}
+
+// LabelFilterer can filter extracted labels.
+type LabelFilterer interface {
+	Stage
+	fmt.Stringer
+}
+
+// BinaryLabelFilter combines two label filters with an `and` or `or` operation.
+type BinaryLabelFilter struct {
+	Left  LabelFilterer
+	Right LabelFilterer
+	and   bool
+}
+
+// NewAndLabelFilter creates a new LabelFilterer from a and binary operation of two LabelFilterer.
+func NewAndLabelFilter(left LabelFilterer, right LabelFilterer) *BinaryLabelFilter {
+	return &BinaryLabelFilter{
+		Left:  left,
+		Right: right,
+		and:   true,
+	}
+}
+
+// NewOrLabelFilter creates a new LabelFilterer from a or binary operation of two LabelFilterer.
+func NewOrLabelFilter(left LabelFilterer, right LabelFilterer) *BinaryLabelFilter {
+	return &BinaryLabelFilter{
+		Left:  left,
+		Right: right,
+	}
+}
+
+func (b *BinaryLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	line, lok := b.Left.Process(line, lbs)
+	if b.and && !lok {
+		return line, false
+	}
+	if !b.and && lok {
+		return line, true
+	}
+	return b.Right.Process(line, lbs)
+}
+
+func (b *BinaryLabelFilter) String() string {
+	var sb strings.Builder
+	sb.WriteString("( ")
+	sb.WriteString(b.Left.String())
+	if b.and {
+		sb.WriteString(" " + OpTypeAnd + " ")
+	} else {
+		sb.WriteString(" " + OpTypeOr + " ")
+	}
+	sb.WriteString(b.Right.String())
+	sb.WriteString(" )")
+	return sb.String()
+}
+
+// BytesLabelFilter filters labels holding a bytes size, e.g. `10KB`.
+type BytesLabelFilter struct {
+	Name  string
+	Value uint64
+	Type  LabelFilterType
+}
+
+// NewBytesLabelFilter creates a new label filterer which parses bytes string representation (1KB) from the value of the named label
+// and compares it with the given b value.
+func NewBytesLabelFilter(t LabelFilterType, name string, b uint64) *BytesLabelFilter {
+	return &BytesLabelFilter{
+		Name:  name,
+		Type:  t,
+		Value: b,
+	}
+}
+
+func (d *BytesLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if lbs.HasErr() {
+		// if there's an error only the string matchers can filter it out.
+		return line, true
+	}
+	v, ok := lbs.Get(d.Name)
+	if !ok {
+		// we have not found this label.
+		return line, false
+	}
+	value, err := humanize.ParseBytes(v)
+	if err != nil {
+		lbs.SetErr(errLabelFilter)
+		return line, true
+	}
+	return line, compareUint64(d.Type, value, d.Value)
+}
+
+func (d *BytesLabelFilter) String() string {
+	return fmt.Sprintf("%s%s%s", d.Name, d.Type, formatBytes(d.Value))
+}
+
+// formatBytes returns a human readable representation of b that parses back to the same value.
+func formatBytes(b uint64) string {
+	h := strings.Replace(humanize.Bytes(b), " ", "", 1)
+	if v, err := humanize.ParseBytes(h); err == nil && v == b {
+		return h
+	}
+	return strconv.FormatUint(b, 10) + "B"
+}
+
+// DurationLabelFilter filters labels holding a duration, e.g. `250ms`.
+type DurationLabelFilter struct {
+	Name  string
+	Value time.Duration
+	Type  LabelFilterType
+}
+
+// NewDurationLabelFilter creates a new label filterer which parses duration string representation (5s)
+// from the value of the named label and compares it with the given d value.
+func NewDurationLabelFilter(t LabelFilterType, name string, d time.Duration) *DurationLabelFilter {
+	return &DurationLabelFilter{
+		Name:  name,
+		Type:  t,
+		Value: d,
+	}
+}
+
+func (d *DurationLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if lbs.HasErr() {
+		// if there's an error only the string matchers can filter it out.
+		return line, true
+	}
+	v, ok := lbs.Get(d.Name)
+	if !ok {
+		// we have not found this label.
+		return line, false
+	}
+	value, err := time.ParseDuration(v)
+	if err != nil {
+		lbs.SetErr(errLabelFilter)
+		return line, true
+	}
+	return line, compareFloat64(d.Type, float64(value), float64(d.Value))
+}
+
+func (d *DurationLabelFilter) String() string {
+	return fmt.Sprintf("%s%s%s", d.Name, d.Type, d.Value)
+}
+
+// NumericLabelFilter filters labels holding a number, e.g. `500`.
+type NumericLabelFilter struct {
+	Name  string
+	Value float64
+	Type  LabelFilterType
+}
+
+// NewNumericLabelFilter creates a new label filterer which parses float64 string representation (5.2)
+// from the value of the named label and compares it with the given f value.
+func NewNumericLabelFilter(t LabelFilterType, name string, v float64) *NumericLabelFilter {
+	return &NumericLabelFilter{
+		Name:  name,
+		Type:  t,
+		Value: v,
+	}
+}
+
+func (n *NumericLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if lbs.HasErr() {
+		// if there's an error only the string matchers can filter it out.
+		return line, true
+	}
+	v, ok := lbs.Get(n.Name)
+	if !ok {
+		// we have not found this label.
+		return line, false
+	}
+	value, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		lbs.SetErr(errLabelFilter)
+		return line, true
+	}
+	return line, compareFloat64(n.Type, value, n.Value)
+}
+
+func (n *NumericLabelFilter) String() string {
+	return fmt.Sprintf("%s%s%s", n.Name, n.Type, strconv.FormatFloat(n.Value, 'f', -1, 64))
+}
+
+// StringLabelFilter filters labels using a label matcher.
+type StringLabelFilter struct {
+	*labels.Matcher
+}
+
+// NewStringLabelFilter creates a new label filterer which compares string label.
+// This is the only LabelFilterer that can filter out the __error__ label.
+// Unlike other LabelFilterer which apply conversion, if the label name doesn't exist it is compared with an empty value.
+func NewStringLabelFilter(m *labels.Matcher) *StringLabelFilter {
+	return &StringLabelFilter{
+		Matcher: m,
+	}
+}
+
+func (s *StringLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	v, _ := lbs.Get(s.Name)
+	return line, s.Matches(v)
+}
+
+// IPLabelFilter filters labels holding an address within an ip range, e.g. `addr=ip("10.0.0.0/8")`.
+type IPLabelFilter struct {
+	Name    string
+	Pattern string
+	Type    LabelFilterType
+
+	matcher *ipMatcher
+}
+
+// NewIPLabelFilter creates a new label filterer which parses the address from the value of the named label
+// and checks if it is within the ip range of the pattern, see ipMatcher for the supported ranges.
+// Only the equal and not equal types are supported.
+func NewIPLabelFilter(t LabelFilterType, name string, pattern string) (*IPLabelFilter, error) {
+	if t != LabelFilterEqual && t != LabelFilterNotEqual {
+		return nil, fmt.Errorf("unsupported ip label filter type %s", t)
+	}
+	m, err := newIPMatcher(pattern)
+	if err != nil {
+		return nil, err
+	}
+	return &IPLabelFilter{
+		Name:    name,
+		Pattern: pattern,
+		Type:    t,
+		matcher: m,
+	}, nil
+}
+
+func mustNewIPLabelFilter(t LabelFilterType, name string, pattern string) *IPLabelFilter {
+	f, err := NewIPLabelFilter(t, name, pattern)
+	if err != nil {
+		panic(newParseError(err.Error(), 0, 0))
+	}
+	return f
+}
+
+func (f *IPLabelFilter) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	if lbs.HasErr() {
+		// if there's an error only the string matchers can filter it out.
+		return line, true
+	}
+	v, ok := lbs.Get(f.Name)
+	if !ok {
+		// we have not found this label.
+		return line, false
+	}
+	// values that are not addresses, e.g. `-`, are not within the range.
+	ip := net.ParseIP(strings.TrimSpace(v))
+	in := ip != nil && f.matcher.contains(ip)
+	if f.Type == LabelFilterNotEqual {
+		return line, !in
+	}
+	return line, in
+}
+
+func (f *IPLabelFilter) String() string {
+	return fmt.Sprintf("%s%s%s(%s)", f.Name, f.Type, OpFilterIP, strconv.Quote(f.Pattern))
+}
+
+func compareFloat64(t LabelFilterType, left, right float64) bool {
+	switch t {
+	case LabelFilterEqual:
+		return left == right
+	case LabelFilterNotEqual:
+		return left != right
+	case LabelFilterGreaterThan:
+		return left > right
+	case LabelFilterGreaterThanOrEqual:
+		return left >= right
+	case LabelFilterLesserThan:
+		return left < right
+	case LabelFilterLesserThanOrEqual:
+		return left <= right
+	default:
+		return false
+	}
+}
+
+func compareUint64(t LabelFilterType, left, right uint64) bool {
+	switch t {
+	case LabelFilterEqual:
+		return left == right
+	case LabelFilterNotEqual:
+		return left != right
+	case LabelFilterGreaterThan:
+		return left > right
+	case LabelFilterGreaterThanOrEqual:
+		return left >= right
+	case LabelFilterLesserThan:
+		return left < right
+	case LabelFilterLesserThanOrEqual:
+		return left <= right
+	default:
+		return false
+	}
+}
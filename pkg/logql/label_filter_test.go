@@ -0,0 +1,227 @@
+package logql
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLabelFilter_Process(t *testing.T) {
+	tests := []struct {
+		f       LabelFilterer
+		lbs     labels.Labels
+		want    bool
+		wantLbs labels.Labels
+	}{
+		{
+			NewAndLabelFilter(NewNumericLabelFilter(LabelFilterEqual, "foo", 5), NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second)),
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "1s"}},
+			true,
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "1s"}},
+		},
+		{
+			NewAndLabelFilter(NewNumericLabelFilter(LabelFilterEqual, "foo", 5), NewBytesLabelFilter(LabelFilterEqual, "bar", 42)),
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "42B"}},
+			true,
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "42B"}},
+		},
+		{
+			NewAndLabelFilter(
+				NewNumericLabelFilter(LabelFilterEqual, "foo", 5),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+			false,
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+		},
+		{
+			NewAndLabelFilter(
+				NewNumericLabelFilter(LabelFilterEqual, "foo", 5),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "2s"}},
+			false,
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "2s"}},
+		},
+		{
+			NewAndLabelFilter(
+				NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, "foo", "5")),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "1s"}},
+			true,
+			labels.Labels{{Name: "foo", Value: "5"}, {Name: "bar", Value: "1s"}},
+		},
+		{
+			NewAndLabelFilter(
+				NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, "foo", "5")),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+			false,
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+		},
+		{
+			NewOrLabelFilter(
+				NewNumericLabelFilter(LabelFilterEqual, "foo", 5),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+			true,
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "1s"}},
+		},
+		{
+			NewOrLabelFilter(
+				NewNumericLabelFilter(LabelFilterEqual, "foo", 5),
+				NewDurationLabelFilter(LabelFilterEqual, "bar", 1*time.Second),
+			),
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "2s"}},
+			false,
+			labels.Labels{{Name: "foo", Value: "6"}, {Name: "bar", Value: "2s"}},
+		},
+		{
+			NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, "status", 500),
+			labels.Labels{{Name: "status", Value: "404"}},
+			false,
+			labels.Labels{{Name: "status", Value: "404"}},
+		},
+		{
+			NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, "status", 500),
+			labels.Labels{{Name: "app", Value: "foo"}},
+			false,
+			labels.Labels{{Name: "app", Value: "foo"}},
+		},
+		{
+			NewDurationLabelFilter(LabelFilterGreaterThan, "latency", 250*time.Millisecond),
+			labels.Labels{{Name: "latency", Value: "1.5s"}},
+			true,
+			labels.Labels{{Name: "latency", Value: "1.5s"}},
+		},
+		{
+			NewBytesLabelFilter(LabelFilterLesserThan, "size", 10000),
+			labels.Labels{{Name: "size", Value: "1.2KiB"}},
+			true,
+			labels.Labels{{Name: "size", Value: "1.2KiB"}},
+		},
+		{
+			NewNumericLabelFilter(LabelFilterEqual, "status", 500),
+			labels.Labels{{Name: "status", Value: "not-a-number"}},
+			true,
+			labels.Labels{{Name: "status", Value: "not-a-number"}, {Name: ErrorLabel, Value: errLabelFilter}},
+		},
+		{
+			NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, ErrorLabel, "")),
+			labels.Labels{{Name: "status", Value: "200"}},
+			true,
+			labels.Labels{{Name: "status", Value: "200"}},
+		},
+		{
+			mustNewIPLabelFilter(LabelFilterEqual, "addr", "10.0.0.0/8"),
+			labels.Labels{{Name: "addr", Value: "10.1.2.3"}},
+			true,
+			labels.Labels{{Name: "addr", Value: "10.1.2.3"}},
+		},
+		{
+			mustNewIPLabelFilter(LabelFilterEqual, "addr", "192.168.0.1-192.168.0.255"),
+			labels.Labels{{Name: "addr", Value: "192.168.1.1"}},
+			false,
+			labels.Labels{{Name: "addr", Value: "192.168.1.1"}},
+		},
+		{
+			mustNewIPLabelFilter(LabelFilterNotEqual, "addr", "10.0.0.0/8"),
+			labels.Labels{{Name: "addr", Value: "-"}},
+			true,
+			labels.Labels{{Name: "addr", Value: "-"}},
+		},
+		{
+			mustNewIPLabelFilter(LabelFilterEqual, "addr", "10.0.0.0/8"),
+			labels.Labels{{Name: "foo", Value: "10.1.2.3"}},
+			false,
+			labels.Labels{{Name: "foo", Value: "10.1.2.3"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.f.String(), func(t *testing.T) {
+			sort.Sort(tt.lbs)
+			b := NewLabelsBuilder(tt.lbs)
+			b.Reset()
+			_, got := tt.f.Process(nil, b)
+			require.Equal(t, tt.want, got)
+			sort.Sort(tt.wantLbs)
+			require.Equal(t, tt.wantLbs, b.Labels())
+		})
+	}
+}
+
+func TestErrorFiltering(t *testing.T) {
+	tests := []struct {
+		f       LabelFilterer
+		lbs     labels.Labels
+		err     string
+		want    bool
+		wantLbs labels.Labels
+	}{
+		{
+			NewNumericLabelFilter(LabelFilterEqual, "status", 500),
+			labels.Labels{{Name: "status", Value: "200"}},
+			errJSON,
+			true,
+			labels.Labels{{Name: "status", Value: "200"}, {Name: ErrorLabel, Value: errJSON}},
+		},
+		{
+			NewStringLabelFilter(labels.MustNewMatcher(labels.MatchNotEqual, ErrorLabel, errJSON)),
+			labels.Labels{{Name: "status", Value: "200"}},
+			errJSON,
+			false,
+			labels.Labels{{Name: "status", Value: "200"}, {Name: ErrorLabel, Value: errJSON}},
+		},
+		{
+			NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, ErrorLabel, "")),
+			labels.Labels{{Name: "status", Value: "200"}},
+			errLogfmt,
+			false,
+			labels.Labels{{Name: "status", Value: "200"}, {Name: ErrorLabel, Value: errLogfmt}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.f.String(), func(t *testing.T) {
+			b := NewLabelsBuilder(tt.lbs)
+			b.Reset()
+			b.SetErr(tt.err)
+			_, got := tt.f.Process(nil, b)
+			require.Equal(t, tt.want, got)
+			sort.Sort(tt.wantLbs)
+			require.Equal(t, tt.wantLbs, b.Labels())
+		})
+	}
+}
+
+func TestLabelFilter_String(t *testing.T) {
+	for _, tc := range []struct {
+		f    LabelFilterer
+		want string
+	}{
+		{NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, "status", 500), "status>=500"},
+		{NewNumericLabelFilter(LabelFilterEqual, "ratio", 0.25), "ratio==0.25"},
+		{NewDurationLabelFilter(LabelFilterGreaterThan, "latency", 250*time.Millisecond), "latency>250ms"},
+		{NewBytesLabelFilter(LabelFilterLesserThan, "size", 10000), "size<10kB"},
+		{NewBytesLabelFilter(LabelFilterLesserThan, "size", 1234), "size<1234B"},
+		{NewStringLabelFilter(labels.MustNewMatcher(labels.MatchRegexp, "method", "GET|POST")), `method=~"GET|POST"`},
+		{mustNewIPLabelFilter(LabelFilterEqual, "addr", "10.0.0.0/8"), `addr==ip("10.0.0.0/8")`},
+		{mustNewIPLabelFilter(LabelFilterNotEqual, "addr", "192.168.0.1-192.168.0.255"), `addr!=ip("192.168.0.1-192.168.0.255")`},
+		{
+			NewOrLabelFilter(
+				NewAndLabelFilter(NewNumericLabelFilter(LabelFilterNotEqual, "status", 200), NewNumericLabelFilter(LabelFilterLesserThan, "status", 300)),
+				NewDurationLabelFilter(LabelFilterLesserThanOrEqual, "latency", time.Second),
+			),
+			"( ( status!=200 and status<300 ) or latency<=1s )",
+		},
+	} {
+		t.Run(tc.want, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.f.String())
+		})
+	}
+}
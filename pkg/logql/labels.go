@@ -0,0 +1,206 @@
+package logql
+
+import (
+	"sort"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// ErrorLabel is the name of the label holding the error that occurred while processing a log line.
+const ErrorLabel = "__error__"
+
+// duplicateSuffix is appended to extracted labels colliding with an existing stream label.
+const duplicateSuffix = "_extracted"
+
+// LabelsResult is the result of a pipeline: the labels of a log line and their cached string representation.
+type LabelsResult interface {
+	String() string
+	Labels() labels.Labels
+	Hash() uint64
+}
+
+// NewLabelsResult creates a new LabelsResult from a labels set and its hash.
+func NewLabelsResult(lbs labels.Labels, hash uint64) LabelsResult {
+	return &labelsResult{lbs: lbs, s: lbs.String(), h: hash}
+}
+
+type labelsResult struct {
+	lbs labels.Labels
+	s   string
+	h   uint64
+}
+
+func (l labelsResult) String() string        { return l.s }
+func (l labelsResult) Labels() labels.Labels { return l.lbs }
+func (l labelsResult) Hash() uint64          { return l.h }
+
+// LabelsBuilder is a labels builder bound to the labels of a stream.
+// It is reset for each line processed by a pipeline and keeps track of the labels
+// added, deleted or in error, caching results for label sets already seen.
+// It is not safe for concurrent use.
+type LabelsBuilder struct {
+	base       labels.Labels
+	baseResult LabelsResult
+
+	add labels.Labels
+	del []string
+	err string
+
+	buf         labels.Labels
+	hashBuf     []byte
+	resultCache map[uint64]LabelsResult
+}
+
+// NewLabelsBuilder creates a new labels builder for the given stream labels.
+func NewLabelsBuilder(base labels.Labels) *LabelsBuilder {
+	return &LabelsBuilder{
+		base:        base,
+		baseResult:  NewLabelsResult(base, base.Hash()),
+		del:         make([]string, 0, 5),
+		add:         make(labels.Labels, 0, 16),
+		buf:         make(labels.Labels, 0, len(base)+16),
+		hashBuf:     make([]byte, 0, 1024),
+		resultCache: make(map[uint64]LabelsResult),
+	}
+}
+
+// Reset clears all labels changes and errors.
+func (b *LabelsBuilder) Reset() {
+	b.del = b.del[:0]
+	b.add = b.add[:0]
+	b.err = ""
+}
+
+// SetErr sets the error label value for the current line.
+func (b *LabelsBuilder) SetErr(err string) *LabelsBuilder {
+	b.err = err
+	return b
+}
+
+// GetErr returns the error label value for the current line.
+func (b *LabelsBuilder) GetErr() string {
+	return b.err
+}
+
+// HasErr tells if an error has been set while processing the current line.
+func (b *LabelsBuilder) HasErr() bool {
+	return b.err != ""
+}
+
+// BaseHas returns true if the stream labels contain the given label name.
+func (b *LabelsBuilder) BaseHas(name string) bool {
+	return b.base.Has(name)
+}
+
+// Get returns the value of a label, either extracted or from the stream.
+func (b *LabelsBuilder) Get(name string) (string, bool) {
+	if name == ErrorLabel && b.err != "" {
+		return b.err, true
+	}
+	for _, a := range b.add {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	for _, d := range b.del {
+		if d == name {
+			return "", false
+		}
+	}
+	for _, l := range b.base {
+		if l.Name == name {
+			return l.Value, true
+		}
+	}
+	return "", false
+}
+
+// Del deletes the labels with the given names.
+func (b *LabelsBuilder) Del(ns ...string) *LabelsBuilder {
+	for _, n := range ns {
+		for i, a := range b.add {
+			if a.Name == n {
+				b.add = append(b.add[:i], b.add[i+1:]...)
+				break
+			}
+		}
+		b.del = append(b.del, n)
+	}
+	return b
+}
+
+// SetStructuredMetadata adds the structured metadata of the current line as labels.
+// Like extracted labels, a metadata named after a stream label is suffixed with _extracted.
+func (b *LabelsBuilder) SetStructuredMetadata(metadata labels.Labels) *LabelsBuilder {
+	for _, m := range metadata {
+		name := m.Name
+		if b.BaseHas(name) {
+			name = name + duplicateSuffix
+		}
+		b.Set(name, m.Value)
+	}
+	return b
+}
+
+// Set the name/value pair as a label.
+func (b *LabelsBuilder) Set(n, v string) *LabelsBuilder {
+	for i, a := range b.add {
+		if a.Name == n {
+			b.add[i].Value = v
+			return b
+		}
+	}
+	b.add = append(b.add, labels.Label{Name: n, Value: v})
+	return b
+}
+
+// Labels returns the labels from the builder. If no modifications
+// were made, the original labels are returned.
+// The returned labels share an internal buffer and are only valid until the next call.
+func (b *LabelsBuilder) Labels() labels.Labels {
+	if len(b.del) == 0 && len(b.add) == 0 && b.err == "" {
+		return b.base
+	}
+
+	res := b.buf[:0]
+Outer:
+	for _, l := range b.base {
+		for _, n := range b.del {
+			if l.Name == n {
+				continue Outer
+			}
+		}
+		for _, la := range b.add {
+			if l.Name == la.Name {
+				continue Outer
+			}
+		}
+		res = append(res, l)
+	}
+	res = append(res, b.add...)
+	if b.err != "" {
+		res = append(res, labels.Label{Name: ErrorLabel, Value: b.err})
+	}
+	sort.Sort(res)
+	b.buf = res
+
+	return res
+}
+
+// LabelsResult returns the LabelsResult from the builder.
+// Results are cached by hash so that streams of identical labels share the same result.
+func (b *LabelsBuilder) LabelsResult() LabelsResult {
+	if len(b.del) == 0 && len(b.add) == 0 && b.err == "" {
+		return b.baseResult
+	}
+	lbs := b.Labels()
+	var hash uint64
+	hash, b.hashBuf = lbs.HashWithoutLabels(b.hashBuf)
+	if cached, ok := b.resultCache[hash]; ok {
+		return cached
+	}
+	// the labels buffer is reused, we need a copy to keep the result.
+	res := NewLabelsResult(lbs.Copy(), hash)
+	b.resultCache[hash] = res
+	return res
+}
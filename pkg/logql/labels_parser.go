@@ -0,0 +1,283 @@
+package logql
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-logfmt/logfmt"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+)
+
+// Values of the ErrorLabel set by the label parsers when a line can't be parsed.
+const (
+	errJSON   = "JSONParserErr"
+	errLogfmt = "LogfmtParserErr"
+)
+
+var (
+	_ Stage = &JSONParser{}
+	_ Stage = &LogfmtParser{}
+	_ Stage = &RegexpParser{}
+	_ Stage = &PatternParser{}
+
+	errMissingCapture = errors.New("at least one named capture must be supplied")
+)
+
+// JSONParser extracts labels from json log lines.
+// Nested objects are flattened using `_` as separator, arrays are ignored.
+type JSONParser struct{}
+
+// NewJSONParser creates a log stage that can parse a json log line and add properties as labels.
+func NewJSONParser() *JSONParser {
+	return &JSONParser{}
+}
+
+func (j *JSONParser) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	data := map[string]interface{}{}
+	if err := jsoniter.ConfigFastest.Unmarshal(line, &data); err != nil {
+		lbs.SetErr(errJSON)
+		return line, true
+	}
+	parseJSONMap("", data, lbs)
+	return line, true
+}
+
+func parseJSONMap(prefix string, data map[string]interface{}, lbs *LabelsBuilder) {
+	for key, val := range data {
+		switch concrete := val.(type) {
+		case map[string]interface{}:
+			parseJSONMap(jsonKey(prefix, key), concrete, lbs)
+		case string:
+			addExtractedLabel(lbs, jsonKey(prefix, key), concrete)
+		case float64:
+			addExtractedLabel(lbs, jsonKey(prefix, key), strconv.FormatFloat(concrete, 'f', -1, 64))
+		case bool:
+			addExtractedLabel(lbs, jsonKey(prefix, key), strconv.FormatBool(concrete))
+		}
+	}
+}
+
+func jsonKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "_" + key
+}
+
+// LogfmtParser extracts labels from logfmt log lines.
+type LogfmtParser struct{}
+
+// NewLogfmtParser creates a parser that can extract labels from a logfmt log line.
+// Each keyval is extracted into a respective label.
+func NewLogfmtParser() *LogfmtParser {
+	return &LogfmtParser{}
+}
+
+func (l *LogfmtParser) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	dec := logfmt.NewDecoder(bytes.NewReader(line))
+	for dec.ScanRecord() {
+		for dec.ScanKeyval() {
+			addExtractedLabel(lbs, string(dec.Key()), string(dec.Value()))
+		}
+	}
+	if dec.Err() != nil {
+		lbs.SetErr(errLogfmt)
+	}
+	return line, true
+}
+
+// RegexpParser extracts labels using the named captures of a regular expression.
+type RegexpParser struct {
+	regex     *regexp.Regexp
+	nameIndex map[int]string
+}
+
+// NewRegexpParser creates a new log stage that can extract labels from a log line using a regex expression.
+// The regex expression must contains at least one named match. If the regex doesn't match the line is not filtered out.
+func NewRegexpParser(re string) (*RegexpParser, error) {
+	regex, err := regexp.Compile(re)
+	if err != nil {
+		return nil, err
+	}
+	if regex.NumSubexp() == 0 {
+		return nil, errMissingCapture
+	}
+	nameIndex := map[int]string{}
+	uniqueNames := map[string]struct{}{}
+	for i, n := range regex.SubexpNames() {
+		if n == "" {
+			continue
+		}
+		if !model.LabelName(n).IsValid() {
+			return nil, fmt.Errorf("invalid extracted label name '%s'", n)
+		}
+		if _, ok := uniqueNames[n]; ok {
+			return nil, fmt.Errorf("duplicate extracted label name '%s'", n)
+		}
+		nameIndex[i] = n
+		uniqueNames[n] = struct{}{}
+	}
+	if len(nameIndex) == 0 {
+		return nil, errMissingCapture
+	}
+	return &RegexpParser{
+		regex:     regex,
+		nameIndex: nameIndex,
+	}, nil
+}
+
+func (r *RegexpParser) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	for i, value := range r.regex.FindSubmatch(line) {
+		if name, ok := r.nameIndex[i]; ok {
+			addExtractedLabel(lbs, name, string(value))
+		}
+	}
+	return line, true
+}
+
+// PatternParser extracts labels using a pattern made of literals and captures, e.g. `<ip> - - [<_>] "<method> <path> <_>"`.
+// Each capture matches everything up to the first occurrence of the literal that follows it, the last capture matches
+// the rest of the line. Captures named `_` are matched but not extracted.
+type PatternParser struct {
+	prefix   []byte
+	captures []patternCapture
+	names    []string
+}
+
+type patternCapture struct {
+	name   string // empty for unnamed captures.
+	suffix []byte // the literal following the capture, empty for the last capture.
+}
+
+// NewPatternParser creates a new log stage that can extract labels from a log line using a pattern.
+// The pattern must contain at least one named capture and captures must be separated by literals.
+// If the pattern doesn't match the line is not filtered out.
+func NewPatternParser(pattern string) (*PatternParser, error) {
+	p := &PatternParser{}
+	uniqueNames := map[string]struct{}{}
+	var literal []byte
+	var capture *patternCapture
+	for rest := pattern; len(rest) > 0; {
+		name, size := patternCaptureName(rest)
+		if size == 0 {
+			literal = append(literal, rest[0])
+			rest = rest[1:]
+			continue
+		}
+		rest = rest[size:]
+		switch {
+		case capture != nil && len(literal) == 0:
+			return nil, fmt.Errorf("consecutive captures in pattern must be separated by a literal")
+		case capture != nil:
+			capture.suffix = literal
+		default:
+			p.prefix = literal
+		}
+		literal = nil
+		p.captures = append(p.captures, patternCapture{})
+		capture = &p.captures[len(p.captures)-1]
+		if name == "_" {
+			continue
+		}
+		if _, ok := uniqueNames[name]; ok {
+			return nil, fmt.Errorf("duplicate extracted label name '%s'", name)
+		}
+		uniqueNames[name] = struct{}{}
+		capture.name = name
+		p.names = append(p.names, name)
+	}
+	if capture != nil {
+		capture.suffix = literal
+	}
+	if len(p.names) == 0 {
+		return nil, errMissingCapture
+	}
+	return p, nil
+}
+
+// patternCaptureName returns the name of the capture at the start of a pattern and its size,
+// or a size of 0 if the pattern doesn't start with a capture.
+func patternCaptureName(pattern string) (string, int) {
+	if pattern[0] != '<' {
+		return "", 0
+	}
+	end := strings.IndexByte(pattern, '>')
+	if end < 0 {
+		return "", 0
+	}
+	name := pattern[1:end]
+	if name != "_" && !model.LabelName(name).IsValid() {
+		return "", 0
+	}
+	return name, end + 1
+}
+
+// Names returns the names of the extracted labels, in the order of the pattern.
+func (p *PatternParser) Names() []string {
+	return p.names
+}
+
+// Matches returns the values of the named captures in the order of Names, or nil if the line doesn't match the pattern.
+func (p *PatternParser) Matches(line []byte) [][]byte {
+	if !bytes.HasPrefix(line, p.prefix) {
+		return nil
+	}
+	line = line[len(p.prefix):]
+	matches := make([][]byte, 0, len(p.names))
+	for _, c := range p.captures {
+		value := line
+		if len(c.suffix) > 0 {
+			i := bytes.Index(line, c.suffix)
+			if i < 0 {
+				return nil
+			}
+			value, line = line[:i], line[i+len(c.suffix):]
+		}
+		if c.name != "" {
+			matches = append(matches, value)
+		}
+	}
+	return matches
+}
+
+func (p *PatternParser) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	for i, value := range p.Matches(line) {
+		addExtractedLabel(lbs, p.names[i], string(value))
+	}
+	return line, true
+}
+
+// addExtractedLabel adds a label extracted from a log line to the builder.
+// The label name is sanitized and suffixed if it collides with a stream label.
+func addExtractedLabel(lbs *LabelsBuilder, key, value string) {
+	key = sanitizeLabelKey(key)
+	if key == "" {
+		return
+	}
+	if lbs.BaseHas(key) {
+		key = key + duplicateSuffix
+	}
+	lbs.Set(key, value)
+}
+
+// sanitizeLabelKey replaces characters that are not allowed in label names by `_`.
+func sanitizeLabelKey(key string) string {
+	if len(key) == 0 {
+		return key
+	}
+	key = strings.TrimSpace(key)
+	if len(key) > 0 && key[0] >= '0' && key[0] <= '9' {
+		key = "_" + key
+	}
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_' || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+}
@@ -1,10 +1,14 @@
 package logql
 
 import (
+	"fmt"
 	"strconv"
+	"strings"
 	"text/scanner"
 	"time"
+	"unicode"
 
+	"github.com/dustin/go-humanize"
 	"github.com/prometheus/common/model"
 )
 
@@ -19,17 +23,31 @@ var tokens = map[string]int{
 	"!~":                 NRE,
 	"|=":                 PIPE_EXACT,
 	"|~":                 PIPE_MATCH,
+	OpPipe:               PIPE,
 	"(":                  OPEN_PARENTHESIS,
 	")":                  CLOSE_PARENTHESIS,
 	"by":                 BY,
 	"without":            WITHOUT,
 	"bool":               BOOL,
+	OpOn:                 ON,
+	OpIgnoring:           IGNORING,
+	OpGroupLeft:          GROUP_LEFT,
+	OpGroupRight:         GROUP_RIGHT,
+	OpOffset:             OFFSET,
 	"[":                  OPEN_BRACKET,
 	"]":                  CLOSE_BRACKET,
 	OpRangeTypeRate:      RATE,
 	OpRangeTypeCount:     COUNT_OVER_TIME,
 	OpRangeTypeBytesRate: BYTES_RATE,
 	OpRangeTypeBytes:     BYTES_OVER_TIME,
+	OpRangeTypeAvg:       AVG_OVER_TIME,
+	OpRangeTypeSum:       SUM_OVER_TIME,
+	OpRangeTypeMin:       MIN_OVER_TIME,
+	OpRangeTypeMax:       MAX_OVER_TIME,
+	OpRangeTypeStdvar:    STDVAR_OVER_TIME,
+	OpRangeTypeStddev:    STDDEV_OVER_TIME,
+	OpRangeTypeQuantile:  QUANTILE_OVER_TIME,
+	OpRangeTypeAbsent:    ABSENT_OVER_TIME,
 	OpTypeSum:            SUM,
 	OpTypeAvg:            AVG,
 	OpTypeMax:            MAX,
@@ -56,6 +74,38 @@ var tokens = map[string]int{
 	OpTypeGTE:   GTE,
 	OpTypeLT:    LT,
 	OpTypeLTE:   LTE,
+
+	// parsers
+	OpParserTypeJSON:    JSON,
+	OpParserTypeLogfmt:  LOGFMT,
+	OpParserTypeRegexp:  REGEXP,
+	OpParserTypePattern: PATTERN,
+
+	// fmt
+	OpFmtLabel: LABEL_FMT,
+	OpFmtLine:  LINE_FMT,
+
+	OpUnwrap: UNWRAP,
+}
+
+// functionTokens are tokens only when followed by an opening parenthesis,
+// they can still be used as label names, e.g. `| duration > 1s`.
+var functionTokens = map[string]int{
+	OpConvBytes:    BYTES_CONV,
+	OpConvDuration: DURATION_CONV,
+	OpFilterIP:     IP,
+
+	// functions
+	OpFuncLabelReplace: LABEL_REPLACE,
+	OpFuncVector:       VECTOR,
+	OpFuncClampMin:     CLAMP_MIN,
+	OpFuncClampMax:     CLAMP_MAX,
+	OpFuncAbs:          ABS,
+	OpFuncRound:        ROUND,
+	OpFuncCeil:         CEIL,
+	OpFuncFloor:        FLOOR,
+	OpFuncSort:         SORT,
+	OpFuncSortDesc:     SORT_DESC,
 }
 
 type lexer struct {
@@ -72,8 +122,29 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return 0
 
 	case scanner.Int, scanner.Float:
-		lval.str = l.TokenText()
-		return NUMBER
+		numberText := l.TokenText()
+		if !isUnitRune(l.Peek()) {
+			lval.str = numberText
+			return NUMBER
+		}
+		// a number directly followed by a unit is either a duration (250ms) or a bytes size (10KB).
+		pos := l.Position
+		literal := l.scanUnit(numberText)
+		if d, err := time.ParseDuration(literal); err == nil {
+			lval.duration = d
+			return DURATION
+		}
+		// Prometheus durations also support days, weeks and years (1d, 1w).
+		if d, err := model.ParseDuration(literal); err == nil {
+			lval.duration = time.Duration(d)
+			return DURATION
+		}
+		if b, err := humanize.ParseBytes(literal); err == nil {
+			lval.bytes = b
+			return BYTES
+		}
+		l.errs = append(l.errs, newParseError(fmt.Sprintf("invalid duration or bytes literal %s", literal), pos.Line, pos.Column))
+		return 0
 
 	case scanner.String, scanner.RawString:
 		var err error
@@ -90,6 +161,10 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		d := ""
 		for r := l.Next(); r != scanner.EOF; r = l.Next() {
 			if string(r) == "]" {
+				// subquery ranges have a resolution, e.g. [1h:1m], which can be omitted, e.g. [1h:].
+				if i := strings.Index(d, ":"); i >= 0 {
+					return l.subqueryRange(lval, d[:i], d[i+1:])
+				}
 				i, err := model.ParseDuration(d)
 				if err != nil {
 					l.Error(err.Error())
@@ -109,14 +184,64 @@ func (l *lexer) Lex(lval *exprSymType) int {
 		return tok
 	}
 
-	if tok, ok := tokens[l.TokenText()]; ok {
+	tokenText := l.TokenText()
+	if tok, ok := tokens[tokenText]; ok {
 		return tok
 	}
 
-	lval.str = l.TokenText()
+	if tok, ok := functionTokens[tokenText]; ok && l.isFunction() {
+		return tok
+	}
+
+	lval.str = tokenText
 	return IDENTIFIER
 }
 
+// subqueryRange parses the range and the optional resolution of a subquery.
+func (l *lexer) subqueryRange(lval *exprSymType, rng, step string) int {
+	i, err := model.ParseDuration(rng)
+	if err != nil {
+		l.Error(err.Error())
+		return 0
+	}
+	lval.subqueryRange = subqueryRange{interval: time.Duration(i)}
+	if step != "" {
+		s, err := model.ParseDuration(step)
+		if err != nil {
+			l.Error(err.Error())
+			return 0
+		}
+		if s == 0 {
+			l.Error("zero subquery resolution")
+			return 0
+		}
+		lval.subqueryRange.step = time.Duration(s)
+	}
+	return SUBQUERY_RANGE
+}
+
+// scanUnit consumes the unit following a number and returns the whole literal.
+func (l *lexer) scanUnit(number string) string {
+	var sb strings.Builder
+	sb.WriteString(number)
+	for r := l.Peek(); isUnitRune(r) || r == '.' || unicode.IsDigit(r); r = l.Peek() {
+		sb.WriteRune(l.Next())
+	}
+	return sb.String()
+}
+
+// isFunction returns true if the next non whitespace rune is an opening parenthesis.
+func (l *lexer) isFunction() bool {
+	for unicode.IsSpace(l.Peek()) {
+		l.Next()
+	}
+	return l.Peek() == '('
+}
+
+func isUnitRune(r rune) bool {
+	return unicode.IsLetter(r)
+}
+
 func (l *lexer) Error(msg string) {
 	l.errs = append(l.errs, newParseError(msg, l.Line, l.Column))
 }
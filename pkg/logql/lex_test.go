@@ -29,6 +29,21 @@ func TestLex(t *testing.T) {
 		{`topk(3,count_over_time({foo="bar"}[5m])) by (foo,bar)`, []int{TOPK, OPEN_PARENTHESIS, NUMBER, COMMA, COUNT_OVER_TIME, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS, BY, OPEN_PARENTHESIS, IDENTIFIER, COMMA, IDENTIFIER, CLOSE_PARENTHESIS}},
 		{`bottomk(10,sum(count_over_time({foo="bar"}[5m])) by (foo,bar))`, []int{BOTTOMK, OPEN_PARENTHESIS, NUMBER, COMMA, SUM, OPEN_PARENTHESIS, COUNT_OVER_TIME, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS, BY, OPEN_PARENTHESIS, IDENTIFIER, COMMA, IDENTIFIER, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS}},
 		{`sum(max(rate({foo="bar"}[5m])) by (foo,bar)) by (foo)`, []int{SUM, OPEN_PARENTHESIS, MAX, OPEN_PARENTHESIS, RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS, BY, OPEN_PARENTHESIS, IDENTIFIER, COMMA, IDENTIFIER, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS, BY, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS}},
+		{`{foo="bar"} | json | status >= 500`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, JSON, PIPE, IDENTIFIER, GTE, NUMBER}},
+		{`{foo="bar"} | logfmt | latency > 250ms or size <= 10KB`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, LOGFMT, PIPE, IDENTIFIER, GT, DURATION, OR, IDENTIFIER, LTE, BYTES}},
+		{`{foo="bar"} | line_format "{{.foo}}" | label_format dst=src,env="{{.ns}}"`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, LINE_FMT, STRING, PIPE, LABEL_FMT, IDENTIFIER, EQ, IDENTIFIER, COMMA, IDENTIFIER, EQ, STRING}},
+		{`quantile_over_time(0.99, {foo="bar"} | unwrap duration(latency) [5m])`, []int{QUANTILE_OVER_TIME, OPEN_PARENTHESIS, NUMBER, COMMA, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, UNWRAP, DURATION_CONV, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS, DURATION, CLOSE_PARENTHESIS}},
+		{`{foo="bar"} | duration > 1s | unwrap bytes`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, IDENTIFIER, GT, DURATION, PIPE, UNWRAP, IDENTIFIER}},
+		{`count_over_time({foo="bar"}[5m] offset 1w)`, []int{COUNT_OVER_TIME, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, OFFSET, DURATION, CLOSE_PARENTHESIS}},
+		{`{foo="bar"} | duration > 2d`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, IDENTIFIER, GT, DURATION}},
+		{`absent_over_time({foo="bar"}[5m]) or vector(0)`, []int{ABSENT_OVER_TIME, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, OR, VECTOR, OPEN_PARENTHESIS, NUMBER, CLOSE_PARENTHESIS}},
+		{`clamp_min(sum by (round) (rate({foo="bar"}[5m])), 1)`, []int{CLAMP_MIN, OPEN_PARENTHESIS, SUM, BY, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS, OPEN_PARENTHESIS, RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, CLOSE_PARENTHESIS, COMMA, NUMBER, CLOSE_PARENTHESIS}},
+		{`rate({foo="bar"}[5m]) / on(foo) group_left(bar) rate({foo="bar"}[5m])`, []int{RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, DIV, ON, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS, GROUP_LEFT, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS, RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS}},
+		{`rate({foo="bar"}[5m]) > bool ignoring(foo) group_right rate({foo="bar"}[5m])`, []int{RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, GT, BOOL, IGNORING, OPEN_PARENTHESIS, IDENTIFIER, CLOSE_PARENTHESIS, GROUP_RIGHT, RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS}},
+		{`max_over_time(rate({foo="bar"}[5m])[1h:1m] offset 1d)`, []int{MAX_OVER_TIME, OPEN_PARENTHESIS, RATE, OPEN_PARENTHESIS, OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, DURATION, CLOSE_PARENTHESIS, SUBQUERY_RANGE, OFFSET, DURATION, CLOSE_PARENTHESIS}},
+		{`{foo="bar"} | pattern "<method> <path>" | method="GET"`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, PATTERN, STRING, PIPE, IDENTIFIER, EQ, STRING}},
+		{`{ip="bar"} |= ip("10.0.0.0/8") | ip = ip("::1")`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE_EXACT, IP, OPEN_PARENTHESIS, STRING, CLOSE_PARENTHESIS, PIPE, IDENTIFIER, EQ, IP, OPEN_PARENTHESIS, STRING, CLOSE_PARENTHESIS}},
+		{`{foo="bar"} | (latency > 1.5s and status == 200)`, []int{OPEN_BRACE, IDENTIFIER, EQ, STRING, CLOSE_BRACE, PIPE, OPEN_PARENTHESIS, IDENTIFIER, GT, DURATION, AND, IDENTIFIER, CMP_EQ, NUMBER, CLOSE_PARENTHESIS}},
 	} {
 		t.Run(tc.input, func(t *testing.T) {
 			actual := []int{}
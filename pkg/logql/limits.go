@@ -0,0 +1,103 @@
+package logql
+
+import (
+	"context"
+	"fmt"
+)
+
+var (
+	// NoLimits is a Limits implementation that doesn't enforce any limit.
+	NoLimits Limits = &fakeLimits{}
+)
+
+// Limits allow the engine to fetch per tenant query limits.
+type Limits interface {
+	MaxQuerySeries(userID string) int
+	MaxSamplesPerQuery(userID string) int
+}
+
+type fakeLimits struct {
+	maxSeries  int
+	maxSamples int
+}
+
+func (f fakeLimits) MaxQuerySeries(userID string) int {
+	return f.maxSeries
+}
+
+func (f fakeLimits) MaxSamplesPerQuery(userID string) int {
+	return f.maxSamples
+}
+
+// LimitError is returned when a query exceeds one of its tenant limits.
+type LimitError struct {
+	limit string
+	max   int
+}
+
+func (e LimitError) Error() string {
+	return fmt.Sprintf("limit error : the query exceeded the %s limit of %d, please reduce the query cardinality or time range", e.limit, e.max)
+}
+
+func newLimitError(limit string, max int) LimitError {
+	return LimitError{
+		limit: limit,
+		max:   max,
+	}
+}
+
+// IsLimitError returns true if the err is a query limit error.
+func IsLimitError(err error) bool {
+	_, ok := err.(LimitError)
+	return ok
+}
+
+type limiterCtxKey string
+
+const queryLimiterKey limiterCtxKey = "limiter"
+
+// queryLimiter tracks the series and samples held by a single query evaluation.
+type queryLimiter struct {
+	maxSeries  int
+	maxSamples int
+	samples    int
+}
+
+// injectQueryLimiter stores the limiter in the context, so that the step evaluators
+// enforce it on the series and samples they hold while evaluating the query.
+func injectQueryLimiter(ctx context.Context, l *queryLimiter) context.Context {
+	return context.WithValue(ctx, queryLimiterKey, l)
+}
+
+// getQueryLimiter returns the limiter of the context, or a limiter without limits.
+func getQueryLimiter(ctx context.Context) *queryLimiter {
+	if l, ok := ctx.Value(queryLimiterKey).(*queryLimiter); ok {
+		return l
+	}
+	return &queryLimiter{}
+}
+
+// checkSeries validates the amount of series currently held by the query.
+func (l *queryLimiter) checkSeries(series int) error {
+	if l.maxSeries > 0 && series > l.maxSeries {
+		return newLimitError("max_query_series", l.maxSeries)
+	}
+	return nil
+}
+
+// checkSamples validates the amount of samples currently held by an evaluator.
+func (l *queryLimiter) checkSamples(samples int) error {
+	if l.maxSamples > 0 && samples > l.maxSamples {
+		return newLimitError("max_samples_per_query", l.maxSamples)
+	}
+	return nil
+}
+
+// addSamples accounts for n new samples and validates the total.
+func (l *queryLimiter) addSamples(n int) error {
+	l.samples += n
+	if l.maxSamples > 0 && l.samples > l.maxSamples {
+		return newLimitError("max_samples_per_query", l.maxSamples)
+	}
+	return nil
+}
@@ -53,6 +53,8 @@ var queryTests = []struct {
 					"totalBatches": 0,
 					"totalChunksMatched": 0,
 					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0,
 					"totalLinesSent": 0,
 					"totalReached": 0
 				},
@@ -65,7 +67,9 @@ var queryTests = []struct {
 					"chunksDownloadTime": 0,
 					"totalChunksRef": 0,
 					"totalChunksDownloaded": 0,
-					"totalDuplicates": 0
+					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0
 				},
 				"summary": {
 					"bytesProcessedPerSecond": 0,
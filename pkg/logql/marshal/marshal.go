@@ -4,6 +4,7 @@ package marshal
 
 import (
 	"io"
+	"time"
 
 	"github.com/grafana/loki/pkg/logql"
 
@@ -81,3 +82,55 @@ type seriesResponseAdapter struct {
 	Status string              `json:"status"`
 	Data   []map[string]string `json:"data"`
 }
+
+// WriteExplainResponseJSON marshals a loghttp.Explanation to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteExplainResponseJSON(e loghttp.Explanation, w io.Writer) error {
+	v1Response := loghttp.ExplainResponse{
+		Status: "success",
+		Data:   e,
+	}
+
+	return json.NewEncoder(w).Encode(v1Response)
+}
+
+// WriteIndexStatsResponseJSON marshals a loghttp.IndexStats to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteIndexStatsResponseJSON(s loghttp.IndexStats, w io.Writer) error {
+	v1Response := loghttp.IndexStatsResponse{
+		Status: "success",
+		Data:   s,
+	}
+
+	return json.NewEncoder(w).Encode(v1Response)
+}
+
+// WriteVolumeResponseJSON marshals a loghttp.VolumeData to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteVolumeResponseJSON(v loghttp.VolumeData, w io.Writer) error {
+	v1Response := loghttp.VolumeResponse{
+		Status: "success",
+		Data:   v,
+	}
+
+	return json.NewEncoder(w).Encode(v1Response)
+}
+
+// WriteContextResponseJSON marshals the streams surrounding an anchor entry to v1 loghttp JSON and then
+// writes it to the provided io.Writer.
+func WriteContextResponseJSON(anchor time.Time, s logql.Streams, w io.Writer) error {
+	streams, err := NewStreams(s)
+	if err != nil {
+		return err
+	}
+
+	v1Response := loghttp.ContextResponse{
+		Status: "success",
+		Data: loghttp.ContextData{
+			Anchor:  anchor,
+			Streams: streams,
+		},
+	}
+
+	return json.NewEncoder(w).Encode(v1Response)
+}
@@ -59,6 +59,8 @@ var queryTests = []struct {
 						"totalBatches": 0,
 						"totalChunksMatched": 0,
 						"totalDuplicates": 0,
+						"totalBlocks": 0,
+						"skippedBlocks": 0,
 						"totalLinesSent": 0,
 						"totalReached": 0
 					},
@@ -71,7 +73,9 @@ var queryTests = []struct {
 						"chunksDownloadTime": 0,
 						"totalChunksRef": 0,
 						"totalChunksDownloaded": 0,
-						"totalDuplicates": 0
+						"totalDuplicates": 0,
+						"totalBlocks": 0,
+						"skippedBlocks": 0
 					},
 					"summary": {
 						"bytesProcessedPerSecond": 0,
@@ -155,6 +159,8 @@ var queryTests = []struct {
 					"totalBatches": 0,
 					"totalChunksMatched": 0,
 					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0,
 					"totalLinesSent": 0,
 					"totalReached": 0
 				},
@@ -167,7 +173,9 @@ var queryTests = []struct {
 					"chunksDownloadTime": 0,
 					"totalChunksRef": 0,
 					"totalChunksDownloaded": 0,
-					"totalDuplicates": 0
+					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0
 				},
 				"summary": {
 					"bytesProcessedPerSecond": 0,
@@ -268,6 +276,8 @@ var queryTests = []struct {
 					"totalBatches": 0,
 					"totalChunksMatched": 0,
 					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0,
 					"totalLinesSent": 0,
 					"totalReached": 0
 				},
@@ -280,7 +290,9 @@ var queryTests = []struct {
 					"chunksDownloadTime": 0,
 					"totalChunksRef": 0,
 					"totalChunksDownloaded": 0,
-					"totalDuplicates": 0
+					"totalDuplicates": 0,
+					"totalBlocks": 0,
+					"skippedBlocks": 0
 				},
 				"summary": {
 					"bytesProcessedPerSecond": 0,
@@ -0,0 +1,83 @@
+package marshal
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	json "github.com/json-iterator/go"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+// DefaultFlushPeriod is how often a NDJSONWriter flushes its entries to clients.
+const DefaultFlushPeriod = time.Second
+
+// NDJSONWriter writes the entries of a streamed query as newline delimited json records.
+// When the underlying writer is a http.Flusher, written records are flushed periodically.
+type NDJSONWriter struct {
+	enc         *json.Encoder
+	flusher     http.Flusher
+	flushPeriod time.Duration
+	lastFlush   time.Time
+	labels      map[string]loghttp.LabelSet
+	entries     int
+}
+
+// NewNDJSONWriter creates a new NDJSONWriter flushing records at most every flushPeriod.
+func NewNDJSONWriter(w io.Writer, flushPeriod time.Duration) *NDJSONWriter {
+	flusher, _ := w.(http.Flusher)
+	return &NDJSONWriter{
+		enc:         json.NewEncoder(w),
+		flusher:     flusher,
+		flushPeriod: flushPeriod,
+		lastFlush:   time.Now(),
+		labels:      map[string]loghttp.LabelSet{},
+	}
+}
+
+// WriteEntry implements logql.EntryWriter, it writes an entry as a single entry stream.
+func (w *NDJSONWriter) WriteEntry(labels string, entry logproto.Entry) error {
+	ls, ok := w.labels[labels]
+	if !ok {
+		var err error
+		if ls, err = NewLabelSet(labels); err != nil {
+			return err
+		}
+		w.labels[labels] = ls
+	}
+	if err := w.enc.Encode(loghttp.Stream{Labels: ls, Entries: []loghttp.Entry{NewEntry(entry)}}); err != nil {
+		return err
+	}
+	w.entries++
+	if time.Since(w.lastFlush) >= w.flushPeriod {
+		w.flush()
+	}
+	return nil
+}
+
+// Entries returns the amount of entries written so far.
+func (w *NDJSONWriter) Entries() int {
+	return w.entries
+}
+
+// WriteStats writes the trailing statistics record and flushes the response.
+func (w *NDJSONWriter) WriteStats(s stats.Result) error {
+	defer w.flush()
+	return w.enc.Encode(loghttp.StreamedStats{Statistics: s})
+}
+
+// WriteError writes the trailing error record and flushes the response.
+func (w *NDJSONWriter) WriteError(err error) error {
+	defer w.flush()
+	return w.enc.Encode(loghttp.StreamedError{Error: err.Error()})
+}
+
+func (w *NDJSONWriter) flush() {
+	if w.flusher != nil {
+		w.flusher.Flush()
+	}
+	w.lastFlush = time.Now()
+}
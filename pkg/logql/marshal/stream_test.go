@@ -0,0 +1,35 @@
+package marshal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+func Test_NDJSONWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf, DefaultFlushPeriod)
+
+	require.NoError(t, w.WriteEntry(`{app="foo"}`, logproto.Entry{Timestamp: time.Unix(0, 1), Line: "foo"}))
+	require.NoError(t, w.WriteEntry(`{app="bar"}`, logproto.Entry{Timestamp: time.Unix(0, 2), Line: "bar"}))
+	require.Equal(t, 2, w.Entries())
+	require.NoError(t, w.WriteStats(stats.Result{}))
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 3)
+	require.JSONEq(t, `{"stream":{"app":"foo"},"values":[["1","foo"]]}`, string(lines[0]))
+	require.JSONEq(t, `{"stream":{"app":"bar"},"values":[["2","bar"]]}`, string(lines[1]))
+	require.Contains(t, string(lines[2]), `{"stats":{"summary":`)
+
+	buf.Reset()
+	require.NoError(t, w.WriteError(errors.New("query timed out")))
+	require.JSONEq(t, `{"error":"query timed out"}`, buf.String())
+
+	require.Error(t, w.WriteEntry(`{app=`, logproto.Entry{}))
+}
@@ -0,0 +1,55 @@
+package logql
+
+import (
+	"fmt"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// RemoveLabelMatchers removes the matchers of a label from all the stream selectors of an expression and returns them.
+// It is used to evaluate matchers of synthetic labels, which are not indexed, before querying the streams.
+// It fails when a stream selector is left without matchers.
+func RemoveLabelMatchers(expr Expr, name string) ([]*labels.Matcher, error) {
+	var removed []*labels.Matcher
+	for _, sel := range logSelectors(expr) {
+		e := streamMatchers(sel)
+		if e == nil {
+			continue
+		}
+		kept := make([]*labels.Matcher, 0, len(e.matchers))
+		for _, m := range e.matchers {
+			if m.Name == name {
+				removed = append(removed, m)
+				continue
+			}
+			kept = append(kept, m)
+		}
+		if len(kept) == 0 {
+			return nil, fmt.Errorf("the stream selector %s must contain at least one matcher besides %s", e, name)
+		}
+		e.matchers = kept
+	}
+	return removed, nil
+}
+
+// streamMatchers returns the stream matchers of a log selector.
+func streamMatchers(e LogSelectorExpr) *matchersExpr {
+	var left LogSelectorExpr
+	switch expr := e.(type) {
+	case *matchersExpr:
+		return expr
+	case *filterExpr:
+		left = expr.left
+	case *labelParserExpr:
+		left = expr.left
+	case *labelFilterExpr:
+		left = expr.left
+	case *lineFmtExpr:
+		left = expr.left
+	case *labelFmtExpr:
+		left = expr.left
+	default:
+		return nil
+	}
+	return streamMatchers(left)
+}
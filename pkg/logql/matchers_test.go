@@ -0,0 +1,58 @@
+package logql
+
+import (
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoveLabelMatchers(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+		removed  []*labels.Matcher
+		err      bool
+	}{
+		{`{app="foo"}`, `{app="foo"}`, nil, false},
+		{
+			`{app="foo", __tenant_id__="team-a"}`,
+			`{app="foo"}`,
+			[]*labels.Matcher{mustNewMatcher(labels.MatchEqual, "__tenant_id__", "team-a")},
+			false,
+		},
+		{
+			`{app="foo", __tenant_id__=~"team-.*"} |= "bar" | json | line_format "{{.foo}}"`,
+			`{app="foo"} |= "bar" | json | line_format "{{.foo}}"`,
+			[]*labels.Matcher{mustNewMatcher(labels.MatchRegexp, "__tenant_id__", "team-.*")},
+			false,
+		},
+		{
+			`sum by (__tenant_id__) (rate({app="foo", __tenant_id__!="team-a"}[1m])) / sum(rate({app="bar", __tenant_id__!="team-b"}[1m]))`,
+			`sum by(__tenant_id__)(rate({app="foo"}[1m])) / sum(rate({app="bar"}[1m]))`,
+			[]*labels.Matcher{
+				mustNewMatcher(labels.MatchNotEqual, "__tenant_id__", "team-a"),
+				mustNewMatcher(labels.MatchNotEqual, "__tenant_id__", "team-b"),
+			},
+			false,
+		},
+		{`{__tenant_id__="team-a"}`, "", nil, true},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := ParseExpr(tc.query)
+			require.NoError(t, err)
+
+			removed, err := RemoveLabelMatchers(expr, "__tenant_id__")
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.removed, removed)
+
+			expected, err := ParseExpr(tc.expected)
+			require.NoError(t, err)
+			require.Equal(t, expected.String(), expr.String())
+		})
+	}
+}
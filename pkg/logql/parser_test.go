@@ -665,7 +665,7 @@ func TestParse(t *testing.T) {
 		{
 			in: `{foo="bar"} |~`,
 			err: ParseError{
-				msg:  "syntax error: unexpected $end, expecting STRING",
+				msg:  "syntax error: unexpected $end, expecting STRING or IP",
 				line: 1,
 				col:  15,
 			},
@@ -1143,6 +1143,561 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			in: `{app="foo"} | json`,
+			exp: &labelParserExpr{
+				left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+				op:   OpParserTypeJSON,
+			},
+		},
+		{
+			in: `{app="foo"} |= "bar" | logfmt |~ "buzz"`,
+			exp: &filterExpr{
+				left: &labelParserExpr{
+					left: &filterExpr{
+						left:  &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						ty:    labels.MatchEqual,
+						match: "bar",
+					},
+					op: OpParserTypeLogfmt,
+				},
+				ty:    labels.MatchRegexp,
+				match: "buzz",
+			},
+		},
+		{
+			in: `{app="foo"} | regexp "(?P<status>\\d+)"`,
+			exp: &labelParserExpr{
+				left:  &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+				op:    OpParserTypeRegexp,
+				param: `(?P<status>\d+)`,
+			},
+		},
+		{
+			in: `{app="foo"} | pattern "<ip> - - [<_>] \"<method> <path> <_>\" <status>"`,
+			exp: &labelParserExpr{
+				left:  &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+				op:    OpParserTypePattern,
+				param: `<ip> - - [<_>] "<method> <path> <_>" <status>`,
+			},
+		},
+		{
+			in: `{app="foo"} |= ip("10.0.0.0/8") != "health"`,
+			exp: &filterExpr{
+				left: &filterExpr{
+					left:  &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					ty:    labels.MatchEqual,
+					match: "10.0.0.0/8",
+					ip:    true,
+				},
+				ty:    labels.MatchNotEqual,
+				match: "health",
+			},
+		},
+		{
+			in: `{app="foo"} |~ ip("10.0.0.0/8")`,
+			err: ParseError{
+				msg:  "ip() is only supported by the |= and != line filters",
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `{app="foo"} |= ip("10.0.0.0/33")`,
+			err: ParseError{
+				msg:  "invalid ip range 10.0.0.0/33: invalid CIDR address: 10.0.0.0/33",
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `{app="foo"} | logfmt | addr = ip("192.168.0.1-192.168.0.255") or ip="127.0.0.1"`,
+			exp: &labelFilterExpr{
+				LabelFilterer: NewOrLabelFilter(
+					mustNewIPLabelFilter(LabelFilterEqual, "addr", "192.168.0.1-192.168.0.255"),
+					NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "ip", "127.0.0.1")),
+				),
+				left: &labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeLogfmt,
+				},
+			},
+		},
+		{
+			in: `{app="foo"} | pattern "<method><path>"`,
+			err: ParseError{
+				msg:  "consecutive captures in pattern must be separated by a literal",
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `{app="foo"} | regexp "(\\d+)"`,
+			err: ParseError{
+				msg:  errMissingCapture.Error(),
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `sum by (status) (count_over_time({app="api"} | json [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					&logRange{
+						left: &labelParserExpr{
+							left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "api")}},
+							op:   OpParserTypeJSON,
+						},
+						interval: 5 * time.Minute,
+					},
+					OpRangeTypeCount,
+				),
+				OpTypeSum,
+				&grouping{groups: []string{"status"}},
+				nil,
+			),
+		},
+		{
+			in: `count_over_time({app="api"}[5m] | logfmt)`,
+			exp: newRangeAggregationExpr(
+				&logRange{
+					left: &labelParserExpr{
+						left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "api")}},
+						op:   OpParserTypeLogfmt,
+					},
+					interval: 5 * time.Minute,
+				},
+				OpRangeTypeCount,
+			),
+		},
+		{
+			in: `{app="foo"} | json | status >= 500 and latency > 250ms or size <= 10KB`,
+			exp: &labelFilterExpr{
+				left: &labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeJSON,
+				},
+				LabelFilterer: NewOrLabelFilter(
+					NewAndLabelFilter(
+						NewNumericLabelFilter(LabelFilterGreaterThanOrEqual, "status", 500),
+						NewDurationLabelFilter(LabelFilterGreaterThan, "latency", 250*time.Millisecond),
+					),
+					NewBytesLabelFilter(LabelFilterLesserThanOrEqual, "size", 10000),
+				),
+			},
+		},
+		{
+			in: `{app="foo"} | logfmt | level="error" and (status == 500 or latency != 1.5s) |= "timeout"`,
+			exp: &filterExpr{
+				left: &labelFilterExpr{
+					left: &labelParserExpr{
+						left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						op:   OpParserTypeLogfmt,
+					},
+					LabelFilterer: NewAndLabelFilter(
+						NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, "level", "error")),
+						NewOrLabelFilter(
+							NewNumericLabelFilter(LabelFilterEqual, "status", 500),
+							NewDurationLabelFilter(LabelFilterNotEqual, "latency", 1500*time.Millisecond),
+						),
+					),
+				},
+				ty:    labels.MatchEqual,
+				match: "timeout",
+			},
+		},
+		{
+			in: `sum by (method) (count_over_time({app="foo"} | regexp "(?P<method>\\w+) (?P<status>\\d+)" | status =~ "5.." [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					&logRange{
+						left: &labelFilterExpr{
+							left: &labelParserExpr{
+								left:  &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+								op:    OpParserTypeRegexp,
+								param: `(?P<method>\w+) (?P<status>\d+)`,
+							},
+							LabelFilterer: NewStringLabelFilter(mustNewMatcher(labels.MatchRegexp, "status", "5..")),
+						},
+						interval: 5 * time.Minute,
+					},
+					OpRangeTypeCount,
+				),
+				OpTypeSum,
+				&grouping{groups: []string{"method"}},
+				nil,
+			),
+		},
+		{
+			in: `{app="foo"} | logfmt | line_format "{{.method}} {{.path}} {{.status}}"`,
+			exp: &lineFmtExpr{
+				left: &labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeLogfmt,
+				},
+				value: "{{.method}} {{.path}} {{.status}}",
+			},
+		},
+		{
+			in: `sum by (env) (count_over_time({app="foo"} | json | label_format dst=src,env="{{.cluster}}-{{.ns}}" [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					&logRange{
+						left: &labelFmtExpr{
+							left: &labelParserExpr{
+								left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+								op:   OpParserTypeJSON,
+							},
+							formats: []LabelFmt{
+								NewRenameLabelFmt("dst", "src"),
+								NewTemplateLabelFmt("env", "{{.cluster}}-{{.ns}}"),
+							},
+						},
+						interval: 5 * time.Minute,
+					},
+					OpRangeTypeCount,
+				),
+				OpTypeSum,
+				&grouping{groups: []string{"env"}},
+				nil,
+			),
+		},
+		{
+			in: `{app="foo"} | label_format foo=bar,foo="{{.buzz}}"`,
+			err: ParseError{
+				msg: "multiple label name 'foo' not allowed in a single format operation",
+			},
+		},
+		{
+			in: `{app="foo"} | line_format "{{.foo"`,
+			err: ParseError{
+				msg: "invalid line template: template: line_format:1: unclosed action",
+			},
+		},
+		{
+			in: `sum_over_time({app="foo"} | json | unwrap latency [5m])`,
+			exp: newRangeAggregationExpr(
+				newLogRange(&labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeJSON,
+				},
+					5*time.Minute,
+					newUnwrapExpr("latency", ""), 0),
+				OpRangeTypeSum,
+			),
+		},
+		{
+			in: `quantile_over_time(0.99, {app="foo"} | logfmt | unwrap duration(latency) | __error__="" [1m])`,
+			exp: mustNewRangeAggregationExpr(
+				newLogRange(&labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeLogfmt,
+				},
+					time.Minute,
+					newUnwrapExpr("latency", OpConvDuration).addPostFilter(NewStringLabelFilter(mustNewMatcher(labels.MatchEqual, ErrorLabel, ""))), 0),
+				OpRangeTypeQuantile, newString("0.99"),
+			),
+		},
+		{
+			in: `sum by (app) (max_over_time({app="foo"} | json | unwrap bytes(size) [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(&labelParserExpr{
+						left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						op:   OpParserTypeJSON,
+					},
+						5*time.Minute,
+						newUnwrapExpr("size", OpConvBytes), 0),
+					OpRangeTypeMax,
+				),
+				OpTypeSum,
+				&grouping{groups: []string{"app"}},
+				nil,
+			),
+		},
+		{
+			in: `count_over_time({app="foo"} | json | unwrap latency [5m])`,
+			err: ParseError{
+				msg: "invalid aggregation count_over_time with unwrap",
+			},
+		},
+		{
+			in: `avg_over_time({app="foo"} | json [5m])`,
+			err: ParseError{
+				msg: "invalid aggregation avg_over_time without unwrap",
+			},
+		},
+		{
+			in: `quantile_over_time({app="foo"} | json | unwrap latency [5m])`,
+			err: ParseError{
+				msg: "parameter required for operation quantile_over_time",
+			},
+		},
+		{
+			in: `{app="foo"} | json | latency > 10zz`,
+			err: ParseError{
+				msg:  "invalid duration or bytes literal 10zz",
+				line: 1,
+				col:  32,
+			},
+		},
+		{
+			in: `sum by (app) (count_over_time({app="foo"}[5m])) / on(app) group_left(team) sum by (app, pod) (count_over_time({app="foo"}[5m]))`,
+			exp: &binOpExpr{
+				op: OpTypeDiv,
+				opts: BinOpOptions{
+					VectorMatching: &VectorMatching{
+						Card:           CardManyToOne,
+						MatchingLabels: []string{"app"},
+						On:             true,
+						Include:        []string{"team"},
+					},
+				},
+				SampleExpr: mustNewVectorAggregationExpr(
+					newRangeAggregationExpr(
+						&logRange{
+							left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							interval: 5 * time.Minute,
+						}, OpRangeTypeCount),
+					OpTypeSum,
+					&grouping{groups: []string{"app"}},
+					nil,
+				),
+				RHS: mustNewVectorAggregationExpr(
+					newRangeAggregationExpr(
+						&logRange{
+							left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							interval: 5 * time.Minute,
+						}, OpRangeTypeCount),
+					OpTypeSum,
+					&grouping{groups: []string{"app", "pod"}},
+					nil,
+				),
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m]) > bool ignoring(pod) group_right count_over_time({app="bar"}[5m])`,
+			exp: &binOpExpr{
+				op: OpTypeGT,
+				opts: BinOpOptions{
+					ReturnBool: true,
+					VectorMatching: &VectorMatching{
+						Card:           CardOneToMany,
+						MatchingLabels: []string{"pod"},
+					},
+				},
+				SampleExpr: newRangeAggregationExpr(
+					&logRange{
+						left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						interval: 5 * time.Minute,
+					}, OpRangeTypeCount),
+				RHS: newRangeAggregationExpr(
+					&logRange{
+						left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "bar")}},
+						interval: 5 * time.Minute,
+					}, OpRangeTypeCount),
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m]) and on() count_over_time({app="bar"}[5m])`,
+			exp: &binOpExpr{
+				op: OpTypeAnd,
+				opts: BinOpOptions{
+					VectorMatching: &VectorMatching{
+						Card: CardManyToMany,
+						On:   true,
+					},
+				},
+				SampleExpr: newRangeAggregationExpr(
+					&logRange{
+						left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						interval: 5 * time.Minute,
+					}, OpRangeTypeCount),
+				RHS: newRangeAggregationExpr(
+					&logRange{
+						left:     &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "bar")}},
+						interval: 5 * time.Minute,
+					}, OpRangeTypeCount),
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m]) or on(app) group_left count_over_time({app="bar"}[5m])`,
+			err: ParseError{
+				msg: "no grouping allowed for logical/set binary operation (or)",
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m]) / on(app) 2`,
+			err: ParseError{
+				msg: "vector matching only allowed between vectors in binary operation (/)",
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m]) / on(app) group_left(app) count_over_time({app="bar"}[5m])`,
+			err: ParseError{
+				msg: "label app must not occur in on and group clauses at once",
+			},
+		},
+		{
+			// the parenthesis after group_left is the list of included labels.
+			in: `count_over_time({app="foo"}[5m]) / on(app) group_left (count_over_time({app="bar"}[5m]))`,
+			err: ParseError{
+				msg:  "syntax error: unexpected count_over_time, expecting IDENTIFIER or )",
+				line: 1,
+				col:  56,
+			},
+		},
+		{
+			in: `count_over_time({app="foo"}[5m] offset 1w)`,
+			exp: newRangeAggregationExpr(
+				newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					5*time.Minute, nil, 7*24*time.Hour),
+				OpRangeTypeCount,
+			),
+		},
+		{
+			in: `sum_over_time({app="foo"} | json | unwrap latency [5m] offset 1h30m)`,
+			exp: newRangeAggregationExpr(
+				newLogRange(&labelParserExpr{
+					left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					op:   OpParserTypeJSON,
+				},
+					5*time.Minute,
+					newUnwrapExpr("latency", ""), 90*time.Minute),
+				OpRangeTypeSum,
+			),
+		},
+		{
+			in: `count_over_time({app="foo"}[5m] offset)`,
+			err: ParseError{
+				msg:  "syntax error: unexpected ), expecting DURATION",
+				line: 1,
+				col:  39,
+			},
+		},
+		{
+			in: `max_over_time(sum(rate({app="foo"}[1m]))[1h:1m])`,
+			exp: mustNewSubqueryAggregationExpr(
+				newSubqueryExpr(
+					mustNewVectorAggregationExpr(newRangeAggregationExpr(
+						newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							time.Minute, nil, 0),
+						OpRangeTypeRate,
+					), OpTypeSum, nil, nil),
+					subqueryRange{interval: time.Hour, step: time.Minute}, 0,
+				),
+				OpRangeTypeMax, nil,
+			),
+		},
+		{
+			in: `quantile_over_time(0.9, rate({app="foo"}[1m])[1h:] offset 1d)`,
+			exp: mustNewSubqueryAggregationExpr(
+				newSubqueryExpr(
+					newRangeAggregationExpr(
+						newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							time.Minute, nil, 0),
+						OpRangeTypeRate,
+					),
+					subqueryRange{interval: time.Hour}, 24*time.Hour,
+				),
+				OpRangeTypeQuantile, newString("0.9"),
+			),
+		},
+		{
+			in: `rate(count_over_time({app="foo"}[1m])[1h:1m])`,
+			err: ParseError{
+				msg: "invalid aggregation rate over a subquery",
+			},
+		},
+		{
+			in: `absent_over_time({app="foo"}[5m])`,
+			exp: newRangeAggregationExpr(
+				newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+					5*time.Minute, nil, 0),
+				OpRangeTypeAbsent,
+			),
+		},
+		{
+			in:  `vector(-1)`,
+			exp: &vectorExpr{value: -1},
+		},
+		{
+			in: `label_replace(rate({app="foo"}[5m]), "dst", "$1", "app", "(.*)")`,
+			exp: mustNewLabelReplaceExpr(
+				newRangeAggregationExpr(
+					newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						5*time.Minute, nil, 0),
+					OpRangeTypeRate,
+				),
+				"dst", "$1", "app", "(.*)",
+			),
+		},
+		{
+			in: `clamp_min(rate({app="foo"}[5m]), 0.5)`,
+			exp: mustNewFunctionExpr(
+				newRangeAggregationExpr(
+					newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+						5*time.Minute, nil, 0),
+					OpRangeTypeRate,
+				),
+				OpFuncClampMin, &literalExpr{value: 0.5},
+			),
+		},
+		{
+			in: `sort_desc(sum by (app) (rate({app="foo"}[5m])))`,
+			exp: mustNewFunctionExpr(
+				mustNewVectorAggregationExpr(
+					newRangeAggregationExpr(
+						newLogRange(&matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							5*time.Minute, nil, 0),
+						OpRangeTypeRate,
+					),
+					OpTypeSum, &grouping{groups: []string{"app"}}, nil,
+				),
+				OpFuncSortDesc, nil,
+			),
+		},
+		{
+			in: `sum by (round) (rate({app="foo"} | logfmt | round > 1 [5m]))`,
+			exp: mustNewVectorAggregationExpr(
+				newRangeAggregationExpr(
+					newLogRange(&labelFilterExpr{
+						left: &labelParserExpr{
+							left: &matchersExpr{matchers: []*labels.Matcher{mustNewMatcher(labels.MatchEqual, "app", "foo")}},
+							op:   OpParserTypeLogfmt,
+						},
+						LabelFilterer: NewNumericLabelFilter(LabelFilterGreaterThan, "round", 1),
+					},
+						5*time.Minute, nil, 0),
+					OpRangeTypeRate,
+				),
+				OpTypeSum, &grouping{groups: []string{"round"}}, nil,
+			),
+		},
+		{
+			in: `clamp_max(rate({app="foo"}[5m]))`,
+			err: ParseError{
+				msg:  "parameter required for function clamp_max",
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `abs(rate({app="foo"}[5m]), 1)`,
+			err: ParseError{
+				msg:  "unsupported parameter for function abs",
+				line: 0,
+				col:  0,
+			},
+		},
+		{
+			in: `label_replace(rate({app="foo"}[5m]), "dst", "$1", "app", "(.*")`,
+			err: ParseError{
+				msg:  "invalid regular expression in label_replace(): (.*",
+				line: 0,
+				col:  0,
+			},
+		},
 		{
 			// test associativity
 			in:  `1 > 1 < 1`,
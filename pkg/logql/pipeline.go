@@ -0,0 +1,140 @@
+package logql
+
+import (
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// Stage is a single step of a Pipeline.
+// A stage can modify the log line and its labels, it returns false if the line should be dropped.
+// A stage returning a line different from the one it received must allocate it, since lines
+// are kept by iterators after the next line has been processed.
+type Stage interface {
+	Process(line []byte, lbs *LabelsBuilder) ([]byte, bool)
+}
+
+// StageFunc is a syntax sugar for creating a pipeline stage from a function.
+type StageFunc func(line []byte, lbs *LabelsBuilder) ([]byte, bool)
+
+func (fn StageFunc) Process(line []byte, lbs *LabelsBuilder) ([]byte, bool) {
+	return fn(line, lbs)
+}
+
+// Pipeline transforms and filters log lines and their labels.
+type Pipeline interface {
+	// ForStream returns a StreamPipeline for the given stream labels.
+	ForStream(labels labels.Labels) StreamPipeline
+}
+
+// StreamPipeline transforms and filters log lines of a single stream.
+// It is not safe for concurrent use.
+type StreamPipeline interface {
+	// Process returns the processed line and its labels, which include the structured metadata of the line.
+	// The last return value is false when the line has been filtered out.
+	Process(line []byte, metadata ...labels.Label) ([]byte, LabelsResult, bool)
+}
+
+// LiteralFilterer is implemented by the stream pipelines and sample extractors which only keep the lines
+// containing all the literals returned by RequiredLiterals. It allows to skip the data which can't contain them.
+type LiteralFilterer interface {
+	RequiredLiterals() [][]byte
+}
+
+// NoopPipeline is a pipeline that doesn't modify nor filter log lines.
+var NoopPipeline Pipeline = noopPipeline{}
+
+type noopPipeline struct{}
+
+func (noopPipeline) ForStream(lbs labels.Labels) StreamPipeline {
+	return &noopStreamPipeline{LabelsResult: NewLabelsResult(lbs, lbs.Hash())}
+}
+
+type noopStreamPipeline struct {
+	LabelsResult
+	// builder is only created for the lines with structured metadata.
+	builder *LabelsBuilder
+}
+
+func (p *noopStreamPipeline) Process(line []byte, metadata ...labels.Label) ([]byte, LabelsResult, bool) {
+	if len(metadata) == 0 {
+		return line, p.LabelsResult, true
+	}
+	if p.builder == nil {
+		p.builder = NewLabelsBuilder(p.LabelsResult.Labels())
+	}
+	p.builder.Reset()
+	p.builder.SetStructuredMetadata(metadata)
+	return line, p.builder.LabelsResult(), true
+}
+
+type pipeline struct {
+	stages   []Stage
+	literals [][]byte
+}
+
+// NewPipeline creates a new pipeline running the given stages in order.
+func NewPipeline(stages []Stage) Pipeline {
+	if len(stages) == 0 {
+		return NoopPipeline
+	}
+	return &pipeline{stages: stages, literals: stagesLiterals(stages)}
+}
+
+func (p *pipeline) ForStream(lbs labels.Labels) StreamPipeline {
+	return &streamPipeline{
+		stages:   p.stages,
+		literals: p.literals,
+		builder:  NewLabelsBuilder(lbs),
+	}
+}
+
+type streamPipeline struct {
+	stages   []Stage
+	literals [][]byte
+	builder  *LabelsBuilder
+}
+
+// RequiredLiterals implements LiteralFilterer.
+func (p *streamPipeline) RequiredLiterals() [][]byte {
+	return p.literals
+}
+
+func (p *streamPipeline) Process(line []byte, metadata ...labels.Label) ([]byte, LabelsResult, bool) {
+	var ok bool
+	p.builder.Reset()
+	p.builder.SetStructuredMetadata(metadata)
+	for _, s := range p.stages {
+		line, ok = s.Process(line, p.builder)
+		if !ok {
+			return nil, nil, false
+		}
+	}
+	return line, p.builder.LabelsResult(), true
+}
+
+// lineFilterStage adapts a LineFilter into a pipeline Stage.
+type lineFilterStage struct {
+	LineFilter
+}
+
+func (f lineFilterStage) Process(line []byte, _ *LabelsBuilder) ([]byte, bool) {
+	return line, f.Filter(line)
+}
+
+// stagesLiterals returns the literals the lines must contain to pass the line filters of the stages.
+// The line filters following a stage which may modify the line are ignored.
+func stagesLiterals(stages []Stage) [][]byte {
+	var literals [][]byte
+	for _, s := range stages {
+		switch s := s.(type) {
+		case lineFilterStage:
+			literals = append(literals, filterLiterals(s.LineFilter)...)
+		case *LineFormatter:
+			return literals
+		case *JSONParser, *LogfmtParser, *RegexpParser, *PatternParser, *LabelsFormatter, LabelFilterer:
+			// These stages only modify the labels.
+		default:
+			return literals
+		}
+	}
+	return literals
+}
@@ -0,0 +1,199 @@
+package logql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// Query policies rules, used as the rule label of the blocked queries metric.
+const (
+	PolicyBlockedQuery                = "blocked_query"
+	PolicyRegexOnlySelector           = "regex_only_selector"
+	PolicyMaxQueryLengthWithoutFilter = "max_query_length_without_filter"
+)
+
+var blockedQueries = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "loki",
+	Name:      "blocked_queries_total",
+	Help:      "Total number of queries rejected by a query policy.",
+}, []string{"rule"})
+
+// BlockedQuery is a query pattern a tenant is not allowed to run.
+type BlockedQuery struct {
+	// Pattern is either a regular expression or a query, matched against the normalized form of the query.
+	Pattern string `yaml:"pattern"`
+	Regex   bool   `yaml:"regex"`
+
+	// the pattern is compiled once, when the limits are loaded or on first use.
+	once       sync.Once
+	re         *regexp.Regexp
+	normalized string
+	err        error
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (b *BlockedQuery) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var plain struct {
+		Pattern string `yaml:"pattern"`
+		Regex   bool   `yaml:"regex"`
+	}
+	if err := unmarshal(&plain); err != nil {
+		return err
+	}
+	b.Pattern, b.Regex = plain.Pattern, plain.Regex
+	if b.Pattern == "" {
+		return errors.New("blocked query pattern cannot be empty")
+	}
+	return b.compile()
+}
+
+// compile compiles the regular expression of the pattern, or normalizes the pattern when it is a valid query.
+func (b *BlockedQuery) compile() error {
+	b.once.Do(func() {
+		if b.Regex {
+			b.re, b.err = regexp.Compile(b.Pattern)
+			if b.err != nil {
+				b.err = fmt.Errorf("invalid blocked query regex %q: %w", b.Pattern, b.err)
+			}
+			return
+		}
+		b.normalized = b.Pattern
+		if expr, err := ParseExpr(b.Pattern); err == nil {
+			b.normalized = expr.String()
+		}
+	})
+	return b.err
+}
+
+// PolicyLimits allow to fetch the per tenant query policies.
+type PolicyLimits interface {
+	BlockedQueries(userID string) []*BlockedQuery
+	RejectRegexOnlySelectors(userID string) bool
+	MaxQueryLengthWithoutFilter(userID string) time.Duration
+}
+
+// PolicyError is returned when a query is rejected by one of its tenant policies.
+type PolicyError struct {
+	rule   string
+	reason string
+}
+
+func (e PolicyError) Error() string {
+	return fmt.Sprintf("query blocked by the %s policy: %s", e.rule, e.reason)
+}
+
+// IsPolicyError returns true if the err is a query policy error.
+func IsPolicyError(err error) bool {
+	_, ok := err.(PolicyError)
+	return ok
+}
+
+func newPolicyError(rule, format string, args ...interface{}) PolicyError {
+	blockedQueries.WithLabelValues(rule).Inc()
+	return PolicyError{
+		rule:   rule,
+		reason: fmt.Sprintf(format, args...),
+	}
+}
+
+// ValidateQueryPolicies verifies that a query over the given time range is allowed by the tenant policies.
+func ValidateQueryPolicies(userID string, expr Expr, start, end time.Time, limits PolicyLimits) error {
+	query := expr.String()
+	for _, blocked := range limits.BlockedQueries(userID) {
+		if matchesBlockedQuery(blocked, query) {
+			return newPolicyError(PolicyBlockedQuery, "the query matches the blocked pattern %q", blocked.Pattern)
+		}
+	}
+
+	if limits.RejectRegexOnlySelectors(userID) {
+		for _, sel := range logSelectors(expr) {
+			if regexOnly(sel.Matchers()) {
+				return newPolicyError(PolicyRegexOnlySelector, "the stream selector %s must contain at least one non regex matcher", newMatcherExpr(sel.Matchers()))
+			}
+		}
+	}
+
+	if maxLength := limits.MaxQueryLengthWithoutFilter(userID); maxLength > 0 {
+		if sel, ok := expr.(LogSelectorExpr); ok && !hasLineFilter(sel) && end.Sub(start) > maxLength {
+			return newPolicyError(PolicyMaxQueryLengthWithoutFilter, "log queries without a line filter are limited to %s (query length: %s)", maxLength, end.Sub(start))
+		}
+	}
+	return nil
+}
+
+type policyCtxKey string
+
+const policyQueryKey policyCtxKey = "policy_query"
+
+// policyQuery is the full query of a request and its time range.
+type policyQuery struct {
+	expr       Expr
+	start, end time.Time
+}
+
+// InjectPolicyQuery stores the full query of a request in the context, so that the selections made while
+// evaluating it are validated against the query policies as a whole rather than on their own.
+func InjectPolicyQuery(ctx context.Context, expr Expr, start, end time.Time) context.Context {
+	return context.WithValue(ctx, policyQueryKey, policyQuery{expr: expr, start: start, end: end})
+}
+
+// PolicyQuery returns the full query stored in the context and its time range, false if there is none.
+func PolicyQuery(ctx context.Context) (Expr, time.Time, time.Time, bool) {
+	q, ok := ctx.Value(policyQueryKey).(policyQuery)
+	if !ok {
+		return nil, time.Time{}, time.Time{}, false
+	}
+	return q.expr, q.start, q.end, true
+}
+
+// matchesBlockedQuery returns true if a normalized query matches a blocked query.
+// Patterns that are not regular expressions are normalized when they are valid queries.
+func matchesBlockedQuery(blocked *BlockedQuery, query string) bool {
+	if err := blocked.compile(); err != nil {
+		return false
+	}
+	if blocked.Regex {
+		return blocked.re.MatchString(query)
+	}
+	return blocked.normalized == query
+}
+
+func regexOnly(matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Type != labels.MatchRegexp && m.Type != labels.MatchNotRegexp {
+			return false
+		}
+	}
+	return len(matchers) > 0
+}
+
+// hasLineFilter returns true if a log selector has a line filter which is not always true.
+func hasLineFilter(e LogSelectorExpr) bool {
+	var left LogSelectorExpr
+	switch expr := e.(type) {
+	case *filterExpr:
+		if f, err := expr.filter(); err == nil && f != TrueFilter {
+			return true
+		}
+		left = expr.left
+	case *labelParserExpr:
+		left = expr.left
+	case *labelFilterExpr:
+		left = expr.left
+	case *lineFmtExpr:
+		left = expr.left
+	case *labelFmtExpr:
+		left = expr.left
+	default:
+		return false
+	}
+	return hasLineFilter(left)
+}
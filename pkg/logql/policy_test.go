@@ -0,0 +1,157 @@
+package logql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+type fakePolicyLimits struct {
+	blocked                []*BlockedQuery
+	rejectRegexOnly        bool
+	maxLengthWithoutFilter time.Duration
+}
+
+func (f fakePolicyLimits) BlockedQueries(string) []*BlockedQuery {
+	return f.blocked
+}
+
+func (f fakePolicyLimits) RejectRegexOnlySelectors(string) bool {
+	return f.rejectRegexOnly
+}
+
+func (f fakePolicyLimits) MaxQueryLengthWithoutFilter(string) time.Duration {
+	return f.maxLengthWithoutFilter
+}
+
+func TestValidateQueryPolicies(t *testing.T) {
+	var (
+		end   = time.Unix(0, 0).Add(48 * time.Hour)
+		day   = end.Add(-24 * time.Hour)
+		month = end.Add(-30 * 24 * time.Hour)
+	)
+
+	for _, tc := range []struct {
+		name   string
+		query  string
+		start  time.Time
+		limits fakePolicyLimits
+		rule   string
+	}{
+		{"no policies", `{app=~".+"}`, month, fakePolicyLimits{}, ""},
+		{
+			"blocked exact query",
+			`{app="foo"}  |=  "bar"`,
+			day,
+			fakePolicyLimits{blocked: []*BlockedQuery{{Pattern: `{app="foo"} |= "bar"`}}},
+			PolicyBlockedQuery,
+		},
+		{
+			"exact query is not a substring",
+			`{app="foo"} |= "bar" |= "buzz"`,
+			day,
+			fakePolicyLimits{blocked: []*BlockedQuery{{Pattern: `{app="foo"} |= "bar"`}}},
+			"",
+		},
+		{
+			"blocked regex",
+			`sum(count_over_time({app="foo"}[5m]))`,
+			day,
+			fakePolicyLimits{blocked: []*BlockedQuery{{Pattern: `count_over_time\(.*app="foo"`, Regex: true}}},
+			PolicyBlockedQuery,
+		},
+		{
+			"regex not matching",
+			`sum(count_over_time({app="bar"}[5m]))`,
+			day,
+			fakePolicyLimits{blocked: []*BlockedQuery{{Pattern: `count_over_time\(.*app="foo"`, Regex: true}}},
+			"",
+		},
+		{
+			"regex only selector",
+			`{app=~"foo|bar", env!~"dev"}`,
+			day,
+			fakePolicyLimits{rejectRegexOnly: true},
+			PolicyRegexOnlySelector,
+		},
+		{
+			"regex only selector in binary operation",
+			`rate({app="foo"}[1m]) / rate({app=~"foo|bar"}[1m])`,
+			day,
+			fakePolicyLimits{rejectRegexOnly: true},
+			PolicyRegexOnlySelector,
+		},
+		{
+			"selector with an equality matcher",
+			`{app=~"foo|bar", env="prod"}`,
+			day,
+			fakePolicyLimits{rejectRegexOnly: true},
+			"",
+		},
+		{
+			"long log query without filter",
+			`{app="foo"} | logfmt | level="error"`,
+			month,
+			fakePolicyLimits{maxLengthWithoutFilter: 7 * 24 * time.Hour},
+			PolicyMaxQueryLengthWithoutFilter,
+		},
+		{
+			"long log query with a filter",
+			`{app="foo"} |= "error" | logfmt`,
+			month,
+			fakePolicyLimits{maxLengthWithoutFilter: 7 * 24 * time.Hour},
+			"",
+		},
+		{
+			"short log query without filter",
+			`{app="foo"}`,
+			day,
+			fakePolicyLimits{maxLengthWithoutFilter: 7 * 24 * time.Hour},
+			"",
+		},
+		{
+			"long metric query without filter",
+			`rate({app="foo"}[1m])`,
+			month,
+			fakePolicyLimits{maxLengthWithoutFilter: 7 * 24 * time.Hour},
+			"",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := ParseExpr(tc.query)
+			require.NoError(t, err)
+
+			err = ValidateQueryPolicies("fake", expr, tc.start, end, tc.limits)
+			if tc.rule == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.True(t, IsPolicyError(err))
+			require.Equal(t, tc.rule, err.(PolicyError).rule)
+		})
+	}
+}
+
+func TestBlockedQuery_UnmarshalYAML(t *testing.T) {
+	var blocked []*BlockedQuery
+	require.NoError(t, yaml.UnmarshalStrict([]byte(`
+- pattern: '{app="foo"}'
+- pattern: 'rate\(.*\)'
+  regex: true
+`), &blocked))
+	require.Len(t, blocked, 2)
+	require.Equal(t, `{app="foo"}`, blocked[0].Pattern)
+	require.False(t, blocked[0].Regex)
+	require.Equal(t, `rate\(.*\)`, blocked[1].Pattern)
+	require.True(t, blocked[1].Regex)
+	// patterns are compiled when loaded.
+	require.NotNil(t, blocked[1].re)
+	require.True(t, matchesBlockedQuery(blocked[0], `{app="foo"}`))
+	require.True(t, matchesBlockedQuery(blocked[1], `rate({app="foo"}[1m])`))
+
+	require.Error(t, yaml.UnmarshalStrict([]byte(`- pattern: '(foo'
+  regex: true`), &blocked))
+	require.Error(t, yaml.UnmarshalStrict([]byte(`- regex: true`), &blocked))
+}
@@ -25,16 +25,22 @@ type RangeVectorIterator interface {
 }
 
 type rangeVectorIterator struct {
-	iter                         iter.PeekingSampleIterator
-	selRange, step, end, current int64
-	window                       map[string]*promql.Series
-	metrics                      map[string]labels.Labels
-	at                           []promql.Sample
+	iter                                 iter.PeekingSampleIterator
+	selRange, step, end, current, offset int64
+	window                               map[string]*promql.Series
+	metrics                              map[string]labels.Labels
+	at                                   []promql.Sample
+
+	// limiter bounds the series and the points held by the window.
+	limiter *queryLimiter
+	points  int
+	err     error
 }
 
 func newRangeVectorIterator(
 	it iter.PeekingSampleIterator,
-	selRange, step, start, end int64) *rangeVectorIterator {
+	limiter *queryLimiter,
+	selRange, step, start, end, offset int64) *rangeVectorIterator {
 	// forces at least one step.
 	if step == 0 {
 		step = 1
@@ -44,9 +50,11 @@ func newRangeVectorIterator(
 		step:     step,
 		end:      end,
 		selRange: selRange,
+		offset:   offset,
 		current:  start - step, // first loop iteration will set it to start
 		window:   map[string]*promql.Series{},
 		metrics:  map[string]labels.Labels{},
+		limiter:  limiter,
 	}
 }
 
@@ -56,12 +64,13 @@ func (r *rangeVectorIterator) Next() bool {
 	if r.current > r.end {
 		return false
 	}
-	rangeEnd := r.current
-	rangeStart := r.current - r.selRange
+	// with an offset the window ends before the current step, samples are still reported at the current step.
+	rangeEnd := r.current - r.offset
+	rangeStart := rangeEnd - r.selRange
 	// load samples
 	r.popBack(rangeStart)
 	r.load(rangeStart, rangeEnd)
-	return true
+	return r.err == nil
 }
 
 func (r *rangeVectorIterator) Close() error {
@@ -69,6 +78,9 @@ func (r *rangeVectorIterator) Close() error {
 }
 
 func (r *rangeVectorIterator) Error() error {
+	if r.err != nil {
+		return r.err
+	}
 	return r.iter.Error()
 }
 
@@ -87,6 +99,7 @@ func (r *rangeVectorIterator) popBack(newStart int64) {
 			break
 		}
 		if remove {
+			r.points -= lastPoint + 1
 			r.window[fp].Points = r.window[fp].Points[lastPoint+1:]
 		}
 		if len(r.window[fp].Points) == 0 {
@@ -124,15 +137,24 @@ func (r *rangeVectorIterator) load(start, end int64) {
 				r.metrics[lbs] = metric
 			}
 
+			if err := r.limiter.checkSeries(len(r.window) + 1); err != nil {
+				r.err = err
+				return
+			}
 			series = getSeries()
 			series.Metric = metric
 			r.window[lbs] = series
 		}
+		if err := r.limiter.checkSamples(r.points + 1); err != nil {
+			r.err = err
+			return
+		}
 		p := promql.Point{
 			T: sample.Timestamp,
 			V: sample.Value,
 		}
 		series.Points = append(series.Points, p)
+		r.points++
 		_ = r.iter.Next()
 	}
 }
@@ -59,6 +59,7 @@ func Test_RangeVectorIterator(t *testing.T) {
 		expectedVectors []promql.Vector
 		expectedTs      []time.Time
 		start, end      time.Time
+		offset          int64
 	}{
 		{
 			(5 * time.Second).Nanoseconds(), // no overlap
@@ -79,7 +80,7 @@ func Test_RangeVectorIterator(t *testing.T) {
 				},
 			},
 			[]time.Time{time.Unix(10, 0), time.Unix(40, 0), time.Unix(70, 0), time.Unix(100, 0)},
-			time.Unix(10, 0), time.Unix(100, 0),
+			time.Unix(10, 0), time.Unix(100, 0), 0,
 		},
 		{
 			(35 * time.Second).Nanoseconds(), // will overlap by 5 sec
@@ -103,7 +104,7 @@ func Test_RangeVectorIterator(t *testing.T) {
 				},
 			},
 			[]time.Time{time.Unix(10, 0), time.Unix(40, 0), time.Unix(70, 0), time.Unix(100, 0)},
-			time.Unix(10, 0), time.Unix(100, 0),
+			time.Unix(10, 0), time.Unix(100, 0), 0,
 		},
 		{
 			(30 * time.Second).Nanoseconds(), // same range
@@ -124,7 +125,7 @@ func Test_RangeVectorIterator(t *testing.T) {
 				},
 			},
 			[]time.Time{time.Unix(10, 0), time.Unix(40, 0), time.Unix(70, 0), time.Unix(100, 0)},
-			time.Unix(10, 0), time.Unix(100, 0),
+			time.Unix(10, 0), time.Unix(100, 0), 0,
 		},
 		{
 			(50 * time.Second).Nanoseconds(), // all step are overlapping
@@ -140,16 +141,37 @@ func Test_RangeVectorIterator(t *testing.T) {
 				},
 			},
 			[]time.Time{time.Unix(110, 0), time.Unix(120, 0)},
-			time.Unix(110, 0), time.Unix(120, 0),
+			time.Unix(110, 0), time.Unix(120, 0), 0,
+		},
+		{
+			(5 * time.Second).Nanoseconds(), // no overlap with an offset
+			(30 * time.Second).Nanoseconds(),
+			[]promql.Vector{
+				[]promql.Sample{
+					{Point: newPoint(time.Unix(40, 0), 2), Metric: labelBar},
+					{Point: newPoint(time.Unix(40, 0), 2), Metric: labelFoo},
+				},
+				[]promql.Sample{
+					{Point: newPoint(time.Unix(70, 0), 2), Metric: labelBar},
+					{Point: newPoint(time.Unix(70, 0), 2), Metric: labelFoo},
+				},
+				{},
+				[]promql.Sample{
+					{Point: newPoint(time.Unix(130, 0), 1), Metric: labelBar},
+					{Point: newPoint(time.Unix(130, 0), 1), Metric: labelFoo},
+				},
+			},
+			[]time.Time{time.Unix(40, 0), time.Unix(70, 0), time.Unix(100, 0), time.Unix(130, 0)},
+			time.Unix(40, 0), time.Unix(130, 0), (30 * time.Second).Nanoseconds(),
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(
-			fmt.Sprintf("logs[%s] - step: %s", time.Duration(tt.selRange), time.Duration(tt.step)),
+			fmt.Sprintf("logs[%s] - step: %s - offset: %s", time.Duration(tt.selRange), time.Duration(tt.step), time.Duration(tt.offset)),
 			func(t *testing.T) {
-				it := newRangeVectorIterator(newfakePeekingSampleIterator(), tt.selRange,
-					tt.step, tt.start.UnixNano(), tt.end.UnixNano())
+				it := newRangeVectorIterator(newfakePeekingSampleIterator(), &queryLimiter{}, tt.selRange,
+					tt.step, tt.start.UnixNano(), tt.end.UnixNano(), tt.offset)
 
 				i := 0
 				for it.Next() {
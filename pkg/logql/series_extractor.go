@@ -1,24 +1,176 @@
 package logql
 
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/prometheus/prometheus/pkg/labels"
+)
+
+// Value of the ErrorLabel set when the unwrapped label value can't be converted to a sample value.
+const errSampleExtraction = "SampleExtractionErr"
+
 var (
-	ExtractBytes = bytesSampleExtractor{}
-	ExtractCount = countSampleExtractor{}
+	ExtractBytes = LineExtractor(func(line []byte) float64 { return float64(len(line)) })
+	ExtractCount = LineExtractor(func(line []byte) float64 { return 1. })
 )
 
-// SampleExtractor transforms a log entry into a sample.
-// In case of failure the second return value will be false.
+// LineExtractor extracts a float64 from a log line.
+type LineExtractor func([]byte) float64
+
+// ToSampleExtractor transforms a LineExtractor into a SampleExtractor running
+// the given pipeline stages before extracting the value from the line.
+func (l LineExtractor) ToSampleExtractor(stages ...Stage) SampleExtractor {
+	return &lineSampleExtractor{
+		stages:    stages,
+		extractor: l,
+	}
+}
+
+// SampleExtractor creates StreamSampleExtractor that can extract samples for a given log stream.
 type SampleExtractor interface {
-	Extract(line []byte) (float64, bool)
+	ForStream(labels labels.Labels) StreamSampleExtractor
+}
+
+// StreamSampleExtractor transforms a log entry of a stream into a sample and its labels.
+// In case of failure or if the line is filtered out the last return value will be false.
+// It is not safe for concurrent use.
+type StreamSampleExtractor interface {
+	Process(line []byte, metadata ...labels.Label) (float64, LabelsResult, bool)
+}
+
+type lineSampleExtractor struct {
+	stages    []Stage
+	extractor LineExtractor
+}
+
+func (l *lineSampleExtractor) ForStream(lbs labels.Labels) StreamSampleExtractor {
+	return &streamLineSampleExtractor{
+		pipeline:  NewPipeline(l.stages).ForStream(lbs),
+		extractor: l.extractor,
+	}
+}
+
+type streamLineSampleExtractor struct {
+	pipeline  StreamPipeline
+	extractor LineExtractor
+}
+
+// RequiredLiterals implements LiteralFilterer.
+func (l *streamLineSampleExtractor) RequiredLiterals() [][]byte {
+	if f, ok := l.pipeline.(LiteralFilterer); ok {
+		return f.RequiredLiterals()
+	}
+	return nil
+}
+
+func (l *streamLineSampleExtractor) Process(line []byte, metadata ...labels.Label) (float64, LabelsResult, bool) {
+	line, lbs, ok := l.pipeline.Process(line, metadata...)
+	if !ok {
+		return 0, nil, false
+	}
+	return l.extractor(line), lbs, true
+}
+
+type conversionFunc func(value string) (float64, error)
+
+type labelSampleExtractor struct {
+	preStages    []Stage
+	postFilters  []Stage
+	labelName    string
+	conversionFn conversionFunc
+	literals     [][]byte
+}
+
+// LabelExtractorWithStages creates a SampleExtractor that uses the value of a label as the sample value.
+// The pre stages are run before extracting the value and the post filters after, the extracted label is
+// removed from the sample labels. The conversion can be empty for numeric labels, or one of the
+// `bytes` and `duration` conversions, durations are converted to seconds.
+func LabelExtractorWithStages(labelName, conversion string, preStages []Stage, postFilters []Stage) (SampleExtractor, error) {
+	var convFn conversionFunc
+	switch conversion {
+	case OpConvBytes:
+		convFn = convertBytes
+	case OpConvDuration:
+		convFn = convertDuration
+	case "":
+		convFn = convertFloat
+	default:
+		return nil, fmt.Errorf("unsupported conversion operation %s", conversion)
+	}
+	return &labelSampleExtractor{
+		preStages:    preStages,
+		postFilters:  postFilters,
+		labelName:    labelName,
+		conversionFn: convFn,
+		literals:     stagesLiterals(preStages),
+	}, nil
 }
 
-type countSampleExtractor struct{}
+func (l *labelSampleExtractor) ForStream(lbs labels.Labels) StreamSampleExtractor {
+	return &streamLabelSampleExtractor{
+		labelSampleExtractor: l,
+		builder:              NewLabelsBuilder(lbs),
+	}
+}
+
+type streamLabelSampleExtractor struct {
+	*labelSampleExtractor
+	builder *LabelsBuilder
+}
+
+// RequiredLiterals implements LiteralFilterer.
+func (l *streamLabelSampleExtractor) RequiredLiterals() [][]byte {
+	return l.literals
+}
+
+func (l *streamLabelSampleExtractor) Process(line []byte, metadata ...labels.Label) (float64, LabelsResult, bool) {
+	var ok bool
+	l.builder.Reset()
+	l.builder.SetStructuredMetadata(metadata)
+	for _, s := range l.preStages {
+		line, ok = s.Process(line, l.builder)
+		if !ok {
+			return 0, nil, false
+		}
+	}
+	var v float64
+	// a line already in error keeps its original error, missing labels fail the conversion.
+	if !l.builder.HasErr() {
+		stringValue, _ := l.builder.Get(l.labelName)
+		var err error
+		if v, err = l.conversionFn(stringValue); err != nil {
+			l.builder.SetErr(errSampleExtraction)
+		}
+	}
+	// the extracted label is the sample value, keeping it would create a series per value.
+	l.builder.Del(l.labelName)
+	for _, f := range l.postFilters {
+		if _, ok = f.Process(line, l.builder); !ok {
+			return 0, nil, false
+		}
+	}
+	return v, l.builder.LabelsResult(), true
+}
 
-func (countSampleExtractor) Extract(line []byte) (float64, bool) {
-	return 1., true
+func convertFloat(v string) (float64, error) {
+	return strconv.ParseFloat(v, 64)
 }
 
-type bytesSampleExtractor struct{}
+func convertDuration(v string) (float64, error) {
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
 
-func (bytesSampleExtractor) Extract(line []byte) (float64, bool) {
-	return float64(len(line)), true
+func convertBytes(v string) (float64, error) {
+	b, err := humanize.ParseBytes(v)
+	if err != nil {
+		return 0, err
+	}
+	return float64(b), nil
 }
@@ -0,0 +1,166 @@
+package logql
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_labelSampleExtractor_Extract(t *testing.T) {
+	tests := []struct {
+		name    string
+		ex      SampleExtractor
+		in      labels.Labels
+		want    float64
+		wantLbs labels.Labels
+		wantOk  bool
+	}{
+		{
+			"convert float",
+			mustLabelExtractor("foo", "", nil, nil),
+			labels.Labels{{Name: "foo", Value: "15.0"}},
+			15,
+			labels.Labels{},
+			true,
+		},
+		{
+			"convert float without the unwrapped label",
+			mustLabelExtractor("foo", "", nil, nil),
+			labels.Labels{{Name: "foo", Value: "15.0"}, {Name: "bar", Value: "buzz"}},
+			15,
+			labels.Labels{{Name: "bar", Value: "buzz"}},
+			true,
+		},
+		{
+			"convert duration",
+			mustLabelExtractor("foo", OpConvDuration, nil, nil),
+			labels.Labels{{Name: "foo", Value: "500ms"}, {Name: "bar", Value: "buzz"}},
+			0.5,
+			labels.Labels{{Name: "bar", Value: "buzz"}},
+			true,
+		},
+		{
+			"convert bytes",
+			mustLabelExtractor("foo", OpConvBytes, nil, nil),
+			labels.Labels{{Name: "foo", Value: "13 MiB"}, {Name: "bar", Value: "buzz"}},
+			13 * 1024 * 1024,
+			labels.Labels{{Name: "bar", Value: "buzz"}},
+			true,
+		},
+		{
+			"not convertible",
+			mustLabelExtractor("foo", "", nil, nil),
+			labels.Labels{{Name: "foo", Value: "not_a_number"}, {Name: "bar", Value: "buzz"}},
+			0,
+			labels.Labels{{Name: "bar", Value: "buzz"}, {Name: ErrorLabel, Value: errSampleExtraction}},
+			true,
+		},
+		{
+			"missing label",
+			mustLabelExtractor("foo", "", nil, nil),
+			labels.Labels{{Name: "bar", Value: "buzz"}},
+			0,
+			labels.Labels{{Name: "bar", Value: "buzz"}, {Name: ErrorLabel, Value: errSampleExtraction}},
+			true,
+		},
+		{
+			"error filtered by post filter",
+			mustLabelExtractor("foo", "", nil, []Stage{
+				NewStringLabelFilter(labels.MustNewMatcher(labels.MatchEqual, ErrorLabel, "")),
+			}),
+			labels.Labels{{Name: "foo", Value: "not_a_number"}, {Name: "bar", Value: "buzz"}},
+			0,
+			nil,
+			false,
+		},
+		{
+			"filtered by pre stage",
+			mustLabelExtractor("foo", "", []Stage{
+				NewNumericLabelFilter(LabelFilterGreaterThan, "foo", 20),
+			}, nil),
+			labels.Labels{{Name: "foo", Value: "15"}},
+			0,
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sort.Sort(tt.in)
+			outval, outlbs, ok := tt.ex.ForStream(tt.in).Process([]byte(""))
+			require.Equal(t, tt.wantOk, ok)
+			require.Equal(t, tt.want, outval)
+			if !tt.wantOk {
+				return
+			}
+			sort.Sort(tt.wantLbs)
+			require.Equal(t, tt.wantLbs, outlbs.Labels())
+		})
+	}
+}
+
+func mustLabelExtractor(labelName, conversion string, preStages, postFilters []Stage) SampleExtractor {
+	ex, err := LabelExtractorWithStages(labelName, conversion, preStages, postFilters)
+	if err != nil {
+		panic(err)
+	}
+	return ex
+}
+
+func Test_StructuredMetadata(t *testing.T) {
+	lbs := labels.Labels{{Name: "app", Value: "foo"}, {Name: "traceID", Value: "stream"}}
+	metadata := labels.Labels{{Name: "traceID", Value: "abc"}, {Name: "user", Value: "42"}}
+	wantLbs := `{app="foo", traceID="stream", traceID_extracted="abc", user="42"}`
+
+	// The structured metadata are labels of the line, the ones named after a stream label are suffixed.
+	for query, want := range map[string]bool{
+		`{app="foo"}`:                            true,
+		`{app="foo"} | user="42"`:                true,
+		`{app="foo"} | user="43"`:                false,
+		`{app="foo"} | traceID_extracted="abc"`:  true,
+		`{app="foo"} | logfmt | user > 40`:       true,
+		`{app="foo"} | json | user="42"`:         true,
+		`{app="foo"} | line_format "{{.user}}"`:  true,
+		`{app="foo"} |= "line" | user=~"4[0-9]"`: true,
+	} {
+		t.Run(query, func(t *testing.T) {
+			expr, err := ParseLogSelector(query)
+			require.NoError(t, err)
+			p, err := expr.Pipeline()
+			require.NoError(t, err)
+			sp := p.ForStream(lbs)
+
+			_, res, ok := sp.Process([]byte("line"), metadata...)
+			require.Equal(t, want, ok)
+			if ok {
+				require.Contains(t, res.String(), `user="42"`)
+				if query == `{app="foo"}` {
+					require.Equal(t, wantLbs, res.String())
+				}
+			}
+			// The metadata of a line don't leak to the next one.
+			_, res, ok = sp.Process([]byte("line"))
+			if ok {
+				require.NotContains(t, res.String(), "user=")
+			}
+		})
+	}
+
+	expr, err := ParseSampleExpr(`sum by (user) (count_over_time({app="foo"} | user="42" [1m]))`)
+	require.NoError(t, err)
+	ex, err := expr.Extractor()
+	require.NoError(t, err)
+	_, res, ok := ex.ForStream(lbs).Process([]byte("line"), metadata...)
+	require.True(t, ok)
+	require.Equal(t, wantLbs, res.String())
+
+	expr, err = ParseSampleExpr(`sum_over_time({app="foo"} | unwrap user [1m])`)
+	require.NoError(t, err)
+	ex, err = expr.Extractor()
+	require.NoError(t, err)
+	v, _, ok := ex.ForStream(lbs).Process([]byte("line"), metadata...)
+	require.True(t, ok)
+	require.Equal(t, 42., v)
+}
@@ -33,15 +33,17 @@ type ShardedEngine struct {
 	timeout        time.Duration
 	downstreamable Downstreamable
 	metrics        *ShardingMetrics
+	limits         Limits
 }
 
 // NewShardedEngine constructs a *ShardedEngine
-func NewShardedEngine(opts EngineOpts, downstreamable Downstreamable, metrics *ShardingMetrics) *ShardedEngine {
+func NewShardedEngine(opts EngineOpts, downstreamable Downstreamable, metrics *ShardingMetrics, limits Limits) *ShardedEngine {
 	opts.applyDefault()
 	return &ShardedEngine{
 		timeout:        opts.Timeout,
 		downstreamable: downstreamable,
 		metrics:        metrics,
+		limits:         limits,
 	}
 
 }
@@ -67,6 +69,8 @@ func (ng *ShardedEngine) Query(p Params, shards int) Query {
 			level.Debug(logger).Log("no-op", noop, "mapped", parsed.String())
 			return parsed, nil
 		},
+		// limits are enforced on the merged downstream results.
+		limits: ng.limits,
 	}
 }
 
@@ -198,7 +202,7 @@ func (errorQuerier) SelectSamples(ctx context.Context, p SelectSampleParams) (it
 func NewDownstreamEvaluator(downstreamer Downstreamer) *DownstreamEvaluator {
 	return &DownstreamEvaluator{
 		Downstreamer:     downstreamer,
-		defaultEvaluator: NewDefaultEvaluator(&errorQuerier{}, 0),
+		defaultEvaluator: NewDefaultEvaluator(&errorQuerier{}, 0, 0),
 	}
 }
 
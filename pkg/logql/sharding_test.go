@@ -8,6 +8,7 @@ import (
 
 	"github.com/prometheus/prometheus/promql"
 	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logproto"
 )
@@ -40,10 +41,20 @@ func TestMappingEquivalence(t *testing.T) {
 		{`max without (a) (rate({a=~".*"}[1s]))`, false},
 		{`count(rate({a=~".*"}[1s]))`, false},
 		{`avg(rate({a=~".*"}[1s]))`, true},
+		{`count(sum by (b) (rate({a=~".*"}[1s])))`, false},
+		{`avg by (a) (count_over_time({a=~".*"}[1s]))`, true},
 		{`1 + sum by (cluster) (rate({a=~".*"}[1s]))`, false},
 		{`sum(max(rate({a=~".*"}[1s])))`, false},
 		{`max(count(rate({a=~".*"}[1s])))`, false},
 		{`max(sum by (cluster) (rate({a=~".*"}[1s]))) / count(rate({a=~".*"}[1s]))`, false},
+		{`{a="1"} | regexp "number: (?P<number>\\d+)" | number >= 10`, false},
+		{`sum by (a) (rate({a=~".*"} | regexp "number: (?P<number>\\d+)" | number < 5 or number >= 5 [1s]))`, false},
+		{`{a="1"} | regexp "number: (?P<number>\\d+)" | label_format n=number | line_format "{{.n}}-{{.a}}"`, false},
+		{`sum by (b) (count_over_time({a=~".*"} | label_format b="{{.a}}-b" [1s]))`, false},
+		{`sum by (a) (sum_over_time({a=~".*"} | regexp "number: (?P<number>\\d+)" | unwrap number [1s]))`, false},
+		{`max(max_over_time({a=~".*"} | regexp "number: (?P<number>\\d+)" | unwrap number [1s]))`, false},
+		{`sum by (a, b) (rate({a=~".*"}[1s])) / on(a) group_left sum by (a) (rate({a=~".*"}[1s]))`, false},
+		{`sum by (a) (clamp_max(label_replace(rate({a=~".*"}[1s]), "b", "$1", "a", "(.*)"), 0.5))`, false},
 		// topk prefers already-seen values in tiebreakers. Since the test data generates
 		// the same log lines for each series & the resulting promql.Vectors aren't deterministically
 		// sorted by labels, we don't expect this to pass.
@@ -56,8 +67,8 @@ func TestMappingEquivalence(t *testing.T) {
 		)
 
 		opts := EngineOpts{}
-		regular := NewEngine(opts, q)
-		sharded := NewShardedEngine(opts, MockDownstreamer{regular}, nilMetrics)
+		regular := NewEngine(opts, q, NoLimits)
+		sharded := NewShardedEngine(opts, MockDownstreamer{regular}, nilMetrics, NoLimits)
 
 		t.Run(tc.query, func(t *testing.T) {
 			params := NewLiteralParams(
@@ -73,10 +84,10 @@ func TestMappingEquivalence(t *testing.T) {
 			qry := regular.Query(params)
 			shardedQry := sharded.Query(params, shards)
 
-			res, err := qry.Exec(context.Background())
+			res, err := qry.Exec(user.InjectOrgID(context.Background(), "fake"))
 			require.Nil(t, err)
 
-			shardedRes, err := shardedQry.Exec(context.Background())
+			shardedRes, err := shardedQry.Exec(user.InjectOrgID(context.Background(), "fake"))
 			require.Nil(t, err)
 
 			if tc.approximate {
@@ -88,6 +99,32 @@ func TestMappingEquivalence(t *testing.T) {
 	}
 }
 
+func TestShardedEngine_Limits(t *testing.T) {
+	var (
+		shards  = 3
+		streams = randomStreams(60, 20, shards, []string{"a", "b", "c", "d"})
+		query   = `sum by (index) (rate({a=~".*"}[1s]))`
+	)
+
+	q := NewMockQuerier(shards, streams)
+	// downstream queries are unbounded, only the merged result exceeds the limit.
+	regular := NewEngine(EngineOpts{}, q, NoLimits)
+	sharded := NewShardedEngine(EngineOpts{}, MockDownstreamer{regular}, nilMetrics, &fakeLimits{maxSeries: 30})
+
+	params := NewLiteralParams(
+		query,
+		time.Unix(0, 0),
+		time.Unix(20, 0),
+		time.Second,
+		0,
+		logproto.FORWARD,
+		100,
+		nil,
+	)
+	_, err := sharded.Query(params, shards).Exec(user.InjectOrgID(context.Background(), "fake"))
+	require.Equal(t, newLimitError("max_query_series", 30), err)
+}
+
 // approximatelyEquals ensures two responses are approximately equal, up to 6 decimals precision per sample
 func approximatelyEquals(t *testing.T, as, bs promql.Matrix) {
 	require.Equal(t, len(as), len(bs))
@@ -20,11 +20,27 @@ const (
 	NoopKey    = "noop"
 )
 
+// reasons why parts of queries were or were not sharded, used in metrics.
+const (
+	// ReasonSharded is recorded when at least one expression of a query was sharded.
+	ReasonSharded = "sharded"
+	// ReasonUnshardableOperation is recorded when an operation can't be merged across shards,
+	// its arguments may still be sharded.
+	ReasonUnshardableOperation = "unshardable_operation"
+	// ReasonCrossShardAggregation is recorded when an operation could be sharded but its argument
+	// already aggregates series across shards, e.g. `count(sum by (app) (rate(...)))`.
+	ReasonCrossShardAggregation = "cross_shard_aggregation"
+	// ReasonRequiresAllShards is recorded for expressions needing all the streams at once,
+	// such as absent_over_time and subqueries, they are executed downstream without shard.
+	ReasonRequiresAllShards = "requires_all_shards"
+)
+
 // ShardingMetrics is the metrics wrapper used in shard mapping
 type ShardingMetrics struct {
 	shards      *prometheus.CounterVec // sharded queries total, partitioned by (streams/metric)
 	parsed      *prometheus.CounterVec // parsed ASTs total, partitioned by (success/failure/noop)
 	shardFactor prometheus.Histogram   // per request shard factor
+	reasons     *prometheus.CounterVec // reasons why queries were or were not sharded, partitioned by reason
 }
 
 func NewShardingMetrics(registerer prometheus.Registerer) *ShardingMetrics {
@@ -44,6 +60,11 @@ func NewShardingMetrics(registerer prometheus.Registerer) *ShardingMetrics {
 			Help:      "Number of shards per request",
 			Buckets:   prometheus.LinearBuckets(0, 16, 4), // 16 is the default shard factor for later schemas
 		}),
+		reasons: promauto.With(registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Name:      "query_frontend_sharding_reasons_total",
+			Help:      "Total number of successfully parsed queries per reason why they were or were not sharded, a query can have several reasons.",
+		}, []string{"reason"}),
 	}
 }
 
@@ -59,8 +80,9 @@ func (m *ShardingMetrics) shardRecorder() *shardRecorder {
 // NOT SAFE FOR CONCURRENT USE! We avoid introducing mutex locking here
 // because AST mapping is single threaded.
 type shardRecorder struct {
-	done  bool
-	total int
+	done    bool
+	total   int
+	reasons []string
 	*ShardingMetrics
 }
 
@@ -70,11 +92,24 @@ func (r *shardRecorder) Add(x int, key string) {
 	r.shards.WithLabelValues(key).Add(float64(x))
 }
 
-// Finish idemptotently records a histogram entry with the total shard factor.
+// Reason tracks a reason why a part of the query was or was not sharded, each reason is recorded once per query.
+func (r *shardRecorder) Reason(reason string) {
+	for _, existing := range r.reasons {
+		if existing == reason {
+			return
+		}
+	}
+	r.reasons = append(r.reasons, reason)
+}
+
+// Finish idemptotently records a histogram entry with the total shard factor and the sharding reasons.
 func (r *shardRecorder) Finish() {
 	if !r.done {
 		r.done = true
 		r.shardFactor.Observe(float64(r.total))
+		for _, reason := range r.reasons {
+			r.ShardingMetrics.reasons.WithLabelValues(reason).Inc()
+		}
 	}
 }
 
@@ -127,9 +162,9 @@ func (m ShardMapper) Parse(query string) (noop bool, expr Expr, err error) {
 
 func (m ShardMapper) Map(expr Expr, r *shardRecorder) (Expr, error) {
 	switch e := expr.(type) {
-	case *literalExpr:
+	case *literalExpr, *vectorExpr:
 		return e, nil
-	case *matchersExpr, *filterExpr:
+	case *matchersExpr, *filterExpr, *labelParserExpr, *labelFilterExpr, *lineFmtExpr, *labelFmtExpr:
 		return m.mapLogSelectorExpr(e.(LogSelectorExpr), r), nil
 	case *vectorAggregationExpr:
 		return m.mapVectorAggregationExpr(e, r)
@@ -155,6 +190,33 @@ func (m ShardMapper) Map(expr Expr, r *shardRecorder) (Expr, error) {
 		e.SampleExpr = lhsSampleExpr
 		e.RHS = rhsSampleExpr
 		return e, nil
+	case *labelReplaceExpr:
+		mapped, err := m.Map(e.left, r)
+		if err != nil {
+			return nil, err
+		}
+		sampleExpr, ok := mapped.(SampleExpr)
+		if !ok {
+			return nil, badASTMapping("SampleExpr", mapped)
+		}
+		e.left = sampleExpr
+		return e, nil
+	case *subqueryAggregationExpr:
+		// subqueries need the results of all the shards at each of their steps, they are executed downstream without shard.
+		r.Add(1, MetricsKey)
+		r.Reason(ReasonRequiresAllShards)
+		return DownstreamSampleExpr{SampleExpr: e}, nil
+	case *functionExpr:
+		mapped, err := m.Map(e.left, r)
+		if err != nil {
+			return nil, err
+		}
+		sampleExpr, ok := mapped.(SampleExpr)
+		if !ok {
+			return nil, badASTMapping("SampleExpr", mapped)
+		}
+		e.left = sampleExpr
+		return e, nil
 	default:
 		return nil, errors.Errorf("unexpected expr type (%T) for ASTMapper type (%T) ", expr, m)
 	}
@@ -175,6 +237,7 @@ func (m ShardMapper) mapLogSelectorExpr(expr LogSelectorExpr, r *shardRecorder)
 		}
 	}
 	r.Add(m.shards, StreamsKey)
+	r.Reason(ReasonSharded)
 
 	return head
 }
@@ -194,6 +257,7 @@ func (m ShardMapper) mapSampleExpr(expr SampleExpr, r *shardRecorder) SampleExpr
 		}
 	}
 	r.Add(m.shards, MetricsKey)
+	r.Reason(ReasonSharded)
 
 	return head
 }
@@ -201,26 +265,31 @@ func (m ShardMapper) mapSampleExpr(expr SampleExpr, r *shardRecorder) SampleExpr
 // technically, std{dev,var} are also parallelizable if there is no cross-shard merging
 // in descendent nodes in the AST. This optimization is currently avoided for simplicity.
 func (m ShardMapper) mapVectorAggregationExpr(expr *vectorAggregationExpr, r *shardRecorder) (SampleExpr, error) {
+	switch expr.operation {
+	case OpTypeTopK, OpTypeBottomK:
+		// topk(k, x) -> topk(k, topk(k, x, shard=1) ++ topk(k, x, shard=2)...)
+		// same goes for bottomk, every series of x belongs to a single shard so the k top series of x
+		// are within the k top series of their shards.
+		if isShardLocal(expr.left.Operations()) {
+			return &vectorAggregationExpr{
+				left:      m.mapSampleExpr(expr, r),
+				grouping:  expr.grouping,
+				params:    expr.params,
+				operation: expr.operation,
+			}, nil
+		}
+		return m.mapVectorAggregationArgument(expr, ReasonCrossShardAggregation, r)
+	case OpTypeCount, OpTypeAvg:
+		// counting series per shard is only correct if each of them belongs to a single shard.
+		if isShardable(expr.Operations()) && !isShardLocal(expr.left.Operations()) {
+			return m.mapVectorAggregationArgument(expr, ReasonCrossShardAggregation, r)
+		}
+	}
 
 	// if this AST contains unshardable operations, don't shard this at this level,
 	// but attempt to shard a child node.
 	if shardable := isShardable(expr.Operations()); !shardable {
-		subMapped, err := m.Map(expr.left, r)
-		if err != nil {
-			return nil, err
-		}
-		sampleExpr, ok := subMapped.(SampleExpr)
-		if !ok {
-			return nil, badASTMapping("SampleExpr", subMapped)
-		}
-
-		return &vectorAggregationExpr{
-			left:      sampleExpr,
-			grouping:  expr.grouping,
-			params:    expr.params,
-			operation: expr.operation,
-		}, nil
-
+		return m.mapVectorAggregationArgument(expr, ReasonUnshardableOperation, r)
 	}
 
 	switch expr.operation {
@@ -277,14 +346,41 @@ func (m ShardMapper) mapVectorAggregationExpr(expr *vectorAggregationExpr, r *sh
 	}
 }
 
+// mapVectorAggregationArgument keeps a vector aggregation unsharded and attempts to shard its argument instead.
+func (m ShardMapper) mapVectorAggregationArgument(expr *vectorAggregationExpr, reason string, r *shardRecorder) (SampleExpr, error) {
+	r.Reason(reason)
+	subMapped, err := m.Map(expr.left, r)
+	if err != nil {
+		return nil, err
+	}
+	sampleExpr, ok := subMapped.(SampleExpr)
+	if !ok {
+		return nil, badASTMapping("SampleExpr", subMapped)
+	}
+
+	return &vectorAggregationExpr{
+		left:      sampleExpr,
+		grouping:  expr.grouping,
+		params:    expr.params,
+		operation: expr.operation,
+	}, nil
+}
+
 func (m ShardMapper) mapRangeAggregationExpr(expr *rangeAggregationExpr, r *shardRecorder) SampleExpr {
 	switch expr.operation {
-	case OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeBytesRate, OpRangeTypeBytes:
+	case OpRangeTypeCount, OpRangeTypeRate, OpRangeTypeBytesRate, OpRangeTypeBytes,
+		OpRangeTypeSum, OpRangeTypeMax, OpRangeTypeMin:
 		// count_over_time(x) -> count_over_time(x, shard=1) ++ count_over_time(x, shard=2)...
 		// rate(x) -> rate(x, shard=1) ++ rate(x, shard=2)...
-		// same goes for bytes_rate and bytes_over_time
+		// same goes for bytes_rate, bytes_over_time, sum_over_time, max_over_time and min_over_time
 		return m.mapSampleExpr(expr, r)
+	case OpRangeTypeAbsent:
+		// absent_over_time(x) needs all the streams of x, it is executed downstream without shard.
+		r.Add(1, MetricsKey)
+		r.Reason(ReasonRequiresAllShards)
+		return DownstreamSampleExpr{SampleExpr: expr}
 	default:
+		r.Reason(ReasonUnshardableOperation)
 		return expr
 	}
 }
@@ -299,8 +395,26 @@ func isShardable(ops []string) bool {
 	return true
 }
 
+// isShardLocal returns true if the listed operations are shardable and none of them aggregates series,
+// every resulting series is then computed from the streams of a single shard.
+func isShardLocal(ops []string) bool {
+	for _, op := range ops {
+		if aggregationOps[op] {
+			return false
+		}
+	}
+	return isShardable(ops)
+}
+
+// aggregationOps lists the shardable operations merging series across shards.
+var aggregationOps = map[string]bool{
+	OpTypeSum:   true,
+	OpTypeAvg:   true,
+	OpTypeCount: true,
+}
+
 // shardableOps lists the operations which may be sharded.
-// topk, botk, max, & min all must be concatenated and then evaluated in order to avoid
+// max & min all must be concatenated and then evaluated in order to avoid
 // potential data loss due to series distribution across shards.
 // For example, grouping by `cluster` for a `max` operation may yield
 // 2 results on the first shard and 10 results on the second. If we prematurely
@@ -328,8 +442,21 @@ var shardableOps = map[string]bool{
 	OpRangeTypeRate:      true,
 	OpRangeTypeBytes:     true,
 	OpRangeTypeBytesRate: true,
+	OpRangeTypeSum:       true,
+	OpRangeTypeMax:       true,
+	OpRangeTypeMin:       true,
 
 	// binops - arith
 	OpTypeAdd: true,
 	OpTypeMul: true,
+
+	// on, ignoring, group_left and group_right are never shardable: they match series
+	// with different label sets, which may belong to different shards.
+	// The legs of such binary operations are still sharded independently.
+
+	// functions are never shardable as a whole either, their arguments are still sharded independently.
+	// subqueries are never shardable: their range aggregations can't be merged across shards.
+
+	// topk and bottomk are not listed either: their results can't be merged by another operation.
+	// They are still evaluated per shard and ranked again when their argument is shard local, see isShardLocal.
 }
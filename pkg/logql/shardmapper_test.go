@@ -1,10 +1,13 @@
 package logql
 
 import (
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/astmapper"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/prometheus/pkg/labels"
 	"github.com/stretchr/testify/require"
 )
@@ -140,7 +143,28 @@ func TestMappingStrings(t *testing.T) {
 		},
 		{
 			in:  `topk(3, rate({foo="bar"}[5m]))`,
-			out: `topk(3,downstream<rate({foo="bar"}[5m]), shard=0_of_2> ++ downstream<rate({foo="bar"}[5m]), shard=1_of_2>)`,
+			out: `topk(3,downstream<topk(3,rate({foo="bar"}[5m])), shard=0_of_2> ++ downstream<topk(3,rate({foo="bar"}[5m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `bottomk(2, rate({foo="bar"}[5m])) by (app)`,
+			out: `bottomk by(app)(2,downstream<bottomk by(app)(2,rate({foo="bar"}[5m])), shard=0_of_2> ++ downstream<bottomk by(app)(2,rate({foo="bar"}[5m])), shard=1_of_2>)`,
+		},
+		{
+			// series of the sum may be spread across shards, only the sum is sharded.
+			in:  `topk(3, sum by (app) (rate({foo="bar"}[5m])))`,
+			out: `topk(3,sum by(app)(downstream<sum by(app)(rate({foo="bar"}[5m])), shard=0_of_2> ++ downstream<sum by(app)(rate({foo="bar"}[5m])), shard=1_of_2>))`,
+		},
+		{
+			in:  `count(sum by (app) (rate({foo="bar"}[5m])))`,
+			out: `count(sum by(app)(downstream<sum by(app)(rate({foo="bar"}[5m])), shard=0_of_2> ++ downstream<sum by(app)(rate({foo="bar"}[5m])), shard=1_of_2>))`,
+		},
+		{
+			in:  `avg by (app) (count_over_time({foo="bar"}[5m]))`,
+			out: `sum by(app)(downstream<sum by(app)(count_over_time({foo="bar"}[5m])), shard=0_of_2> ++ downstream<sum by(app)(count_over_time({foo="bar"}[5m])), shard=1_of_2>) / sum by(app)(downstream<count by(app)(count_over_time({foo="bar"}[5m])), shard=0_of_2> ++ downstream<count by(app)(count_over_time({foo="bar"}[5m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `sum(rate({foo="bar"}[5m])) / sum(rate({foo="buzz"}[5m]))`,
+			out: `sum(downstream<sum(rate({foo="bar"}[5m])), shard=0_of_2> ++ downstream<sum(rate({foo="bar"}[5m])), shard=1_of_2>) / sum(downstream<sum(rate({foo="buzz"}[5m])), shard=0_of_2> ++ downstream<sum(rate({foo="buzz"}[5m])), shard=1_of_2>)`,
 		},
 		{
 			in:  `sum(max(rate({foo="bar"}[5m])))`,
@@ -154,6 +178,56 @@ func TestMappingStrings(t *testing.T) {
 			in:  `sum by (cluster) (rate({foo="bar"} |= "id=123" [5m]))`,
 			out: `sum by(cluster)(downstream<sum by(cluster)(rate({foo="bar"}|="id=123"[5m])), shard=0_of_2> ++ downstream<sum by(cluster)(rate({foo="bar"}|="id=123"[5m])), shard=1_of_2>)`,
 		},
+		{
+			in:  `{foo="bar"} | json | status >= 500 and latency > 250ms`,
+			out: `downstream<{foo="bar"} | json | ( status>=500 and latency>250ms ), shard=0_of_2> ++ downstream<{foo="bar"} | json | ( status>=500 and latency>250ms ), shard=1_of_2>`,
+		},
+		{
+			in:  `sum by (status) (count_over_time({foo="bar"} | logfmt | size > 10KB [5m]))`,
+			out: `sum by(status)(downstream<sum by(status)(count_over_time({foo="bar"} | logfmt | size>10kB[5m])), shard=0_of_2> ++ downstream<sum by(status)(count_over_time({foo="bar"} | logfmt | size>10kB[5m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `{foo="bar"} | logfmt | label_format dst=src | line_format "{{.dst}} {{.status}}"`,
+			out: `downstream<{foo="bar"} | logfmt | label_format dst=src | line_format "{{.dst}} {{.status}}", shard=0_of_2> ++ downstream<{foo="bar"} | logfmt | label_format dst=src | line_format "{{.dst}} {{.status}}", shard=1_of_2>`,
+		},
+		{
+			in:  `sum(max_over_time({foo="bar"} | json | unwrap bytes(size) [5m]))`,
+			out: `sum(downstream<sum(max_over_time({foo="bar"} | json | unwrap bytes(size)[5m])), shard=0_of_2> ++ downstream<sum(max_over_time({foo="bar"} | json | unwrap bytes(size)[5m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `sum(quantile_over_time(0.99, {foo="bar"} | json | unwrap latency [5m]))`,
+			out: `sum(quantile_over_time(0.99,{foo="bar"} | json | unwrap latency[5m]))`,
+		},
+		{
+			in:  `sum by (app) (rate({foo="bar"}[1m])) / on(app) group_left sum by (app, pod) (rate({foo="bar"}[1m]))`,
+			out: `sum by(app)(downstream<sum by(app)(rate({foo="bar"}[1m])), shard=0_of_2> ++ downstream<sum by(app)(rate({foo="bar"}[1m])), shard=1_of_2>) / on(app) group_left() sum by(app,pod)(downstream<sum by(app,pod)(rate({foo="bar"}[1m])), shard=0_of_2> ++ downstream<sum by(app,pod)(rate({foo="bar"}[1m])), shard=1_of_2>)`,
+		},
+		{
+			in:  `sum(rate({foo="bar"}[1m] offset 1h))`,
+			out: `sum(downstream<sum(rate({foo="bar"}[1m] offset 1h)), shard=0_of_2> ++ downstream<sum(rate({foo="bar"}[1m] offset 1h)), shard=1_of_2>)`,
+		},
+		{
+			in:  `sort_desc(sum(rate({foo="bar"}[1m])))`,
+			out: `sort_desc(sum(downstream<sum(rate({foo="bar"}[1m])), shard=0_of_2> ++ downstream<sum(rate({foo="bar"}[1m])), shard=1_of_2>))`,
+		},
+		{
+			// functions are evaluated after merging the shards.
+			in:  `sum(abs(rate({foo="bar"}[1m])))`,
+			out: `sum(abs(downstream<rate({foo="bar"}[1m]), shard=0_of_2> ++ downstream<rate({foo="bar"}[1m]), shard=1_of_2>))`,
+		},
+		{
+			in:  `sum(absent_over_time({foo="bar"}[1m])) or vector(0)`,
+			out: `sum(downstream<absent_over_time({foo="bar"}[1m]), shard=<nil>>) or vector(0)`,
+		},
+		{
+			in:  `max_over_time(sum(rate({foo="bar"}[1m]))[1h:1m])`,
+			out: `downstream<max_over_time(sum(rate({foo="bar"}[1m]))[1h:1m]), shard=<nil>>`,
+		},
+		{
+			// matching series may belong to different shards, only the legs are sharded.
+			in:  `sum(rate({foo="bar"}[1m]) * on(app) group_left(team) rate({foo="buzz"}[1m]))`,
+			out: `sum(downstream<rate({foo="bar"}[1m]), shard=0_of_2> ++ downstream<rate({foo="bar"}[1m]), shard=1_of_2> * on(app) group_left(team) downstream<rate({foo="buzz"}[1m]), shard=0_of_2> ++ downstream<rate({foo="buzz"}[1m]), shard=1_of_2>)`,
+		},
 	} {
 		t.Run(tc.in, func(t *testing.T) {
 			ast, err := ParseExpr(tc.in)
@@ -395,15 +469,20 @@ func TestMapping(t *testing.T) {
 							Shard: 0,
 							Of:    2,
 						},
-						SampleExpr: &rangeAggregationExpr{
-							operation: OpRangeTypeRate,
-							left: &logRange{
-								left: &matchersExpr{
-									matchers: []*labels.Matcher{
-										mustNewMatcher(labels.MatchEqual, "foo", "bar"),
+						SampleExpr: &vectorAggregationExpr{
+							grouping:  &grouping{},
+							params:    3,
+							operation: OpTypeTopK,
+							left: &rangeAggregationExpr{
+								operation: OpRangeTypeRate,
+								left: &logRange{
+									left: &matchersExpr{
+										matchers: []*labels.Matcher{
+											mustNewMatcher(labels.MatchEqual, "foo", "bar"),
+										},
 									},
+									interval: 5 * time.Minute,
 								},
-								interval: 5 * time.Minute,
 							},
 						},
 					},
@@ -413,15 +492,20 @@ func TestMapping(t *testing.T) {
 								Shard: 1,
 								Of:    2,
 							},
-							SampleExpr: &rangeAggregationExpr{
-								operation: OpRangeTypeRate,
-								left: &logRange{
-									left: &matchersExpr{
-										matchers: []*labels.Matcher{
-											mustNewMatcher(labels.MatchEqual, "foo", "bar"),
+							SampleExpr: &vectorAggregationExpr{
+								grouping:  &grouping{},
+								params:    3,
+								operation: OpTypeTopK,
+								left: &rangeAggregationExpr{
+									operation: OpRangeTypeRate,
+									left: &logRange{
+										left: &matchersExpr{
+											matchers: []*labels.Matcher{
+												mustNewMatcher(labels.MatchEqual, "foo", "bar"),
+											},
 										},
+										interval: 5 * time.Minute,
 									},
-									interval: 5 * time.Minute,
 								},
 							},
 						},
@@ -946,3 +1030,37 @@ func TestMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestShardMapper_Reasons(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  string
+	}{
+		{`sum(rate({foo="bar"}[5m]))`, `
+loki_query_frontend_sharding_reasons_total{reason="sharded"} 1
+`},
+		{`max(rate({foo="bar"}[5m]))`, `
+loki_query_frontend_sharding_reasons_total{reason="sharded"} 1
+loki_query_frontend_sharding_reasons_total{reason="unshardable_operation"} 1
+`},
+		{`count(sum by (app) (rate({foo="bar"}[5m]))) / count(sum by (env) (rate({foo="bar"}[5m])))`, `
+loki_query_frontend_sharding_reasons_total{reason="cross_shard_aggregation"} 1
+loki_query_frontend_sharding_reasons_total{reason="sharded"} 1
+`},
+		{`quantile_over_time(0.99, {foo="bar"} | json | unwrap latency [5m]) or absent_over_time({foo="bar"}[5m])`, `
+loki_query_frontend_sharding_reasons_total{reason="requires_all_shards"} 1
+loki_query_frontend_sharding_reasons_total{reason="unshardable_operation"} 1
+`},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			m, err := NewShardMapper(2, NewShardingMetrics(registry))
+			require.Nil(t, err)
+			_, _, err = m.Parse(tc.query)
+			require.Nil(t, err)
+			require.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP loki_query_frontend_sharding_reasons_total Total number of successfully parsed queries per reason why they were or were not sharded, a query can have several reasons.
+# TYPE loki_query_frontend_sharding_reasons_total counter`+tc.want), "loki_query_frontend_sharding_reasons_total"))
+		})
+	}
+}
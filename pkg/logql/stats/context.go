@@ -56,6 +56,8 @@ func (r Result) Log(log log.Logger) {
 		"Ingester.DecompressedLines", r.Ingester.DecompressedLines,
 		"Ingester.CompressedBytes", humanize.Bytes(uint64(r.Ingester.CompressedBytes)),
 		"Ingester.TotalDuplicates", r.Ingester.TotalDuplicates,
+		"Ingester.TotalBlocks", r.Ingester.TotalBlocks,
+		"Ingester.SkippedBlocks", r.Ingester.SkippedBlocks,
 
 		"Store.TotalChunksRef", r.Store.TotalChunksRef,
 		"Store.TotalChunksDownloaded", r.Store.TotalChunksDownloaded,
@@ -67,6 +69,8 @@ func (r Result) Log(log log.Logger) {
 		"Store.DecompressedLines", r.Store.DecompressedLines,
 		"Store.CompressedBytes", humanize.Bytes(uint64(r.Store.CompressedBytes)),
 		"Store.TotalDuplicates", r.Store.TotalDuplicates,
+		"Store.TotalBlocks", r.Store.TotalBlocks,
+		"Store.SkippedBlocks", r.Store.SkippedBlocks,
 	)
 	r.Summary.Log(log)
 }
@@ -100,6 +104,8 @@ type ChunkData struct {
 	DecompressedLines int64 `json:"decompressedLines"` // Total lines decompressed and processed from chunks.
 	CompressedBytes   int64 `json:"compressedBytes"`   // Total bytes of compressed chunks (blocks) processed.
 	TotalDuplicates   int64 `json:"totalDuplicates"`   // Total duplicates found while processing.
+	TotalBlocks       int64 `json:"totalBlocks"`       // Total blocks of compressed chunks processed.
+	SkippedBlocks     int64 `json:"skippedBlocks"`     // Total blocks skipped because their filter can't match the query.
 }
 
 // GetChunkData returns the chunks statistics data from the current context.
@@ -163,6 +169,8 @@ func Snapshot(ctx context.Context, execTime time.Duration) Result {
 		res.Store.DecompressedLines = c.DecompressedLines
 		res.Store.CompressedBytes = c.CompressedBytes
 		res.Store.TotalDuplicates = c.TotalDuplicates
+		res.Store.TotalBlocks = c.TotalBlocks
+		res.Store.SkippedBlocks = c.SkippedBlocks
 	}
 
 	existing, err := GetResult(ctx)
@@ -206,6 +214,8 @@ func (r *Result) Merge(m Result) {
 	r.Store.DecompressedLines += m.Store.DecompressedLines
 	r.Store.CompressedBytes += m.Store.CompressedBytes
 	r.Store.TotalDuplicates += m.Store.TotalDuplicates
+	r.Store.TotalBlocks += m.Store.TotalBlocks
+	r.Store.SkippedBlocks += m.Store.SkippedBlocks
 
 	r.Ingester.TotalReached += m.Ingester.TotalReached
 	r.Ingester.TotalChunksMatched += m.Ingester.TotalChunksMatched
@@ -217,6 +227,8 @@ func (r *Result) Merge(m Result) {
 	r.Ingester.DecompressedLines += m.Ingester.DecompressedLines
 	r.Ingester.CompressedBytes += m.Ingester.CompressedBytes
 	r.Ingester.TotalDuplicates += m.Ingester.TotalDuplicates
+	r.Ingester.TotalBlocks += m.Ingester.TotalBlocks
+	r.Ingester.SkippedBlocks += m.Ingester.SkippedBlocks
 
 	r.ComputeSummary(time.Duration(int64((r.Summary.ExecTime + m.Summary.ExecTime) * float64(time.Second))))
 }
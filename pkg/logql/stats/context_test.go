@@ -19,6 +19,8 @@ func TestSnapshot(t *testing.T) {
 	GetChunkData(ctx).DecompressedLines += 20
 	GetChunkData(ctx).CompressedBytes += 30
 	GetChunkData(ctx).TotalDuplicates += 10
+	GetChunkData(ctx).TotalBlocks += 8
+	GetChunkData(ctx).SkippedBlocks += 6
 
 	GetStoreData(ctx).TotalChunksRef += 50
 	GetStoreData(ctx).TotalChunksDownloaded += 60
@@ -52,6 +54,8 @@ func TestSnapshot(t *testing.T) {
 			DecompressedLines:     20,
 			CompressedBytes:       30,
 			TotalDuplicates:       10,
+			TotalBlocks:           8,
+			SkippedBlocks:         6,
 		},
 		Summary: Summary{
 			ExecTime:                2 * time.Second.Seconds(),
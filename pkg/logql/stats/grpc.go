@@ -102,6 +102,8 @@ func decodeTrailers(ctx context.Context) Result {
 		res.Ingester.DecompressedLines += ing.Ingester.DecompressedLines
 		res.Ingester.CompressedBytes += ing.Ingester.CompressedBytes
 		res.Ingester.TotalDuplicates += ing.Ingester.TotalDuplicates
+		res.Ingester.TotalBlocks += ing.Ingester.TotalBlocks
+		res.Ingester.SkippedBlocks += ing.Ingester.SkippedBlocks
 		res.Store.TotalChunksRef += ing.Store.TotalChunksRef
 		res.Store.TotalChunksDownloaded += ing.Store.TotalChunksDownloaded
 		res.Store.ChunksDownloadTime += ing.Store.ChunksDownloadTime
@@ -143,6 +145,8 @@ func decodeTrailer(ctx context.Context, meta *metadata.MD) Result {
 			DecompressedLines:  chunkData.DecompressedLines,
 			CompressedBytes:    chunkData.CompressedBytes,
 			TotalDuplicates:    chunkData.TotalDuplicates,
+			TotalBlocks:        chunkData.TotalBlocks,
+			SkippedBlocks:      chunkData.SkippedBlocks,
 		},
 		Store: Store{
 			TotalChunksRef:        storeData.TotalChunksRef,
@@ -47,6 +47,8 @@ func TestCollectTrailer(t *testing.T) {
 		GetChunkData(ingCtx).DecompressedLines++
 		GetChunkData(ingCtx).CompressedBytes++
 		GetChunkData(ingCtx).TotalDuplicates++
+		GetChunkData(ingCtx).TotalBlocks++
+		GetChunkData(ingCtx).SkippedBlocks++
 		return nil
 	})
 	logproto.RegisterQuerierServer(server, ing)
@@ -92,6 +94,8 @@ func TestCollectTrailer(t *testing.T) {
 	require.Equal(t, int64(2), res.Ingester.DecompressedLines)
 	require.Equal(t, int64(2), res.Ingester.CompressedBytes)
 	require.Equal(t, int64(2), res.Ingester.TotalDuplicates)
+	require.Equal(t, int64(2), res.Ingester.TotalBlocks)
+	require.Equal(t, int64(2), res.Ingester.SkippedBlocks)
 }
 
 type ingesterFn func(grpc.ServerStream) error
@@ -113,3 +117,6 @@ func (ingesterFn) Series(context.Context, *logproto.SeriesRequest) (*logproto.Se
 func (ingesterFn) TailersCount(context.Context, *logproto.TailersCountRequest) (*logproto.TailersCountResponse, error) {
 	return nil, nil
 }
+func (ingesterFn) GetStreamStats(context.Context, *logproto.StreamStatsRequest) (*logproto.StreamStatsResponse, error) {
+	return nil, nil
+}
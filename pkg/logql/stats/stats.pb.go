@@ -185,6 +185,10 @@ type Store struct {
 	CompressedBytes int64 `protobuf:"varint,8,opt,name=compressedBytes,proto3" json:"compressedBytes"`
 	// Total duplicates found while processing.
 	TotalDuplicates int64 `protobuf:"varint,9,opt,name=totalDuplicates,proto3" json:"totalDuplicates"`
+	// Total blocks of compressed chunks processed.
+	TotalBlocks int64 `protobuf:"varint,10,opt,name=totalBlocks,proto3" json:"totalBlocks"`
+	// Total blocks skipped because their filter can't match the query.
+	SkippedBlocks int64 `protobuf:"varint,11,opt,name=skippedBlocks,proto3" json:"skippedBlocks"`
 }
 
 func (m *Store) Reset()      { *m = Store{} }
@@ -282,6 +286,20 @@ func (m *Store) GetTotalDuplicates() int64 {
 	return 0
 }
 
+func (m *Store) GetTotalBlocks() int64 {
+	if m != nil {
+		return m.TotalBlocks
+	}
+	return 0
+}
+
+func (m *Store) GetSkippedBlocks() int64 {
+	if m != nil {
+		return m.SkippedBlocks
+	}
+	return 0
+}
+
 type Ingester struct {
 	// Total ingester reached for this query.
 	TotalReached int32 `protobuf:"varint,1,opt,name=totalReached,proto3" json:"totalReached"`
@@ -303,6 +321,10 @@ type Ingester struct {
 	CompressedBytes int64 `protobuf:"varint,9,opt,name=compressedBytes,proto3" json:"compressedBytes"`
 	// Total duplicates found while processing.
 	TotalDuplicates int64 `protobuf:"varint,10,opt,name=totalDuplicates,proto3" json:"totalDuplicates"`
+	// Total blocks of compressed chunks processed.
+	TotalBlocks int64 `protobuf:"varint,11,opt,name=totalBlocks,proto3" json:"totalBlocks"`
+	// Total blocks skipped because their filter can't match the query.
+	SkippedBlocks int64 `protobuf:"varint,12,opt,name=skippedBlocks,proto3" json:"skippedBlocks"`
 }
 
 func (m *Ingester) Reset()      { *m = Ingester{} }
@@ -407,6 +429,20 @@ func (m *Ingester) GetTotalDuplicates() int64 {
 	return 0
 }
 
+func (m *Ingester) GetTotalBlocks() int64 {
+	if m != nil {
+		return m.TotalBlocks
+	}
+	return 0
+}
+
+func (m *Ingester) GetSkippedBlocks() int64 {
+	if m != nil {
+		return m.SkippedBlocks
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Result)(nil), "stats.Result")
 	proto.RegisterType((*Summary)(nil), "stats.Summary")
@@ -417,50 +453,52 @@ func init() {
 func init() { proto.RegisterFile("pkg/logql/stats/stats.proto", fileDescriptor_770b8387e5696475) }
 
 var fileDescriptor_770b8387e5696475 = []byte{
-	// 675 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x55, 0xcd, 0x6e, 0xd3, 0x4c,
-	0x14, 0xf5, 0x34, 0xcd, 0x4f, 0xe7, 0xeb, 0xd7, 0x96, 0xa9, 0x4a, 0x03, 0x95, 0xc6, 0x55, 0x36,
-	0x74, 0x43, 0x23, 0x7e, 0x36, 0x20, 0x75, 0xe3, 0x56, 0x48, 0x95, 0x40, 0x54, 0x53, 0xd8, 0x20,
-	0xb1, 0x70, 0x9c, 0x69, 0x62, 0xd5, 0xf1, 0x04, 0x7b, 0x2c, 0xe8, 0x8e, 0x47, 0xe0, 0x31, 0x78,
-	0x01, 0xde, 0xa1, 0xcb, 0x2e, 0xbb, 0x40, 0x16, 0x75, 0x37, 0xc8, 0xab, 0xee, 0x90, 0x58, 0x21,
-	0x5f, 0xff, 0x34, 0x9e, 0x38, 0x12, 0x52, 0xd8, 0x24, 0x73, 0xcf, 0xb9, 0xe7, 0xce, 0xcc, 0x9d,
-	0x33, 0x1e, 0xbc, 0x35, 0x3e, 0x1d, 0x74, 0x1d, 0x31, 0xf8, 0xe0, 0x74, 0x7d, 0x69, 0x4a, 0x3f,
-	0xfd, 0xdd, 0x1d, 0x7b, 0x42, 0x0a, 0x52, 0x87, 0xe0, 0xfe, 0xc3, 0x81, 0x2d, 0x87, 0x41, 0x6f,
-	0xd7, 0x12, 0xa3, 0xee, 0x40, 0x0c, 0x44, 0x17, 0xd8, 0x5e, 0x70, 0x02, 0x11, 0x04, 0x30, 0x4a,
-	0x55, 0x9d, 0x6f, 0x08, 0x37, 0x18, 0xf7, 0x03, 0x47, 0x92, 0x67, 0xb8, 0xe9, 0x07, 0xa3, 0x91,
-	0xe9, 0x9d, 0xb5, 0xd1, 0x36, 0xda, 0xf9, 0xef, 0xf1, 0xca, 0x6e, 0x5a, 0xff, 0x38, 0x45, 0x8d,
-	0xd5, 0xf3, 0x50, 0xd7, 0xe2, 0x50, 0xcf, 0xd3, 0x58, 0x3e, 0x20, 0x8f, 0x70, 0xdd, 0x97, 0xc2,
-	0xe3, 0xed, 0x05, 0x10, 0x2e, 0xe7, 0xc2, 0x04, 0x33, 0xfe, 0xcf, 0x64, 0x69, 0x0a, 0x4b, 0xff,
-	0xc8, 0x1e, 0x6e, 0xd9, 0xee, 0x80, 0xfb, 0x92, 0x7b, 0xed, 0x1a, 0xa8, 0x56, 0x33, 0xd5, 0x61,
-	0x06, 0x1b, 0x6b, 0x99, 0xb0, 0x48, 0x64, 0xc5, 0xa8, 0xf3, 0x6b, 0x01, 0x37, 0xb3, 0x75, 0x91,
-	0xb7, 0x78, 0xb3, 0x77, 0x26, 0xb9, 0x7f, 0xe4, 0x09, 0x8b, 0xfb, 0x3e, 0xef, 0x1f, 0x71, 0xef,
-	0x98, 0x5b, 0xc2, 0xed, 0xc3, 0x46, 0x6a, 0xc6, 0x56, 0x1c, 0xea, 0xb3, 0x52, 0xd8, 0x2c, 0x22,
-	0x29, 0xeb, 0xd8, 0x6e, 0x65, 0xd9, 0x85, 0xdb, 0xb2, 0x33, 0x52, 0xd8, 0x2c, 0x82, 0x1c, 0xe2,
-	0x75, 0x29, 0xa4, 0xe9, 0x18, 0xa5, 0x69, 0xa1, 0x07, 0x35, 0x63, 0x33, 0x0e, 0xf5, 0x2a, 0x9a,
-	0x55, 0x81, 0x45, 0xa9, 0x97, 0xa5, 0xa9, 0xda, 0x8b, 0x4a, 0xa9, 0x32, 0xcd, 0xaa, 0x40, 0xb2,
-	0x83, 0x5b, 0xfc, 0x13, 0xb7, 0xde, 0xd8, 0x23, 0xde, 0xae, 0x6f, 0xa3, 0x1d, 0x64, 0x2c, 0x27,
-	0x9d, 0xcf, 0x31, 0x56, 0x8c, 0x3a, 0xdf, 0x17, 0x71, 0x1d, 0x0e, 0x96, 0x3c, 0xc7, 0x2b, 0x50,
-	0x6a, 0x7f, 0x18, 0xb8, 0xa7, 0x3e, 0xe3, 0x27, 0x59, 0xbb, 0x49, 0x1c, 0xea, 0x0a, 0xc3, 0x94,
-	0x98, 0xbc, 0xc6, 0x1b, 0x13, 0xc8, 0x81, 0xf8, 0xe8, 0x3a, 0xc2, 0xec, 0xf3, 0xbc, 0xb5, 0xf7,
-	0xe2, 0x50, 0xaf, 0x4e, 0x60, 0xd5, 0x30, 0x79, 0x81, 0x89, 0x55, 0xc2, 0x60, 0x2b, 0x35, 0xd8,
-	0xca, 0xdd, 0x38, 0xd4, 0x2b, 0x58, 0x56, 0x81, 0x25, 0x9b, 0x1a, 0x72, 0xb3, 0x0f, 0xf5, 0xa1,
-	0xdd, 0x59, 0x3b, 0x61, 0x53, 0x65, 0x86, 0x29, 0x71, 0x49, 0x0b, 0xfd, 0x85, 0x56, 0xaa, 0x5a,
-	0x60, 0x98, 0x12, 0x93, 0x7d, 0x7c, 0xa7, 0xcf, 0x2d, 0x31, 0x1a, 0x7b, 0x70, 0x20, 0xe9, 0xd4,
-	0x0d, 0x90, 0x6f, 0xc4, 0xa1, 0x3e, 0x4d, 0xb2, 0x69, 0x48, 0x2d, 0x92, 0xae, 0xa1, 0x59, 0x5d,
-	0x24, 0x5d, 0xc6, 0x34, 0x44, 0xf6, 0xf0, 0xaa, 0xba, 0x8e, 0x16, 0x94, 0x58, 0x8f, 0x43, 0x5d,
-	0xa5, 0x98, 0x0a, 0x24, 0x72, 0x38, 0xa1, 0x83, 0x60, 0xec, 0xd8, 0x96, 0x99, 0xc8, 0x97, 0x6e,
-	0xe5, 0x0a, 0xc5, 0x54, 0xa0, 0xf3, 0x7b, 0x11, 0xb7, 0xf2, 0x2f, 0x00, 0x79, 0x8a, 0x97, 0x81,
-	0x67, 0xdc, 0xb4, 0x86, 0x3c, 0xbd, 0xce, 0x75, 0x63, 0x2d, 0x0e, 0xf5, 0x12, 0xce, 0x4a, 0x51,
-	0x62, 0x85, 0x09, 0x8f, 0xbc, 0x32, 0x25, 0x68, 0x53, 0x63, 0x81, 0x15, 0xa6, 0x59, 0x56, 0x81,
-	0x15, 0xb3, 0x1b, 0x10, 0xfb, 0xd9, 0x15, 0xbd, 0x9d, 0x3d, 0xc3, 0x59, 0x29, 0x2a, 0x6e, 0x05,
-	0x34, 0xf3, 0x98, 0xbb, 0x72, 0xd2, 0x40, 0x65, 0x86, 0x29, 0x71, 0x85, 0xf9, 0xea, 0x73, 0x98,
-	0xaf, 0x31, 0x9f, 0xf9, 0x9a, 0xff, 0xc2, 0x7c, 0xad, 0xf9, 0xcd, 0xb7, 0x34, 0x9f, 0xf9, 0xf0,
-	0xdf, 0x9b, 0xcf, 0x78, 0x7f, 0x71, 0x45, 0xb5, 0xcb, 0x2b, 0xaa, 0xdd, 0x5c, 0x51, 0xf4, 0x39,
-	0xa2, 0xe8, 0x6b, 0x44, 0xd1, 0x79, 0x44, 0xd1, 0x45, 0x44, 0xd1, 0x8f, 0x88, 0xa2, 0x9f, 0x11,
-	0xd5, 0x6e, 0x22, 0x8a, 0xbe, 0x5c, 0x53, 0xed, 0xe2, 0x9a, 0x6a, 0x97, 0xd7, 0x54, 0x7b, 0xf7,
-	0x60, 0xf2, 0xc9, 0xf5, 0xcc, 0x13, 0xd3, 0x35, 0xbb, 0x8e, 0x38, 0xb5, 0xbb, 0xca, 0x73, 0xdd,
-	0x6b, 0xc0, 0x9b, 0xfb, 0xe4, 0x4f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x43, 0x1d, 0xe6, 0xf7, 0xc8,
-	0x07, 0x00, 0x00,
+	// 719 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x41, 0x4f, 0xdb, 0x3e,
+	0x1c, 0x4d, 0x28, 0x69, 0x8b, 0x5b, 0x28, 0x18, 0xf1, 0x27, 0xff, 0x21, 0x25, 0xa8, 0x97, 0x71,
+	0x19, 0x15, 0xdb, 0xa4, 0x69, 0x93, 0xb8, 0x04, 0x34, 0x09, 0x69, 0xd3, 0x90, 0xd9, 0x2e, 0x93,
+	0x76, 0x48, 0x53, 0xd3, 0x46, 0x4d, 0xe3, 0x2e, 0x4e, 0xb5, 0x71, 0xdb, 0x47, 0xd8, 0xc7, 0xd8,
+	0x17, 0xd8, 0x77, 0xe0, 0xc8, 0x91, 0x53, 0x34, 0x82, 0x34, 0x4d, 0x39, 0x71, 0xdb, 0x75, 0xca,
+	0x2f, 0x69, 0xda, 0xb8, 0xa9, 0x34, 0xd1, 0x5d, 0xc0, 0xbf, 0xf7, 0xfc, 0x9e, 0xed, 0x9f, 0x5f,
+	0x23, 0xa3, 0x9d, 0x61, 0xbf, 0xdb, 0x72, 0x58, 0xf7, 0xa3, 0xd3, 0xe2, 0xbe, 0xe9, 0xf3, 0xe4,
+	0xef, 0xfe, 0xd0, 0x63, 0x3e, 0xc3, 0x0a, 0x14, 0x0f, 0x1e, 0x75, 0x6d, 0xbf, 0x37, 0x6a, 0xef,
+	0x5b, 0x6c, 0xd0, 0xea, 0xb2, 0x2e, 0x6b, 0x01, 0xdb, 0x1e, 0x9d, 0x43, 0x05, 0x05, 0x8c, 0x12,
+	0x55, 0xf3, 0xbb, 0x8c, 0xca, 0x84, 0xf2, 0x91, 0xe3, 0xe3, 0xe7, 0xa8, 0xc2, 0x47, 0x83, 0x81,
+	0xe9, 0x5d, 0xa8, 0xf2, 0xae, 0xbc, 0x57, 0x7b, 0xbc, 0xb6, 0x9f, 0xf8, 0x9f, 0x25, 0xa8, 0xd1,
+	0xb8, 0x0c, 0x74, 0x29, 0x0a, 0xf4, 0xf1, 0x34, 0x32, 0x1e, 0xe0, 0x03, 0xa4, 0x70, 0x9f, 0x79,
+	0x54, 0x5d, 0x02, 0x61, 0x7d, 0x2c, 0x8c, 0x31, 0x63, 0x35, 0x95, 0x25, 0x53, 0x48, 0xf2, 0x0f,
+	0x1f, 0xa2, 0xaa, 0xed, 0x76, 0x29, 0xf7, 0xa9, 0xa7, 0x96, 0x40, 0xd5, 0x48, 0x55, 0x27, 0x29,
+	0x6c, 0xac, 0xa7, 0xc2, 0x6c, 0x22, 0xc9, 0x46, 0xcd, 0xdf, 0x4b, 0xa8, 0x92, 0xee, 0x0b, 0xbf,
+	0x43, 0xdb, 0xed, 0x0b, 0x9f, 0xf2, 0x53, 0x8f, 0x59, 0x94, 0x73, 0xda, 0x39, 0xa5, 0xde, 0x19,
+	0xb5, 0x98, 0xdb, 0x81, 0x83, 0x94, 0x8c, 0x9d, 0x28, 0xd0, 0xe7, 0x4d, 0x21, 0xf3, 0x88, 0xd8,
+	0xd6, 0xb1, 0xdd, 0x42, 0xdb, 0xa5, 0x89, 0xed, 0x9c, 0x29, 0x64, 0x1e, 0x81, 0x4f, 0xd0, 0xa6,
+	0xcf, 0x7c, 0xd3, 0x31, 0x72, 0xcb, 0x42, 0x0f, 0x4a, 0xc6, 0x76, 0x14, 0xe8, 0x45, 0x34, 0x29,
+	0x02, 0x33, 0xab, 0x57, 0xb9, 0xa5, 0xd4, 0x65, 0xc1, 0x2a, 0x4f, 0x93, 0x22, 0x10, 0xef, 0xa1,
+	0x2a, 0xfd, 0x4c, 0xad, 0xb7, 0xf6, 0x80, 0xaa, 0xca, 0xae, 0xbc, 0x27, 0x1b, 0xf5, 0xb8, 0xf3,
+	0x63, 0x8c, 0x64, 0xa3, 0xe6, 0xa5, 0x82, 0x14, 0xb8, 0x58, 0xfc, 0x02, 0xad, 0x81, 0xd5, 0x51,
+	0x6f, 0xe4, 0xf6, 0x39, 0xa1, 0xe7, 0x69, 0xbb, 0x71, 0x14, 0xe8, 0x02, 0x43, 0x84, 0x1a, 0xbf,
+	0x41, 0x5b, 0x53, 0xc8, 0x31, 0xfb, 0xe4, 0x3a, 0xcc, 0xec, 0xd0, 0x71, 0x6b, 0xff, 0x8f, 0x02,
+	0xbd, 0x78, 0x02, 0x29, 0x86, 0xf1, 0x4b, 0x84, 0xad, 0x1c, 0x06, 0x47, 0x29, 0xc1, 0x51, 0xfe,
+	0x8b, 0x02, 0xbd, 0x80, 0x25, 0x05, 0x58, 0x7c, 0xa8, 0x1e, 0x35, 0x3b, 0xe0, 0x0f, 0xed, 0x56,
+	0x97, 0x27, 0x87, 0xca, 0x33, 0x44, 0xa8, 0x73, 0x5a, 0xe8, 0xaf, 0xaa, 0x14, 0x68, 0x81, 0x21,
+	0x42, 0x8d, 0x8f, 0xd0, 0x46, 0x87, 0x5a, 0x6c, 0x30, 0xf4, 0xe0, 0x42, 0x92, 0xa5, 0xcb, 0x20,
+	0xdf, 0x8a, 0x02, 0x7d, 0x96, 0x24, 0xb3, 0x90, 0x68, 0x92, 0xec, 0xa1, 0x52, 0x6c, 0x92, 0x6c,
+	0x63, 0x16, 0xc2, 0x87, 0xa8, 0x21, 0xee, 0xa3, 0x0a, 0x16, 0x9b, 0x51, 0xa0, 0x8b, 0x14, 0x11,
+	0x81, 0x58, 0x0e, 0x37, 0x74, 0x3c, 0x1a, 0x3a, 0xb6, 0x65, 0xc6, 0xf2, 0x95, 0x89, 0x5c, 0xa0,
+	0x88, 0x08, 0xe0, 0x03, 0x54, 0x03, 0xc8, 0x70, 0x98, 0xd5, 0xe7, 0x2a, 0x02, 0x69, 0x23, 0x0a,
+	0xf4, 0x69, 0x98, 0x4c, 0x17, 0xf8, 0x19, 0x5a, 0xe5, 0x7d, 0x7b, 0x38, 0xa4, 0x9d, 0x54, 0x54,
+	0x03, 0xd1, 0x46, 0x14, 0xe8, 0x79, 0x82, 0xe4, 0xcb, 0xe6, 0x4f, 0x05, 0x55, 0xc7, 0x5f, 0x1b,
+	0xfc, 0x14, 0xd5, 0xc1, 0x94, 0x50, 0xd3, 0xea, 0xd1, 0xe4, 0xd3, 0xa1, 0x18, 0xeb, 0x51, 0xa0,
+	0xe7, 0x70, 0x92, 0xab, 0xe2, 0xd8, 0x4d, 0xe5, 0xf1, 0xb5, 0xe9, 0x5b, 0xbd, 0x2c, 0xc4, 0x10,
+	0xbb, 0x59, 0x96, 0x14, 0x60, 0xd9, 0xea, 0x06, 0xd4, 0x3c, 0xfd, 0x1c, 0x4c, 0x56, 0x4f, 0x71,
+	0x92, 0xab, 0xb2, 0x5f, 0x20, 0x5c, 0xdc, 0x19, 0x75, 0xfd, 0xe9, 0xb0, 0xe6, 0x19, 0x22, 0xd4,
+	0x05, 0x41, 0x57, 0x16, 0x08, 0x7a, 0x79, 0xb1, 0xa0, 0x57, 0xfe, 0x45, 0xd0, 0xab, 0x8b, 0x07,
+	0x7d, 0x65, 0xb1, 0xa0, 0xa3, 0xfb, 0x07, 0xbd, 0x76, 0x9f, 0xa0, 0xd7, 0xff, 0x2e, 0xe8, 0xc6,
+	0x87, 0xab, 0x1b, 0x4d, 0xba, 0xbe, 0xd1, 0xa4, 0xbb, 0x1b, 0x4d, 0xfe, 0x12, 0x6a, 0xf2, 0xb7,
+	0x50, 0x93, 0x2f, 0x43, 0x4d, 0xbe, 0x0a, 0x35, 0xf9, 0x47, 0xa8, 0xc9, 0xbf, 0x42, 0x4d, 0xba,
+	0x0b, 0x35, 0xf9, 0xeb, 0xad, 0x26, 0x5d, 0xdd, 0x6a, 0xd2, 0xf5, 0xad, 0x26, 0xbd, 0x7f, 0x38,
+	0xfd, 0x94, 0xf0, 0xcc, 0x73, 0xd3, 0x35, 0x5b, 0x0e, 0xeb, 0xdb, 0x2d, 0xe1, 0x19, 0xd2, 0x2e,
+	0xc3, 0x5b, 0xe2, 0xc9, 0x9f, 0x01, 0x00, 0x80, 0xb4, 0x68, 0x14, 0xa0, 0x08, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -575,6 +613,12 @@ func (this *Store) Equal(that interface{}) bool {
 	if this.TotalDuplicates != that1.TotalDuplicates {
 		return false
 	}
+	if this.TotalBlocks != that1.TotalBlocks {
+		return false
+	}
+	if this.SkippedBlocks != that1.SkippedBlocks {
+		return false
+	}
 	return true
 }
 func (this *Ingester) Equal(that interface{}) bool {
@@ -626,6 +670,12 @@ func (this *Ingester) Equal(that interface{}) bool {
 	if this.TotalDuplicates != that1.TotalDuplicates {
 		return false
 	}
+	if this.TotalBlocks != that1.TotalBlocks {
+		return false
+	}
+	if this.SkippedBlocks != that1.SkippedBlocks {
+		return false
+	}
 	return true
 }
 func (this *Result) GoString() string {
@@ -658,7 +708,7 @@ func (this *Store) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 13)
+	s := make([]string, 0, 15)
 	s = append(s, "&stats.Store{")
 	s = append(s, "TotalChunksRef: "+fmt.Sprintf("%#v", this.TotalChunksRef)+",\n")
 	s = append(s, "TotalChunksDownloaded: "+fmt.Sprintf("%#v", this.TotalChunksDownloaded)+",\n")
@@ -669,6 +719,8 @@ func (this *Store) GoString() string {
 	s = append(s, "DecompressedLines: "+fmt.Sprintf("%#v", this.DecompressedLines)+",\n")
 	s = append(s, "CompressedBytes: "+fmt.Sprintf("%#v", this.CompressedBytes)+",\n")
 	s = append(s, "TotalDuplicates: "+fmt.Sprintf("%#v", this.TotalDuplicates)+",\n")
+	s = append(s, "TotalBlocks: "+fmt.Sprintf("%#v", this.TotalBlocks)+",\n")
+	s = append(s, "SkippedBlocks: "+fmt.Sprintf("%#v", this.SkippedBlocks)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -676,7 +728,7 @@ func (this *Ingester) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 14)
+	s := make([]string, 0, 16)
 	s = append(s, "&stats.Ingester{")
 	s = append(s, "TotalReached: "+fmt.Sprintf("%#v", this.TotalReached)+",\n")
 	s = append(s, "TotalChunksMatched: "+fmt.Sprintf("%#v", this.TotalChunksMatched)+",\n")
@@ -688,6 +740,8 @@ func (this *Ingester) GoString() string {
 	s = append(s, "DecompressedLines: "+fmt.Sprintf("%#v", this.DecompressedLines)+",\n")
 	s = append(s, "CompressedBytes: "+fmt.Sprintf("%#v", this.CompressedBytes)+",\n")
 	s = append(s, "TotalDuplicates: "+fmt.Sprintf("%#v", this.TotalDuplicates)+",\n")
+	s = append(s, "TotalBlocks: "+fmt.Sprintf("%#v", this.TotalBlocks)+",\n")
+	s = append(s, "SkippedBlocks: "+fmt.Sprintf("%#v", this.SkippedBlocks)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -846,6 +900,16 @@ func (m *Store) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintStats(dAtA, i, uint64(m.TotalDuplicates))
 	}
+	if m.TotalBlocks != 0 {
+		dAtA[i] = 0x50
+		i++
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalBlocks))
+	}
+	if m.SkippedBlocks != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintStats(dAtA, i, uint64(m.SkippedBlocks))
+	}
 	return i, nil
 }
 
@@ -914,6 +978,16 @@ func (m *Ingester) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintStats(dAtA, i, uint64(m.TotalDuplicates))
 	}
+	if m.TotalBlocks != 0 {
+		dAtA[i] = 0x58
+		i++
+		i = encodeVarintStats(dAtA, i, uint64(m.TotalBlocks))
+	}
+	if m.SkippedBlocks != 0 {
+		dAtA[i] = 0x60
+		i++
+		i = encodeVarintStats(dAtA, i, uint64(m.SkippedBlocks))
+	}
 	return i, nil
 }
 
@@ -998,6 +1072,12 @@ func (m *Store) Size() (n int) {
 	if m.TotalDuplicates != 0 {
 		n += 1 + sovStats(uint64(m.TotalDuplicates))
 	}
+	if m.TotalBlocks != 0 {
+		n += 1 + sovStats(uint64(m.TotalBlocks))
+	}
+	if m.SkippedBlocks != 0 {
+		n += 1 + sovStats(uint64(m.SkippedBlocks))
+	}
 	return n
 }
 
@@ -1037,6 +1117,12 @@ func (m *Ingester) Size() (n int) {
 	if m.TotalDuplicates != 0 {
 		n += 1 + sovStats(uint64(m.TotalDuplicates))
 	}
+	if m.TotalBlocks != 0 {
+		n += 1 + sovStats(uint64(m.TotalBlocks))
+	}
+	if m.SkippedBlocks != 0 {
+		n += 1 + sovStats(uint64(m.SkippedBlocks))
+	}
 	return n
 }
 
@@ -1093,6 +1179,8 @@ func (this *Store) String() string {
 		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
 		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
 		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
+		`TotalBlocks:` + fmt.Sprintf("%v", this.TotalBlocks) + `,`,
+		`SkippedBlocks:` + fmt.Sprintf("%v", this.SkippedBlocks) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1112,6 +1200,8 @@ func (this *Ingester) String() string {
 		`DecompressedLines:` + fmt.Sprintf("%v", this.DecompressedLines) + `,`,
 		`CompressedBytes:` + fmt.Sprintf("%v", this.CompressedBytes) + `,`,
 		`TotalDuplicates:` + fmt.Sprintf("%v", this.TotalDuplicates) + `,`,
+		`TotalBlocks:` + fmt.Sprintf("%v", this.TotalBlocks) + `,`,
+		`SkippedBlocks:` + fmt.Sprintf("%v", this.SkippedBlocks) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -1608,6 +1698,44 @@ func (m *Store) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalBlocks", wireType)
+			}
+			m.TotalBlocks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalBlocks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SkippedBlocks", wireType)
+			}
+			m.SkippedBlocks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SkippedBlocks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -1851,6 +1979,44 @@ func (m *Ingester) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TotalBlocks", wireType)
+			}
+			m.TotalBlocks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TotalBlocks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 12:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SkippedBlocks", wireType)
+			}
+			m.SkippedBlocks = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.SkippedBlocks |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -0,0 +1,72 @@
+package logql
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/util/spanlogger"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/loki/pkg/helpers"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+// EntryWriter receives the entries of a streamed log query.
+type EntryWriter interface {
+	WriteEntry(labels string, entry logproto.Entry) error
+}
+
+// Stream executes a log query and writes its entries to w as they are produced by the iterator,
+// instead of buffering the whole result. Metric queries can't be streamed.
+func (ng *Engine) Stream(ctx context.Context, params Params, w EntryWriter) (stats.Result, error) {
+	log, ctx := spanlogger.New(ctx, "query.Stream")
+	defer log.Finish()
+
+	timer := prometheus.NewTimer(queryTime.WithLabelValues(string(GetRangeType(params))))
+	defer timer.ObserveDuration()
+
+	start := time.Now()
+	ctx = stats.NewContext(ctx)
+
+	err := ng.stream(ctx, params, w)
+
+	statResult := stats.Snapshot(ctx, time.Since(start))
+	statResult.Log(level.Debug(log))
+
+	status := "200"
+	if err != nil {
+		status = "500"
+		if IsParseError(err) || IsLimitError(err) {
+			status = "400"
+		}
+	}
+	RecordMetrics(ctx, params, status, statResult)
+
+	return statResult, err
+}
+
+func (ng *Engine) stream(ctx context.Context, params Params, w EntryWriter) error {
+	ctx, cancel := context.WithTimeout(ctx, ng.timeout)
+	defer cancel()
+
+	expr, err := ParseExpr(params.Query())
+	if err != nil {
+		return err
+	}
+	sel, ok := expr.(LogSelectorExpr)
+	if !ok {
+		return fmt.Errorf("unexpected type (%T): only log queries can be streamed", expr)
+	}
+	ctx = InjectPolicyQuery(ctx, expr, params.Start(), params.End())
+
+	iter, err := ng.evaluator.Iterator(ctx, sel, params)
+	if err != nil {
+		return err
+	}
+	defer helpers.LogErrorWithContext(ctx, "closing iterator", iter.Close)
+
+	return forEachEntry(iter, params.Limit(), params.Direction(), params.Interval(), w.WriteEntry)
+}
@@ -0,0 +1,94 @@
+package logql
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+type entryRecorder struct {
+	streams map[string]*logproto.Stream
+	entries int
+	err     error
+}
+
+func (r *entryRecorder) WriteEntry(labels string, entry logproto.Entry) error {
+	if r.err != nil {
+		return r.err
+	}
+	if r.streams == nil {
+		r.streams = map[string]*logproto.Stream{}
+	}
+	stream, ok := r.streams[labels]
+	if !ok {
+		stream = &logproto.Stream{Labels: labels}
+		r.streams[labels] = stream
+	}
+	stream.Entries = append(stream.Entries, entry)
+	r.entries++
+	return nil
+}
+
+func (r *entryRecorder) result() Streams {
+	result := make(Streams, 0, len(r.streams))
+	for _, stream := range r.streams {
+		result = append(result, *stream)
+	}
+	sort.Sort(result)
+	return result
+}
+
+func TestEngine_Stream(t *testing.T) {
+	streams := randomStreams(10, 20, 1, []string{"a"})
+	eng := NewEngine(EngineOpts{}, NewMockQuerier(1, streams), NoLimits)
+	ctx := user.InjectOrgID(context.Background(), "fake")
+
+	for _, tc := range []struct {
+		query    string
+		limit    uint32
+		interval time.Duration
+	}{
+		{`{a=~".+"}`, 1000, 0},
+		{`{a=~".+"}`, 42, 0},
+		{`{a=~".+"} |= "number: 1"`, 1000, 0},
+		{`{a=~".+"}`, 1000, 5 * time.Second},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			params := NewLiteralParams(tc.query, time.Unix(0, 0), time.Unix(20, 0), 0, tc.interval, logproto.FORWARD, tc.limit, nil)
+
+			expected, err := eng.Query(params).Exec(ctx)
+			require.NoError(t, err)
+
+			rec := &entryRecorder{}
+			_, err = eng.Stream(ctx, params, rec)
+			require.NoError(t, err)
+			require.Equal(t, expected.Data, rec.result())
+			require.LessOrEqual(t, rec.entries, int(tc.limit))
+		})
+	}
+}
+
+func TestEngine_StreamErrors(t *testing.T) {
+	eng := NewEngine(EngineOpts{}, NewMockQuerier(1, randomStreams(1, 10, 1, []string{"a"})), NoLimits)
+	ctx := user.InjectOrgID(context.Background(), "fake")
+	params := func(query string) Params {
+		return NewLiteralParams(query, time.Unix(0, 0), time.Unix(10, 0), 0, 0, logproto.FORWARD, 100, nil)
+	}
+
+	_, err := eng.Stream(ctx, params(`rate({a=~".+"}[1s])`), &entryRecorder{})
+	require.EqualError(t, err, "unexpected type (*logql.rangeAggregationExpr): only log queries can be streamed")
+
+	_, err = eng.Stream(ctx, params(`{a=~".+"`), &entryRecorder{})
+	require.True(t, IsParseError(err))
+
+	writeErr := errors.New("connection closed")
+	_, err = eng.Stream(ctx, params(`{a=~".+"}`), &entryRecorder{err: writeErr})
+	require.Equal(t, writeErr, err)
+}
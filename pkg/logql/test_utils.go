@@ -33,7 +33,7 @@ func (q MockQuerier) SelectLogs(ctx context.Context, req SelectLogParams) (iter.
 	if err != nil {
 		return nil, err
 	}
-	filter, err := expr.Filter()
+	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -68,28 +68,8 @@ outer:
 		matched = append(matched, stream)
 	}
 
-	// apply the LineFilter
-	filtered := make([]logproto.Stream, 0, len(matched))
-	if filter == nil || filter == TrueFilter {
-		filtered = matched
-	} else {
-		for _, s := range matched {
-			var entries []logproto.Entry
-			for _, entry := range s.Entries {
-				if filter.Filter([]byte(entry.Line)) {
-					entries = append(entries, entry)
-				}
-			}
-
-			if len(entries) > 0 {
-				filtered = append(filtered, logproto.Stream{
-					Labels:  s.Labels,
-					Entries: entries,
-				})
-			}
-		}
-
-	}
+	// apply the pipeline
+	filtered := processStream(matched, pipeline)
 
 	streamIters := make([]iter.EntryIterator, 0, len(filtered))
 	for i := range filtered {
@@ -115,10 +95,6 @@ func (q MockQuerier) SelectSamples(ctx context.Context, req SelectSampleParams)
 	if err != nil {
 		return nil, err
 	}
-	filter, err := selector.Filter()
-	if err != nil {
-		return nil, err
-	}
 	expr, err := req.Expr()
 	if err != nil {
 		return nil, err
@@ -159,37 +135,71 @@ outer:
 		matched = append(matched, stream)
 	}
 
-	// apply the LineFilter
-	filtered := make([]logproto.Series, 0, len(matched))
-	for _, s := range matched {
-		var samples []logproto.Sample
-		for _, entry := range s.Entries {
-			if filter == nil || filter.Filter([]byte(entry.Line)) {
-				v, ok := extractor.Extract([]byte(entry.Line))
-				if !ok {
-					continue
+	// apply the extractor
+	filtered := processSeries(matched, extractor)
+
+	return iter.NewTimeRangedSampleIterator(
+		iter.NewMultiSeriesIterator(ctx, filtered),
+		req.Start.UnixNano(),
+		req.End.UnixNano(),
+	), nil
+}
+
+func processStream(in []logproto.Stream, pipeline Pipeline) []logproto.Stream {
+	resByStream := map[string]*logproto.Stream{}
+
+	for _, stream := range in {
+		sp := pipeline.ForStream(mustParseLabels(stream.Labels))
+		for _, e := range stream.Entries {
+			if l, lbs, ok := sp.Process([]byte(e.Line), logproto.StructuredMetadataLabels(e.StructuredMetadata)...); ok {
+				var s *logproto.Stream
+				var found bool
+				s, found = resByStream[lbs.String()]
+				if !found {
+					s = &logproto.Stream{Labels: lbs.String()}
+					resByStream[s.Labels] = s
 				}
-				samples = append(samples, logproto.Sample{
-					Timestamp: entry.Timestamp.UnixNano(),
-					Value:     v,
-					Hash:      xxhash.Sum64([]byte(entry.Line)),
+				s.Entries = append(s.Entries, logproto.Entry{
+					Timestamp: e.Timestamp,
+					Line:      string(l),
 				})
 			}
 		}
+	}
+	streams := []logproto.Stream{}
+	for _, stream := range resByStream {
+		streams = append(streams, *stream)
+	}
+	return streams
+}
 
-		if len(samples) > 0 {
-			filtered = append(filtered, logproto.Series{
-				Labels:  s.Labels,
-				Samples: samples,
-			})
+func processSeries(in []logproto.Stream, ex SampleExtractor) []logproto.Series {
+	resBySeries := map[string]*logproto.Series{}
+
+	for _, stream := range in {
+		exs := ex.ForStream(mustParseLabels(stream.Labels))
+		for _, e := range stream.Entries {
+			if f, lbs, ok := exs.Process([]byte(e.Line), logproto.StructuredMetadataLabels(e.StructuredMetadata)...); ok {
+				var s *logproto.Series
+				var found bool
+				s, found = resBySeries[lbs.String()]
+				if !found {
+					s = &logproto.Series{Labels: lbs.String()}
+					resBySeries[lbs.String()] = s
+				}
+				s.Samples = append(s.Samples, logproto.Sample{
+					Timestamp: e.Timestamp.UnixNano(),
+					Value:     f,
+					Hash:      xxhash.Sum64([]byte(e.Line)),
+				})
+			}
 		}
 	}
-
-	return iter.NewTimeRangedSampleIterator(
-		iter.NewMultiSeriesIterator(ctx, filtered),
-		req.Start.UnixNano(),
-		req.End.UnixNano(),
-	), nil
+	series := []logproto.Series{}
+	for _, s := range resBySeries {
+		series = append(series, *s)
+	}
+	return series
 }
 
 type MockDownstreamer struct {
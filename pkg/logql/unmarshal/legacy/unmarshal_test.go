@@ -43,6 +43,34 @@ var pushTests = []struct {
 			]
 		}`,
 	},
+	{
+		[]logproto.Stream{
+			{
+				Entries: []logproto.Entry{
+					{
+						Timestamp:          mustParse(time.RFC3339Nano, "2019-09-13T18:32:22.380001319Z"),
+						Line:               "super line",
+						StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "2c9a6a4c5d7e1f30"}},
+					},
+				},
+				Labels: `{test="test"}`,
+			},
+		},
+		`{
+			"streams":[
+				{
+					"labels":"{test=\"test\"}",
+					"entries":[
+						{
+							"ts": "2019-09-13T18:32:22.380001319Z",
+							"line": "super line",
+							"structuredMetadata": [{"name": "traceID", "value": "2c9a6a4c5d7e1f30"}]
+						}
+					]
+				}
+			]
+		}`,
+	},
 }
 
 func Test_DecodePushRequest(t *testing.T) {
@@ -41,6 +41,40 @@ var pushTests = []struct {
 			]
 		}`,
 	},
+	{
+		[]logproto.Stream{
+			{
+				Entries: []logproto.Entry{
+					{
+						Timestamp: time.Unix(0, 123456789012345),
+						Line:      "super line",
+					},
+					{
+						Timestamp: time.Unix(0, 123456789012346),
+						Line:      "super line with metadata",
+						StructuredMetadata: []logproto.LabelPair{
+							{Name: "traceID", Value: "2c9a6a4c5d7e1f30"},
+							{Name: "user", Value: "42"},
+						},
+					},
+				},
+				Labels: `{test="test"}`,
+			},
+		},
+		`{
+			"streams": [
+				{
+					"stream": {
+						"test": "test"
+					},
+					"values":[
+						[ "123456789012345", "super line" ],
+						[ "123456789012346", "super line with metadata", { "user": "42", "traceID": "2c9a6a4c5d7e1f30" } ]
+					]
+				}
+			]
+		}`,
+	},
 }
 
 func Test_DecodePushRequest(t *testing.T) {
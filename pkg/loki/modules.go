@@ -170,6 +170,10 @@ func (t *Loki) initQuerier() (services.Service, error) {
 	t.server.HTTP.Handle("/loki/api/v1/label/{name}/values", httpMiddleware.Wrap(http.HandlerFunc(t.querier.LabelHandler)))
 	t.server.HTTP.Handle("/loki/api/v1/tail", httpMiddleware.Wrap(http.HandlerFunc(t.querier.TailHandler)))
 	t.server.HTTP.Handle("/loki/api/v1/series", httpMiddleware.Wrap(http.HandlerFunc(t.querier.SeriesHandler)))
+	t.server.HTTP.Handle("/loki/api/v1/explain", httpMiddleware.Wrap(http.HandlerFunc(t.querier.ExplainHandler)))
+	t.server.HTTP.Handle("/loki/api/v1/index/stats", httpMiddleware.Wrap(http.HandlerFunc(t.querier.IndexStatsHandler)))
+	t.server.HTTP.Handle("/loki/api/v1/index/volume", httpMiddleware.Wrap(http.HandlerFunc(t.querier.VolumeHandler)))
+	t.server.HTTP.Handle("/loki/api/v1/context", httpMiddleware.Wrap(http.HandlerFunc(t.querier.LogContextHandler)))
 
 	t.server.HTTP.Handle("/api/prom/query", httpMiddleware.Wrap(http.HandlerFunc(t.querier.LogQueryHandler)))
 	t.server.HTTP.Handle("/api/prom/label", httpMiddleware.Wrap(http.HandlerFunc(t.querier.LabelHandler)))
@@ -291,10 +295,14 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		"config", fmt.Sprintf("%+v", t.cfg.QueryRange),
 		"limits", fmt.Sprintf("%+v", t.cfg.LimitsConfig),
 	)
+	var limits queryrange.Limits = t.overrides
+	if t.cfg.Querier.MultiTenantQueriesEnabled {
+		limits = queryrange.WithMultiTenantLimits(limits)
+	}
 	tripperware, stopper, err := queryrange.NewTripperware(
 		t.cfg.QueryRange,
 		util.Logger,
-		t.overrides,
+		limits,
 		t.cfg.SchemaConfig.SchemaConfig,
 		t.cfg.Querier.QueryIngestersWithin,
 		prometheus.DefaultRegisterer,
@@ -334,6 +342,10 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 	t.server.HTTP.Handle("/loki/api/v1/labels", frontendHandler)
 	t.server.HTTP.Handle("/loki/api/v1/label/{name}/values", frontendHandler)
 	t.server.HTTP.Handle("/loki/api/v1/series", frontendHandler)
+	t.server.HTTP.Handle("/loki/api/v1/explain", frontendHandler)
+	t.server.HTTP.Handle("/loki/api/v1/index/stats", frontendHandler)
+	t.server.HTTP.Handle("/loki/api/v1/index/volume", frontendHandler)
+	t.server.HTTP.Handle("/loki/api/v1/context", frontendHandler)
 	t.server.HTTP.Handle("/api/prom/query", frontendHandler)
 	t.server.HTTP.Handle("/api/prom/label", frontendHandler)
 	t.server.HTTP.Handle("/api/prom/label/{name}/values", frontendHandler)
@@ -36,7 +36,7 @@ func newBatch(entries ...entry) *batch {
 
 // add an entry to the batch
 func (b *batch) add(entry entry) {
-	b.bytes += len(entry.Line)
+	b.bytes += entrySize(entry)
 
 	// Append the entry to an already existing stream (if any)
 	labels := entry.labels.String()
@@ -60,7 +60,16 @@ func (b *batch) sizeBytes() int {
 // sizeBytesAfter returns the size of the batch after the input entry
 // will be added to the batch itself
 func (b *batch) sizeBytesAfter(entry entry) int {
-	return b.bytes + len(entry.Line)
+	return b.bytes + entrySize(entry)
+}
+
+// entrySize returns the size of the line and of the structured metadata of an entry
+func entrySize(entry entry) int {
+	size := len(entry.Line)
+	for _, m := range entry.StructuredMetadata {
+		size += len(m.Name) + len(m.Value)
+	}
+	return size
 }
 
 // age of the batch since its creation
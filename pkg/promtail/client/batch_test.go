@@ -44,6 +44,12 @@ func TestBatch_add(t *testing.T) {
 			},
 			expectedSizeBytes: len(logEntries[0].Entry.Line) + len(logEntries[1].Entry.Line) + len(logEntries[2].Entry.Line),
 		},
+		"single stream with structured metadata": {
+			inputEntries: []entry{
+				{"tenant", model.LabelSet{}, logproto.Entry{Timestamp: time.Unix(1, 0), Line: "line1", StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}}}},
+			},
+			expectedSizeBytes: len("line1") + len("traceID") + len("abc"),
+		},
 	}
 
 	for testName, testData := range tests {
@@ -8,7 +8,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -34,6 +36,11 @@ const (
 	// Label reserved to override the tenant ID while processing
 	// pipeline stages
 	ReservedLabelTenantID = "__tenant_id__"
+
+	// Prefix of the labels turned into structured metadata of the entry
+	// while processing pipeline stages, the metadata being named after the
+	// rest of the label name.
+	ReservedLabelStructuredMetadataPrefix = "__structured_metadata_"
 )
 
 var (
@@ -316,17 +323,38 @@ func (c *client) Handle(ls model.LabelSet, t time.Time, s string) error {
 	}
 
 	// Get the tenant  ID in case it has been overridden while processing
-	// the pipeline stages, then remove the special label
+	// the pipeline stages, and the structured metadata, then remove the
+	// special labels
 	tenantID := c.getTenantID(ls)
-	if _, ok := ls[ReservedLabelTenantID]; ok {
+	metadata := getStructuredMetadata(ls)
+	if _, ok := ls[ReservedLabelTenantID]; ok || len(metadata) > 0 {
 		// Clone the label set to not manipulate the input one
 		ls = ls.Clone()
 		delete(ls, ReservedLabelTenantID)
+		for _, m := range metadata {
+			delete(ls, model.LabelName(ReservedLabelStructuredMetadataPrefix+m.Name))
+		}
 	}
 
 	c.entries <- entry{tenantID, ls, logproto.Entry{
-		Timestamp: t,
-		Line:      s,
+		Timestamp:          t,
+		Line:               s,
+		StructuredMetadata: metadata,
 	}}
 	return nil
 }
+
+// getStructuredMetadata returns the structured metadata set with the reserved labels, sorted by name.
+func getStructuredMetadata(ls model.LabelSet) []logproto.LabelPair {
+	var metadata []logproto.LabelPair
+	for name, value := range ls {
+		if strings.HasPrefix(string(name), ReservedLabelStructuredMetadataPrefix) {
+			metadata = append(metadata, logproto.LabelPair{
+				Name:  strings.TrimPrefix(string(name), ReservedLabelStructuredMetadataPrefix),
+				Value: string(value),
+			})
+		}
+	}
+	sort.Slice(metadata, func(i, j int) bool { return metadata[i].Name < metadata[j].Name })
+	return metadata
+}
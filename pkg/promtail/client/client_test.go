@@ -31,6 +31,8 @@ var (
 		{labels: model.LabelSet{"__tenant_id__": "tenant-1"}, Entry: logproto.Entry{Timestamp: time.Unix(4, 0).UTC(), Line: "line4"}},
 		{labels: model.LabelSet{"__tenant_id__": "tenant-1"}, Entry: logproto.Entry{Timestamp: time.Unix(5, 0).UTC(), Line: "line5"}},
 		{labels: model.LabelSet{"__tenant_id__": "tenant-2"}, Entry: logproto.Entry{Timestamp: time.Unix(6, 0).UTC(), Line: "line6"}},
+		{labels: model.LabelSet{"__structured_metadata_traceID": "abc"}, Entry: logproto.Entry{Timestamp: time.Unix(7, 0).UTC(), Line: "line7"}},
+		{labels: model.LabelSet{"__structured_metadata_traceID": "def"}, Entry: logproto.Entry{Timestamp: time.Unix(8, 0).UTC(), Line: "line8"}},
 	}
 )
 
@@ -233,6 +235,35 @@ func TestClient_Handle(t *testing.T) {
 				promtail_dropped_entries_total{host="__HOST__"} 0
 			`,
 		},
+		"send the structured metadata set while processing the pipeline stages, counting them in the batch size": {
+			clientBatchSize:      20,
+			clientBatchWait:      100 * time.Millisecond,
+			clientMaxRetries:     3,
+			serverResponseStatus: 200,
+			inputEntries:         []entry{logEntries[6], logEntries[7]},
+			expectedReqs: []receivedReq{
+				{
+					tenantID: "",
+					pushReq: logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{
+						{Timestamp: logEntries[6].Timestamp, Line: logEntries[6].Line, StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "abc"}}},
+					}}}},
+				},
+				{
+					tenantID: "",
+					pushReq: logproto.PushRequest{Streams: []logproto.Stream{{Labels: "{}", Entries: []logproto.Entry{
+						{Timestamp: logEntries[7].Timestamp, Line: logEntries[7].Line, StructuredMetadata: []logproto.LabelPair{{Name: "traceID", Value: "def"}}},
+					}}}},
+				},
+			},
+			expectedMetrics: `
+				# HELP promtail_sent_entries_total Number of log entries sent to the ingester.
+				# TYPE promtail_sent_entries_total counter
+				promtail_sent_entries_total{host="__HOST__"} 2.0
+				# HELP promtail_dropped_entries_total Number of log entries dropped because failed to be sent to the ingester after all retries.
+				# TYPE promtail_dropped_entries_total counter
+				promtail_dropped_entries_total{host="__HOST__"} 0
+			`,
+		},
 	}
 
 	for testName, testData := range tests {
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/pkg/distributor"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/promtail/api"
+	"github.com/grafana/loki/pkg/promtail/client"
 	"github.com/grafana/loki/pkg/promtail/scrapeconfig"
 	"github.com/grafana/loki/pkg/promtail/targets/target"
 )
@@ -144,11 +145,17 @@ func (t *PushTarget) handle(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for _, entry := range stream.Entries {
+			ls := filtered.Clone()
+			// Keep the structured metadata of the entry, they are restored by the client.
+			for _, m := range entry.StructuredMetadata {
+				ls[model.LabelName(client.ReservedLabelStructuredMetadataPrefix+m.Name)] = model.LabelValue(m.Value)
+			}
+
 			var err error
 			if t.config.KeepTimestamp {
-				err = t.handler.Handle(filtered.Clone(), entry.Timestamp, entry.Line)
+				err = t.handler.Handle(ls, entry.Timestamp, entry.Line)
 			} else {
-				err = t.handler.Handle(filtered.Clone(), time.Now(), entry.Line)
+				err = t.handler.Handle(ls, time.Now(), entry.Line)
 			}
 
 			if err != nil {
@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/marshal"
 	marshal_legacy "github.com/grafana/loki/pkg/logql/marshal/legacy"
+	lokiutil "github.com/grafana/loki/pkg/util"
 	serverutil "github.com/grafana/loki/pkg/util/server"
 )
 
@@ -47,6 +48,11 @@ func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := q.validateQueryPolicies(ctx, request.Query, request.Start, request.End); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
 	params := logql.NewLiteralParams(
 		request.Query,
 		request.Start,
@@ -57,6 +63,12 @@ func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
 		request.Limit,
 		request.Shards,
 	)
+	if loghttp.AcceptsNDJSON(r) {
+		if _, err := logql.ParseLogSelector(request.Query); err == nil {
+			q.streamQuery(ctx, params, w)
+			return
+		}
+	}
 	query := q.engine.Query(params)
 	result, err := query.Exec(ctx)
 	if err != nil {
@@ -70,6 +82,27 @@ func (q *Querier) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// streamQuery writes the entries of a log query as newline delimited json while they are read.
+func (q *Querier) streamQuery(ctx context.Context, params logql.Params, w http.ResponseWriter) {
+	w.Header().Set("Content-Type", loghttp.NDJSONContentType)
+	nd := marshal.NewNDJSONWriter(w, marshal.DefaultFlushPeriod)
+	statResult, err := q.engine.Stream(ctx, params, nd)
+	if err != nil {
+		// the status code can't be changed once entries are written.
+		if nd.Entries() == 0 {
+			serverutil.WriteError(err, w)
+			return
+		}
+		if err := nd.WriteError(err); err != nil {
+			level.Error(util.WithContext(ctx, util.Logger)).Log("msg", "error writing streamed query error", "err", err)
+		}
+		return
+	}
+	if err := nd.WriteStats(statResult); err != nil {
+		level.Error(util.WithContext(ctx, util.Logger)).Log("msg", "error writing streamed query stats", "err", err)
+	}
+}
+
 // InstantQueryHandler is a http.HandlerFunc for instant queries.
 func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
 	// Enforce the query timeout while querying backends
@@ -87,6 +120,11 @@ func (q *Querier) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := q.validateQueryPolicies(ctx, request.Query, request.Ts, request.Ts); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
 	params := logql.NewLiteralParams(
 		request.Query,
 		request.Ts,
@@ -144,6 +182,11 @@ func (q *Querier) LogQueryHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := q.validateQueryPolicies(ctx, request.Query, request.Start, request.End); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
 	params := logql.NewLiteralParams(
 		request.Query,
 		request.Start,
@@ -326,6 +369,99 @@ func (q *Querier) SeriesHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// LogContextHandler returns the lines of the streams selected by a stream selector surrounding an anchor entry.
+func (q *Querier) LogContextHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseContextQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	streams, err := q.LogContext(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteContextResponseJSON(req.Anchor, streams, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
+// IndexStatsHandler returns the amount of streams, chunks, bytes and entries selected by a stream selector.
+func (q *Querier) IndexStatsHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseIndexStatsQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	stats, err := q.IndexStats(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteIndexStatsResponseJSON(*stats, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
+// VolumeHandler returns the index stats of the streams selected by a stream selector grouped by the values of a label.
+func (q *Querier) VolumeHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := loghttp.ParseVolumeQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	volumes, err := q.Volume(r.Context(), req)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	if err := marshal.WriteVolumeResponseJSON(loghttp.VolumeData{Label: req.Label, Volumes: volumes}, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
+// ExplainHandler is a http.HandlerFunc for explain queries.
+// Queriers neither split nor shard queries, the explanation contains the query as a single split.
+func (q *Querier) ExplainHandler(w http.ResponseWriter, r *http.Request) {
+	request, err := loghttp.ParseRangeQuery(r)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	expr, err := logql.ParseExpr(request.Query)
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+	filters, err := logql.ExplainLineFilters(expr)
+	if err != nil {
+		serverutil.WriteError(httpgrpc.Errorf(http.StatusBadRequest, err.Error()), w)
+		return
+	}
+
+	explanation := loghttp.Explanation{
+		Query:   expr.String(),
+		Filters: filters,
+		Splits: []loghttp.Split{
+			{Query: expr.String(), Start: request.Start, End: request.End},
+		},
+	}
+	if err := marshal.WriteExplainResponseJSON(explanation, w); err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+}
+
 // parseRegexQuery parses regex and query querystring from httpRequest and returns the combined LogQL query.
 // This is used only to keep regexp query string support until it gets fully deprecated.
 func parseRegexQuery(httpRequest *http.Request) (string, error) {
@@ -341,13 +477,22 @@ func parseRegexQuery(httpRequest *http.Request) (string, error) {
 	return query, nil
 }
 
+// maxEntriesLimit returns the max entries limit per query of the tenants of a request, 0 if there is none.
+func (q *Querier) maxEntriesLimit(userID string) int {
+	if q.cfg.MultiTenantQueriesEnabled {
+		// multi-tenant queries are limited by the most restrictive limit of their tenants.
+		return lokiutil.SmallestPositiveIntPerTenant(userID, q.limits.MaxEntriesLimitPerQuery)
+	}
+	return q.limits.MaxEntriesLimitPerQuery(userID)
+}
+
 func (q *Querier) validateEntriesLimits(ctx context.Context, limit uint32) error {
 	userID, err := user.ExtractOrgID(ctx)
 	if err != nil {
 		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 	}
 
-	maxEntriesLimit := q.limits.MaxEntriesLimitPerQuery(userID)
+	maxEntriesLimit := q.maxEntriesLimit(userID)
 	if int(limit) > maxEntriesLimit && maxEntriesLimit != 0 {
 		return httpgrpc.Errorf(http.StatusBadRequest,
 			"max entries limit per query exceeded, limit > max_entries_limit (%d > %d)", limit, maxEntriesLimit)
@@ -0,0 +1,217 @@
+package querier
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// indexStream holds the stats of a stream selected by an index stats request.
+type indexStream struct {
+	// value is the value of the label the stats are grouped by.
+	value                  string
+	chunks, bytes, entries uint64
+}
+
+// IndexStats returns the amount of streams, chunks, bytes and entries selected by a stream selector.
+// Chunks of the store are resolved from the index without being fetched, their bytes and entries are
+// unknown, only the bytes and entries of chunks held by ingesters are accounted.
+func (q *Querier) IndexStats(ctx context.Context, req *logproto.StreamStatsRequest) (*loghttp.IndexStats, error) {
+	streams, err := q.indexStreams(ctx, req, "")
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &loghttp.IndexStats{Streams: uint64(len(streams))}
+	for _, s := range streams {
+		stats.Chunks += s.chunks
+		stats.Bytes += s.bytes
+		stats.Entries += s.entries
+	}
+	return stats, nil
+}
+
+// Volume returns the index stats of the streams selected by a stream selector grouped by the values of a label,
+// sorted by decreasing bytes then chunks. Streams without the label are left out.
+func (q *Querier) Volume(ctx context.Context, req *loghttp.VolumeQuery) ([]loghttp.Volume, error) {
+	streams, err := q.indexStreams(ctx, &req.StreamStatsRequest, req.Label)
+	if err != nil {
+		return nil, err
+	}
+
+	byValue := map[string]*loghttp.Volume{}
+	for _, s := range streams {
+		v, ok := byValue[s.value]
+		if !ok {
+			v = &loghttp.Volume{Value: s.value}
+			byValue[s.value] = v
+		}
+		v.Streams++
+		v.Chunks += s.chunks
+		v.Bytes += s.bytes
+		v.Entries += s.entries
+	}
+
+	volumes := make([]loghttp.Volume, 0, len(byValue))
+	for _, v := range byValue {
+		volumes = append(volumes, *v)
+	}
+	sort.Slice(volumes, func(i, j int) bool {
+		if volumes[i].Bytes != volumes[j].Bytes {
+			return volumes[i].Bytes > volumes[j].Bytes
+		}
+		if volumes[i].Chunks != volumes[j].Chunks {
+			return volumes[i].Chunks > volumes[j].Chunks
+		}
+		return volumes[i].Value < volumes[j].Value
+	})
+	if req.Limit > 0 && len(volumes) > int(req.Limit) {
+		volumes = volumes[:req.Limit]
+	}
+	return volumes, nil
+}
+
+// indexStreams returns the stats of the streams selected by a request, by fingerprint, merging the chunks held by
+// ingesters and the chunks of the store. When label is set, only streams with the label are returned.
+func (q *Querier) indexStreams(ctx context.Context, req *logproto.StreamStatsRequest, label string) (map[model.Fingerprint]*indexStream, error) {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = q.rejectMultiTenant(ctx); err != nil {
+		return nil, err
+	}
+
+	if err = q.validateQueryTimeRange(userID, req.Start, req.End); err != nil {
+		return nil, err
+	}
+
+	matchers, err := logql.ParseMatchers(req.Matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	// Enforce the query timeout while querying backends
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	// buffer the channels to the # of calls they're expecting
+	var (
+		ingesterStreams = make(chan []logproto.StreamStats, 1)
+		storeStreams    = make(chan map[model.Fingerprint]*indexStream, 1)
+		errs            = make(chan error, 2)
+	)
+
+	// fetch stats from ingesters and store concurrently
+	go func() {
+		resps, err := q.forAllIngesters(ctx, func(client logproto.QuerierClient) (interface{}, error) {
+			return client.GetStreamStats(ctx, req)
+		})
+		if err != nil {
+			errs <- err
+			return
+		}
+		var acc []logproto.StreamStats
+		for _, resp := range resps {
+			acc = append(acc, resp.response.(*logproto.StreamStatsResponse).Streams...)
+		}
+		ingesterStreams <- acc
+	}()
+
+	go func() {
+		streams, err := q.storeStreams(ctx, userID, req, matchers, label)
+		if err != nil {
+			errs <- err
+			return
+		}
+		storeStreams <- streams
+	}()
+
+	var (
+		streams  map[model.Fingerprint]*indexStream
+		inMemory []logproto.StreamStats
+	)
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			return nil, err
+		case s := <-ingesterStreams:
+			inMemory = s
+		case s := <-storeStreams:
+			streams = s
+		}
+	}
+
+	// replicas of a stream hold the same entries, the stats of the replica with the most chunks are kept.
+	replicas := map[model.Fingerprint]logproto.StreamStats{}
+	for _, s := range inMemory {
+		fp := model.Fingerprint(s.Fingerprint)
+		if r, ok := replicas[fp]; !ok || s.Chunks > r.Chunks {
+			replicas[fp] = s
+		}
+	}
+	for fp, s := range replicas {
+		var value string
+		if label != "" {
+			lbs, err := parser.ParseMetric(s.Labels)
+			if err != nil {
+				return nil, err
+			}
+			if value = lbs.Get(label); value == "" {
+				continue
+			}
+		}
+		stream, ok := streams[fp]
+		if !ok {
+			stream = &indexStream{value: value}
+			streams[fp] = stream
+		}
+		stream.chunks += s.Chunks
+		stream.bytes += s.Bytes
+		stream.entries += s.Entries
+	}
+	return streams, nil
+}
+
+// storeStreams returns the amount of chunks of the streams of the store selected by a request.
+// When label is set, streams are looked up for each value of the label.
+func (q *Querier) storeStreams(ctx context.Context, userID string, req *logproto.StreamStatsRequest, matchers []*labels.Matcher, label string) (map[model.Fingerprint]*indexStream, error) {
+	from, through := model.TimeFromUnixNano(req.Start.UnixNano()), model.TimeFromUnixNano(req.End.UnixNano())
+	streams := map[model.Fingerprint]*indexStream{}
+
+	if label == "" {
+		chunks, err := q.store.GetStreamChunks(ctx, from, through, matchers...)
+		if err != nil {
+			return nil, err
+		}
+		for fp, n := range chunks {
+			streams[fp] = &indexStream{chunks: uint64(n)}
+		}
+		return streams, nil
+	}
+
+	values, err := q.store.LabelValuesForMetricName(ctx, userID, from, through, "logs", label)
+	if err != nil {
+		return nil, err
+	}
+	for _, value := range values {
+		chunks, err := q.store.GetStreamChunks(ctx, from, through, append(matchers[:len(matchers):len(matchers)], labels.MustNewMatcher(labels.MatchEqual, label, value))...)
+		if err != nil {
+			return nil, err
+		}
+		for fp, n := range chunks {
+			streams[fp] = &indexStream{value: value, chunks: uint64(n)}
+		}
+	}
+	return streams, nil
+}
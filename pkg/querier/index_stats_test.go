@@ -0,0 +1,84 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+func newIndexStatsQuerier(t *testing.T, store *storeMock, ingesterClient *querierClientMock) *Querier {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	q, err := newQuerier(
+		mockQuerierConfig(),
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+	return q
+}
+
+func TestQuerier_IndexStats(t *testing.T) {
+	req := &logproto.StreamStatsRequest{Start: time.Unix(0, 0), End: time.Unix(10, 0), Matchers: `{app="foo"}`}
+
+	store := newStoreMock()
+	store.On("GetStreamChunks", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(map[model.Fingerprint]int{1: 3, 2: 1}, nil)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("GetStreamStats", mock.Anything, req).Return(&logproto.StreamStatsResponse{
+		Streams: []logproto.StreamStats{
+			{Labels: `{app="foo", env="dev"}`, Fingerprint: 2, Chunks: 1, Bytes: 100, Entries: 10},
+			{Labels: `{app="foo", env="prod"}`, Fingerprint: 3, Chunks: 2, Bytes: 200, Entries: 20},
+		},
+	}, nil)
+
+	q := newIndexStatsQuerier(t, store, ingesterClient)
+	stats, err := q.IndexStats(user.InjectOrgID(context.Background(), "test"), req)
+	require.NoError(t, err)
+	require.Equal(t, &loghttp.IndexStats{Streams: 3, Chunks: 7, Bytes: 300, Entries: 30}, stats)
+}
+
+func TestQuerier_Volume(t *testing.T) {
+	req := &loghttp.VolumeQuery{
+		StreamStatsRequest: logproto.StreamStatsRequest{Start: time.Unix(0, 0), End: time.Unix(10, 0), Matchers: `{app="foo"}`},
+		Label:              "env",
+		Limit:              10,
+	}
+
+	store := newStoreMock()
+	store.On("LabelValuesForMetricName", mock.Anything, "test", mock.Anything, mock.Anything, "logs", "env").Return([]string{"dev", "prod"}, nil)
+	store.On("GetStreamChunks", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(m []*labels.Matcher) bool {
+		return m[len(m)-1].Value == "dev"
+	})).Return(map[model.Fingerprint]int{1: 3}, nil)
+	store.On("GetStreamChunks", mock.Anything, mock.Anything, mock.Anything, mock.MatchedBy(func(m []*labels.Matcher) bool {
+		return m[len(m)-1].Value == "prod"
+	})).Return(map[model.Fingerprint]int{2: 1}, nil)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("GetStreamStats", mock.Anything, &req.StreamStatsRequest).Return(&logproto.StreamStatsResponse{
+		Streams: []logproto.StreamStats{
+			{Labels: `{app="foo", env="prod"}`, Fingerprint: 2, Chunks: 1, Bytes: 100, Entries: 10},
+			{Labels: `{app="foo"}`, Fingerprint: 3, Chunks: 2, Bytes: 200, Entries: 20},
+		},
+	}, nil)
+
+	q := newIndexStatsQuerier(t, store, ingesterClient)
+	volumes, err := q.Volume(user.InjectOrgID(context.Background(), "test"), req)
+	require.NoError(t, err)
+	require.Equal(t, []loghttp.Volume{
+		{Value: "prod", Streams: 1, Chunks: 2, Bytes: 100, Entries: 10},
+		{Value: "dev", Streams: 1, Chunks: 3},
+	}, volumes)
+}
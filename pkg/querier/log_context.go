@@ -0,0 +1,122 @@
+package querier
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+// LogContext returns the entries of the streams selected by a context query surrounding its anchor timestamp:
+// the entries at the anchor timestamp, up to the max entries limit, then up to req.Before entries before it and
+// req.After entries after it, sorted by timestamp.
+// The entries at the anchor timestamp and the ones going backward and forward from it are looked up by three queries,
+// validated against the query policies as the stream selector over [req.Start, req.End].
+func (q *Querier) LogContext(ctx context.Context, req *loghttp.ContextQuery) (logql.Streams, error) {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.validateEntriesLimits(ctx, req.Before+req.After); err != nil {
+		return nil, err
+	}
+	if err := q.validateQueryPolicies(ctx, req.Stream, req.Start, req.End); err != nil {
+		return nil, err
+	}
+	expr, err := logql.ParseLogSelector(req.Stream)
+	if err != nil {
+		return nil, err
+	}
+	ctx = logql.InjectPolicyQuery(ctx, expr, req.Start, req.End)
+
+	// Enforce the query timeout while querying backends
+	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
+	defer cancel()
+
+	c := newContextStreams()
+	if err := q.selectContext(ctx, c, &logproto.QueryRequest{
+		Selector: req.Stream,
+		Start:    req.Anchor,
+		// the end is exclusive.
+		End:       req.Anchor.Add(time.Nanosecond),
+		Limit:     uint32(q.maxEntriesLimit(userID)),
+		Direction: logproto.FORWARD,
+	}); err != nil {
+		return nil, err
+	}
+	if req.Before > 0 {
+		if err := q.selectContext(ctx, c, &logproto.QueryRequest{
+			Selector:  req.Stream,
+			Start:     req.Start,
+			End:       req.Anchor,
+			Limit:     req.Before,
+			Direction: logproto.BACKWARD,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if req.After > 0 {
+		if err := q.selectContext(ctx, c, &logproto.QueryRequest{
+			Selector:  req.Stream,
+			Start:     req.Anchor.Add(time.Nanosecond),
+			End:       req.End,
+			Limit:     req.After,
+			Direction: logproto.FORWARD,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return c.streams(), nil
+}
+
+// selectContext appends the entries selected by a request to c, up to its limit, all of them if it is 0.
+func (q *Querier) selectContext(ctx context.Context, c *contextStreams, req *logproto.QueryRequest) error {
+	it, err := q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: req})
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for read := uint32(0); (req.Limit == 0 || read < req.Limit) && it.Next(); read++ {
+		c.append(it.Labels(), it.Entry())
+	}
+	return it.Error()
+}
+
+// contextStreams accumulates the entries surrounding an anchor timestamp by stream.
+type contextStreams struct {
+	byLabel map[string]*logproto.Stream
+}
+
+func newContextStreams() *contextStreams {
+	return &contextStreams{
+		byLabel: map[string]*logproto.Stream{},
+	}
+}
+
+func (c *contextStreams) append(labels string, entry logproto.Entry) {
+	stream, ok := c.byLabel[labels]
+	if !ok {
+		stream = &logproto.Stream{Labels: labels}
+		c.byLabel[labels] = stream
+	}
+	stream.Entries = append(stream.Entries, entry)
+}
+
+func (c *contextStreams) streams() logql.Streams {
+	result := make(logql.Streams, 0, len(c.byLabel))
+	for _, stream := range c.byLabel {
+		entries := stream.Entries
+		sort.SliceStable(entries, func(i, j int) bool {
+			return entries[i].Timestamp.Before(entries[j].Timestamp)
+		})
+		result = append(result, *stream)
+	}
+	sort.Sort(result)
+	return result
+}
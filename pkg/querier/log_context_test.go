@@ -0,0 +1,103 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+func startsAt(ts time.Time) interface{} {
+	return mock.MatchedBy(func(p logql.SelectLogParams) bool {
+		return p.Start.Equal(ts)
+	})
+}
+
+func TestQuerier_LogContext(t *testing.T) {
+	before := mockStream(0, 5)
+	for i, j := 0, len(before.Entries)-1; i < j; i, j = i+1, j-1 {
+		before.Entries[i], before.Entries[j] = before.Entries[j], before.Entries[i]
+	}
+	anchor := mockStream(5, 1)
+	anchor.Entries = append(anchor.Entries, logproto.Entry{Timestamp: time.Unix(5, 0), Line: "line 5 again"})
+
+	store := newStoreMock()
+	store.On("SelectLogs", mock.Anything, startsAt(time.Unix(0, 0))).Return(iter.NewStreamIterator(before), nil)
+	store.On("SelectLogs", mock.Anything, startsAt(time.Unix(5, 0))).Return(iter.NewStreamIterator(anchor), nil)
+	store.On("SelectLogs", mock.Anything, startsAt(time.Unix(5, 1))).Return(mockStreamIterator(6, 4), nil)
+
+	defaultLimits := defaultLimitsTestConfig()
+	defaultLimits.MaxEntriesLimitPerQuery = 100
+	limits, err := validation.NewOverrides(defaultLimits, nil)
+	require.NoError(t, err)
+
+	conf := mockQuerierConfig()
+	// only the store is queried.
+	conf.QueryIngestersWithin = time.Hour
+	q, err := newQuerier(
+		conf,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+
+	ctx := user.InjectOrgID(context.Background(), "test")
+	req := &loghttp.ContextQuery{
+		Stream: `{type="test"}`,
+		Anchor: time.Unix(5, 0),
+		Before: 2,
+		After:  3,
+		Start:  time.Unix(0, 0),
+		End:    time.Unix(10, 0),
+	}
+	streams, err := q.LogContext(ctx, req)
+	require.NoError(t, err)
+
+	// the entries at the anchor timestamp don't count toward the lines before and after it.
+	expected := mockStream(3, 6)
+	expected.Entries = append(expected.Entries[:3], append([]logproto.Entry{anchor.Entries[1]}, expected.Entries[3:]...)...)
+	require.Equal(t, logql.Streams{expected}, streams)
+
+	for _, call := range store.Calls {
+		params := call.Arguments.Get(1).(logql.SelectLogParams)
+		switch {
+		case params.Start.Equal(time.Unix(0, 0)):
+			require.Equal(t, logproto.BACKWARD, params.Direction)
+			require.Equal(t, time.Unix(5, 0), params.End)
+			require.Equal(t, uint32(2), params.Limit)
+		case params.Start.Equal(time.Unix(5, 0)):
+			require.Equal(t, time.Unix(5, 1), params.End)
+			require.Equal(t, uint32(100), params.Limit)
+		case params.Start.Equal(time.Unix(5, 1)):
+			require.Equal(t, logproto.FORWARD, params.Direction)
+			require.Equal(t, time.Unix(10, 0), params.End)
+			require.Equal(t, uint32(3), params.Limit)
+		}
+	}
+	store.AssertExpectations(t)
+
+	// the query policies apply to the stream selector over the whole time range.
+	defaultLimits.RejectRegexOnlySelectors = true
+	defaultLimits.MaxQueryLengthWithoutFilter = 5 * time.Second
+	q.limits, err = validation.NewOverrides(defaultLimits, nil)
+	require.NoError(t, err)
+
+	_, err = q.LogContext(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "max_query_length_without_filter")
+
+	req.Stream = `{type=~".+"}`
+	_, err = q.LogContext(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "regex_only_selector")
+}
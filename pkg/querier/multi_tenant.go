@@ -0,0 +1,173 @@
+package querier
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// multiTenantIDs returns the tenants selected by the org ID of a multi-tenant query,
+// or nil for single tenant queries and when multi-tenant queries are disabled.
+func (q *Querier) multiTenantIDs(ctx context.Context) ([]string, error) {
+	if !q.cfg.MultiTenantQueriesEnabled {
+		return nil, nil
+	}
+	orgID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !util.IsMultiTenant(orgID) {
+		return nil, nil
+	}
+	tenants, err := util.ParseTenantIDs(orgID)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	return tenants, nil
+}
+
+// rejectMultiTenant fails for multi-tenant requests, which are only supported by queries.
+func (q *Querier) rejectMultiTenant(ctx context.Context) error {
+	tenants, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return err
+	}
+	if len(tenants) > 0 {
+		return httpgrpc.Errorf(http.StatusBadRequest, "multi-tenant requests are only supported by queries")
+	}
+	return nil
+}
+
+// selectLogsMultiTenant selects the logs of each tenant matching the tenant label matchers of the query,
+// the streams of each tenant are labeled with their tenant.
+func (q *Querier) selectLogsMultiTenant(ctx context.Context, tenants []string, params logql.SelectLogParams) (iter.EntryIterator, error) {
+	expr, err := params.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	tenants, err = matchingTenants(expr, tenants)
+	if err != nil {
+		return nil, err
+	}
+
+	iters := make([]iter.EntryIterator, 0, len(tenants))
+	for _, tenant := range tenants {
+		req := *params.QueryRequest
+		req.Selector = expr.String()
+		it, err := q.SelectLogs(user.InjectOrgID(ctx, tenant), logql.SelectLogParams{QueryRequest: &req})
+		if err != nil {
+			closeEntryIterators(iters)
+			return nil, err
+		}
+		iters = append(iters, &tenantEntryIterator{EntryIterator: it, labels: newTenantLabels(tenant)})
+	}
+	return iter.NewHeapIterator(ctx, iters, params.Direction), nil
+}
+
+// selectSamplesMultiTenant selects the samples of each tenant matching the tenant label matchers of the query,
+// the series of each tenant are labeled with their tenant.
+func (q *Querier) selectSamplesMultiTenant(ctx context.Context, tenants []string, params logql.SelectSampleParams) (iter.SampleIterator, error) {
+	expr, err := params.Expr()
+	if err != nil {
+		return nil, err
+	}
+	tenants, err = matchingTenants(expr, tenants)
+	if err != nil {
+		return nil, err
+	}
+
+	iters := make([]iter.SampleIterator, 0, len(tenants))
+	for _, tenant := range tenants {
+		req := *params.SampleQueryRequest
+		req.Selector = expr.String()
+		it, err := q.SelectSamples(user.InjectOrgID(ctx, tenant), logql.SelectSampleParams{SampleQueryRequest: &req})
+		if err != nil {
+			closeSampleIterators(iters)
+			return nil, err
+		}
+		iters = append(iters, &tenantSampleIterator{SampleIterator: it, labels: newTenantLabels(tenant)})
+	}
+	return iter.NewHeapSampleIterator(ctx, iters), nil
+}
+
+// matchingTenants removes the tenant label matchers from the stream selectors of an expression
+// and returns the tenants matching all of them.
+func matchingTenants(expr logql.Expr, tenants []string) ([]string, error) {
+	matchers, err := logql.RemoveLabelMatchers(expr, util.TenantIDLabel)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	result := make([]string, 0, len(tenants))
+outer:
+	for _, tenant := range tenants {
+		for _, m := range matchers {
+			if !m.Matches(tenant) {
+				continue outer
+			}
+		}
+		result = append(result, tenant)
+	}
+	return result, nil
+}
+
+func closeEntryIterators(iters []iter.EntryIterator) {
+	for _, it := range iters {
+		_ = it.Close()
+	}
+}
+
+func closeSampleIterators(iters []iter.SampleIterator) {
+	for _, it := range iters {
+		_ = it.Close()
+	}
+}
+
+// tenantLabels adds the tenant label to the labels of the streams of a tenant.
+type tenantLabels struct {
+	tenant string
+	// cache of the labels with the tenant label, by original labels.
+	cache map[string]string
+}
+
+func newTenantLabels(tenant string) *tenantLabels {
+	return &tenantLabels{tenant: tenant, cache: map[string]string{}}
+}
+
+func (t *tenantLabels) add(lbs string) string {
+	if res, ok := t.cache[lbs]; ok {
+		return res
+	}
+	res := lbs
+	if parsed, err := parser.ParseMetric(lbs); err == nil {
+		res = labels.NewBuilder(parsed).Set(util.TenantIDLabel, t.tenant).Labels().String()
+	}
+	t.cache[lbs] = res
+	return res
+}
+
+type tenantEntryIterator struct {
+	iter.EntryIterator
+	labels *tenantLabels
+}
+
+func (i *tenantEntryIterator) Labels() string {
+	return i.labels.add(i.EntryIterator.Labels())
+}
+
+type tenantSampleIterator struct {
+	iter.SampleIterator
+	labels *tenantLabels
+}
+
+func (i *tenantSampleIterator) Labels() string {
+	return i.labels.add(i.SampleIterator.Labels())
+}
@@ -0,0 +1,145 @@
+package querier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/iter"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util/validation"
+)
+
+func newMultiTenantQuerier(t *testing.T, store *storeMock, enabled bool) *Querier {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	conf := mockQuerierConfig()
+	// only the store is queried.
+	conf.QueryIngestersWithin = time.Hour
+	conf.MultiTenantQueriesEnabled = enabled
+	q, err := newQuerier(
+		conf,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(newQuerierClientMock()),
+		mockReadRingWithOneActiveIngester(),
+		store, limits)
+	require.NoError(t, err)
+	return q
+}
+
+func tenantContext(tenant string) interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		orgID, err := user.ExtractOrgID(ctx)
+		return err == nil && orgID == tenant
+	})
+}
+
+func TestQuerier_SelectLogsMultiTenant(t *testing.T) {
+	for _, tc := range []struct {
+		desc     string
+		selector string
+		expected []string
+	}{
+		{
+			"all tenants",
+			`{type="test"}`,
+			[]string{`{__tenant_id__="team-a", type="test"}`, `{__tenant_id__="team-b", type="test"}`},
+		},
+		{
+			"tenant matchers",
+			`{type="test", __tenant_id__!="team-a"}`,
+			[]string{`{__tenant_id__="team-b", type="test"}`},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			store := newStoreMock()
+			store.On("SelectLogs", tenantContext("team-a"), mock.Anything).Return(mockStreamIterator(0, 1), nil)
+			store.On("SelectLogs", tenantContext("team-b"), mock.Anything).Return(mockStreamIterator(1, 1), nil)
+
+			q := newMultiTenantQuerier(t, store, true)
+			req := logproto.QueryRequest{
+				Selector:  tc.selector,
+				Limit:     1000,
+				Start:     time.Unix(0, 0),
+				End:       time.Unix(10, 0),
+				Direction: logproto.FORWARD,
+			}
+			res, err := q.SelectLogs(user.InjectOrgID(context.Background(), "team-b|team-a"), logql.SelectLogParams{QueryRequest: &req})
+			require.NoError(t, err)
+
+			var streams []string
+			for res.Next() {
+				streams = append(streams, res.Labels())
+			}
+			require.NoError(t, res.Close())
+			require.Equal(t, tc.expected, streams)
+
+			// tenant matchers are not sent to the store.
+			for _, call := range store.Calls {
+				require.Equal(t, `{type="test"}`, call.Arguments.Get(1).(logql.SelectLogParams).Selector)
+			}
+		})
+	}
+}
+
+func TestQuerier_SelectSamplesMultiTenant(t *testing.T) {
+	store := newStoreMock()
+	store.On("SelectSamples", tenantContext("team-a"), mock.Anything).Return(iter.NewSeriesIterator(logproto.Series{
+		Labels:  `{type="test"}`,
+		Samples: []logproto.Sample{{Timestamp: 1, Value: 1}},
+	}), nil)
+
+	q := newMultiTenantQuerier(t, store, true)
+	req := logproto.SampleQueryRequest{
+		Selector: `count_over_time({type="test", __tenant_id__=~"team-a|team-c"}[1m])`,
+		Start:    time.Unix(0, 0),
+		End:      time.Unix(10, 0),
+	}
+	res, err := q.SelectSamples(user.InjectOrgID(context.Background(), "team-a|team-b"), logql.SelectSampleParams{SampleQueryRequest: &req})
+	require.NoError(t, err)
+
+	require.True(t, res.Next())
+	require.Equal(t, `{__tenant_id__="team-a", type="test"}`, res.Labels())
+	require.False(t, res.Next())
+	require.NoError(t, res.Close())
+	store.AssertExpectations(t)
+}
+
+func TestQuerier_MultiTenantDisabled(t *testing.T) {
+	store := newStoreMock()
+	store.On("SelectLogs", tenantContext("team-a|team-b"), mock.Anything).Return(mockStreamIterator(0, 1), nil)
+
+	q := newMultiTenantQuerier(t, store, false)
+	req := logproto.QueryRequest{
+		Selector:  `{type="test"}`,
+		Limit:     1000,
+		Start:     time.Unix(0, 0),
+		End:       time.Unix(10, 0),
+		Direction: logproto.FORWARD,
+	}
+	res, err := q.SelectLogs(user.InjectOrgID(context.Background(), "team-a|team-b"), logql.SelectLogParams{QueryRequest: &req})
+	require.NoError(t, err)
+	require.True(t, res.Next())
+	require.Equal(t, `{type="test"}`, res.Labels())
+	store.AssertExpectations(t)
+}
+
+func TestQuerier_MultiTenantRejected(t *testing.T) {
+	q := newMultiTenantQuerier(t, newStoreMock(), true)
+	ctx := user.InjectOrgID(context.Background(), "team-a|team-b")
+
+	_, err := q.Label(ctx, &logproto.LabelRequest{})
+	require.Error(t, err)
+
+	_, err = q.Series(ctx, &logproto.SeriesRequest{Start: time.Unix(0, 0), End: time.Unix(10, 0)})
+	require.Error(t, err)
+
+	_, err = q.SelectLogs(user.InjectOrgID(context.Background(), "team-a||team-b"), logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{Selector: `{type="test"}`}})
+	require.Error(t, err)
+}
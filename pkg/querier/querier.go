@@ -46,6 +46,7 @@ type Config struct {
 	IngesterQueryStoreMaxLookback time.Duration    `yaml:"-"`
 	Engine                        logql.EngineOpts `yaml:"engine,omitempty"`
 	MaxConcurrent                 int              `yaml:"max_concurrent"`
+	MultiTenantQueriesEnabled     bool             `yaml:"multi_tenant_queries_enabled"`
 }
 
 // RegisterFlags register flags.
@@ -56,6 +57,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.ExtraQueryDelay, "querier.extra-query-delay", 0, "Time to wait before sending more than the minimum successful query requests.")
 	f.DurationVar(&cfg.QueryIngestersWithin, "querier.query-ingesters-within", 0, "Maximum lookback beyond which queries are not sent to ingester. 0 means all queries are sent to ingester.")
 	f.IntVar(&cfg.MaxConcurrent, "querier.max-concurrent", 20, "The maximum number of concurrent queries.")
+	f.BoolVar(&cfg.MultiTenantQueriesEnabled, "querier.multi-tenant-queries-enabled", false, "When enabled, queries can select multiple tenants by separating them with '|' in the org ID, e.g. 'team-a|team-b'.")
 }
 
 // Querier handlers queries.
@@ -88,7 +90,11 @@ func newQuerier(cfg Config, clientCfg client.Config, clientFactory ring_client.P
 		limits: limits,
 	}
 
-	querier.engine = logql.NewEngine(cfg.Engine, &querier)
+	var engineLimits logql.Limits = limits
+	if cfg.MultiTenantQueriesEnabled {
+		engineLimits = validation.NewMultiTenantLimits(limits)
+	}
+	querier.engine = logql.NewEngine(cfg.Engine, &querier, engineLimits)
 	err := services.StartAndAwaitRunning(context.Background(), querier.pool)
 	if err != nil {
 		return nil, errors.Wrap(err, "querier pool")
@@ -143,7 +149,15 @@ func (q *Querier) forGivenIngesters(ctx context.Context, replicationSet ring.Rep
 
 // Select Implements logql.Querier which select logs via matchers and regex filters.
 func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams) (iter.EntryIterator, error) {
-	err := q.validateQueryRequest(ctx, params)
+	tenants, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenants) > 0 {
+		return q.selectLogsMultiTenant(ctx, tenants, params)
+	}
+
+	err = q.validateQueryRequest(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -196,7 +210,15 @@ func (q *Querier) SelectLogs(ctx context.Context, params logql.SelectLogParams)
 }
 
 func (q *Querier) SelectSamples(ctx context.Context, params logql.SelectSampleParams) (iter.SampleIterator, error) {
-	err := q.validateQueryRequest(ctx, params)
+	tenants, err := q.multiTenantIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(tenants) > 0 {
+		return q.selectSamplesMultiTenant(ctx, tenants, params)
+	}
+
+	err = q.validateQueryRequest(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -281,6 +303,10 @@ func (q *Querier) queryIngestersForSample(ctx context.Context, params logql.Sele
 
 // Label does the heavy lifting for a Label query.
 func (q *Querier) Label(ctx context.Context, req *logproto.LabelRequest) (*logproto.LabelResponse, error) {
+	if err := q.rejectMultiTenant(ctx); err != nil {
+		return nil, err
+	}
+
 	// Enforce the query timeout while querying backends
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(q.cfg.QueryTimeout))
 	defer cancel()
@@ -329,6 +355,10 @@ func (*Querier) Check(_ context.Context, _ *grpc_health_v1.HealthCheckRequest) (
 
 // Tail keeps getting matching logs from all ingesters for given query
 func (q *Querier) Tail(ctx context.Context, req *logproto.TailRequest) (*Tailer, error) {
+	if err := q.rejectMultiTenant(ctx); err != nil {
+		return nil, err
+	}
+
 	err := q.checkTailRequestLimit(ctx)
 	if err != nil {
 		return nil, err
@@ -446,6 +476,10 @@ func (q *Querier) Series(ctx context.Context, req *logproto.SeriesRequest) (*log
 		return nil, err
 	}
 
+	if err = q.rejectMultiTenant(ctx); err != nil {
+		return nil, err
+	}
+
 	if err = q.validateQueryTimeRange(userID, req.Start, req.End); err != nil {
 		return nil, err
 	}
@@ -586,9 +620,44 @@ func (q *Querier) validateQueryRequest(ctx context.Context, req logql.QueryParam
 			"max streams matchers per query exceeded, matchers-count > limit (%d > %d)", len(matchers), maxStreamMatchersPerQuery)
 	}
 
+	// The policies apply to the full query when the selection is made by the engine,
+	// otherwise the selection is validated on its own.
+	expr, start, end, ok := logql.PolicyQuery(ctx)
+	if !ok {
+		expr, start, end = selector, req.GetStart(), req.GetEnd()
+		if sampleReq, ok := req.(logql.SelectSampleParams); ok {
+			if expr, err = sampleReq.Expr(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := logql.ValidateQueryPolicies(userID, expr, start, end, q.limits); err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
 	return q.validateQueryTimeRange(userID, req.GetStart(), req.GetEnd())
 }
 
+// validateQueryPolicies validates the full query of a request against the query policies of its tenants,
+// before it is evaluated.
+func (q *Querier) validateQueryPolicies(ctx context.Context, query string, start, end time.Time) error {
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return err
+	}
+
+	expr, err := logql.ParseExpr(query)
+	if err != nil {
+		return err
+	}
+	for _, tenant := range listutil.TenantIDsOrSelf(userID) {
+		if err := logql.ValidateQueryPolicies(tenant, expr, start, end, q.limits); err != nil {
+			return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+	}
+	return nil
+}
+
 func (q *Querier) validateQueryTimeRange(userID string, from time.Time, through time.Time) error {
 	if (through).Before(from) {
 		return httpgrpc.Errorf(http.StatusBadRequest, "invalid query, through < from (%s < %s)", through, from)
@@ -67,6 +67,15 @@ func (c *querierClientMock) TailersCount(ctx context.Context, in *logproto.Taile
 	return args.Get(0).(*logproto.TailersCountResponse), args.Error(1)
 }
 
+func (c *querierClientMock) GetStreamStats(ctx context.Context, in *logproto.StreamStatsRequest, opts ...grpc.CallOption) (*logproto.StreamStatsResponse, error) {
+	args := c.Called(ctx, in)
+	res := args.Get(0)
+	if res == nil {
+		return (*logproto.StreamStatsResponse)(nil), args.Error(1)
+	}
+	return res.(*logproto.StreamStatsResponse), args.Error(1)
+}
+
 func (c *querierClientMock) Context() context.Context {
 	return context.Background()
 }
@@ -270,6 +279,15 @@ func (s *storeMock) GetSeries(ctx context.Context, req logql.SelectLogParams) ([
 	return res.([]logproto.SeriesIdentifier), args.Error(1)
 }
 
+func (s *storeMock) GetStreamChunks(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) (map[model.Fingerprint]int, error) {
+	args := s.Called(ctx, from, through, matchers)
+	res := args.Get(0)
+	if res == nil {
+		return map[model.Fingerprint]int(nil), args.Error(1)
+	}
+	return res.(map[model.Fingerprint]int), args.Error(1)
+}
+
 func (s *storeMock) Stop() {
 
 }
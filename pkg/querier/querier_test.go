@@ -296,6 +296,35 @@ func TestQuerier_validateQueryRequest(t *testing.T) {
 	request.Start = request.End.Add(-3 * time.Minute)
 	_, err = q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
 	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "the query time range exceeds the limit (query length: 3m0s, limit: 2m0s)"), err)
+
+	defaultLimits.RejectRegexOnlySelectors = true
+	defaultLimits.BlockedQueries = []*logql.BlockedQuery{{Pattern: `^sum\(count_over_time.*type="blocked"`, Regex: true}}
+	limits, err = validation.NewOverrides(defaultLimits, nil)
+	require.NoError(t, err)
+	q.limits = limits
+
+	request.Start = request.End.Add(-1 * time.Minute)
+	err = q.validateQueryPolicies(ctx, "{type=~\"test|foo\"}", request.Start, request.End)
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "query blocked by the regex_only_selector policy: the stream selector {type=~\"test|foo\"} must contain at least one non regex matcher"), err)
+
+	// the policies apply to the full query, not only to its range aggregations.
+	err = q.validateQueryPolicies(ctx, "sum(count_over_time({type=\"blocked\"}[1m]))", request.Start, request.End)
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, `query blocked by the blocked_query policy: the query matches the blocked pattern "^sum\\(count_over_time.*type=\"blocked\""`), err)
+	require.NoError(t, q.validateQueryPolicies(ctx, "count_over_time({type=\"blocked\"}[1m])", request.Start, request.End))
+
+	// the selections are validated as well, against the full query when it is evaluated by the engine.
+	request.Selector = "{type=~\"test|foo\"}"
+	_, err = q.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: &request})
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, "query blocked by the regex_only_selector policy: the stream selector {type=~\"test|foo\"} must contain at least one non regex matcher"), err)
+
+	full, err := logql.ParseExpr("sum(count_over_time({type=\"blocked\"}[1m]))")
+	require.NoError(t, err)
+	_, err = q.SelectSamples(logql.InjectPolicyQuery(ctx, full, request.Start, request.End), logql.SelectSampleParams{SampleQueryRequest: &logproto.SampleQueryRequest{
+		Selector: "count_over_time({type=\"blocked\"}[1m])",
+		Start:    request.Start,
+		End:      request.End,
+	}})
+	require.Equal(t, httpgrpc.Errorf(http.StatusBadRequest, `query blocked by the blocked_query policy: the query matches the blocked pattern "^sum\\(count_over_time.*type=\"blocked\""`), err)
 }
 
 func TestQuerier_SeriesAPI(t *testing.T) {
@@ -165,6 +165,21 @@ func (codec) DecodeRequest(_ context.Context, r *http.Request) (queryrange.Reque
 			Path:   r.URL.Path,
 			Shards: req.Shards,
 		}, nil
+	case InstantQueryOp:
+		req, err := loghttp.ParseInstantQuery(r)
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+		// instant queries are evaluated at a single point in time, both start and end are set to it.
+		return &LokiRequest{
+			Query:     req.Query,
+			Limit:     req.Limit,
+			Direction: req.Direction,
+			StartTs:   req.Ts.UTC(),
+			EndTs:     req.Ts.UTC(),
+			Step:      instantQueryStep,
+			Path:      r.URL.Path,
+		}, nil
 	case SeriesOp:
 		req, err := loghttp.ParseSeriesQuery(r)
 		if err != nil {
@@ -195,6 +210,9 @@ func (codec) DecodeRequest(_ context.Context, r *http.Request) (queryrange.Reque
 func (codec) EncodeRequest(ctx context.Context, r queryrange.Request) (*http.Request, error) {
 	switch request := r.(type) {
 	case *LokiRequest:
+		if getOperation(request.Path) == InstantQueryOp {
+			return encodeInstantRequest(ctx, request), nil
+		}
 		params := url.Values{
 			"start":     []string{fmt.Sprintf("%d", request.StartTs.UnixNano())},
 			"end":       []string{fmt.Sprintf("%d", request.EndTs.UnixNano())},
@@ -264,6 +282,32 @@ func (codec) EncodeRequest(ctx context.Context, r queryrange.Request) (*http.Req
 	}
 }
 
+// instantQueryStep is the step, in milliseconds, of instant queries.
+// It is not used to evaluate them but the results cache aligns extents on the step of requests, it can't be 0.
+const instantQueryStep = 1
+
+func encodeInstantRequest(ctx context.Context, request *LokiRequest) *http.Request {
+	params := url.Values{
+		"time":      []string{fmt.Sprintf("%d", request.EndTs.UnixNano())},
+		"query":     []string{request.Query},
+		"direction": []string{request.Direction.String()},
+		"limit":     []string{fmt.Sprintf("%d", request.Limit)},
+	}
+	u := &url.URL{
+		// the request could come /api/prom/query but we want to only use the new api.
+		Path:     "/loki/api/v1/query",
+		RawQuery: params.Encode(),
+	}
+	req := &http.Request{
+		Method:     "GET",
+		RequestURI: u.String(), // This is what the httpgrpc code looks at.
+		URL:        u,
+		Body:       http.NoBody,
+		Header:     http.Header{},
+	}
+	return req.WithContext(ctx)
+}
+
 func (codec) DecodeResponse(ctx context.Context, r *http.Response, req queryrange.Request) (queryrange.Response, error) {
 	if r.StatusCode/100 != 2 {
 		body, _ := ioutil.ReadAll(r.Body)
@@ -328,6 +372,17 @@ func (codec) DecodeResponse(ctx context.Context, r *http.Response, req queryrang
 				},
 				Statistics: resp.Data.Statistics,
 			}, nil
+		case loghttp.ResultTypeVector:
+			return &LokiPromResponse{
+				Response: &queryrange.PrometheusResponse{
+					Status: resp.Status,
+					Data: queryrange.PrometheusData{
+						ResultType: loghttp.ResultTypeVector,
+						Result:     vectorToProto(resp.Data.Result.(loghttp.Vector)),
+					},
+				},
+				Statistics: resp.Data.Statistics,
+			}, nil
 		case loghttp.ResultTypeStream:
 			return &LokiResponse{
 				Status:     resp.Status,
@@ -428,6 +483,10 @@ func (codec) MergeResponse(responses ...queryrange.Response) (queryrange.Respons
 		if err != nil {
 			return nil, err
 		}
+		// instant query results served from the results cache keep their vector type.
+		if responses[0].(*LokiPromResponse).Response.Data.ResultType == loghttp.ResultTypeVector {
+			promRes.(*queryrange.PrometheusResponse).Data.ResultType = loghttp.ResultTypeVector
+		}
 		return &LokiPromResponse{
 			Response:   promRes.(*queryrange.PrometheusResponse),
 			Statistics: mergedStats,
@@ -623,6 +682,24 @@ func toProto(m loghttp.Matrix) []queryrange.SampleStream {
 	return res
 }
 
+// vectorToProto converts a vector to streams of a single sample, the way vectors are cached.
+func vectorToProto(v loghttp.Vector) []queryrange.SampleStream {
+	if len(v) == 0 {
+		return nil
+	}
+	res := make([]queryrange.SampleStream, 0, len(v))
+	for _, s := range v {
+		res = append(res, queryrange.SampleStream{
+			Labels: client.FromMetricsToLabelAdapters(s.Metric),
+			Samples: []client.Sample{{
+				Value:       float64(s.Value),
+				TimestampMs: int64(s.Timestamp),
+			}},
+		})
+	}
+	return res
+}
+
 func (res LokiResponse) Count() int64 {
 	var result int64
 	for _, s := range res.Data.Result {
@@ -48,6 +48,18 @@ func Test_codec_DecodeRequest(t *testing.T) {
 			StartTs:   start,
 			EndTs:     end,
 		}, false},
+		{"instant", func() (*http.Request, error) {
+			return http.NewRequest(http.MethodGet,
+				fmt.Sprintf(`/loki/api/v1/query?time=%d&query=count_over_time({foo="bar"}[1h])&limit=200&direction=FORWARD`, end.UnixNano()), nil)
+		}, &LokiRequest{
+			Query:     `count_over_time({foo="bar"}[1h])`,
+			Limit:     200,
+			Direction: logproto.FORWARD,
+			Path:      "/loki/api/v1/query",
+			StartTs:   end,
+			EndTs:     end,
+			Step:      instantQueryStep,
+		}, false},
 		{"series", func() (*http.Request, error) {
 			return http.NewRequest(http.MethodGet,
 				fmt.Sprintf(`/series?start=%d&end=%d&match={foo="bar"}`, start.UnixNano(), end.UnixNano()), nil)
@@ -106,6 +118,17 @@ func Test_codec_DecodeResponse(t *testing.T) {
 				},
 				Statistics: statsResult,
 			}, false},
+		{"vector", &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(vectorString))}, nil,
+			&LokiPromResponse{
+				Response: &queryrange.PrometheusResponse{
+					Status: loghttp.QueryStatusSuccess,
+					Data: queryrange.PrometheusData{
+						ResultType: loghttp.ResultTypeVector,
+						Result:     vectorSampleStreams,
+					},
+				},
+				Statistics: statsResult,
+			}, false},
 		{"streams v1", &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(streamsString))},
 			&LokiRequest{Direction: logproto.FORWARD, Limit: 100, Path: "/loki/api/v1/query_range"},
 			&LokiResponse{
@@ -199,6 +222,59 @@ func Test_codec_EncodeRequest(t *testing.T) {
 	require.Equal(t, "/loki/api/v1/query_range", req.(*LokiRequest).Path)
 }
 
+func Test_codec_instant_EncodeRequest(t *testing.T) {
+	ctx := context.Background()
+	toEncode := &LokiRequest{
+		Query:     `count_over_time({foo="bar"}[1h])`,
+		Limit:     200,
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query",
+		StartTs:   end,
+		EndTs:     end,
+	}
+	got, err := lokiCodec.EncodeRequest(ctx, toEncode)
+	require.NoError(t, err)
+	require.Equal(t, ctx, got.Context())
+	require.Equal(t, "/loki/api/v1/query", got.URL.Path)
+	require.Equal(t, fmt.Sprintf("%d", end.UnixNano()), got.URL.Query().Get("time"))
+	require.Equal(t, toEncode.Query, got.URL.Query().Get("query"))
+	require.Equal(t, fmt.Sprintf("%d", 200), got.URL.Query().Get("limit"))
+	require.Equal(t, `FORWARD`, got.URL.Query().Get("direction"))
+
+	// testing a full roundtrip
+	req, err := lokiCodec.DecodeRequest(context.TODO(), got)
+	require.NoError(t, err)
+	require.Equal(t, &LokiRequest{
+		Query:     toEncode.Query,
+		Limit:     toEncode.Limit,
+		Direction: toEncode.Direction,
+		Path:      "/loki/api/v1/query",
+		StartTs:   end,
+		EndTs:     end,
+		Step:      instantQueryStep,
+	}, req)
+}
+
+func Test_codec_vector_EncodeResponse(t *testing.T) {
+	res := &LokiPromResponse{
+		Response: &queryrange.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrange.PrometheusData{
+				ResultType: loghttp.ResultTypeVector,
+				Result:     vectorSampleStreams,
+			},
+		},
+		Statistics: statsResult,
+	}
+	got, err := lokiCodec.EncodeResponse(context.TODO(), res)
+	require.NoError(t, err)
+
+	// the encoded vector is decoded back to the same response.
+	decoded, err := lokiCodec.DecodeResponse(context.TODO(), got, nil)
+	require.NoError(t, err)
+	require.Equal(t, res, decoded)
+}
+
 func Test_codec_series_EncodeRequest(t *testing.T) {
 	got, err := lokiCodec.EncodeRequest(context.TODO(), &queryrange.PrometheusRequest{})
 	require.Error(t, err)
@@ -812,6 +888,8 @@ var (
 			"totalBatches": 6,
 			"totalChunksMatched": 7,
 			"totalDuplicates": 8,
+			"totalBlocks": 0,
+			"skippedBlocks": 0,
 			"totalLinesSent": 9,
 			"totalReached": 10
 		},
@@ -824,7 +902,9 @@ var (
 			"chunksDownloadTime": 16,
 			"totalChunksRef": 17,
 			"totalChunksDownloaded": 18,
-			"totalDuplicates": 19
+			"totalDuplicates": 19,
+			"totalBlocks": 0,
+			"skippedBlocks": 0
 		},
 		"summary": {
 			"bytesProcessedPerSecond": 20,
@@ -881,6 +961,45 @@ var (
 			Samples: []client.Sample{{Value: 3.45, TimestampMs: 1568404331324}, {Value: 4.45, TimestampMs: 1568404331339}},
 		},
 	}
+	vectorString = `{
+	"data": {
+	  ` + statsResultString + `
+	  "resultType": "vector",
+	  "result": [
+		{
+		  "metric": {
+			"filename": "\/var\/hostlog\/apport.log",
+			"job": "varlogs"
+		  },
+		  "value": [
+			1568404331.324,
+			"0.013333333333333334"
+		  ]
+		},
+		{
+		  "metric": {
+			"filename": "\/var\/hostlog\/syslog",
+			"job": "varlogs"
+		  },
+		  "value": [
+			1568404331.324,
+			"3.45"
+		  ]
+		}
+	  ]
+	},
+	"status": "success"
+  }`
+	vectorSampleStreams = []queryrange.SampleStream{
+		{
+			Labels:  []client.LabelAdapter{{Name: "filename", Value: "/var/hostlog/apport.log"}, {Name: "job", Value: "varlogs"}},
+			Samples: []client.Sample{{Value: 0.013333333333333334, TimestampMs: 1568404331324}},
+		},
+		{
+			Labels:  []client.LabelAdapter{{Name: "filename", Value: "/var/hostlog/syslog"}, {Name: "job", Value: "varlogs"}},
+			Samples: []client.Sample{{Value: 3.45, TimestampMs: 1568404331324}},
+		},
+	}
 	streamsString = `{
 		"status": "success",
 		"data": {
@@ -0,0 +1,247 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/gogo/protobuf/proto"
+	"github.com/prometheus/common/model"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/marshal"
+)
+
+// explainRoundTripper answers explain requests with the plan the query frontend follows to execute a query:
+// how it is split by time, which splits are found in the results cache and how each split is sharded.
+type explainRoundTripper struct {
+	cfg                 Config
+	limits              Limits
+	confs               queryrange.ShardingConfigs
+	minShardingLookback time.Duration
+	cache               cache.Cache
+	metrics             *logql.ShardingMetrics
+	now                 func() time.Time
+}
+
+func newExplainRoundTripper(cfg Config, limits Limits, schema chunk.SchemaConfig, minShardingLookback time.Duration, c cache.Cache) explainRoundTripper {
+	return explainRoundTripper{
+		cfg:                 cfg,
+		limits:              limits,
+		confs:               schema.Configs,
+		minShardingLookback: minShardingLookback,
+		cache:               c,
+		// explained queries are not executed, they must not be accounted by the sharding metrics.
+		metrics: logql.NewShardingMetrics(nil),
+		now:     time.Now,
+	}
+}
+
+func (e explainRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	rangeQuery, err := loghttp.ParseRangeQuery(req)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	userID, err := user.ExtractOrgID(req.Context())
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := logql.ParseExpr(rangeQuery.Query)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	filters, err := logql.ExplainLineFilters(expr)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	r := &LokiRequest{
+		Query:     rangeQuery.Query,
+		Limit:     rangeQuery.Limit,
+		Direction: rangeQuery.Direction,
+		StartTs:   rangeQuery.Start.UTC(),
+		EndTs:     rangeQuery.End.UTC(),
+		Step:      int64(rangeQuery.Step) / 1e6,
+		Path:      req.URL.Path,
+	}
+	explanation := loghttp.Explanation{
+		Query:   expr.String(),
+		Filters: filters,
+		Splits:  e.splits(req.Context(), userID, expr, r),
+	}
+
+	var buf bytes.Buffer
+	if err := marshal.WriteExplainResponseJSON(explanation, &buf); err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          ioutil.NopCloser(&buf),
+		ContentLength: int64(buf.Len()),
+	}, nil
+}
+
+// splits mirrors the routing of the tripperware to explain the sub-queries of a request.
+func (e explainRoundTripper) splits(ctx context.Context, userID string, expr logql.Expr, r *LokiRequest) []loghttp.Split {
+	var req queryrange.Request = r
+	_, metric := expr.(logql.SampleExpr)
+	if !metric {
+		// log queries without filters are not processed by the frontend.
+		if sel, ok := expr.(logql.LogSelectorExpr); ok && !sel.HasFilter() {
+			return []loghttp.Split{newSplit(req)}
+		}
+	}
+
+	interval := e.limits.QuerySplitDuration(userID)
+	if !metric && e.cfg.SplitQueriesByInterval == 0 {
+		interval = 0
+	}
+	if metric {
+		if e.cfg.AlignQueriesWithStep && req.GetStep() > 0 {
+			req = req.WithStartEnd((req.GetStart()/req.GetStep())*req.GetStep(), (req.GetEnd()/req.GetStep())*req.GetStep())
+		}
+		if interval != 0 {
+			if rewritten, _, ok := removeOffset(req); ok {
+				req = rewritten
+			}
+		}
+	}
+
+	reqs := []queryrange.Request{req}
+	if interval != 0 {
+		if intervals := splitByTime(req, interval); len(intervals) > 0 {
+			reqs = intervals
+		}
+	}
+
+	splits := make([]loghttp.Split, 0, len(reqs))
+	for _, r := range reqs {
+		split := newSplit(r)
+		if e.cfg.CacheResults && e.cache != nil {
+			if metric {
+				split.Cache = e.cacheStatus(ctx, userID, r)
+			} else if lr, ok := r.(*LokiRequest); ok {
+				split.Cache = e.logCacheStatus(ctx, userID, lr)
+			}
+		}
+		if e.cfg.ShardedQueries && hasShards(e.confs) {
+			split.Shards, split.Mapped = e.shards(r)
+		}
+		splits = append(splits, split)
+	}
+	return splits
+}
+
+// shards returns the amount of shards and the mapped query of a split, see shardSplitter and astMapperware.
+func (e explainRoundTripper) shards(r queryrange.Request) (int, string) {
+	cutoff := e.now().Add(-e.minShardingLookback)
+	if !cutoff.After(util.TimeFromMillis(r.GetEnd())) {
+		return 0, ""
+	}
+	conf, err := e.confs.GetConf(r)
+	if err != nil {
+		return 0, ""
+	}
+	mapper, err := logql.NewShardMapper(int(conf.RowShards), e.metrics)
+	if err != nil {
+		return 0, ""
+	}
+	_, mapped, err := mapper.Parse(r.GetQuery())
+	if err != nil {
+		return 0, ""
+	}
+	return int(conf.RowShards), mapped.String()
+}
+
+// cacheStatus returns whether the extents of a split are found in the results cache.
+func (e explainRoundTripper) cacheStatus(ctx context.Context, userID string, r queryrange.Request) string {
+	maxCacheFreshness := e.cfg.ResultsCacheConfig.LegacyMaxCacheFreshness
+	if maxCacheFreshness == time.Duration(0) {
+		maxCacheFreshness = e.limits.MaxCacheFreshness(userID)
+	}
+	if r.GetStart() > int64(model.Now().Add(-maxCacheFreshness)) {
+		return loghttp.CacheStatusTooFresh
+	}
+
+	extents, ok := e.cachedExtents(ctx, cacheKeyLimits{e.limits}.GenerateCacheKey(userID, r))
+	if !ok {
+		return loghttp.CacheStatusMiss
+	}
+	return extentsStatus(extents, r.GetStart(), r.GetEnd())
+}
+
+// logCacheStatus returns whether the extents of a log query split are found in the results cache, see logResultCache.
+func (e explainRoundTripper) logCacheStatus(ctx context.Context, userID string, r *LokiRequest) string {
+	maxCacheFreshness := e.cfg.ResultsCacheConfig.LegacyMaxCacheFreshness
+	if maxCacheFreshness == time.Duration(0) {
+		maxCacheFreshness = e.limits.MaxCacheFreshness(userID)
+	}
+	if r.StartTs.After(e.now().Add(-maxCacheFreshness)) {
+		return loghttp.CacheStatusTooFresh
+	}
+
+	extents, ok := e.cachedExtents(ctx, logCacheKey(e.limits, userID, r))
+	if !ok {
+		return loghttp.CacheStatusMiss
+	}
+	// extents of log responses are stored in nanoseconds.
+	return extentsStatus(extents, r.StartTs.UnixNano(), r.EndTs.UnixNano())
+}
+
+// cachedExtents fetches the extents cached for a key.
+func (e explainRoundTripper) cachedExtents(ctx context.Context, key string) ([]queryrange.Extent, bool) {
+	found, bufs, _ := e.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil, false
+	}
+	var cached queryrange.CachedResponse
+	if err := proto.Unmarshal(bufs[0], &cached); err != nil || cached.Key != key {
+		return nil, false
+	}
+	return cached.Extents, true
+}
+
+// extentsStatus returns whether cached extents fully or partially cover a time range.
+func extentsStatus(extents []queryrange.Extent, start, end int64) string {
+	sort.Slice(extents, func(i, j int) bool { return extents[i].Start < extents[j].Start })
+	covered, overlap := start, false
+	for _, extent := range extents {
+		if extent.End < start || extent.Start > end {
+			continue
+		}
+		overlap = true
+		if extent.Start <= covered && extent.End > covered {
+			covered = extent.End
+		}
+	}
+	switch {
+	case covered >= end:
+		return loghttp.CacheStatusHit
+	case overlap:
+		return loghttp.CacheStatusPartial
+	default:
+		return loghttp.CacheStatusMiss
+	}
+}
+
+func newSplit(r queryrange.Request) loghttp.Split {
+	return loghttp.Split{
+		Query: r.GetQuery(),
+		Start: util.TimeFromMillis(r.GetStart()).UTC(),
+		End:   util.TimeFromMillis(r.GetEnd()).UTC(),
+	}
+}
@@ -0,0 +1,170 @@
+package queryrange
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk"
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
+)
+
+func Test_explainRoundTripper(t *testing.T) {
+	var (
+		end    = testTime.Truncate(30 * time.Second)
+		start  = end.Add(-3 * time.Hour)
+		limits = fakeLimits{splits: map[string]time.Duration{"1": time.Hour}}
+		c      = cache.NewMockCache()
+		ctx    = user.InjectOrgID(context.Background(), "1")
+		query  = `sum by (app) (rate({app="foo"} |~ "foo|bar"[1m]))`
+	)
+
+	cfg := Config{queryrange.Config{
+		SplitQueriesByInterval: time.Hour,
+		AlignQueriesWithStep:   true,
+		CacheResults:           true,
+		ShardedQueries:         true,
+	}}
+	schema := chunk.SchemaConfig{Configs: []chunk.PeriodConfig{{RowShards: 2}}}
+
+	// the first split is cached, the second one only for its first half.
+	cacheExtents := func(from, through time.Time, extents ...queryrange.Extent) {
+		key := cacheKeyLimits{limits}.GenerateCacheKey("1", &LokiRequest{Query: query, Step: 30000, StartTs: from, EndTs: through})
+		buf, err := proto.Marshal(&queryrange.CachedResponse{Key: key, Extents: extents})
+		require.NoError(t, err)
+		c.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+	}
+	cacheExtents(start, start.Add(time.Hour), queryrange.Extent{Start: toMs(start), End: toMs(start.Add(time.Hour))})
+	cacheExtents(start.Add(time.Hour), start.Add(2*time.Hour), queryrange.Extent{Start: toMs(start.Add(time.Hour)), End: toMs(start.Add(90 * time.Minute))})
+
+	rt := newExplainRoundTripper(cfg, limits, schema, 30*time.Minute, c)
+	rt.now = func() time.Time { return end }
+
+	req, err := lokiCodec.EncodeRequest(ctx, &LokiRequest{
+		Query:     query,
+		Limit:     100,
+		Step:      30000,
+		StartTs:   start,
+		EndTs:     end,
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/explain",
+	})
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var explained loghttp.ExplainResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&explained))
+
+	mapped := `sum by(app)(downstream<sum by(app)(rate({app="foo"}|~"foo|bar"[1m])), shard=0_of_2> ++ downstream<sum by(app)(rate({app="foo"}|~"foo|bar"[1m])), shard=1_of_2>)`
+	require.Equal(t, loghttp.Explanation{
+		Query: `sum by(app)(rate({app="foo"}|~"foo|bar"[1m]))`,
+		Filters: []logql.LineFilterExplanation{
+			{Expr: `|~"foo|bar"`, Simplified: `(contains("foo") or contains("bar"))`},
+		},
+		Splits: []loghttp.Split{
+			{Query: query, Start: start, End: start.Add(time.Hour), Shards: 2, Mapped: mapped, Cache: loghttp.CacheStatusHit},
+			{Query: query, Start: start.Add(time.Hour), End: start.Add(2 * time.Hour), Shards: 2, Mapped: mapped, Cache: loghttp.CacheStatusPartial},
+			{Query: query, Start: start.Add(2 * time.Hour), End: end, Cache: loghttp.CacheStatusMiss},
+		},
+	}, explained.Data)
+}
+
+func Test_explainRoundTripper_LogQuery(t *testing.T) {
+	var (
+		end   = testTime
+		start = end.Add(-3 * time.Hour)
+		ctx   = user.InjectOrgID(context.Background(), "1")
+	)
+	rt := newExplainRoundTripper(testConfig, fakeLimits{splits: map[string]time.Duration{"1": time.Hour}}, chunk.SchemaConfig{}, 0, nil)
+
+	for _, tc := range []struct {
+		query  string
+		splits int
+	}{
+		// log queries without filters are not processed by the frontend.
+		{`{app="foo"}`, 1},
+		{`{app="foo"} |= "foo"`, 3},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			req, err := lokiCodec.EncodeRequest(ctx, &LokiRequest{
+				Query:     tc.query,
+				Limit:     100,
+				StartTs:   start,
+				EndTs:     end,
+				Direction: logproto.BACKWARD,
+				Path:      "/loki/api/v1/explain",
+			})
+			require.NoError(t, err)
+			resp, err := rt.RoundTrip(req.WithContext(ctx))
+			require.NoError(t, err)
+
+			var explained loghttp.ExplainResponse
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&explained))
+			require.Len(t, explained.Data.Splits, tc.splits)
+			for _, split := range explained.Data.Splits {
+				require.Empty(t, split.Cache)
+				require.Zero(t, split.Shards)
+			}
+		})
+	}
+}
+
+func Test_explainRoundTripper_LogCache(t *testing.T) {
+	var (
+		end    = testTime.Truncate(time.Second)
+		start  = end.Add(-3 * time.Hour)
+		limits = fakeLimits{splits: map[string]time.Duration{"1": time.Hour}}
+		c      = cache.NewMockCache()
+		ctx    = user.InjectOrgID(context.Background(), "1")
+		query  = `{app="foo"} |= "foo"`
+	)
+	cfg := Config{queryrange.Config{
+		SplitQueriesByInterval: time.Hour,
+		CacheResults:           true,
+	}}
+
+	// the first split is cached by the log results cache, with extents in nanoseconds.
+	first := &LokiRequest{Query: query, Limit: 100, Direction: logproto.BACKWARD, StartTs: start, EndTs: start.Add(time.Hour)}
+	key := logCacheKey(limits, "1", first)
+	buf, err := proto.Marshal(&queryrange.CachedResponse{Key: key, Extents: []queryrange.Extent{
+		{Start: start.UnixNano(), End: start.Add(time.Hour).UnixNano()},
+	}})
+	require.NoError(t, err)
+	c.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+
+	rt := newExplainRoundTripper(cfg, limits, chunk.SchemaConfig{}, 0, c)
+	rt.now = func() time.Time { return end }
+
+	req, err := lokiCodec.EncodeRequest(ctx, &LokiRequest{
+		Query:     query,
+		Limit:     100,
+		StartTs:   start,
+		EndTs:     end,
+		Direction: logproto.BACKWARD,
+		Path:      "/loki/api/v1/explain",
+	})
+	require.NoError(t, err)
+	resp, err := rt.RoundTrip(req.WithContext(ctx))
+	require.NoError(t, err)
+
+	var explained loghttp.ExplainResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&explained))
+	require.Len(t, explained.Data.Splits, 3)
+	require.Equal(t, start, explained.Data.Splits[0].Start)
+	require.Equal(t, loghttp.CacheStatusHit, explained.Data.Splits[0].Cache)
+	require.Equal(t, loghttp.CacheStatusMiss, explained.Data.Splits[1].Cache)
+}
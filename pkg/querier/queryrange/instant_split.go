@@ -0,0 +1,129 @@
+package queryrange
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/cortexproject/cortex/pkg/ingester/client"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+// NewInstantSplitMiddleware creates a new Middleware that splits instant metric queries with a range longer
+// than the split interval into sub-ranges, see logql.SplitInstantQuery.
+// Sub-ranges are executed in parallel, then their results are merged per series.
+func NewInstantSplitMiddleware(limits Limits, metrics *SplitByMetrics) queryrange.Middleware {
+	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
+		return instantSplitter{
+			next:    next,
+			limits:  limits,
+			metrics: metrics,
+		}
+	})
+}
+
+type instantSplitter struct {
+	next    queryrange.Handler
+	limits  Limits
+	metrics *SplitByMetrics
+}
+
+func (s instantSplitter) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
+	req, ok := r.(*LokiRequest)
+	if !ok {
+		return s.next.Do(ctx, r)
+	}
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	expr, err := logql.ParseSampleExpr(req.Query)
+	if err != nil {
+		return s.next.Do(ctx, r)
+	}
+	splits, merge, ok := logql.SplitInstantQuery(expr, req.EndTs, s.limits.QuerySplitDuration(userID))
+	if !ok {
+		return s.next.Do(ctx, r)
+	}
+	s.metrics.splits.Observe(float64(len(splits)))
+
+	reqs := make([]queryrange.Request, 0, len(splits))
+	for _, split := range splits {
+		reqs = append(reqs, &LokiRequest{
+			Query:     split.Query,
+			Limit:     req.Limit,
+			Direction: req.Direction,
+			StartTs:   split.Ts,
+			EndTs:     split.Ts,
+			Step:      req.Step,
+			Path:      req.Path,
+		})
+	}
+	reqResps, err := queryrange.DoRequests(ctx, s.next, reqs, s.limits)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		statistics stats.Result
+		merged     = map[string]*queryrange.SampleStream{}
+		ts         = req.GetEnd()
+	)
+	for _, reqResp := range reqResps {
+		resp, ok := reqResp.Response.(*LokiPromResponse)
+		if !ok {
+			return nil, httpgrpc.Errorf(http.StatusInternalServerError, "unexpected response type %T", reqResp.Response)
+		}
+		statistics.Merge(resp.Statistics)
+		for _, stream := range resp.Response.Data.Result {
+			key := client.FromLabelAdaptersToLabels(stream.Labels).String()
+			for _, sample := range stream.Samples {
+				if existing, ok := merged[key]; ok {
+					existing.Samples[0].Value = mergeValues(merge, existing.Samples[0].Value, sample.Value)
+					continue
+				}
+				merged[key] = &queryrange.SampleStream{
+					Labels:  stream.Labels,
+					Samples: []client.Sample{{Value: sample.Value, TimestampMs: ts}},
+				}
+			}
+		}
+	}
+	result := make([]queryrange.SampleStream, 0, len(merged))
+	for _, stream := range merged {
+		result = append(result, *stream)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return labels.Compare(client.FromLabelAdaptersToLabels(result[i].Labels), client.FromLabelAdaptersToLabels(result[j].Labels)) < 0
+	})
+
+	return &LokiPromResponse{
+		Response: &queryrange.PrometheusResponse{
+			Status: loghttp.QueryStatusSuccess,
+			Data: queryrange.PrometheusData{
+				ResultType: loghttp.ResultTypeVector,
+				Result:     result,
+			},
+		},
+		Statistics: statistics,
+	}, nil
+}
+
+func mergeValues(op string, a, b float64) float64 {
+	switch op {
+	case logql.OpTypeMax:
+		return math.Max(a, b)
+	case logql.OpTypeMin:
+		return math.Min(a, b)
+	default:
+		return a + b
+	}
+}
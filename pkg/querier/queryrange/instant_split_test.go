@@ -0,0 +1,150 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/frontend"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/cortexproject/cortex/pkg/util"
+	json "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/pkg/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/logql/marshal"
+)
+
+var rangeRegexp = regexp.MustCompile(`\[(\w+)\]`)
+
+// rangeMinutesRoundTripper answers instant queries with a single sample valued with the number of minutes
+// of the query range, and records the queries requested.
+type rangeMinutesRoundTripper struct {
+	mtx     sync.Mutex
+	queries []string
+}
+
+func (rt *rangeMinutesRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := req.ParseForm(); err != nil {
+		return nil, err
+	}
+	q, err := loghttp.ParseInstantQuery(req)
+	if err != nil {
+		return nil, err
+	}
+	rt.mtx.Lock()
+	rt.queries = append(rt.queries, q.Query)
+	rt.mtx.Unlock()
+
+	selRange, err := model.ParseDuration(rangeRegexp.FindStringSubmatch(q.Query)[1])
+	if err != nil {
+		return nil, err
+	}
+	minutes := time.Duration(selRange).Minutes()
+
+	var buf bytes.Buffer
+	err = marshal.WriteQueryResponseJSON(logql.Result{Data: promql.Vector{{
+		Metric: labels.Labels{{Name: "app", Value: "foo"}},
+		Point:  promql.Point{T: q.Ts.UnixNano() / int64(time.Millisecond), V: minutes},
+	}}}, &buf)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: ioutil.NopCloser(&buf)}, nil
+}
+
+func (rt *rangeMinutesRoundTripper) reset() []string {
+	rt.mtx.Lock()
+	defer rt.mtx.Unlock()
+	queries := rt.queries
+	rt.queries = nil
+	return queries
+}
+
+func instantQueryRequest(t *testing.T, query string, ts time.Time) *http.Request {
+	t.Helper()
+	params := url.Values{
+		"query": []string{query},
+		"time":  []string{ts.Format(time.RFC3339Nano)},
+	}
+	req, err := http.NewRequest(http.MethodGet, "/loki/api/v1/query?"+params.Encode(), nil)
+	require.NoError(t, err)
+	return req.WithContext(user.InjectOrgID(context.Background(), "1"))
+}
+
+func Test_InstantSplitter(t *testing.T) {
+	var (
+		// the whole query range is old enough to be cached.
+		ts         = time.Now().UTC().Truncate(time.Hour).Add(-30 * time.Minute)
+		downstream = &rangeMinutesRoundTripper{}
+		cfg        = Config{Config: queryrange.Config{CacheResults: true}}
+	)
+	tpw, err := NewInstantMetricTripperware(cfg, util.Logger, fakeLimits{maxQueryParallelism: 2, splits: map[string]time.Duration{"1": time.Hour}},
+		lokiCodec, cache.NewMockCache(), queryrange.NewInstrumentMiddlewareMetrics(nil), nil, NewSplitByMetrics(nil), nil)
+	require.NoError(t, err)
+	rt := tpw(downstream)
+
+	for i := 0; i < 2; i++ {
+		resp, err := rt.RoundTrip(instantQueryRequest(t, `sum(count_over_time({app="foo"}[3h]))`, ts))
+		require.NoError(t, err)
+		var res loghttp.QueryResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+		vector := res.Data.Result.(loghttp.Vector)
+		require.Len(t, vector, 1)
+		// the sum of the sub-ranges is the whole range.
+		require.Equal(t, 180.0, float64(vector[0].Value))
+		require.Equal(t, ts.UnixNano()/int64(time.Millisecond), int64(vector[0].Timestamp))
+
+		if i == 0 {
+			require.ElementsMatch(t, []string{
+				`sum(count_over_time({app="foo"}[30m]))`,
+				`sum(count_over_time({app="foo"}[1h]))`,
+				`sum(count_over_time({app="foo"}[1h]))`,
+				`sum(count_over_time({app="foo"}[30m]))`,
+			}, downstream.reset())
+			continue
+		}
+		// sub-ranges are served from the results cache.
+		require.Empty(t, downstream.reset())
+	}
+
+	// queries that can't be split are forwarded as is.
+	for _, query := range []string{
+		`sum(count_over_time({app="foo"}[1h]))`,
+		`sum(rate({app="foo"}[3h]))`,
+	} {
+		_, err := rt.RoundTrip(instantQueryRequest(t, query, ts))
+		require.NoError(t, err)
+		require.Equal(t, []string{query}, downstream.reset())
+	}
+}
+
+func Test_InstantSplitter_Errors(t *testing.T) {
+	ts := time.Date(2020, 10, 10, 12, 30, 0, 0, time.UTC)
+	tpw, err := NewInstantMetricTripperware(Config{}, util.Logger, fakeLimits{splits: map[string]time.Duration{"1": time.Hour}},
+		lokiCodec, nil, queryrange.NewInstrumentMiddlewareMetrics(nil), nil, NewSplitByMetrics(nil), nil)
+	require.NoError(t, err)
+	rt := tpw(frontend.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusBadRequest, Body: ioutil.NopCloser(bytes.NewBufferString("bad request"))}, nil
+	}))
+
+	_, err = rt.RoundTrip(instantQueryRequest(t, `count_over_time({app="foo"}[3h])`, ts))
+	require.Error(t, err)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusBadRequest), resp.Code)
+	require.Equal(t, "bad request", string(resp.Body))
+}
@@ -5,11 +5,17 @@ import (
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util/validation"
 )
 
 // Limits extends the cortex limits interface with support for per tenant splitby parameters
+// and the LogQL engine limits.
 type Limits interface {
 	queryrange.Limits
+	logql.Limits
+	logql.PolicyLimits
 	QuerySplitDuration(string) time.Duration
 	MaxEntriesLimitPerQuery(string) int
 }
@@ -63,7 +69,9 @@ type cacheKeyLimits struct {
 }
 
 // GenerateCacheKey will panic if it encounters a 0 split duration. We ensure against this by requiring
-// a nonzero split interval when caching is enabled
+// a nonzero split interval when caching is enabled.
+// Queries with a single offset reach the cache without it (see removeOffset), keys and extents
+// are then based on the time range of the data selected, which is shared with queries without offset.
 func (l cacheKeyLimits) GenerateCacheKey(userID string, r queryrange.Request) string {
 	split := l.QuerySplitDuration(userID)
 	currentInterval := r.GetStart() / int64(split/time.Millisecond)
@@ -71,3 +79,20 @@ func (l cacheKeyLimits) GenerateCacheKey(userID string, r queryrange.Request) st
 	// a cache key can't be reused when an interval changes
 	return fmt.Sprintf("%s:%s:%d:%d:%d", userID, r.GetQuery(), r.GetStep(), currentInterval, split)
 }
+
+// instantCacheKeyLimits intersects Limits and CacheSplitter for instant queries.
+type instantCacheKeyLimits struct {
+	Limits
+}
+
+// GenerateCacheKey uses the time instant queries are evaluated at in place of the interval of cacheKeyLimits:
+// the extent of an instant query is a single point, requests at other times of the same interval can't be
+// answered from it.
+func (l instantCacheKeyLimits) GenerateCacheKey(userID string, r queryrange.Request) string {
+	return fmt.Sprintf("%s:%s:%d:%d", userID, r.GetQuery(), r.GetStep(), r.GetStart())
+}
+
+// WithMultiTenantLimits will construct a Limits applying to multi-tenant queries the most restrictive limits of their tenants.
+func WithMultiTenantLimits(l Limits) Limits {
+	return validation.NewMultiTenantLimits(l)
+}
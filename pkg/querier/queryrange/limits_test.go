@@ -44,3 +44,18 @@ func TestLimits(t *testing.T) {
 		cacheKeyLimits{wrapped}.GenerateCacheKey("a", r),
 	)
 }
+
+func TestMultiTenantLimits(t *testing.T) {
+	l := fakeLimits{
+		splits: map[string]time.Duration{"a": time.Minute, "b": time.Hour},
+	}
+
+	wrapped := WithDefaultLimits(WithMultiTenantLimits(l), queryrange.Config{
+		SplitQueriesByInterval: 24 * time.Hour,
+	})
+
+	require.Equal(t, time.Minute, wrapped.QuerySplitDuration("a|b"))
+	require.Equal(t, time.Hour, wrapped.QuerySplitDuration("b|c"))
+	require.Equal(t, 24*time.Hour, wrapped.QuerySplitDuration("c|d"))
+	require.Equal(t, time.Minute, wrapped.QuerySplitDuration("a"))
+}
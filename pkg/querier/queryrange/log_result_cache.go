@@ -0,0 +1,331 @@
+package queryrange
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/gogo/protobuf/proto"
+	"github.com/gogo/protobuf/types"
+	"github.com/weaveworks/common/httpgrpc"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql/stats"
+)
+
+// logResultCache caches the responses of log queries as extents of time covered by a response.
+// Unlike metric responses, log responses are truncated to the query limit, only responses
+// holding all the entries of their time range (less entries than the limit) are cached.
+// Extents of log responses are stored in nanoseconds.
+type logResultCache struct {
+	logger log.Logger
+	cfg    queryrange.ResultsCacheConfig
+	next   queryrange.Handler
+	cache  cache.Cache
+	limits Limits
+	now    func() time.Time
+}
+
+// NewLogResultCacheMiddleware creates a results cache middleware for log queries, keyed by query, direction and limit.
+// Repeated or overlapping queries only fetch the time ranges missing from the cache.
+func NewLogResultCacheMiddleware(logger log.Logger, cfg queryrange.ResultsCacheConfig, c cache.Cache, limits Limits) queryrange.Middleware {
+	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
+		return &logResultCache{
+			logger: logger,
+			cfg:    cfg,
+			next:   next,
+			cache:  c,
+			limits: limits,
+			now:    time.Now,
+		}
+	})
+}
+
+func (l *logResultCache) Do(ctx context.Context, r queryrange.Request) (queryrange.Response, error) {
+	req, ok := r.(*LokiRequest)
+	if !ok {
+		return l.next.Do(ctx, r)
+	}
+	userID, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	// check if cache freshness value is provided in legacy config
+	maxCacheFreshness := l.cfg.LegacyMaxCacheFreshness
+	if maxCacheFreshness == time.Duration(0) {
+		maxCacheFreshness = l.limits.MaxCacheFreshness(userID)
+	}
+	maxCacheTime := l.now().Add(-maxCacheFreshness)
+	if req.StartTs.After(maxCacheTime) || !req.StartTs.Before(req.EndTs) {
+		return l.next.Do(ctx, r)
+	}
+
+	key := l.GenerateCacheKey(userID, req)
+	cached := l.get(ctx, key)
+	requests, responses, err := partitionLogRequest(req, cached)
+	if err != nil {
+		return nil, err
+	}
+
+	var extents []queryrange.Extent
+	if len(requests) > 0 {
+		reqResps, err := queryrange.DoRequests(ctx, l.next, requests, l.limits)
+		if err != nil {
+			return nil, err
+		}
+		for _, reqResp := range reqResps {
+			responses = append(responses, timedResponse{start: reqResp.Request.(*LokiRequest).StartTs, resp: reqResp.Response.(*LokiResponse)})
+			extent, ok, err := toLogExtent(reqResp.Request.(*LokiRequest), reqResp.Response.(*LokiResponse))
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				extents = append(extents, extent)
+			}
+		}
+	}
+
+	response, err := mergeTimedResponses(req.Direction, responses)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(extents) > 0 {
+		extents, err = mergeLogExtents(append(cached, extents...), maxCacheTime.UnixNano())
+		if err != nil {
+			return nil, err
+		}
+		l.put(ctx, key, extents)
+	}
+	return response, nil
+}
+
+// GenerateCacheKey generates a cache key for a log query based on the split interval of its start,
+// the direction and the limit which both change the entries of a response.
+func (l *logResultCache) GenerateCacheKey(userID string, r *LokiRequest) string {
+	return logCacheKey(l.limits, userID, r)
+}
+
+// logCacheKey returns the results cache key of a log query, see GenerateCacheKey.
+func logCacheKey(limits Limits, userID string, r *LokiRequest) string {
+	split := limits.QuerySplitDuration(userID)
+	currentInterval := r.StartTs.UnixNano() / int64(split)
+	return fmt.Sprintf("%s:%s:%d:%d:%d:%d", userID, r.Query, r.Direction, r.Limit, currentInterval, split)
+}
+
+func (l *logResultCache) get(ctx context.Context, key string) []queryrange.Extent {
+	found, bufs, _ := l.cache.Fetch(ctx, []string{cache.HashKey(key)})
+	if len(found) != 1 {
+		return nil
+	}
+
+	var resp queryrange.CachedResponse
+	if err := proto.Unmarshal(bufs[0], &resp); err != nil {
+		level.Error(l.logger).Log("msg", "error unmarshalling cached value", "err", err)
+		return nil
+	}
+	if resp.Key != key {
+		return nil
+	}
+	for _, e := range resp.Extents {
+		if e.Response == nil {
+			return nil
+		}
+	}
+	return resp.Extents
+}
+
+func (l *logResultCache) put(ctx context.Context, key string, extents []queryrange.Extent) {
+	buf, err := proto.Marshal(&queryrange.CachedResponse{
+		Key:     key,
+		Extents: extents,
+	})
+	if err != nil {
+		level.Error(l.logger).Log("msg", "error marshalling cached value", "err", err)
+		return
+	}
+	l.cache.Store(ctx, []string{cache.HashKey(key)}, [][]byte{buf})
+}
+
+// timedResponse is a response to a part of a request starting at start.
+type timedResponse struct {
+	start time.Time
+	resp  *LokiResponse
+}
+
+// partitionLogRequest calculates the requests required to complete the cached extents overlapping a request.
+func partitionLogRequest(req *LokiRequest, extents []queryrange.Extent) ([]queryrange.Request, []timedResponse, error) {
+	var (
+		requests  []queryrange.Request
+		responses []timedResponse
+		start     = req.StartTs
+	)
+
+	for _, extent := range extents {
+		// If there is no overlap, ignore this extent.
+		if extent.End <= start.UnixNano() || extent.Start >= req.EndTs.UnixNano() {
+			continue
+		}
+		// If there is a bit missing at the front, make a request for that.
+		if start.UnixNano() < extent.Start {
+			requests = append(requests, withTimeRange(req, start, time.Unix(0, extent.Start)))
+			start = time.Unix(0, extent.Start)
+		}
+		res, err := logExtentResponse(extent)
+		if err != nil {
+			return nil, nil, err
+		}
+		end := time.Unix(0, extent.End)
+		if end.After(req.EndTs) {
+			end = req.EndTs
+		}
+		responses = append(responses, timedResponse{start: start, resp: extractLogResponse(res, start, end)})
+		start = end
+	}
+
+	if start.Before(req.EndTs) {
+		requests = append(requests, withTimeRange(req, start, req.EndTs))
+	}
+	return requests, responses, nil
+}
+
+// mergeTimedResponses merges responses in the order of the query direction, as expected when merging
+// responses which might have reached the query limit.
+func mergeTimedResponses(direction logproto.Direction, responses []timedResponse) (queryrange.Response, error) {
+	sort.Slice(responses, func(i, j int) bool {
+		if direction == logproto.BACKWARD {
+			return responses[i].start.After(responses[j].start)
+		}
+		return responses[i].start.Before(responses[j].start)
+	})
+	resps := make([]queryrange.Response, 0, len(responses))
+	for _, r := range responses {
+		resps = append(resps, r.resp)
+	}
+	return lokiCodec.MergeResponse(resps...)
+}
+
+// toLogExtent returns the extent of a response, if the response is complete for the time range of its request.
+func toLogExtent(req *LokiRequest, res *LokiResponse) (queryrange.Extent, bool, error) {
+	if res.Count() >= int64(req.Limit) {
+		return queryrange.Extent{}, false, nil
+	}
+	// statistics of cached responses are not accounted again once served from the cache.
+	cached := *res
+	cached.Statistics = stats.Result{}
+	any, err := types.MarshalAny(&cached)
+	if err != nil {
+		return queryrange.Extent{}, false, err
+	}
+	return queryrange.Extent{
+		Start:    req.StartTs.UnixNano(),
+		End:      req.EndTs.UnixNano(),
+		Response: any,
+	}, true, nil
+}
+
+// mergeLogExtents merges overlapping or contiguous extents and removes the part of them that is too recent to be cached.
+func mergeLogExtents(extents []queryrange.Extent, maxCacheTime int64) ([]queryrange.Extent, error) {
+	sort.Slice(extents, func(i, j int) bool { return extents[i].Start < extents[j].Start })
+
+	var (
+		merged []queryrange.Extent
+		acc    *queryrange.Extent
+		accRes *LokiResponse
+	)
+	flush := func() error {
+		if acc == nil {
+			return nil
+		}
+		if acc.End > maxCacheTime {
+			acc.End = maxCacheTime
+			accRes = extractLogResponse(accRes, time.Unix(0, acc.Start), time.Unix(0, acc.End))
+		}
+		if acc.End <= acc.Start {
+			return nil
+		}
+		any, err := types.MarshalAny(accRes)
+		if err != nil {
+			return err
+		}
+		acc.Response = any
+		merged = append(merged, *acc)
+		return nil
+	}
+
+	for i := range extents {
+		res, err := logExtentResponse(extents[i])
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil || acc.End < extents[i].Start {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			acc, accRes = &queryrange.Extent{Start: extents[i].Start, End: extents[i].End}, res
+			continue
+		}
+		if extents[i].End <= acc.End {
+			continue
+		}
+		// only the part of the extent which isn't already accumulated is merged.
+		res = extractLogResponse(res, time.Unix(0, acc.End), time.Unix(0, extents[i].End))
+		accRes = &LokiResponse{
+			Status:    accRes.Status,
+			Direction: accRes.Direction,
+			Limit:     accRes.Limit,
+			Version:   accRes.Version,
+			Data: LokiData{
+				ResultType: accRes.Data.ResultType,
+				Result:     mergeOrderedNonOverlappingStreams([]*LokiResponse{accRes, res}, math.MaxUint32, accRes.Direction),
+			},
+		}
+		acc.End = extents[i].End
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return merged, nil
+}
+
+func logExtentResponse(e queryrange.Extent) (*LokiResponse, error) {
+	var res LokiResponse
+	if err := types.UnmarshalAny(e.Response, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// extractLogResponse returns a response with only the entries of a time range, from start inclusive to end exclusive.
+func extractLogResponse(res *LokiResponse, start, end time.Time) *LokiResponse {
+	extracted := *res
+	extracted.Data.Result = make([]logproto.Stream, 0, len(res.Data.Result))
+	for _, stream := range res.Data.Result {
+		var entries []logproto.Entry
+		for _, e := range stream.Entries {
+			if !e.Timestamp.Before(start) && e.Timestamp.Before(end) {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) > 0 {
+			extracted.Data.Result = append(extracted.Data.Result, logproto.Stream{Labels: stream.Labels, Entries: entries})
+		}
+	}
+	return &extracted
+}
+
+func withTimeRange(req *LokiRequest, start, end time.Time) *LokiRequest {
+	r := *req
+	r.StartTs = start
+	r.EndTs = end
+	return &r
+}
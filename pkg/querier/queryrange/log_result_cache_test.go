@@ -0,0 +1,194 @@
+package queryrange
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cortexproject/cortex/pkg/chunk/cache"
+	"github.com/cortexproject/cortex/pkg/querier/queryrange"
+	"github.com/cortexproject/cortex/pkg/util"
+	"github.com/stretchr/testify/require"
+	"github.com/weaveworks/common/user"
+
+	"github.com/grafana/loki/pkg/loghttp"
+	"github.com/grafana/loki/pkg/logproto"
+)
+
+// logsHandler answers log requests with one entry per second, and records the time ranges requested.
+type logsHandler struct {
+	mtx      sync.Mutex
+	requests [][2]time.Time
+}
+
+func (h *logsHandler) Do(_ context.Context, r queryrange.Request) (queryrange.Response, error) {
+	req := r.(*LokiRequest)
+	h.mtx.Lock()
+	h.requests = append(h.requests, [2]time.Time{req.StartTs, req.EndTs})
+	h.mtx.Unlock()
+
+	return &LokiResponse{
+		Status:    loghttp.QueryStatusSuccess,
+		Direction: req.Direction,
+		Limit:     req.Limit,
+		Version:   uint32(loghttp.VersionV1),
+		Data: LokiData{
+			ResultType: loghttp.ResultTypeStream,
+			Result:     []logproto.Stream{{Labels: `{app="foo"}`, Entries: entriesEverySecond(req.StartTs, req.EndTs, req.Limit, req.Direction)}},
+		},
+	}, nil
+}
+
+func (h *logsHandler) reset() [][2]time.Time {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	requests := h.requests
+	h.requests = nil
+	return requests
+}
+
+func entriesEverySecond(start, end time.Time, limit uint32, direction logproto.Direction) []logproto.Entry {
+	var entries []logproto.Entry
+	first := start.Truncate(time.Second)
+	if first.Before(start) {
+		first = first.Add(time.Second)
+	}
+	for ts := first; ts.Before(end); ts = ts.Add(time.Second) {
+		entries = append(entries, logproto.Entry{Timestamp: ts, Line: ts.String()})
+	}
+	if direction == logproto.BACKWARD {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+	}
+	if len(entries) > int(limit) {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+func newLogResultCacheHandler(t *testing.T, now time.Time) (queryrange.Handler, *logsHandler) {
+	t.Helper()
+	h := &logsHandler{}
+	mw := NewLogResultCacheMiddleware(util.Logger, queryrange.ResultsCacheConfig{}, cache.NewMockCache(), fakeLimits{splits: map[string]time.Duration{"1": time.Hour}})
+	handler := mw.Wrap(h)
+	handler.(*logResultCache).now = func() time.Time { return now }
+	return handler, h
+}
+
+func Test_LogResultCache(t *testing.T) {
+	var (
+		ctx   = user.InjectOrgID(context.Background(), "1")
+		start = time.Unix(3600, 0)
+	)
+	handler, downstream := newLogResultCacheHandler(t, start.Add(time.Hour))
+
+	req := &LokiRequest{
+		Query:     `{app="foo"} |= "foo"`,
+		Limit:     1000,
+		StartTs:   start,
+		EndTs:     start.Add(10 * time.Minute),
+		Direction: logproto.FORWARD,
+	}
+	resp, err := handler.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, [][2]time.Time{{req.StartTs, req.EndTs}}, downstream.reset())
+	require.Equal(t, int64(600), resp.(*LokiResponse).Count())
+
+	// the same query is served from the cache.
+	cached, err := handler.Do(ctx, req)
+	require.NoError(t, err)
+	require.Empty(t, downstream.reset())
+	require.Equal(t, resp.(*LokiResponse).Data, cached.(*LokiResponse).Data)
+
+	// an overlapping query only fetches the uncached edge.
+	shifted := withTimeRange(req, start.Add(time.Minute), start.Add(11*time.Minute))
+	resp, err = handler.Do(ctx, shifted)
+	require.NoError(t, err)
+	require.Equal(t, [][2]time.Time{{req.EndTs, shifted.EndTs}}, downstream.reset())
+	expected, err := (&logsHandler{}).Do(ctx, shifted)
+	require.NoError(t, err)
+	require.Equal(t, expected.(*LokiResponse).Data, resp.(*LokiResponse).Data)
+
+	// the query limit and direction are part of the cache key.
+	backward := withTimeRange(req, start.Add(time.Minute), start.Add(11*time.Minute))
+	backward.Direction = logproto.BACKWARD
+	backward.Limit = 100
+	resp, err = handler.Do(ctx, backward)
+	require.NoError(t, err)
+	require.Len(t, downstream.reset(), 1)
+	expected, err = (&logsHandler{}).Do(ctx, backward)
+	require.NoError(t, err)
+	require.Equal(t, expected.(*LokiResponse).Data, resp.(*LokiResponse).Data)
+}
+
+func Test_LogResultCache_TruncatedResponses(t *testing.T) {
+	var (
+		ctx   = user.InjectOrgID(context.Background(), "1")
+		start = time.Unix(3600, 0)
+	)
+	handler, downstream := newLogResultCacheHandler(t, start.Add(time.Hour))
+
+	// responses reaching the limit don't hold all the entries of their time range.
+	req := &LokiRequest{
+		Query:     `{app="foo"} |= "foo"`,
+		Limit:     100,
+		StartTs:   start,
+		EndTs:     start.Add(10 * time.Minute),
+		Direction: logproto.FORWARD,
+	}
+	for i := 0; i < 2; i++ {
+		resp, err := handler.Do(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, int64(100), resp.(*LokiResponse).Count())
+		require.Len(t, downstream.reset(), 1)
+	}
+
+	// a cached extent completes a query that reaches the limit.
+	small := withTimeRange(req, start, start.Add(time.Minute))
+	_, err := handler.Do(ctx, small)
+	require.NoError(t, err)
+	require.Len(t, downstream.reset(), 1)
+
+	resp, err := handler.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, [][2]time.Time{{small.EndTs, req.EndTs}}, downstream.reset())
+	expected, err := (&logsHandler{}).Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, expected.(*LokiResponse).Data, resp.(*LokiResponse).Data)
+}
+
+func Test_LogResultCache_MaxCacheFreshness(t *testing.T) {
+	var (
+		ctx   = user.InjectOrgID(context.Background(), "1")
+		start = time.Unix(3600, 0)
+		// fakeLimits has a max cache freshness of 1 minute.
+		handler, downstream = newLogResultCacheHandler(t, start.Add(10*time.Minute))
+	)
+
+	req := &LokiRequest{
+		Query:     `{app="foo"} |= "foo"`,
+		Limit:     1000,
+		StartTs:   start,
+		EndTs:     start.Add(10 * time.Minute),
+		Direction: logproto.FORWARD,
+	}
+	_, err := handler.Do(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, downstream.reset(), 1)
+
+	// the last minute is too recent to be cached.
+	resp, err := handler.Do(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, [][2]time.Time{{start.Add(9 * time.Minute), req.EndTs}}, downstream.reset())
+	require.Equal(t, int64(600), resp.(*LokiResponse).Count())
+
+	// queries starting within the freshness period are not cached.
+	recent := withTimeRange(req, start.Add(9*time.Minute+30*time.Second), req.EndTs)
+	for i := 0; i < 2; i++ {
+		_, err := handler.Do(ctx, recent)
+		require.NoError(t, err)
+		require.Len(t, downstream.reset(), 1)
+	}
+}
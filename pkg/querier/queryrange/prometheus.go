@@ -6,11 +6,14 @@ import (
 	"io/ioutil"
 	"net/http"
 
+	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/opentracing/opentracing-go"
 	otlog "github.com/opentracing/opentracing-go/log"
+	"github.com/prometheus/common/model"
 
+	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logql/stats"
 )
 
@@ -41,11 +44,16 @@ func (PrometheusExtractor) ResponseWithoutHeaders(resp queryrange.Response) quer
 // encode encodes a Prometheus response and injects Loki stats.
 func (p *LokiPromResponse) encode(ctx context.Context) (*http.Response, error) {
 	sp := opentracing.SpanFromContext(ctx)
+	var result interface{} = p.Response.Data.Result
+	if p.Response.Data.ResultType == loghttp.ResultTypeVector {
+		result = toVector(p.Response.Data.Result)
+	}
 	// embed response and add statistics.
 	b, err := jsonStd.Marshal(struct {
 		Status string `json:"status"`
 		Data   struct {
-			queryrange.PrometheusData
+			ResultType string       `json:"resultType"`
+			Result     interface{}  `json:"result"`
 			Statistics stats.Result `json:"stats"`
 		} `json:"data,omitempty"`
 		ErrorType string `json:"errorType,omitempty"`
@@ -53,11 +61,13 @@ func (p *LokiPromResponse) encode(ctx context.Context) (*http.Response, error) {
 	}{
 		Error: p.Response.Error,
 		Data: struct {
-			queryrange.PrometheusData
+			ResultType string       `json:"resultType"`
+			Result     interface{}  `json:"result"`
 			Statistics stats.Result `json:"stats"`
 		}{
-			PrometheusData: p.Response.Data,
-			Statistics:     p.Statistics,
+			ResultType: p.Response.Data.ResultType,
+			Result:     result,
+			Statistics: p.Statistics,
 		},
 		ErrorType: p.Response.ErrorType,
 		Status:    p.Response.Status,
@@ -79,3 +89,18 @@ func (p *LokiPromResponse) encode(ctx context.Context) (*http.Response, error) {
 	}
 	return &resp, nil
 }
+
+// toVector converts streams of a single sample, the way vectors are cached, back to a vector.
+func toVector(streams []queryrange.SampleStream) model.Vector {
+	vector := make(model.Vector, 0, len(streams))
+	for _, stream := range streams {
+		for _, sample := range stream.Samples {
+			vector = append(vector, &model.Sample{
+				Metric:    client.FromLabelAdaptersToMetric(stream.Labels),
+				Value:     model.SampleValue(sample.Value),
+				Timestamp: model.Time(sample.TimestampMs),
+			})
+		}
+	}
+	return vector
+}
@@ -3,6 +3,7 @@ package queryrange
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
@@ -11,6 +12,7 @@ import (
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/weaveworks/common/httpgrpc"
 
 	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logql"
@@ -24,6 +26,7 @@ func NewQueryShardMiddleware(
 	minShardingLookback time.Duration,
 	middlewareMetrics *queryrange.InstrumentMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
+	limits logql.Limits,
 ) queryrange.Middleware {
 
 	noshards := !hasShards(confs)
@@ -38,7 +41,7 @@ func NewQueryShardMiddleware(
 	}
 
 	mapperware := queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
-		return newASTMapperware(confs, next, logger, shardingMetrics)
+		return newASTMapperware(confs, next, logger, shardingMetrics, limits)
 	})
 
 	return queryrange.MiddlewareFunc(func(next queryrange.Handler) queryrange.Handler {
@@ -60,13 +63,14 @@ func newASTMapperware(
 	next queryrange.Handler,
 	logger log.Logger,
 	metrics *logql.ShardingMetrics,
+	limits logql.Limits,
 ) *astMapperware {
 
 	return &astMapperware{
 		confs:  confs,
 		logger: log.With(logger, "middleware", "QueryShard.astMapperware"),
 		next:   next,
-		ng:     logql.NewShardedEngine(logql.EngineOpts{}, DownstreamHandler{next}, metrics),
+		ng:     logql.NewShardedEngine(logql.EngineOpts{}, DownstreamHandler{next}, metrics, limits),
 	}
 }
 
@@ -97,6 +101,9 @@ func (ast *astMapperware) Do(ctx context.Context, r queryrange.Request) (queryra
 
 	res, err := query.Exec(ctx)
 	if err != nil {
+		if logql.IsLimitError(err) {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
 		return nil, err
 	}
 
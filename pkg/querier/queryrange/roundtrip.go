@@ -55,12 +55,14 @@ func NewTripperware(
 	shardingMetrics := logql.NewShardingMetrics(registerer)
 	splitByMetrics := NewSplitByMetrics(registerer)
 
-	metricsTripperware, cache, err := NewMetricTripperware(cfg, log, limits, schema, minShardingLookback, lokiCodec,
+	metricsTripperware, c, err := NewMetricTripperware(cfg, log, limits, schema, minShardingLookback, lokiCodec,
 		PrometheusExtractor{}, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics, registerer)
 	if err != nil {
 		return nil, nil, err
 	}
-	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, minShardingLookback, lokiCodec, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics)
+	// the results cache is shared by metric and log queries.
+	resultsCache, _ := c.(cache.Cache)
+	logFilterTripperware, err := NewLogFilterTripperware(cfg, log, limits, schema, minShardingLookback, lokiCodec, resultsCache, instrumentMetrics, retryMetrics, shardingMetrics, splitByMetrics)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -75,30 +77,41 @@ func NewTripperware(
 		return nil, nil, err
 	}
 
+	instantMetricTripperware, err := NewInstantMetricTripperware(cfg, log, limits, lokiCodec, resultsCache, instrumentMetrics, retryMetrics, splitByMetrics, registerer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the results cache is looked up to explain which splits of a metric query are cached.
+	explainRT := newExplainRoundTripper(cfg, limits, schema, minShardingLookback, resultsCache)
+
 	return func(next http.RoundTripper) http.RoundTripper {
 		metricRT := metricsTripperware(next)
 		logFilterRT := logFilterTripperware(next)
 		seriesRT := seriesTripperware(next)
 		labelsRT := labelsTripperware(next)
-		return newRoundTripper(next, logFilterRT, metricRT, seriesRT, labelsRT, limits)
-	}, cache, nil
+		instantRT := instantMetricTripperware(next)
+		return newRoundTripper(next, logFilterRT, metricRT, seriesRT, labelsRT, explainRT, instantRT, limits)
+	}, c, nil
 }
 
 type roundTripper struct {
-	next, log, metric, series, labels http.RoundTripper
+	next, log, metric, series, labels, explain, instant http.RoundTripper
 
 	limits Limits
 }
 
 // newRoundTripper creates a new queryrange roundtripper
-func newRoundTripper(next, log, metric, series, labels http.RoundTripper, limits Limits) roundTripper {
+func newRoundTripper(next, log, metric, series, labels, explain, instant http.RoundTripper, limits Limits) roundTripper {
 	return roundTripper{
-		log:    log,
-		limits: limits,
-		metric: metric,
-		series: series,
-		labels: labels,
-		next:   next,
+		log:     log,
+		limits:  limits,
+		metric:  metric,
+		series:  series,
+		labels:  labels,
+		explain: explain,
+		instant: instant,
+		next:    next,
 	}
 }
 
@@ -118,18 +131,32 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		if err != nil {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
-		switch e := expr.(type) {
+		// the legacy regexp param is part of the query validated against the policies.
+		if e, ok := expr.(logql.LogSelectorExpr); ok {
+			expr = transformRegexQuery(req, e)
+		}
+		if err := validatePolicies(req, expr, rangeQuery.Start, rangeQuery.End, r.limits); err != nil {
+			return nil, err
+		}
+		switch expr := expr.(type) {
 		case logql.SampleExpr:
 			return r.metric.RoundTrip(req)
 		case logql.LogSelectorExpr:
-			filter, err := transformRegexQuery(req, e).Filter()
-			if err != nil {
+			if _, err := expr.Pipeline(); err != nil {
 				return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 			}
 			if err := validateLimits(req, rangeQuery.Limit, r.limits); err != nil {
 				return nil, err
 			}
-			if filter == nil {
+			// the frontend buffers the responses it splits, merges and caches: log queries are only
+			// streamed by the queriers, the clients which don't accept json are told so.
+			if loghttp.AcceptsNDJSON(req) {
+				if !loghttp.AcceptsJSON(req) {
+					return nil, httpgrpc.Errorf(http.StatusNotAcceptable, "streamed responses (%s) are not served by the query frontend, query the queriers directly", loghttp.NDJSONContentType)
+				}
+				req.Header.Set("Accept", "application/json")
+			}
+			if !expr.HasFilter() {
 				return r.next.RoundTrip(req)
 			}
 			return r.log.RoundTrip(req)
@@ -137,6 +164,23 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 		default:
 			return r.next.RoundTrip(req)
 		}
+	case InstantQueryOp:
+		instantQuery, err := loghttp.ParseInstantQuery(req)
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+		expr, err := logql.ParseExpr(instantQuery.Query)
+		if err != nil {
+			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+		}
+		// policies are validated against the original query, sub-queries of split queries differ from it.
+		if err := validatePolicies(req, expr, instantQuery.Ts, instantQuery.Ts, r.limits); err != nil {
+			return nil, err
+		}
+		if _, ok := expr.(logql.SampleExpr); ok {
+			return r.instant.RoundTrip(req)
+		}
+		return r.next.RoundTrip(req)
 	case SeriesOp:
 		_, err := loghttp.ParseSeriesQuery(req)
 		if err != nil {
@@ -149,6 +193,8 @@ func (r roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 			return nil, httpgrpc.Errorf(http.StatusBadRequest, err.Error())
 		}
 		return r.labels.RoundTrip(req)
+	case ExplainOp:
+		return r.explain.RoundTrip(req)
 	default:
 		return r.next.RoundTrip(req)
 	}
@@ -184,20 +230,39 @@ func validateLimits(req *http.Request, reqLimit uint32, limits Limits) error {
 	return nil
 }
 
+// validates the query against the tenant query policies
+func validatePolicies(req *http.Request, expr logql.Expr, start, end time.Time, limits Limits) error {
+	userID, err := user.ExtractOrgID(req.Context())
+	if err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+
+	if err := logql.ValidateQueryPolicies(userID, expr, start, end, limits); err != nil {
+		return httpgrpc.Errorf(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
 const (
-	QueryRangeOp = "query_range"
-	SeriesOp     = "series"
-	LabelNamesOp = "labels"
+	QueryRangeOp   = "query_range"
+	InstantQueryOp = "instant_query"
+	SeriesOp       = "series"
+	LabelNamesOp   = "labels"
+	ExplainOp      = "explain"
 )
 
 func getOperation(path string) string {
 	switch {
 	case strings.HasSuffix(path, "/query_range") || strings.HasSuffix(path, "/prom/query"):
 		return QueryRangeOp
+	case strings.HasSuffix(path, "/query"):
+		return InstantQueryOp
 	case strings.HasSuffix(path, "/series"):
 		return SeriesOp
 	case strings.HasSuffix(path, "/labels") || strings.HasSuffix(path, "/label"):
 		return LabelNamesOp
+	case strings.HasSuffix(path, "/explain"):
+		return ExplainOp
 	default:
 		return ""
 	}
@@ -211,6 +276,7 @@ func NewLogFilterTripperware(
 	schema chunk.SchemaConfig,
 	minShardingLookback time.Duration,
 	codec queryrange.Codec,
+	c cache.Cache,
 	instrumentMetrics *queryrange.InstrumentMiddlewareMetrics,
 	retryMiddlewareMetrics *queryrange.RetryMiddlewareMetrics,
 	shardingMetrics *logql.ShardingMetrics,
@@ -221,6 +287,14 @@ func NewLogFilterTripperware(
 		queryRangeMiddleware = append(queryRangeMiddleware, queryrange.InstrumentMiddleware("split_by_interval", instrumentMetrics), SplitByIntervalMiddleware(limits, codec, splitByMetrics))
 	}
 
+	// the results cache is created by the metric tripperware, it is nil when caching is disabled.
+	if cfg.CacheResults && c != nil {
+		queryRangeMiddleware = append(queryRangeMiddleware,
+			queryrange.InstrumentMiddleware("log_results_cache", instrumentMetrics),
+			NewLogResultCacheMiddleware(log, cfg.ResultsCacheConfig, c, limits),
+		)
+	}
+
 	if cfg.ShardedQueries {
 		if minShardingLookback == 0 {
 			return nil, errors.New("a non-zero value is required for querier.query-ingesters-within when -querier.parallelise-shardable-queries is enabled")
@@ -232,6 +306,7 @@ func NewLogFilterTripperware(
 				minShardingLookback,
 				instrumentMetrics, // instrumentation is included in the sharding middleware
 				shardingMetrics,
+				limits,
 			),
 		)
 	}
@@ -309,6 +384,58 @@ func NewLabelsTripperware(
 	}, nil
 }
 
+// NewInstantMetricTripperware creates a new frontend tripperware responsible for handling instant metric queries.
+func NewInstantMetricTripperware(
+	cfg Config,
+	log log.Logger,
+	limits Limits,
+	codec queryrange.Codec,
+	c cache.Cache,
+	instrumentMetrics *queryrange.InstrumentMiddlewareMetrics,
+	retryMiddlewareMetrics *queryrange.RetryMiddlewareMetrics,
+	splitByMetrics *SplitByMetrics,
+	registerer prometheus.Registerer,
+) (frontend.Tripperware, error) {
+	queryRangeMiddleware := []queryrange.Middleware{
+		StatsCollectorMiddleware(),
+		queryrange.LimitsMiddleware(limits),
+		queryrange.InstrumentMiddleware("instant_split", instrumentMetrics),
+		NewInstantSplitMiddleware(limits, splitByMetrics),
+	}
+
+	// instant queries and sub-ranges of split queries are cached in the results cache created by the metric tripperware.
+	if cfg.CacheResults && c != nil {
+		cacheCfg := cfg.ResultsCacheConfig
+		cacheCfg.CacheConfig.Cache = c
+		queryCacheMiddleware, _, err := queryrange.NewResultsCacheMiddleware(
+			log,
+			cacheCfg,
+			instantCacheKeyLimits{limits},
+			limits,
+			codec,
+			PrometheusExtractor{},
+			nil,
+			registerer,
+		)
+		if err != nil {
+			return nil, err
+		}
+		queryRangeMiddleware = append(
+			queryRangeMiddleware,
+			queryrange.InstrumentMiddleware("results_cache", instrumentMetrics),
+			queryCacheMiddleware,
+		)
+	}
+
+	if cfg.MaxRetries > 0 {
+		queryRangeMiddleware = append(queryRangeMiddleware, queryrange.InstrumentMiddleware("retry", instrumentMetrics), queryrange.NewRetryMiddleware(log, cfg.MaxRetries, retryMiddlewareMetrics))
+	}
+
+	return func(next http.RoundTripper) http.RoundTripper {
+		return queryrange.NewRoundTripper(next, codec, queryRangeMiddleware...)
+	}, nil
+}
+
 // NewMetricTripperware creates a new frontend tripperware responsible for handling metric queries
 func NewMetricTripperware(
 	cfg Config,
@@ -378,6 +505,7 @@ func NewMetricTripperware(
 				minShardingLookback,
 				instrumentMetrics, // instrumentation is included in the sharding middleware
 				shardingMetrics,
+				limits,
 			),
 		)
 	}
@@ -25,6 +25,7 @@ import (
 	"github.com/weaveworks/common/middleware"
 	"github.com/weaveworks/common/user"
 
+	"github.com/grafana/loki/pkg/loghttp"
 	"github.com/grafana/loki/pkg/logproto"
 	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/logql/marshal"
@@ -326,6 +327,73 @@ func TestLogNoRegex(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestLogNDJSONTripperware(t *testing.T) {
+	tpw, stopper, err := NewTripperware(testConfig, util.Logger, fakeLimits{maxEntriesLimitPerQuery: 100}, chunk.SchemaConfig{}, 0, nil)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+	rt, err := newfakeRoundTripper()
+	require.NoError(t, err)
+	defer rt.Close()
+
+	lreq := &LokiRequest{
+		Query:     `{app="foo"}`,
+		Limit:     100,
+		StartTs:   testTime.Add(-6 * time.Hour),
+		EndTs:     testTime,
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+	}
+
+	ctx := user.InjectOrgID(context.Background(), "1")
+	req, err := lokiCodec.EncodeRequest(ctx, lreq)
+	require.NoError(t, err)
+
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", loghttp.NDJSONContentType+", application/json")
+	err = user.InjectOrgIDIntoHTTPRequest(ctx, req)
+	require.NoError(t, err)
+
+	count := 0
+	rt.setHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		// the frontend doesn't stream, queriers answer with json when the client accepts it.
+		require.False(t, loghttp.AcceptsNDJSON(r))
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[]}}`))
+	}))
+	_, err = tpw(rt).RoundTrip(req)
+	require.NoError(t, err)
+	require.Equal(t, 1, count)
+
+	// streamed queries are still subject to the limits.
+	lreq.Limit = 1000
+	req, err = lokiCodec.EncodeRequest(ctx, lreq)
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", loghttp.NDJSONContentType)
+	err = user.InjectOrgIDIntoHTTPRequest(ctx, req)
+	require.NoError(t, err)
+	_, err = tpw(rt).RoundTrip(req)
+	require.Error(t, err)
+	require.Equal(t, 1, count)
+
+	// the clients only accepting streamed responses are told the frontend doesn't serve them.
+	lreq.Limit = 100
+	req, err = lokiCodec.EncodeRequest(ctx, lreq)
+	require.NoError(t, err)
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", loghttp.NDJSONContentType)
+	err = user.InjectOrgIDIntoHTTPRequest(ctx, req)
+	require.NoError(t, err)
+	_, err = tpw(rt).RoundTrip(req)
+	resp, ok := httpgrpc.HTTPResponseFromError(err)
+	require.True(t, ok)
+	require.Equal(t, int32(http.StatusNotAcceptable), resp.Code)
+	require.Equal(t, 1, count)
+}
+
 func TestUnhandledPath(t *testing.T) {
 	tpw, stopper, err := NewTripperware(testConfig, util.Logger, fakeLimits{}, chunk.SchemaConfig{}, 0, nil)
 	if stopper != nil {
@@ -424,6 +492,14 @@ func TestPostQueries(t *testing.T) {
 			t.Error("unexpected labels roundtripper called")
 			return nil, nil
 		}),
+		frontend.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Error("unexpected explain roundtripper called")
+			return nil, nil
+		}),
+		frontend.RoundTripFunc(func(*http.Request) (*http.Response, error) {
+			t.Error("unexpected instant roundtripper called")
+			return nil, nil
+		}),
 		fakeLimits{},
 	).RoundTrip(req)
 	require.NoError(t, err)
@@ -491,10 +567,68 @@ func TestEntriesLimitWithZeroTripperware(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestQueryPoliciesTripperware(t *testing.T) {
+	limits := fakeLimits{
+		blockedQueries:  []*logql.BlockedQuery{{Pattern: `{app="foo"} |= "foo"`}, {Pattern: `{app="bar"} |~ "foo"`}},
+		rejectRegexOnly: true,
+	}
+	tpw, stopper, err := NewTripperware(testConfig, util.Logger, limits, chunk.SchemaConfig{}, 0, nil)
+	if stopper != nil {
+		defer stopper.Stop()
+	}
+	require.NoError(t, err)
+	rt, err := newfakeRoundTripper()
+	require.NoError(t, err)
+	defer rt.Close()
+
+	for _, tc := range []struct {
+		query  string
+		regexp string
+		err    error
+	}{
+		{`{app="foo"}|="foo"`, "", httpgrpc.Errorf(http.StatusBadRequest, `query blocked by the blocked_query policy: the query matches the blocked pattern "{app=\"foo\"} |= \"foo\""`)},
+		{`rate({app=~"foo|bar"}[1m])`, "", httpgrpc.Errorf(http.StatusBadRequest, `query blocked by the regex_only_selector policy: the stream selector {app=~"foo|bar"} must contain at least one non regex matcher`)},
+		{`{app="bar"}`, "foo", httpgrpc.Errorf(http.StatusBadRequest, `query blocked by the blocked_query policy: the query matches the blocked pattern "{app=\"bar\"} |~ \"foo\""`)},
+		{`{app="foo"}`, "", nil},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			lreq := &LokiRequest{
+				Query:     tc.query,
+				Limit:     1000,
+				StartTs:   testTime.Add(-6 * time.Hour),
+				EndTs:     testTime,
+				Direction: logproto.FORWARD,
+				Path:      "/loki/api/v1/query_range",
+			}
+
+			ctx := user.InjectOrgID(context.Background(), "1")
+			req, err := lokiCodec.EncodeRequest(ctx, lreq)
+			require.NoError(t, err)
+			if tc.regexp != "" {
+				params := req.URL.Query()
+				params.Set("regexp", tc.regexp)
+				req.URL.RawQuery = params.Encode()
+			}
+
+			req = req.WithContext(ctx)
+			err = user.InjectOrgIDIntoHTTPRequest(ctx, req)
+			require.NoError(t, err)
+
+			_, err = tpw(rt).RoundTrip(req)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
+
 type fakeLimits struct {
 	maxQueryParallelism     int
 	maxEntriesLimitPerQuery int
+	maxQuerySeries          int
+	maxSamplesPerQuery      int
 	splits                  map[string]time.Duration
+	blockedQueries          []*logql.BlockedQuery
+	rejectRegexOnly         bool
+	maxLengthWithoutFilter  time.Duration
 }
 
 func (f fakeLimits) QuerySplitDuration(key string) time.Duration {
@@ -519,6 +653,26 @@ func (f fakeLimits) MaxEntriesLimitPerQuery(string) int {
 	return f.maxEntriesLimitPerQuery
 }
 
+func (f fakeLimits) MaxQuerySeries(string) int {
+	return f.maxQuerySeries
+}
+
+func (f fakeLimits) MaxSamplesPerQuery(string) int {
+	return f.maxSamplesPerQuery
+}
+
+func (f fakeLimits) BlockedQueries(string) []*logql.BlockedQuery {
+	return f.blockedQueries
+}
+
+func (f fakeLimits) RejectRegexOnlySelectors(string) bool {
+	return f.rejectRegexOnly
+}
+
+func (f fakeLimits) MaxQueryLengthWithoutFilter(string) time.Duration {
+	return f.maxLengthWithoutFilter
+}
+
 func (f fakeLimits) MaxCacheFreshness(string) time.Duration {
 	return 1 * time.Minute
 }
@@ -14,6 +14,7 @@ import (
 	"github.com/weaveworks/common/user"
 
 	"github.com/grafana/loki/pkg/logproto"
+	"github.com/grafana/loki/pkg/logql"
 )
 
 type lokiResult struct {
@@ -165,6 +166,16 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrange.Request) (queryra
 		return h.next.Do(ctx, r)
 	}
 
+	// metric queries with a single offset are split, cached and sharded using the time range of the data they select,
+	// their results are then shifted back to the requested time range.
+	if req, offset, ok := removeOffset(r); ok {
+		resp, err := h.Do(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return shiftResponse(resp, offset), nil
+	}
+
 	intervals := splitByTime(r, interval)
 	h.metrics.splits.Observe(float64(len(intervals)))
 
@@ -249,6 +260,47 @@ func splitByTime(req queryrange.Request, interval time.Duration) []queryrange.Re
 
 }
 
+// removeOffset rewrites a metric query whose range aggregations share the same offset into the query
+// without offset over the time range shifted back by the offset.
+func removeOffset(r queryrange.Request) (queryrange.Request, time.Duration, bool) {
+	req, ok := r.(*LokiRequest)
+	if !ok {
+		return nil, 0, false
+	}
+	expr, err := logql.ParseSampleExpr(req.Query)
+	if err != nil {
+		return nil, 0, false
+	}
+	offset, ok := logql.RemoveOffset(expr)
+	if !ok {
+		return nil, 0, false
+	}
+	return &LokiRequest{
+		Query:     expr.String(),
+		Limit:     req.Limit,
+		Step:      req.Step,
+		Direction: req.Direction,
+		Path:      req.Path,
+		StartTs:   req.StartTs.Add(-offset),
+		EndTs:     req.EndTs.Add(-offset),
+	}, offset, true
+}
+
+// shiftResponse moves forward by the offset the timestamps of the samples of a metric query response.
+func shiftResponse(resp queryrange.Response, offset time.Duration) queryrange.Response {
+	promResp, ok := resp.(*LokiPromResponse)
+	if !ok {
+		return resp
+	}
+	for i := range promResp.Response.Data.Result {
+		samples := promResp.Response.Data.Result[i].Samples
+		for j := range samples {
+			samples[j].TimestampMs += offset.Milliseconds()
+		}
+	}
+	return promResp
+}
+
 func forInterval(interval time.Duration, start, end time.Time, callback func(start, end time.Time)) {
 	for start := start; start.Before(end); start = start.Add(interval) {
 		newEnd := start.Add(interval)
@@ -8,6 +8,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/cortexproject/cortex/pkg/ingester/client"
 	"github.com/cortexproject/cortex/pkg/querier/queryrange"
 	"github.com/stretchr/testify/require"
 	"github.com/weaveworks/common/user"
@@ -338,6 +339,104 @@ func Test_series_splitByInterval_Do(t *testing.T) {
 
 }
 
+func Test_offset_splitByInterval_Do(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+	var (
+		mtx  sync.Mutex
+		reqs []*LokiRequest
+	)
+	next := queryrange.HandlerFunc(func(_ context.Context, r queryrange.Request) (queryrange.Response, error) {
+		mtx.Lock()
+		defer mtx.Unlock()
+		reqs = append(reqs, r.(*LokiRequest))
+		return &LokiPromResponse{
+			Response: &queryrange.PrometheusResponse{
+				Status: loghttp.QueryStatusSuccess,
+				Data: queryrange.PrometheusData{
+					ResultType: loghttp.ResultTypeMatrix,
+					Result: []queryrange.SampleStream{
+						{
+							Labels:  []client.LabelAdapter{{Name: "app", Value: "foo"}},
+							Samples: []client.Sample{{Value: 1, TimestampMs: r.GetEnd()}},
+						},
+					},
+				},
+			},
+		}, nil
+	})
+
+	l := WithDefaultLimits(fakeLimits{}, queryrange.Config{SplitQueriesByInterval: time.Hour})
+	split := SplitByIntervalMiddleware(
+		l,
+		lokiCodec,
+		nilMetrics,
+	).Wrap(next)
+
+	res, err := split.Do(ctx, &LokiRequest{
+		StartTs: time.Unix(0, (2 * time.Hour).Nanoseconds()),
+		EndTs:   time.Unix(0, (4 * time.Hour).Nanoseconds()),
+		Query:   `sum(count_over_time({app="foo"}[5m] offset 1h))`,
+		Step:    60000,
+		Path:    "/loki/api/v1/query_range",
+	})
+	require.NoError(t, err)
+
+	// the downstream queries select the data of the time range shifted back by the offset.
+	require.Len(t, reqs, 2)
+	for _, r := range reqs {
+		require.Equal(t, `sum(count_over_time({app="foo"}[5m]))`, r.Query)
+	}
+	require.ElementsMatch(t, []int64{
+		time.Hour.Milliseconds(),
+		(2 * time.Hour).Milliseconds(),
+	}, []int64{reqs[0].GetStart(), reqs[1].GetStart()})
+
+	// the samples are reported at the requested time range.
+	require.Equal(t, []queryrange.SampleStream{
+		{
+			Labels: []client.LabelAdapter{{Name: "app", Value: "foo"}},
+			Samples: []client.Sample{
+				{Value: 1, TimestampMs: (3 * time.Hour).Milliseconds()},
+				{Value: 1, TimestampMs: (4 * time.Hour).Milliseconds()},
+			},
+		},
+	}, res.(*LokiPromResponse).Response.Data.Result)
+}
+
+func Test_removeOffset(t *testing.T) {
+	for _, tc := range []struct {
+		query  string
+		ok     bool
+		want   string
+		offset time.Duration
+	}{
+		{`count_over_time({app="foo"}[5m] offset 1w)`, true, `count_over_time({app="foo"}[5m])`, 7 * 24 * time.Hour},
+		{`sum(rate({app="foo"}[5m] offset 1h)) / sum(rate({app="bar"}[5m] offset 1h))`, true, `sum(rate({app="foo"}[5m])) / sum(rate({app="bar"}[5m]))`, time.Hour},
+		{`sum(rate({app="foo"}[5m])) / sum(rate({app="foo"}[5m] offset 1w))`, false, "", 0},
+		// subqueries are evaluated at steps aligned to their resolution, they are never shifted.
+		{`max_over_time(rate({app="foo"}[5m] offset 1h)[1h:1m])`, false, "", 0},
+		{`max_over_time(rate({app="foo"}[5m])[1h:1m] offset 1h)`, false, "", 0},
+		{`count_over_time({app="foo"}[5m])`, false, "", 0},
+		{`{app="foo"}`, false, "", 0},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			req, offset, ok := removeOffset(&LokiRequest{
+				Query:   tc.query,
+				StartTs: time.Unix(0, (2 * time.Hour).Nanoseconds()),
+				EndTs:   time.Unix(0, (4 * time.Hour).Nanoseconds()),
+			})
+			require.Equal(t, tc.ok, ok)
+			if !ok {
+				return
+			}
+			require.Equal(t, tc.offset, offset)
+			require.Equal(t, tc.want, req.GetQuery())
+			require.Equal(t, time.Unix(0, (2*time.Hour-tc.offset).Nanoseconds()).UnixNano()/1e6, req.GetStart())
+			require.Equal(t, time.Unix(0, (4*time.Hour-tc.offset).Nanoseconds()).UnixNano()/1e6, req.GetEnd())
+		})
+	}
+}
+
 func Test_ExitEarly(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 
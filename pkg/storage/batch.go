@@ -281,14 +281,14 @@ func (it *batchChunkIterator) Close() error {
 	return nil
 }
 
-type labelCache map[model.Fingerprint]string
+type labelCache map[model.Fingerprint]labels.Labels
 
-// computeLabels compute the labels string representation, uses a map to cache result per fingerprint.
-func (l labelCache) computeLabels(c *LazyChunk) string {
+// computeLabels compute the labels of a chunk, uses a map to cache result per fingerprint.
+func (l labelCache) computeLabels(c *LazyChunk) labels.Labels {
 	if lbs, ok := l[c.Chunk.Fingerprint]; ok {
 		return lbs
 	}
-	lbs := dropLabels(c.Chunk.Metric, labels.MetricName).String()
+	lbs := dropLabels(c.Chunk.Metric, labels.MetricName)
 	l[c.Chunk.Fingerprint] = lbs
 	return lbs
 }
@@ -298,7 +298,7 @@ type logBatchIterator struct {
 
 	ctx      context.Context
 	matchers []*labels.Matcher
-	filter   logql.LineFilter
+	pipeline logql.Pipeline
 	labels   labelCache
 }
 
@@ -307,7 +307,7 @@ func newLogBatchIterator(
 	chunks []*LazyChunk,
 	batchSize int,
 	matchers []*labels.Matcher,
-	filter logql.LineFilter,
+	pipeline logql.Pipeline,
 	direction logproto.Direction,
 	start, end time.Time,
 ) (iter.EntryIterator, error) {
@@ -316,9 +316,9 @@ func newLogBatchIterator(
 	// The same applies to the sharding label which is injected by the cortex storage code.
 	matchers = removeMatchersByName(matchers, labels.MetricName, astmapper.ShardLabel)
 	logbatch := &logBatchIterator{
-		labels:   map[model.Fingerprint]string{},
+		labels:   map[model.Fingerprint]labels.Labels{},
 		matchers: matchers,
-		filter:   filter,
+		pipeline: pipeline,
 		ctx:      ctx,
 	}
 
@@ -369,13 +369,14 @@ func (it *logBatchIterator) buildHeapIterator(chks [][]*LazyChunk, from, through
 
 	// __name__ is only used for upstream compatibility and is hardcoded within loki. Strip it from the return label set.
 	labels := it.labels.computeLabels(chks[0][0])
+	streamPipeline := it.pipeline.ForStream(labels)
 	for i := range chks {
 		iterators := make([]iter.EntryIterator, 0, len(chks[i]))
 		for j := range chks[i] {
 			if !chks[i][j].IsValid {
 				continue
 			}
-			iterator, err := chks[i][j].Iterator(it.ctx, from, through, it.direction, it.filter, nextChunk)
+			iterator, err := chks[i][j].Iterator(it.ctx, from, through, it.direction, streamPipeline, nextChunk)
 			if err != nil {
 				return nil, err
 			}
@@ -386,7 +387,7 @@ func (it *logBatchIterator) buildHeapIterator(chks [][]*LazyChunk, from, through
 				iterators[i], iterators[j] = iterators[j], iterators[i]
 			}
 		}
-		result = append(result, iter.NewNonOverlappingIterator(iterators, labels))
+		result = append(result, iter.NewNonOverlappingIterator(iterators, ""))
 	}
 
 	return iter.NewHeapIterator(it.ctx, result, it.direction), nil
@@ -397,7 +398,6 @@ type sampleBatchIterator struct {
 
 	ctx       context.Context
 	matchers  []*labels.Matcher
-	filter    logql.LineFilter
 	extractor logql.SampleExtractor
 	labels    labelCache
 }
@@ -407,7 +407,6 @@ func newSampleBatchIterator(
 	chunks []*LazyChunk,
 	batchSize int,
 	matchers []*labels.Matcher,
-	filter logql.LineFilter,
 	extractor logql.SampleExtractor,
 	start, end time.Time,
 ) (iter.SampleIterator, error) {
@@ -417,9 +416,8 @@ func newSampleBatchIterator(
 	matchers = removeMatchersByName(matchers, labels.MetricName, astmapper.ShardLabel)
 
 	samplebatch := &sampleBatchIterator{
-		labels:    map[model.Fingerprint]string{},
+		labels:    map[model.Fingerprint]labels.Labels{},
 		matchers:  matchers,
-		filter:    filter,
 		extractor: extractor,
 		ctx:       ctx,
 	}
@@ -468,20 +466,21 @@ func (it *sampleBatchIterator) buildHeapIterator(chks [][]*LazyChunk, from, thro
 
 	// __name__ is only used for upstream compatibility and is hardcoded within loki. Strip it from the return label set.
 	labels := it.labels.computeLabels(chks[0][0])
+	streamExtractor := it.extractor.ForStream(labels)
 	for i := range chks {
 		iterators := make([]iter.SampleIterator, 0, len(chks[i]))
 		for j := range chks[i] {
 			if !chks[i][j].IsValid {
 				continue
 			}
-			iterator, err := chks[i][j].SampleIterator(it.ctx, from, through, it.filter, it.extractor, nextChunk)
+			iterator, err := chks[i][j].SampleIterator(it.ctx, from, through, streamExtractor, nextChunk)
 			if err != nil {
 				return nil, err
 			}
 			iterators = append(iterators, iterator)
 		}
 
-		result = append(result, iter.NewNonOverlappingSampleIterator(iterators, labels))
+		result = append(result, iter.NewNonOverlappingSampleIterator(iterators, ""))
 	}
 
 	return iter.NewHeapSampleIterator(it.ctx, result), nil
@@ -5,13 +5,17 @@ import (
 	"github.com/grafana/loki/pkg/logproto"
 )
 
+type entryWithLabels struct {
+	logproto.Entry
+	labels string
+}
+
 // cachedIterator is an iterator that caches iteration to be replayed later on.
 type cachedIterator struct {
-	cache []*logproto.Entry
+	cache []entryWithLabels
 	base  iter.EntryIterator
 
-	labels string
-	curr   int
+	curr int
 
 	closeErr error
 	iterErr  error
@@ -19,11 +23,11 @@ type cachedIterator struct {
 
 // newCachedIterator creates an iterator that cache iteration result and can be iterated again
 // after closing it without re-using the underlaying iterator `it`.
-// The cache iterator should be used for entries that belongs to the same stream only.
+// Labels are cached per entry since pipelines can produce different labels for the same stream.
 func newCachedIterator(it iter.EntryIterator, cap int) *cachedIterator {
 	c := &cachedIterator{
 		base:  it,
-		cache: make([]*logproto.Entry, 0, cap),
+		cache: make([]entryWithLabels, 0, cap),
 		curr:  -1,
 	}
 	c.load()
@@ -42,19 +46,9 @@ func (it *cachedIterator) load() {
 			it.base = nil
 			it.reset()
 		}()
-		// set labels using the first entry
-		if !it.base.Next() {
-			return
-		}
-		it.labels = it.base.Labels()
-
 		// add all entries until the base iterator is exhausted
-		for {
-			e := it.base.Entry()
-			it.cache = append(it.cache, &e)
-			if !it.base.Next() {
-				break
-			}
+		for it.base.Next() {
+			it.cache = append(it.cache, entryWithLabels{it.base.Entry(), it.base.Labels()})
 		}
 
 	}
@@ -77,13 +71,19 @@ func (it *cachedIterator) Entry() logproto.Entry {
 		return logproto.Entry{}
 	}
 	if it.curr < 0 {
-		return *it.cache[0]
+		return it.cache[0].Entry
 	}
-	return *it.cache[it.curr]
+	return it.cache[it.curr].Entry
 }
 
 func (it *cachedIterator) Labels() string {
-	return it.labels
+	if len(it.cache) == 0 {
+		return ""
+	}
+	if it.curr < 0 {
+		return it.cache[0].labels
+	}
+	return it.cache[it.curr].labels
 }
 
 func (it *cachedIterator) Error() error { return it.iterErr }
@@ -93,13 +93,17 @@ func (it *cachedIterator) Close() error {
 	return it.closeErr
 }
 
-// cachedIterator is an iterator that caches iteration to be replayed later on.
+type sampleWithLabels struct {
+	logproto.Sample
+	labels string
+}
+
+// cachedSampleIterator is an iterator that caches iteration to be replayed later on.
 type cachedSampleIterator struct {
-	cache []logproto.Sample
+	cache []sampleWithLabels
 	base  iter.SampleIterator
 
-	labels string
-	curr   int
+	curr int
 
 	closeErr error
 	iterErr  error
@@ -107,11 +111,11 @@ type cachedSampleIterator struct {
 
 // newSampleCachedIterator creates an iterator that cache iteration result and can be iterated again
 // after closing it without re-using the underlaying iterator `it`.
-// The cache iterator should be used for entries that belongs to the same stream only.
+// Labels are cached per sample since extractors can produce different labels for the same stream.
 func newCachedSampleIterator(it iter.SampleIterator, cap int) *cachedSampleIterator {
 	c := &cachedSampleIterator{
 		base:  it,
-		cache: make([]logproto.Sample, 0, cap),
+		cache: make([]sampleWithLabels, 0, cap),
 		curr:  -1,
 	}
 	c.load()
@@ -130,18 +134,9 @@ func (it *cachedSampleIterator) load() {
 			it.base = nil
 			it.reset()
 		}()
-		// set labels using the first entry
-		if !it.base.Next() {
-			return
-		}
-		it.labels = it.base.Labels()
-
-		// add all entries until the base iterator is exhausted
-		for {
-			it.cache = append(it.cache, it.base.Sample())
-			if !it.base.Next() {
-				break
-			}
+		// add all samples until the base iterator is exhausted
+		for it.base.Next() {
+			it.cache = append(it.cache, sampleWithLabels{it.base.Sample(), it.base.Labels()})
 		}
 
 	}
@@ -164,13 +159,19 @@ func (it *cachedSampleIterator) Sample() logproto.Sample {
 		return logproto.Sample{}
 	}
 	if it.curr < 0 {
-		return it.cache[0]
+		return it.cache[0].Sample
 	}
-	return it.cache[it.curr]
+	return it.cache[it.curr].Sample
 }
 
 func (it *cachedSampleIterator) Labels() string {
-	return it.labels
+	if len(it.cache) == 0 {
+		return ""
+	}
+	if it.curr < 0 {
+		return it.cache[0].labels
+	}
+	return it.cache[it.curr].labels
 }
 
 func (it *cachedSampleIterator) Error() error { return it.iterErr }
@@ -32,7 +32,7 @@ func (c *LazyChunk) Iterator(
 	ctx context.Context,
 	from, through time.Time,
 	direction logproto.Direction,
-	filter logql.LineFilter,
+	pipeline logql.StreamPipeline,
 	nextChunk *LazyChunk,
 ) (iter.EntryIterator, error) {
 
@@ -58,7 +58,7 @@ func (c *LazyChunk) Iterator(
 		}
 		// if the block is overlapping cache it with the next chunk boundaries.
 		if nextChunk != nil && IsBlockOverlapping(b, nextChunk, direction) {
-			it := newCachedIterator(b.Iterator(ctx, filter), b.Entries())
+			it := newCachedIterator(b.Iterator(ctx, pipeline), b.Entries())
 			its = append(its, it)
 			if c.overlappingBlocks == nil {
 				c.overlappingBlocks = make(map[int]*cachedIterator)
@@ -70,12 +70,18 @@ func (c *LazyChunk) Iterator(
 			delete(c.overlappingBlocks, b.Offset())
 		}
 		// non-overlapping block with the next chunk are not cached.
-		its = append(its, b.Iterator(ctx, filter))
+		its = append(its, b.Iterator(ctx, pipeline))
 	}
 
 	// build the final iterator bound to the requested time range.
+	var it iter.EntryIterator
+	if lokiChunk.Ordered() {
+		it = iter.NewNonOverlappingIterator(its, "")
+	} else {
+		it = iter.NewHeapIterator(ctx, its, logproto.FORWARD)
+	}
 	iterForward := iter.NewTimeRangedIterator(
-		iter.NewNonOverlappingIterator(its, ""),
+		it,
 		from,
 		through,
 	)
@@ -93,8 +99,7 @@ func (c *LazyChunk) Iterator(
 func (c *LazyChunk) SampleIterator(
 	ctx context.Context,
 	from, through time.Time,
-	filter logql.LineFilter,
-	extractor logql.SampleExtractor,
+	extractor logql.StreamSampleExtractor,
 	nextChunk *LazyChunk,
 ) (iter.SampleIterator, error) {
 
@@ -120,7 +125,7 @@ func (c *LazyChunk) SampleIterator(
 		}
 		// if the block is overlapping cache it with the next chunk boundaries.
 		if nextChunk != nil && IsBlockOverlapping(b, nextChunk, logproto.FORWARD) {
-			it := newCachedSampleIterator(b.SampleIterator(ctx, filter, extractor), b.Entries())
+			it := newCachedSampleIterator(b.SampleIterator(ctx, extractor), b.Entries())
 			its = append(its, it)
 			if c.overlappingSampleBlocks == nil {
 				c.overlappingSampleBlocks = make(map[int]*cachedSampleIterator)
@@ -132,12 +137,18 @@ func (c *LazyChunk) SampleIterator(
 			delete(c.overlappingSampleBlocks, b.Offset())
 		}
 		// non-overlapping block with the next chunk are not cached.
-		its = append(its, b.SampleIterator(ctx, filter, extractor))
+		its = append(its, b.SampleIterator(ctx, extractor))
 	}
 
 	// build the final iterator bound to the requested time range.
+	var it iter.SampleIterator
+	if lokiChunk.Ordered() {
+		it = iter.NewNonOverlappingSampleIterator(its, "")
+	} else {
+		it = iter.NewHeapSampleIterator(ctx, its)
+	}
 	return iter.NewTimeRangedSampleIterator(
-		iter.NewNonOverlappingSampleIterator(its, ""),
+		it,
 		from.UnixNano(),
 		through.UnixNano(),
 	), nil
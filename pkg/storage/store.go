@@ -72,6 +72,7 @@ type Store interface {
 	SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error)
 	SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error)
 	GetSeries(ctx context.Context, req logql.SelectLogParams) ([]logproto.SeriesIdentifier, error)
+	GetStreamChunks(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) (map[model.Fingerprint]int, error)
 }
 
 type store struct {
@@ -103,28 +104,23 @@ func NewTableClient(name string, cfg Config) (chunk.TableClient, error) {
 
 // decodeReq sanitizes an incoming request, rounds bounds, appends the __name__ matcher,
 // and adds the "__cortex_shard__" label if this is a sharded query.
-func decodeReq(req logql.QueryParams) ([]*labels.Matcher, logql.LineFilter, model.Time, model.Time, error) {
+func decodeReq(req logql.QueryParams) ([]*labels.Matcher, model.Time, model.Time, error) {
 	expr, err := req.LogSelector()
 	if err != nil {
-		return nil, nil, 0, 0, err
-	}
-
-	filter, err := expr.Filter()
-	if err != nil {
-		return nil, nil, 0, 0, err
+		return nil, 0, 0, err
 	}
 
 	matchers := expr.Matchers()
 	nameLabelMatcher, err := labels.NewMatcher(labels.MatchEqual, labels.MetricName, "logs")
 	if err != nil {
-		return nil, nil, 0, 0, err
+		return nil, 0, 0, err
 	}
 	matchers = append(matchers, nameLabelMatcher)
 
 	if shards := req.GetShards(); shards != nil {
 		parsed, err := logql.ParseShards(shards)
 		if err != nil {
-			return nil, nil, 0, 0, err
+			return nil, 0, 0, err
 		}
 		for _, s := range parsed {
 			shardMatcher, err := labels.NewMatcher(
@@ -133,7 +129,7 @@ func decodeReq(req logql.QueryParams) ([]*labels.Matcher, logql.LineFilter, mode
 				s.String(),
 			)
 			if err != nil {
-				return nil, nil, 0, 0, err
+				return nil, 0, 0, err
 			}
 			matchers = append(matchers, shardMatcher)
 
@@ -145,7 +141,7 @@ func decodeReq(req logql.QueryParams) ([]*labels.Matcher, logql.LineFilter, mode
 	}
 
 	from, through := util.RoundToMilliseconds(req.GetStart(), req.GetEnd())
-	return matchers, filter, from, through, nil
+	return matchers, from, through, nil
 }
 
 // lazyChunks is an internal function used to resolve a set of lazy chunks from the store without actually loading them. It's used internally by `LazyQuery` and `GetSeries`
@@ -193,7 +189,7 @@ func (s *store) GetSeries(ctx context.Context, req logql.SelectLogParams) ([]log
 		matchers = []*labels.Matcher{nameLabelMatcher}
 	} else {
 		var err error
-		matchers, _, from, through, err = decodeReq(req)
+		matchers, from, through, err = decodeReq(req)
 		if err != nil {
 			return nil, err
 		}
@@ -258,10 +254,42 @@ func (s *store) GetSeries(ctx context.Context, req logql.SelectLogParams) ([]log
 
 }
 
+// GetStreamChunks returns the number of chunks of each stream matching the matchers within the time range.
+// Chunks are only resolved from the index, they are not fetched.
+func (s *store) GetStreamChunks(ctx context.Context, from, through model.Time, matchers ...*labels.Matcher) (map[model.Fingerprint]int, error) {
+	nameLabelMatcher, err := labels.NewMatcher(labels.MatchEqual, labels.MetricName, "logs")
+	if err != nil {
+		return nil, err
+	}
+
+	matchers = append(matchers[:len(matchers):len(matchers)], nameLabelMatcher)
+
+	lazyChunks, err := s.lazyChunks(ctx, matchers, from, through)
+	if err != nil {
+		return nil, err
+	}
+
+	chunksByStream := make(map[model.Fingerprint]int)
+	for _, c := range lazyChunks {
+		chunksByStream[c.Chunk.Fingerprint]++
+	}
+	return chunksByStream, nil
+}
+
 // SelectLogs returns an iterator that will query the store for more chunks while iterating instead of fetching all chunks upfront
 // for that request.
 func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
-	matchers, filter, from, through, err := decodeReq(req)
+	matchers, from, through, err := decodeReq(req)
+	if err != nil {
+		return nil, err
+	}
+
+	expr, err := req.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+
+	pipeline, err := expr.Pipeline()
 	if err != nil {
 		return nil, err
 	}
@@ -275,12 +303,12 @@ func (s *store) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter
 		return iter.NoopIterator, nil
 	}
 
-	return newLogBatchIterator(ctx, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, filter, req.Direction, req.Start, req.End)
+	return newLogBatchIterator(ctx, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, req.Direction, req.Start, req.End)
 
 }
 
 func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error) {
-	matchers, filter, from, through, err := decodeReq(req)
+	matchers, from, through, err := decodeReq(req)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +331,7 @@ func (s *store) SelectSamples(ctx context.Context, req logql.SelectSampleParams)
 	if len(lazyChunks) == 0 {
 		return iter.NoopIterator, nil
 	}
-	return newSampleBatchIterator(ctx, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, filter, extractor, req.Start, req.End)
+	return newSampleBatchIterator(ctx, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, extractor, req.Start, req.End)
 }
 
 func filterChunksByTime(from, through model.Time, chunks []chunk.Chunk) []chunk.Chunk {
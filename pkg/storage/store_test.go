@@ -672,6 +672,37 @@ func Test_store_GetSeries(t *testing.T) {
 	}
 }
 
+func Test_store_GetStreamChunks(t *testing.T) {
+	s := &store{
+		Store: storeFixture,
+	}
+	ctx := user.InjectOrgID(context.Background(), "test-user")
+	bar, bazz := newChunk(*streamsFixture[0]).Fingerprint, newChunk(*streamsFixture[2]).Fingerprint
+
+	for _, tc := range []struct {
+		name          string
+		from, through time.Time
+		expected      map[model.Fingerprint]int
+	}{
+		{
+			"all chunks",
+			from, from.Add(6 * time.Millisecond),
+			map[model.Fingerprint]int{bar: 2, bazz: 2},
+		},
+		{
+			"chunks overlapping the time range",
+			from.Add(3 * time.Millisecond), from.Add(6 * time.Millisecond),
+			map[model.Fingerprint]int{bar: 1, bazz: 1},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			chunks, err := s.GetStreamChunks(ctx, model.TimeFromUnixNano(tc.from.UnixNano()), model.TimeFromUnixNano(tc.through.UnixNano()), labels.MustNewMatcher(labels.MatchRegexp, "foo", "ba.*"))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, chunks)
+		})
+	}
+}
+
 func Test_store_decodeReq_Matchers(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -707,7 +738,7 @@ func Test_store_decodeReq_Matchers(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			ms, _, _, _, err := decodeReq(logql.SelectLogParams{QueryRequest: tt.req})
+			ms, _, _, err := decodeReq(logql.SelectLogParams{QueryRequest: tt.req})
 			if err != nil {
 				t.Errorf("store.GetSeries() error = %v", err)
 				return
@@ -32,6 +32,8 @@ func WriteError(err error, w http.ResponseWriter) {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 	case logql.IsParseError(err):
 		http.Error(w, err.Error(), http.StatusBadRequest)
+	case logql.IsLimitError(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
 	default:
 		if grpcErr, ok := httpgrpc.HTTPResponseFromError(err); ok {
 			http.Error(w, string(grpcErr.Body), int(grpcErr.Code))
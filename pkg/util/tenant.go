@@ -0,0 +1,73 @@
+package util
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// TenantIDLabel is the synthetic label identifying the tenant of the streams returned by multi-tenant queries.
+	TenantIDLabel = "__tenant_id__"
+
+	// tenantIDsSeparator separates the tenants of the org ID of multi-tenant queries, e.g. `team-a|team-b`.
+	tenantIDsSeparator = "|"
+)
+
+// IsMultiTenant returns true if an org ID selects multiple tenants.
+func IsMultiTenant(orgID string) bool {
+	return strings.Contains(orgID, tenantIDsSeparator)
+}
+
+// ParseTenantIDs returns the sorted and deduplicated tenants selected by an org ID.
+func ParseTenantIDs(orgID string) ([]string, error) {
+	tenants := strings.Split(orgID, tenantIDsSeparator)
+	sort.Strings(tenants)
+
+	result := tenants[:0]
+	for i, tenant := range tenants {
+		if tenant == "" {
+			return nil, errors.New("the org ID contains an empty tenant ID")
+		}
+		if i > 0 && tenant == tenants[i-1] {
+			continue
+		}
+		result = append(result, tenant)
+	}
+	return result, nil
+}
+
+// SmallestPositiveIntPerTenant returns the smallest positive value of a per tenant limit among the tenants
+// selected by an org ID, or 0 when the limit is disabled for all of them.
+func SmallestPositiveIntPerTenant(orgID string, limit func(string) int) int {
+	var result int
+	for _, tenant := range TenantIDsOrSelf(orgID) {
+		if v := limit(tenant); v > 0 && (result == 0 || v < result) {
+			result = v
+		}
+	}
+	return result
+}
+
+// SmallestPositiveDurationPerTenant returns the smallest positive value of a per tenant limit among the tenants
+// selected by an org ID, or 0 when the limit is disabled for all of them.
+func SmallestPositiveDurationPerTenant(orgID string, limit func(string) time.Duration) time.Duration {
+	var result time.Duration
+	for _, tenant := range TenantIDsOrSelf(orgID) {
+		if v := limit(tenant); v > 0 && (result == 0 || v < result) {
+			result = v
+		}
+	}
+	return result
+}
+
+// TenantIDsOrSelf returns the tenants selected by an org ID, or the org ID itself when it's invalid.
+// It is used to look up per tenant limits, invalid org IDs are rejected when queries are validated.
+func TenantIDsOrSelf(orgID string) []string {
+	tenants, err := ParseTenantIDs(orgID)
+	if err != nil {
+		return []string{orgID}
+	}
+	return tenants
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTenantIDs(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		orgID    string
+		expected []string
+		err      bool
+	}{
+		"should return a single tenant": {
+			orgID:    "team-a",
+			expected: []string{"team-a"},
+		},
+		"should return sorted tenants": {
+			orgID:    "team-b|team-a",
+			expected: []string{"team-a", "team-b"},
+		},
+		"should deduplicate tenants": {
+			orgID:    "team-a|team-b|team-a",
+			expected: []string{"team-a", "team-b"},
+		},
+		"should fail on empty tenant": {
+			orgID: "team-a||team-b",
+			err:   true,
+		},
+	}
+
+	for testName, testData := range tests {
+		testData := testData
+
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			actual, err := ParseTenantIDs(testData.orgID)
+			if testData.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testData.expected, actual)
+		})
+	}
+}
+
+func TestSmallestPositiveIntPerTenant(t *testing.T) {
+	t.Parallel()
+
+	limits := map[string]int{"team-a": 0, "team-b": 100, "team-c": 10}
+	limit := func(tenant string) int { return limits[tenant] }
+
+	assert.Equal(t, 100, SmallestPositiveIntPerTenant("team-b", limit))
+	assert.Equal(t, 100, SmallestPositiveIntPerTenant("team-a|team-b", limit))
+	assert.Equal(t, 10, SmallestPositiveIntPerTenant("team-a|team-b|team-c", limit))
+	assert.Equal(t, 0, SmallestPositiveIntPerTenant("team-a", limit))
+}
@@ -2,8 +2,11 @@ package validation
 
 import (
 	"flag"
+	"fmt"
 	"time"
 
+	"github.com/grafana/loki/pkg/chunkenc"
+	"github.com/grafana/loki/pkg/logql"
 	"github.com/grafana/loki/pkg/util/flagext"
 )
 
@@ -21,21 +24,24 @@ const (
 // limits via flags, or per-user limits via yaml config.
 type Limits struct {
 	// Distributor enforced limits.
-	IngestionRateStrategy  string           `yaml:"ingestion_rate_strategy"`
-	IngestionRateMB        float64          `yaml:"ingestion_rate_mb"`
-	IngestionBurstSizeMB   float64          `yaml:"ingestion_burst_size_mb"`
-	MaxLabelNameLength     int              `yaml:"max_label_name_length"`
-	MaxLabelValueLength    int              `yaml:"max_label_value_length"`
-	MaxLabelNamesPerSeries int              `yaml:"max_label_names_per_series"`
-	RejectOldSamples       bool             `yaml:"reject_old_samples"`
-	RejectOldSamplesMaxAge time.Duration    `yaml:"reject_old_samples_max_age"`
-	CreationGracePeriod    time.Duration    `yaml:"creation_grace_period"`
-	EnforceMetricName      bool             `yaml:"enforce_metric_name"`
-	MaxLineSize            flagext.ByteSize `yaml:"max_line_size"`
+	IngestionRateStrategy   string           `yaml:"ingestion_rate_strategy"`
+	IngestionRateMB         float64          `yaml:"ingestion_rate_mb"`
+	IngestionBurstSizeMB    float64          `yaml:"ingestion_burst_size_mb"`
+	MaxLabelNameLength      int              `yaml:"max_label_name_length"`
+	MaxLabelValueLength     int              `yaml:"max_label_value_length"`
+	MaxLabelNamesPerSeries  int              `yaml:"max_label_names_per_series"`
+	RejectOldSamples        bool             `yaml:"reject_old_samples"`
+	RejectOldSamplesMaxAge  time.Duration    `yaml:"reject_old_samples_max_age"`
+	CreationGracePeriod     time.Duration    `yaml:"creation_grace_period"`
+	EnforceMetricName       bool             `yaml:"enforce_metric_name"`
+	MaxLineSize             flagext.ByteSize `yaml:"max_line_size"`
+	AllowStructuredMetadata bool             `yaml:"allow_structured_metadata"`
 
 	// Ingester enforced limits.
-	MaxLocalStreamsPerUser  int `yaml:"max_streams_per_user"`
-	MaxGlobalStreamsPerUser int `yaml:"max_global_streams_per_user"`
+	MaxLocalStreamsPerUser  int           `yaml:"max_streams_per_user"`
+	MaxGlobalStreamsPerUser int           `yaml:"max_global_streams_per_user"`
+	OutOfOrderWindow        time.Duration `yaml:"out_of_order_window"`
+	ChunkEncoding           string        `yaml:"chunk_encoding"`
 
 	// Querier enforced limits.
 	MaxChunksPerQuery          int           `yaml:"max_chunks_per_query"`
@@ -46,6 +52,13 @@ type Limits struct {
 	MaxConcurrentTailRequests  int           `yaml:"max_concurrent_tail_requests"`
 	MaxEntriesLimitPerQuery    int           `yaml:"max_entries_limit_per_query"`
 	MaxCacheFreshness          time.Duration `yaml:"max_cache_freshness_per_query"`
+	MaxQuerySeries             int           `yaml:"max_query_series"`
+	MaxSamplesPerQuery         int           `yaml:"max_samples_per_query"`
+
+	// Query policies, enforced by the query frontend and the querier.
+	BlockedQueries              []*logql.BlockedQuery `yaml:"blocked_queries"`
+	RejectRegexOnlySelectors    bool                  `yaml:"reject_regex_only_selectors"`
+	MaxQueryLengthWithoutFilter time.Duration         `yaml:"max_query_length_without_filter"`
 
 	// Query frontend enforced limits. The default is actually parameterized by the queryrange config.
 	QuerySplitDuration time.Duration `yaml:"split_queries_by_interval"`
@@ -69,9 +82,12 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&l.CreationGracePeriod, "validation.create-grace-period", 10*time.Minute, "Duration which table will be created/deleted before/after it's needed; we won't accept sample from before this time.")
 	f.BoolVar(&l.EnforceMetricName, "validation.enforce-metric-name", true, "Enforce every sample has a metric name.")
 	f.IntVar(&l.MaxEntriesLimitPerQuery, "validation.max-entries-limit", 5000, "Per-user entries limit per query")
+	f.BoolVar(&l.AllowStructuredMetadata, "validation.allow-structured-metadata", false, "Accept the entries with structured metadata, which are stored in the chunks along with the lines without being indexed.")
 
 	f.IntVar(&l.MaxLocalStreamsPerUser, "ingester.max-streams-per-user", 10e3, "Maximum number of active streams per user, per ingester. 0 to disable.")
 	f.IntVar(&l.MaxGlobalStreamsPerUser, "ingester.max-global-streams-per-user", 0, "Maximum number of active streams per user, across the cluster. 0 to disable.")
+	f.DurationVar(&l.OutOfOrderWindow, "ingester.out-of-order-window", 0, "Maximum age of the entries pushed to a stream in any order, relative to its most recent entry. 0 to reject the entries older than the most recent one.")
+	f.StringVar(&l.ChunkEncoding, "ingester.per-tenant-chunk-encoding", "", fmt.Sprintf("The algorithm to use for compressing the chunks of the tenant, overriding -ingester.chunk-encoding when set. (%s)", chunkenc.SupportedEncoding()))
 
 	f.IntVar(&l.MaxChunksPerQuery, "store.query-chunk-limit", 2e6, "Maximum number of chunks that can be fetched in a single query.")
 	f.DurationVar(&l.MaxQueryLength, "store.max-query-length", 0, "Limit to length of chunk store queries, 0 to disable.")
@@ -79,6 +95,10 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&l.CardinalityLimit, "store.cardinality-limit", 1e5, "Cardinality limit for index queries.")
 	f.IntVar(&l.MaxStreamsMatchersPerQuery, "querier.max-streams-matcher-per-query", 1000, "Limit the number of streams matchers per query")
 	f.IntVar(&l.MaxConcurrentTailRequests, "querier.max-concurrent-tail-requests", 10, "Limit the number of concurrent tail requests")
+	f.IntVar(&l.MaxQuerySeries, "querier.max-query-series", 0, "Limit the number of series a metric query can hold at once. 0 to disable.")
+	f.IntVar(&l.MaxSamplesPerQuery, "querier.max-samples-per-query", 0, "Limit the number of samples a metric query can return, and hold in the window of each range aggregation. 0 to disable.")
+	f.BoolVar(&l.RejectRegexOnlySelectors, "querier.reject-regex-only-selectors", false, "Reject queries with a stream selector made only of regex matchers.")
+	f.DurationVar(&l.MaxQueryLengthWithoutFilter, "querier.max-query-length-without-filter", 0, "Limit the time range of log queries without a line filter. 0 to disable.")
 	f.DurationVar(&l.MaxCacheFreshness, "frontend.max-cache-freshness", 1*time.Minute, "Most recent allowed cacheable result per-tenant, to prevent caching very recent results that might still be in flux.")
 
 	f.StringVar(&l.PerTenantOverrideConfig, "limits.per-user-override-config", "", "File name of per-user overrides.")
@@ -96,7 +116,20 @@ func (l *Limits) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		*l = *defaultLimits
 	}
 	type plain Limits
-	return unmarshal((*plain)(l))
+	if err := unmarshal((*plain)(l)); err != nil {
+		return err
+	}
+	return l.Validate()
+}
+
+// Validate validates the limits.
+func (l *Limits) Validate() error {
+	if l.ChunkEncoding != "" {
+		if _, err := chunkenc.ParseEncoding(l.ChunkEncoding); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // When we load YAML from disk, we want the various per-customer limits
@@ -194,6 +227,22 @@ func (o *Overrides) MaxGlobalStreamsPerUser(userID string) int {
 	return o.getOverridesForUser(userID).MaxGlobalStreamsPerUser
 }
 
+// OutOfOrderWindow returns the maximum age of the entries accepted in any order, relative to the
+// most recent entry of their stream.
+func (o *Overrides) OutOfOrderWindow(userID string) time.Duration {
+	return o.getOverridesForUser(userID).OutOfOrderWindow
+}
+
+// ChunkEncoding returns the encoding of the chunks of the user, empty to use the one of the ingester.
+func (o *Overrides) ChunkEncoding(userID string) string {
+	return o.getOverridesForUser(userID).ChunkEncoding
+}
+
+// AllowStructuredMetadata returns whether the entries of the user can have structured metadata.
+func (o *Overrides) AllowStructuredMetadata(userID string) bool {
+	return o.getOverridesForUser(userID).AllowStructuredMetadata
+}
+
 // MaxChunksPerQuery returns the maximum number of chunks allowed per query.
 func (o *Overrides) MaxChunksPerQuery(userID string) int {
 	return o.getOverridesForUser(userID).MaxChunksPerQuery
@@ -249,6 +298,31 @@ func (o *Overrides) MaxCacheFreshness(userID string) time.Duration {
 	return o.getOverridesForUser(userID).MaxCacheFreshness
 }
 
+// MaxQuerySeries returns the limit to number of series a metric query can hold.
+func (o *Overrides) MaxQuerySeries(userID string) int {
+	return o.getOverridesForUser(userID).MaxQuerySeries
+}
+
+// MaxSamplesPerQuery returns the limit to number of samples a metric query can return.
+func (o *Overrides) MaxSamplesPerQuery(userID string) int {
+	return o.getOverridesForUser(userID).MaxSamplesPerQuery
+}
+
+// BlockedQueries returns the queries the tenant is not allowed to run.
+func (o *Overrides) BlockedQueries(userID string) []*logql.BlockedQuery {
+	return o.getOverridesForUser(userID).BlockedQueries
+}
+
+// RejectRegexOnlySelectors returns whether queries selecting streams only with regex matchers are rejected.
+func (o *Overrides) RejectRegexOnlySelectors(userID string) bool {
+	return o.getOverridesForUser(userID).RejectRegexOnlySelectors
+}
+
+// MaxQueryLengthWithoutFilter returns the limit to the time range of log queries without a line filter.
+func (o *Overrides) MaxQueryLengthWithoutFilter(userID string) time.Duration {
+	return o.getOverridesForUser(userID).MaxQueryLengthWithoutFilter
+}
+
 func (o *Overrides) getOverridesForUser(userID string) *Limits {
 	if o.tenantLimits != nil {
 		l := o.tenantLimits(userID)
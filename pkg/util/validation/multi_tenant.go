@@ -0,0 +1,85 @@
+package validation
+
+import (
+	"time"
+
+	"github.com/grafana/loki/pkg/logql"
+	"github.com/grafana/loki/pkg/util"
+)
+
+// QueryLimits are the per tenant limits applied to queries.
+type QueryLimits interface {
+	logql.Limits
+	logql.PolicyLimits
+	MaxQueryLength(userID string) time.Duration
+	MaxQueryParallelism(userID string) int
+	MaxCacheFreshness(userID string) time.Duration
+	QuerySplitDuration(userID string) time.Duration
+	MaxEntriesLimitPerQuery(userID string) int
+}
+
+// multiTenantLimits applies the most restrictive limits of their tenants to multi-tenant queries.
+type multiTenantLimits struct {
+	QueryLimits
+}
+
+// NewMultiTenantLimits returns QueryLimits applying to multi-tenant queries, whose org ID selects several
+// tenants (e.g. `team-a|team-b`), the most restrictive limits of their tenants.
+func NewMultiTenantLimits(l QueryLimits) QueryLimits {
+	return multiTenantLimits{QueryLimits: l}
+}
+
+func (l multiTenantLimits) MaxQueryLength(userID string) time.Duration {
+	return util.SmallestPositiveDurationPerTenant(userID, l.QueryLimits.MaxQueryLength)
+}
+
+func (l multiTenantLimits) MaxQueryParallelism(userID string) int {
+	return util.SmallestPositiveIntPerTenant(userID, l.QueryLimits.MaxQueryParallelism)
+}
+
+func (l multiTenantLimits) MaxCacheFreshness(userID string) time.Duration {
+	var freshness time.Duration
+	for _, tenant := range util.TenantIDsOrSelf(userID) {
+		if f := l.QueryLimits.MaxCacheFreshness(tenant); f > freshness {
+			freshness = f
+		}
+	}
+	return freshness
+}
+
+func (l multiTenantLimits) MaxQuerySeries(userID string) int {
+	return util.SmallestPositiveIntPerTenant(userID, l.QueryLimits.MaxQuerySeries)
+}
+
+func (l multiTenantLimits) MaxSamplesPerQuery(userID string) int {
+	return util.SmallestPositiveIntPerTenant(userID, l.QueryLimits.MaxSamplesPerQuery)
+}
+
+func (l multiTenantLimits) BlockedQueries(userID string) []*logql.BlockedQuery {
+	var blocked []*logql.BlockedQuery
+	for _, tenant := range util.TenantIDsOrSelf(userID) {
+		blocked = append(blocked, l.QueryLimits.BlockedQueries(tenant)...)
+	}
+	return blocked
+}
+
+func (l multiTenantLimits) RejectRegexOnlySelectors(userID string) bool {
+	for _, tenant := range util.TenantIDsOrSelf(userID) {
+		if l.QueryLimits.RejectRegexOnlySelectors(tenant) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l multiTenantLimits) MaxQueryLengthWithoutFilter(userID string) time.Duration {
+	return util.SmallestPositiveDurationPerTenant(userID, l.QueryLimits.MaxQueryLengthWithoutFilter)
+}
+
+func (l multiTenantLimits) QuerySplitDuration(userID string) time.Duration {
+	return util.SmallestPositiveDurationPerTenant(userID, l.QueryLimits.QuerySplitDuration)
+}
+
+func (l multiTenantLimits) MaxEntriesLimitPerQuery(userID string) int {
+	return util.SmallestPositiveIntPerTenant(userID, l.QueryLimits.MaxEntriesLimitPerQuery)
+}
@@ -39,6 +39,12 @@ const (
 	// DuplicateLabelNames is a reason for discarding a log line which has duplicate label names
 	DuplicateLabelNames         = "duplicate_label_names"
 	duplicateLabelNamesErrorMsg = "stream '%s' has duplicate label name: '%s'"
+	// DisallowedStructuredMetadata is a reason for discarding a log line which has structured metadata when they are not allowed
+	DisallowedStructuredMetadata         = "disallowed_structured_metadata"
+	disallowedStructuredMetadataErrorMsg = "entry for stream '%s' has structured metadata, which are not allowed"
+	// InvalidStructuredMetadata is a reason for discarding a log line which has an invalid structured metadata
+	InvalidStructuredMetadata         = "invalid_structured_metadata"
+	invalidStructuredMetadataErrorMsg = "entry for stream '%s' has invalid structured metadata '%s': %s"
 )
 
 // DiscardedBytes is a metric of the total discarded bytes, by reason.
@@ -109,3 +115,13 @@ func LabelValueTooLongErrorMsg(stream, labelValue string) string {
 func DuplicateLabelNamesErrorMsg(stream, label string) string {
 	return fmt.Sprintf(duplicateLabelNamesErrorMsg, stream, label)
 }
+
+// DisallowedStructuredMetadataErrorMsg returns an error string for a line with structured metadata when they are not allowed
+func DisallowedStructuredMetadataErrorMsg(stream string) string {
+	return fmt.Sprintf(disallowedStructuredMetadataErrorMsg, stream)
+}
+
+// InvalidStructuredMetadataErrorMsg returns an error string for a line with an invalid structured metadata
+func InvalidStructuredMetadataErrorMsg(stream, name, reason string) string {
+	return fmt.Sprintf(invalidStructuredMetadataErrorMsg, stream, name, reason)
+}